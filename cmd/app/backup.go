@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"leafnote/internal/config"
+	"leafnote/internal/service"
+)
+
+const backupUsage = "用法: backup create | backup list | backup restore <备份名或路径>"
+
+// backupOptions 根据配置生成备份选项
+func backupOptions(cfg *config.Config) service.BackupOptions {
+	return service.BackupOptions{
+		Dir:      cfg.Backup.Dir,
+		DBPath:   cfg.Database.Name,
+		VaultDir: cfg.Vault.Dir,
+		KeepLast: cfg.Backup.KeepLast,
+		MaxAge:   cfg.Backup.MaxAge,
+	}
+}
+
+// runBackup 执行 backup create 和 backup list 子命令
+func runBackup(db *gorm.DB, cfg *config.Config, logger *zap.Logger, args []string) error {
+	if len(args) == 0 {
+		return errors.New(backupUsage)
+	}
+
+	s := service.NewBackupService(db, logger, backupOptions(cfg))
+	switch args[0] {
+	case "create":
+		backup, err := s.CreateBackup(context.Background())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created   %s (%d bytes)\n", backup.Name, backup.Size)
+		return nil
+
+	case "list":
+		backups, err := s.ListBackups()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tSIZE\tCREATED AT")
+		for _, b := range backups {
+			fmt.Fprintf(w, "%s\t%d\t%s\n", b.Name, b.Size, b.CreatedAt.Format("2006-01-02 15:04:05"))
+		}
+		return w.Flush()
+
+	default:
+		return fmt.Errorf("未知的 backup 命令 %q，%s", args[0], backupUsage)
+	}
+}
+
+// runRestore 执行 backup restore 子命令，必须在打开数据库之前调用
+func runRestore(cfg *config.Config, args []string) error {
+	if len(args) != 1 {
+		return errors.New(backupUsage)
+	}
+	if !cfg.Database.IsSQLite() {
+		return service.ErrBackupUnsupported
+	}
+
+	// 只给出名称时在备份目录中查找
+	archive := args[0]
+	if filepath.Base(archive) == archive {
+		if _, err := os.Stat(archive); errors.Is(err, os.ErrNotExist) {
+			archive = filepath.Join(cfg.Backup.Dir, archive)
+		}
+	}
+
+	manifest, err := service.RestoreBackup(archive, backupOptions(cfg))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("restored  %s (created at %s, schema version %d, %d vault files)\n",
+		archive, manifest.CreatedAt.Format("2006-01-02 15:04:05"), manifest.SchemaVersion, manifest.VaultFiles)
+	return nil
+}
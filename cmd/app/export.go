@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"leafnote/internal/service"
+)
+
+const exportUsage = "用法: export [--site] [--category ID] [--tag ID]... [--include-trash] <目录或 .zip 文件>"
+
+// stringList 可重复指定的命令行参数
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ",") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+// runExport 执行 export 子命令，目标以 .zip 结尾时导出为 zip，否则导出到目录；
+// 指定 --site 时导出为静态站点
+func runExport(db *gorm.DB, logger *zap.Logger, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	var filter service.ExportFilter
+	var categoryID string
+	var site bool
+	flags.BoolVar(&site, "site", false, "导出为 HTML 静态站点")
+	flags.StringVar(&categoryID, "category", "", "只导出该目录及其子目录下的笔记")
+	flags.Var((*stringList)(&filter.TagIDs), "tag", "只导出带有该标签的笔记，可重复指定")
+	flags.BoolVar(&filter.IncludeTrash, "include-trash", false, "包含回收站中的笔记")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New(exportUsage)
+	}
+	if categoryID != "" {
+		filter.CategoryID = &categoryID
+	}
+
+	target := flags.Arg(0)
+	s := service.NewExportService(db, logger)
+	exportDir, exportZip := s.ExportDir, s.ExportZip
+	if site {
+		exportDir, exportZip = s.ExportSiteDir, s.ExportSiteZip
+	}
+	ctx := context.Background()
+	// 静态站点与接口渲染的页面一致，同样经过已启用插件的 Markdown 转换
+	if site {
+		if err := service.NewPluginService(db, logger).Load(ctx); err != nil {
+			return err
+		}
+	}
+	if !strings.HasSuffix(strings.ToLower(target), ".zip") {
+		result, err := exportDir(ctx, target, filter)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("exported  %d notes to %s\n", result.Notes, target)
+		return nil
+	}
+
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	result, err := exportZip(ctx, f, filter)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(target)
+		return err
+	}
+	fmt.Printf("exported  %d notes to %s\n", result.Notes, target)
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"leafnote/internal/config"
+	"leafnote/internal/service"
+)
+
+const importUsage = "用法: import [--format markdown|enex|notion] [--category ID] <目录、.zip 或 .enex 文件>"
+
+// runImport 执行 import 子命令，导入 Obsidian 库、Markdown 文件夹、ENEX 或 Notion 导出，
+// 存在失败的文件时返回错误
+func runImport(db *gorm.DB, cfg *config.Config, logger *zap.Logger, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	var categoryID, format string
+	flags.StringVar(&categoryID, "category", "", "导入到该目录下")
+	flags.StringVar(&format, "format", service.ImportFormatMarkdown, "导入格式：markdown、enex 或 notion")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New(importUsage)
+	}
+	var opts service.ImportOptions
+	if categoryID != "" {
+		opts.CategoryID = &categoryID
+	}
+
+	source := flags.Arg(0)
+	var fsys fs.FS = os.DirFS(source)
+	switch ext := strings.ToLower(filepath.Ext(source)); ext {
+	case ".enex":
+		// 单个 ENEX 文件，以所在目录作为导入源只导入该文件
+		fsys = singleFileFS{FS: os.DirFS(filepath.Dir(source)), name: filepath.Base(source)}
+	case ".zip":
+		zr, err := zip.OpenReader(source)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		fsys = zr
+	}
+
+	result, err := service.NewImportService(db, logger, service.NewAttachmentService(db, logger, attachmentOptions(cfg))).Import(context.Background(), format, fsys, opts)
+	if err != nil {
+		return err
+	}
+	for _, f := range result.Files {
+		if f.Status == service.ImportFailed {
+			fmt.Printf("failed    %s  %s\n", f.Path, f.Message)
+		}
+		for _, w := range f.Warnings {
+			fmt.Printf("warning   %s  %s\n", f.Path, w)
+		}
+	}
+	fmt.Printf("created %d, skipped %d, failed %d\n", result.Created, result.Skipped, result.Failed)
+	if result.Failed > 0 {
+		return fmt.Errorf("%d 个文件导入失败", result.Failed)
+	}
+	return nil
+}
+
+// singleFileFS 只包含 FS 根目录下一个文件的文件系统，用于导入单个文件
+type singleFileFS struct {
+	fs.FS
+	name string
+}
+
+// ReadDir 实现 fs.ReadDirFS，根目录只列出该文件
+func (f singleFileFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name != "." {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	info, err := fs.Stat(f.FS, f.name)
+	if err != nil {
+		return nil, err
+	}
+	return []fs.DirEntry{fs.FileInfoToDirEntry(info)}, nil
+}
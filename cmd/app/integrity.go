@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"gorm.io/gorm"
+
+	"leafnote/internal/integrity"
+)
+
+const integrityUsage = "用法: integrity check | integrity repair"
+
+// runIntegrity 执行 integrity 子命令，check 发现问题时返回错误
+func runIntegrity(db *gorm.DB, args []string) error {
+	if len(args) == 0 {
+		return errors.New(integrityUsage)
+	}
+
+	var (
+		report *integrity.Report
+		err    error
+	)
+	switch args[0] {
+	case "check":
+		report, err = integrity.Check(db)
+	case "repair":
+		report, err = integrity.Repair(db)
+	default:
+		return fmt.Errorf("未知的 integrity 命令 %q，%s", args[0], integrityUsage)
+	}
+	if err != nil {
+		return err
+	}
+
+	if report.OK() {
+		fmt.Println("未发现引用完整性问题")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tCOUNT\tPROBLEM\tREPAIR")
+	for _, issue := range report.Issues {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", issue.Check, issue.Count, issue.Description, issue.Repair)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if args[0] == "check" {
+		return errors.New("发现引用完整性问题，可执行 integrity repair 修复")
+	}
+	fmt.Println("已修复以上问题")
+	return nil
+}
@@ -1,37 +1,103 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"leafnote/internal/config"
 	"leafnote/internal/handler"
 	"leafnote/internal/middleware"
+	"leafnote/internal/migration"
+	"leafnote/internal/server"
+	"leafnote/internal/service"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
-	"gorm.io/gorm"
-)
-
-var (
-	db     *gorm.DB
-	logger *zap.Logger
 )
 
 func main() {
+	configPath := flag.String("config", "configs/config.yaml", "配置文件路径，为空时只使用默认值和环境变量")
+	flag.Parse()
+
+	if err := run(*configPath, flag.Args()); err != nil {
+		log.Println("Server exited with error:", err)
+		os.Exit(1)
+	}
+}
+
+// run 初始化依赖并运行服务或子命令，返回时所有资源都已释放
+func run(configPath string, args []string) error {
 	// 加载配置
-	cfg, err := config.LoadConfig("configs/config.yaml")
+	cfg, err := config.LoadConfig(configPath)
 	if err != nil {
-		log.Fatal("Failed to load config:", err)
+		return err
 	}
 
 	// 初始化日志系统
-	logger, err = config.InitLogger(&cfg.Log)
+	logger, err := config.InitLogger(&cfg.Log)
 	if err != nil {
-		log.Fatal("Failed to initialize logger:", err)
+		return err
 	}
 	defer logger.Sync()
 
+	// 恢复备份会替换数据库文件，必须在打开数据库之前执行
+	if len(args) >= 2 && args[0] == "backup" && args[1] == "restore" {
+		return runRestore(cfg, args[2:])
+	}
+
+	// 初始化数据库
+	db, err := config.InitDB(&cfg.Database, logger)
+	if err != nil {
+		logger.Error("Failed to initialize database", zap.Error(err))
+		return err
+	}
+
+	// 获取底层的 sqlDB
+	sqlDB, err := db.DB()
+	if err != nil {
+		logger.Error("Failed to get underlying *sql.DB", zap.Error(err))
+		return err
+	}
+	defer sqlDB.Close()
+
+	if len(args) > 0 {
+		switch args[0] {
+		case "migrate":
+			return runMigrate(db, logger, args[1:])
+		case "integrity":
+			return runIntegrity(db, args[1:])
+		case "backup":
+			return runBackup(db, cfg, logger, args[1:])
+		case "export":
+			return runExport(db, logger, args[1:])
+		case "import":
+			return runImport(db, cfg, logger, args[1:])
+		default:
+			return fmt.Errorf("未知的子命令 %q", args[0])
+		}
+	}
+
+	// 执行未执行的迁移
+	if cfg.Database.AutoMigrate {
+		if _, err := migration.New(db, logger).Up(); err != nil {
+			logger.Error("Failed to migrate database", zap.Error(err))
+			return err
+		}
+	}
+
+	// 监听配置文件，热更新日志级别、CORS 和限流配置
+	live := config.NewLive(cfg)
+	live.Watch(configPath, logger)
+
+	// 收到 SIGINT 或 SIGTERM 时取消 ctx，触发优雅关闭
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// 设置 Gin 模式
 	gin.SetMode(cfg.Server.Mode)
 
@@ -40,33 +106,135 @@ func main() {
 
 	// 添加中间件
 	r.Use(middleware.Logger(logger))
+	r.Use(middleware.Locale())
 	r.Use(middleware.ErrorHandler())
-	r.Use(middleware.CORS())
+	r.Use(middleware.CORS(live))
+	r.Use(middleware.RateLimit(live))
 	r.Use(gin.Recovery())
 
-	// 初始化数据库
-	db, err = config.InitDB(&cfg.Database)
+	// 初始化处理器
+	jobService := service.NewJobService(db, logger)
+	attachmentService := service.NewAttachmentService(db, logger, attachmentOptions(cfg))
+	periodicService, err := service.NewPeriodicService(db, logger, service.PeriodicOptions{
+		Daily:   service.PeriodicNoteOptions{Path: cfg.Periodic.Daily.Path, Template: cfg.Periodic.Daily.Template},
+		Weekly:  service.PeriodicNoteOptions{Path: cfg.Periodic.Weekly.Path, Template: cfg.Periodic.Weekly.Template},
+		Monthly: service.PeriodicNoteOptions{Path: cfg.Periodic.Monthly.Path, Template: cfg.Periodic.Monthly.Template},
+	})
 	if err != nil {
-		logger.Fatal("Failed to initialize database", zap.Error(err))
+		return err
 	}
-
-	// 获取底层的 sqlDB
-	sqlDB, err := db.DB()
-	if err != nil {
-		logger.Fatal("Failed to get underlying *sql.DB", zap.Error(err))
+	reminderService := service.NewReminderService(db, logger, service.ReminderOptions{
+		WebhookURL:     cfg.Reminder.WebhookURL,
+		WebhookTimeout: cfg.Reminder.WebhookTimeout,
+	})
+	eventService := service.NewEventService(db, logger, service.EventOptions{
+		Retention: cfg.Events.Retention,
+	})
+	// 插件在 plugins.go 中引入，按数据库中保存的状态启用
+	pluginService := service.NewPluginService(db, logger)
+	if err := pluginService.Load(ctx); err != nil {
+		logger.Error("Failed to load plugins", zap.Error(err))
+		return err
 	}
-	defer sqlDB.Close()
-
-	// 初始化处理器
-	h := handler.NewHandler(logger, db)
+	webhookService := service.NewWebhookService(db, logger, service.WebhookOptions{
+		Timeout:     cfg.Webhook.Timeout,
+		MaxAttempts: cfg.Webhook.MaxAttempts,
+		Backoff:     cfg.Webhook.Backoff,
+		MaxBackoff:  cfg.Webhook.MaxBackoff,
+		Retention:   cfg.Webhook.Retention,
+	})
+	syncService := service.NewSyncService(db, logger, eventService, service.SyncOptions{
+		ChangeRetention: cfg.Sync.ChangeRetention,
+	})
+	opts := []handler.Option{
+		handler.WithJobService(jobService),
+		handler.WithAttachmentService(attachmentService),
+		handler.WithPeriodicService(periodicService),
+		handler.WithReminderService(reminderService),
+		handler.WithEventService(eventService),
+		handler.WithWebhookService(webhookService),
+		handler.WithPluginService(pluginService),
+		handler.WithSyncService(syncService),
+		handler.WithExportDir(cfg.Export.Dir),
+		handler.WithPDFFonts(service.PDFFonts{
+			Regular: cfg.Export.PDFFont,
+			Bold:    cfg.Export.PDFBoldFont,
+			Mono:    cfg.Export.PDFMonoFont,
+		}),
+		handler.WithMaxUploadSize(cfg.Import.MaxUploadMB << 20),
+		handler.WithMaxAttachmentSize(cfg.Attachment.MaxSizeMB << 20),
+	}
+	var backupService *service.BackupService
+	if cfg.Database.IsSQLite() {
+		backupService = service.NewBackupService(db, logger, backupOptions(cfg))
+		opts = append(opts, handler.WithBackupService(backupService))
+	}
+	h := handler.NewHandler(logger, db, opts...)
 
 	// 注册路由
 	h.RegisterRoutes(r)
 
-	// 启动服务器
-	addr := fmt.Sprintf(":%d", cfg.Server.Port)
-	logger.Info("Server starting", zap.String("addr", addr))
-	if err := r.Run(addr); err != nil {
-		logger.Fatal("Server startup failed", zap.Error(err))
+	srv := server.New(&cfg.Server, r, logger)
+	// 关闭时断开提醒和变更事件推送的长连接
+	srv.OnShutdown(reminderService.Close)
+	srv.OnShutdown(eventService.Close)
+
+	// 执行导入等后台任务
+	srv.AddWorker("jobs", jobService.Run)
+
+	// 推送变更事件并清理过期事件
+	srv.AddWorker("events", eventService.Schedule(cfg.Events.PollInterval))
+
+	// 投递 Webhook 并清理投递记录
+	if cfg.Webhook.Enabled {
+		srv.AddWorker("webhooks", webhookService.Schedule(cfg.Webhook.Interval))
+	}
+
+	// 清理过期的同步修改记录
+	if cfg.Sync.ChangeRetention > 0 {
+		srv.AddWorker("sync-changes", syncService.Run)
+	}
+
+	// 定期整理 SQLite 数据库
+	if cfg.Database.IsSQLite() && cfg.Database.SQLite.MaintenanceInterval > 0 {
+		srv.AddWorker("sqlite-maintenance", config.SQLiteMaintenance(db, cfg.Database.SQLite.MaintenanceInterval, logger))
+	}
+
+	// 定期清理未被引用的附件
+	if cfg.Attachment.GCInterval > 0 {
+		srv.AddWorker("attachment-gc", attachmentService.Schedule(cfg.Attachment.GCInterval))
+	}
+
+	// 定时触发提醒
+	if cfg.Reminder.Enabled {
+		srv.AddWorker("reminders", reminderService.Schedule(cfg.Reminder.CheckInterval))
+	}
+
+	// 定期自动备份
+	if backupService != nil && cfg.Backup.Enabled {
+		srv.AddWorker("backup", backupService.Schedule(cfg.Backup.Interval))
+	}
+
+	// 启动服务器，阻塞直到收到退出信号
+	if err := srv.Run(ctx); err != nil {
+		logger.Error("Server stopped with error", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// attachmentOptions 根据配置生成附件选项，上传和导入的附件使用同样的大小上限和图片处理
+func attachmentOptions(cfg *config.Config) service.AttachmentOptions {
+	return service.AttachmentOptions{
+		VaultDir:    cfg.Vault.Dir,
+		MaxSize:     cfg.Attachment.MaxSizeMB << 20,
+		GracePeriod: cfg.Attachment.GracePeriod,
+		Image: service.ImageOptions{
+			StripMetadata:  cfg.Attachment.Image.StripMetadata,
+			MaxDimension:   cfg.Attachment.Image.MaxDimension,
+			Recompress:     cfg.Attachment.Image.Recompress,
+			JPEGQuality:    cfg.Attachment.Image.JPEGQuality,
+			ThumbnailSizes: cfg.Attachment.Image.ThumbnailSizes,
+		},
 	}
 }
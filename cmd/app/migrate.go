@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"leafnote/internal/migration"
+)
+
+const migrateUsage = "用法: migrate up | migrate down [步数] | migrate status"
+
+// runMigrate 执行 migrate 子命令
+func runMigrate(db *gorm.DB, logger *zap.Logger, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	m := migration.New(db, logger)
+	switch args[0] {
+	case "up":
+		applied, err := m.Up()
+		for _, mig := range applied {
+			fmt.Printf("applied   %04d_%s\n", mig.Version, mig.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("数据库已是最新版本")
+		}
+		return err
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("回滚步数必须是正整数: %q", args[1])
+			}
+			steps = n
+		}
+		rolledBack, err := m.Down(steps)
+		for _, mig := range rolledBack {
+			fmt.Printf("reverted  %04d_%s\n", mig.Version, mig.Name)
+		}
+		return err
+
+	case "status":
+		statuses, err := m.Status()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		return w.Flush()
+
+	default:
+		return fmt.Errorf("未知的 migrate 命令 %q，%s", args[0], migrateUsage)
+	}
+}
@@ -0,0 +1,6 @@
+package main
+
+// 编译进程序的插件在此以空导入的方式引入，插件包在 init 中调用 plugin.Register 注册。
+// 插件代码需要放在本仓库中（例如 plugins/<名称>），才能引用 leafnote/internal/plugin：
+//
+//	import _ "leafnote/plugins/wordcount"
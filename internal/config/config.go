@@ -1,23 +1,80 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
+	"go.uber.org/zap"
 )
 
+// EnvPrefix 环境变量前缀，例如 LEAFNOTE_SERVER_PORT 覆盖 server.port
+const EnvPrefix = "LEAFNOTE"
+
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Log      LogConfig      `mapstructure:"log"`
+	Server     ServerConfig     `mapstructure:"server"`
+	Database   DatabaseConfig   `mapstructure:"database"`
+	Log        LogConfig        `mapstructure:"log"`
+	CORS       CORSConfig       `mapstructure:"cors"`
+	RateLimit  RateLimitConfig  `mapstructure:"rate_limit"`
+	Vault      VaultConfig      `mapstructure:"vault"`
+	Backup     BackupConfig     `mapstructure:"backup"`
+	Export     ExportConfig     `mapstructure:"export"`
+	Import     ImportConfig     `mapstructure:"import"`
+	Attachment AttachmentConfig `mapstructure:"attachment"`
+	Periodic   PeriodicConfig   `mapstructure:"periodic"`
+	Reminder   ReminderConfig   `mapstructure:"reminder"`
+	Events     EventsConfig     `mapstructure:"events"`
+	Webhook    WebhookConfig    `mapstructure:"webhook"`
+	Sync       SyncConfig       `mapstructure:"sync"`
 }
 
 type ServerConfig struct {
-	Port int    `mapstructure:"port"`
-	Mode string `mapstructure:"mode"`
+	Port            int           `mapstructure:"port"`
+	Mode            string        `mapstructure:"mode"`
+	ReadTimeout     time.Duration `mapstructure:"read_timeout"`     // 读取整个请求的超时时间
+	WriteTimeout    time.Duration `mapstructure:"write_timeout"`    // 写入响应的超时时间
+	IdleTimeout     time.Duration `mapstructure:"idle_timeout"`     // keep-alive 空闲连接超时时间
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"` // 优雅关闭时等待请求完成的最长时间
 }
 
 type DatabaseConfig struct {
-	Driver string `mapstructure:"driver"`
-	Name   string `mapstructure:"name"`
+	Driver      string            `mapstructure:"driver"` // sqlite3、postgres 或 mysql
+	Name        string            `mapstructure:"name"`   // SQLite 文件路径或数据库名
+	DSN         string            `mapstructure:"dsn"`    // 完整连接串，设置后忽略 host、port 等选项
+	Host        string            `mapstructure:"host"`
+	Port        int               `mapstructure:"port"`
+	User        string            `mapstructure:"user"`
+	Password    string            `mapstructure:"password"`
+	Params      map[string]string `mapstructure:"params"`       // 附加连接参数，例如 sslmode、charset
+	AutoMigrate bool              `mapstructure:"auto_migrate"` // 启动时自动执行未执行的迁移
+
+	MaxOpenConns    int           `mapstructure:"max_open_conns"`     // 最大打开连接数，0 表示不限制
+	MaxIdleConns    int           `mapstructure:"max_idle_conns"`     // 最大空闲连接数
+	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`  // 连接最长复用时间，0 表示不限制
+	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time"` // 连接最长空闲时间，0 表示不限制
+
+	LogLevel      string        `mapstructure:"log_level"`      // SQL 日志级别：silent、error、warn、info
+	SlowThreshold time.Duration `mapstructure:"slow_threshold"` // 超过该耗时的查询记为慢查询，0 表示不记录
+
+	SQLite SQLiteConfig `mapstructure:"sqlite"`
+}
+
+// SQLiteConfig SQLite 专用配置，仅在 driver 为 sqlite3 时生效
+type SQLiteConfig struct {
+	JournalMode         string        `mapstructure:"journal_mode"`         // 日志模式，推荐 WAL
+	Synchronous         string        `mapstructure:"synchronous"`          // 同步级别：OFF、NORMAL、FULL、EXTRA
+	BusyTimeout         time.Duration `mapstructure:"busy_timeout"`         // 数据库被锁定时的等待时间
+	ForeignKeys         bool          `mapstructure:"foreign_keys"`         // 是否启用外键约束
+	CacheSize           int           `mapstructure:"cache_size"`           // 页缓存大小，负数表示 KiB
+	IntegrityCheck      bool          `mapstructure:"integrity_check"`      // 启动时执行 PRAGMA integrity_check
+	MaintenanceInterval time.Duration `mapstructure:"maintenance_interval"` // 定期 ANALYZE/VACUUM 的间隔，0 表示不执行
 }
 
 type LogConfig struct {
@@ -28,20 +85,434 @@ type LogConfig struct {
 	MaxBackups int    `mapstructure:"max_backups"`
 }
 
-// LoadConfig 加载配置文件
+// CORSConfig 跨域配置，支持热更新
+type CORSConfig struct {
+	AllowOrigins []string `mapstructure:"allow_origins"` // 允许的来源，* 表示全部
+	AllowMethods []string `mapstructure:"allow_methods"`
+	AllowHeaders []string `mapstructure:"allow_headers"`
+}
+
+// RateLimitConfig 按客户端 IP 限流的配置，支持热更新
+type RateLimitConfig struct {
+	Enabled           bool    `mapstructure:"enabled"`
+	RequestsPerSecond float64 `mapstructure:"requests_per_second"` // 每秒补充的令牌数
+	Burst             int     `mapstructure:"burst"`               // 令牌桶容量
+}
+
+// VaultConfig 笔记库配置
+type VaultConfig struct {
+	Dir string `mapstructure:"dir"` // 笔记库根目录，存放附件等文件
+}
+
+// BackupConfig 自动备份配置，仅支持 SQLite
+type BackupConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`   // 是否定期自动备份
+	Dir      string        `mapstructure:"dir"`       // 备份文件目录
+	Interval time.Duration `mapstructure:"interval"`  // 自动备份间隔
+	KeepLast int           `mapstructure:"keep_last"` // 最多保留的备份数，0 表示不限制
+	MaxAge   time.Duration `mapstructure:"max_age"`   // 备份最长保留时间，0 表示不限制
+}
+
+// ExportConfig 导出配置
+type ExportConfig struct {
+	Dir         string `mapstructure:"dir"`           // 通过接口导出到目录时的根目录
+	PDFFont     string `mapstructure:"pdf_font"`      // PDF 正文字体（TrueType），为空时使用内置的 Go 字体（不含中文字形）
+	PDFBoldFont string `mapstructure:"pdf_bold_font"` // PDF 粗体字体，为空时使用正文字体
+	PDFMonoFont string `mapstructure:"pdf_mono_font"` // PDF 代码字体，为空时使用正文字体
+}
+
+// ImportConfig 导入配置
+type ImportConfig struct {
+	MaxUploadMB int64 `mapstructure:"max_upload_mb"` // 上传文件的大小上限（MiB）
+}
+
+// AttachmentConfig 附件配置，附件保存在笔记库的 attachments 目录
+type AttachmentConfig struct {
+	MaxSizeMB   int64         `mapstructure:"max_size_mb"`  // 单个附件的大小上限（MiB）
+	GCInterval  time.Duration `mapstructure:"gc_interval"`  // 清理未被引用的附件的间隔，0 表示不自动清理
+	GracePeriod time.Duration `mapstructure:"grace_period"` // 附件上传后未被引用多久才会被清理
+
+	Image ImageConfig `mapstructure:"image"`
+}
+
+// ImageConfig 图片附件的处理配置，只处理 JPEG、PNG，GIF 等其他格式原样保存
+type ImageConfig struct {
+	StripMetadata  bool  `mapstructure:"strip_metadata"`  // 上传时去除 EXIF、XMP 等元数据（包括拍摄位置）
+	MaxDimension   int   `mapstructure:"max_dimension"`   // 上传的图片长边超过该像素时缩小，0 表示不缩小
+	Recompress     bool  `mapstructure:"recompress"`      // 上传时重新编码，结果更小时才替换原图
+	JPEGQuality    int   `mapstructure:"jpeg_quality"`    // 重新编码 JPEG 和生成 JPEG 缩略图时的质量
+	ThumbnailSizes []int `mapstructure:"thumbnail_sizes"` // 可请求的缩略图尺寸（长边像素）
+}
+
+// PeriodicConfig 日记、周记、月记配置，周以 ISO 周为准（周一开始）
+type PeriodicConfig struct {
+	Daily   PeriodicNoteConfig `mapstructure:"daily"`
+	Weekly  PeriodicNoteConfig `mapstructure:"weekly"`
+	Monthly PeriodicNoteConfig `mapstructure:"monthly"`
+}
+
+// PeriodicNoteConfig 一种周期笔记的配置
+type PeriodicNoteConfig struct {
+	Path     string `mapstructure:"path"`     // 文件路径格式，日期记号同模板的 {{date:格式}}，为空时不启用
+	Template string `mapstructure:"template"` // 新建时使用的模板名称，为空时创建空白笔记
+}
+
+// ReminderConfig 提醒配置
+type ReminderConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`         // 是否定时触发提醒
+	CheckInterval  time.Duration `mapstructure:"check_interval"`  // 检查到期提醒的间隔，提醒最多延迟该时长
+	WebhookURL     string        `mapstructure:"webhook_url"`     // 提醒触发时 POST 通知的地址，为空时不发送
+	WebhookTimeout time.Duration `mapstructure:"webhook_timeout"` // 发送通知的超时时间
+}
+
+// EventsConfig 变更事件配置
+type EventsConfig struct {
+	PollInterval time.Duration `mapstructure:"poll_interval"` // 读取新事件的间隔，事件最多延迟该时长推送
+	Retention    time.Duration `mapstructure:"retention"`     // 事件保留时长，0 表示永久保留
+}
+
+// WebhookConfig Webhook 投递配置，订阅通过接口管理
+type WebhookConfig struct {
+	Enabled     bool          `mapstructure:"enabled"`      // 是否投递，关闭时投递记录保持等待
+	Interval    time.Duration `mapstructure:"interval"`     // 检查待投递记录的间隔
+	Timeout     time.Duration `mapstructure:"timeout"`      // 单次投递的超时时间
+	MaxAttempts int           `mapstructure:"max_attempts"` // 最多尝试的次数，用尽后标记为失败
+	Backoff     time.Duration `mapstructure:"backoff"`      // 第一次重试前的等待时间，之后每次翻倍
+	MaxBackoff  time.Duration `mapstructure:"max_backoff"`  // 重试等待时间的上限
+	Retention   time.Duration `mapstructure:"retention"`    // 已结束的投递记录保留时长，0 表示永久保留
+}
+
+// SyncConfig 客户端同步配置
+type SyncConfig struct {
+	ChangeRetention time.Duration `mapstructure:"change_retention"` // 已应用修改的记录保留时长，用于识别重复上传，0 表示永久保留
+}
+
+// defaults 各配置项的默认值，同时让 viper 知道所有键，使环境变量覆盖生效
+var defaults = map[string]interface{}{
+	"server.port":             8080,
+	"server.mode":             "release",
+	"server.read_timeout":     "15s",
+	"server.write_timeout":    "30s",
+	"server.idle_timeout":     "60s",
+	"server.shutdown_timeout": "10s",
+
+	"database.driver":       "sqlite3",
+	"database.name":         "leafnote.db",
+	"database.dsn":          "",
+	"database.host":         "",
+	"database.port":         0,
+	"database.user":         "",
+	"database.password":     "",
+	"database.auto_migrate": true,
+
+	"database.max_open_conns":     0,
+	"database.max_idle_conns":     2,
+	"database.conn_max_lifetime":  "0s",
+	"database.conn_max_idle_time": "0s",
+	"database.log_level":          "warn",
+	"database.slow_threshold":     "200ms",
+
+	"database.sqlite.journal_mode":         "WAL",
+	"database.sqlite.synchronous":          "NORMAL",
+	"database.sqlite.busy_timeout":         "5s",
+	"database.sqlite.foreign_keys":         true,
+	"database.sqlite.cache_size":           -20000,
+	"database.sqlite.integrity_check":      true,
+	"database.sqlite.maintenance_interval": "24h",
+
+	"log.level":       "info",
+	"log.filename":    "logs/leafnote.log",
+	"log.max_size":    100,
+	"log.max_age":     7,
+	"log.max_backups": 10,
+
+	"cors.allow_origins": []string{"*"},
+	"cors.allow_methods": []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+	"cors.allow_headers": []string{"Content-Type", "Authorization", "Accept-Language"},
+
+	"rate_limit.enabled":             false,
+	"rate_limit.requests_per_second": 20,
+	"rate_limit.burst":               40,
+
+	"vault.dir": "vault",
+
+	"backup.enabled":   true,
+	"backup.dir":       "backups",
+	"backup.interval":  "24h",
+	"backup.keep_last": 7,
+	"backup.max_age":   "720h",
+
+	"export.dir":           "exports",
+	"export.pdf_font":      "",
+	"export.pdf_bold_font": "",
+	"export.pdf_mono_font": "",
+
+	"import.max_upload_mb": 256,
+
+	"attachment.max_size_mb":  20,
+	"attachment.gc_interval":  "24h",
+	"attachment.grace_period": "24h",
+
+	"attachment.image.strip_metadata":  true,
+	"attachment.image.max_dimension":   0,
+	"attachment.image.recompress":      false,
+	"attachment.image.jpeg_quality":    85,
+	"attachment.image.thumbnail_sizes": []int{256, 1024},
+
+	"periodic.daily.path":       "日记/YYYY/YYYY-MM-DD.md",
+	"periodic.daily.template":   "",
+	"periodic.weekly.path":      "周记/GGGG/GGGG-[W]WW.md",
+	"periodic.weekly.template":  "",
+	"periodic.monthly.path":     "月记/YYYY/YYYY-MM.md",
+	"periodic.monthly.template": "",
+
+	"reminder.enabled":         true,
+	"reminder.check_interval":  "30s",
+	"reminder.webhook_url":     "",
+	"reminder.webhook_timeout": "10s",
+
+	"events.poll_interval": "500ms",
+	"events.retention":     "720h",
+
+	"webhook.enabled":      true,
+	"webhook.interval":     "2s",
+	"webhook.timeout":      "10s",
+	"webhook.max_attempts": 8,
+	"webhook.backoff":      "30s",
+	"webhook.max_backoff":  "6h",
+	"webhook.retention":    "720h",
+
+	"sync.change_retention": "720h",
+}
+
+// newViper 创建带默认值和环境变量覆盖的 viper 实例
+func newViper(configPath string) *viper.Viper {
+	v := viper.New()
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	if configPath != "" {
+		v.SetConfigFile(configPath)
+	}
+	return v
+}
+
+// LoadConfig 加载配置文件，优先级：环境变量 > 配置文件 > 默认值
+// configPath 为空时只使用默认值和环境变量
 func LoadConfig(configPath string) (*Config, error) {
+	v := newViper(configPath)
+	if configPath != "" {
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("读取配置文件 %s 失败: %w", configPath, err)
+		}
+	}
+	return unmarshal(v)
+}
+
+// unmarshal 解析并校验配置
+func unmarshal(v *viper.Viper) (*Config, error) {
 	var config Config
+	if err := v.Unmarshal(&config); err != nil {
+		return nil, fmt.Errorf("解析配置失败: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
 
-	viper.SetConfigFile(configPath)
-	viper.AutomaticEnv()
+// Validate 校验配置，返回所有不合法的配置项
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
 
-	if err := viper.ReadInConfig(); err != nil {
-		return nil, err
+	check(c.Server.Port > 0 && c.Server.Port <= 65535, "server.port 必须在 1-65535 之间，当前为 %d", c.Server.Port)
+	check(oneOf(c.Server.Mode, "debug", "release", "test"), "server.mode 必须是 debug、release 或 test，当前为 %q", c.Server.Mode)
+	check(c.Server.ReadTimeout >= 0, "server.read_timeout 不能为负数")
+	check(c.Server.WriteTimeout >= 0, "server.write_timeout 不能为负数")
+	check(c.Server.IdleTimeout >= 0, "server.idle_timeout 不能为负数")
+	check(c.Server.ShutdownTimeout >= 0, "server.shutdown_timeout 不能为负数")
+
+	check(oneOf(c.Database.Driver, "sqlite3", "sqlite", "postgres", "mysql"), "database.driver 不支持 %q，可选值：sqlite3、postgres、mysql", c.Database.Driver)
+	if c.Database.DSN == "" {
+		check(c.Database.Name != "", "database.name 不能为空")
+		if !c.Database.IsSQLite() {
+			check(c.Database.Host != "", "database.host 不能为空（或直接设置 database.dsn）")
+		}
+	}
+	check(c.Database.Port >= 0 && c.Database.Port <= 65535, "database.port 必须在 0-65535 之间，当前为 %d", c.Database.Port)
+	check(c.Database.MaxOpenConns >= 0, "database.max_open_conns 不能为负数")
+	check(c.Database.MaxIdleConns >= 0, "database.max_idle_conns 不能为负数")
+	check(c.Database.ConnMaxLifetime >= 0, "database.conn_max_lifetime 不能为负数")
+	check(c.Database.ConnMaxIdleTime >= 0, "database.conn_max_idle_time 不能为负数")
+	check(oneOf(c.Database.LogLevel, "silent", "error", "warn", "info"), "database.log_level 必须是 silent、error、warn 或 info，当前为 %q", c.Database.LogLevel)
+	check(c.Database.SlowThreshold >= 0, "database.slow_threshold 不能为负数")
+	if c.Database.IsSQLite() {
+		sc := c.Database.SQLite
+		check(oneOf(strings.ToUpper(sc.JournalMode), "DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF"), "database.sqlite.journal_mode 不支持 %q，可选值：DELETE、TRUNCATE、PERSIST、MEMORY、WAL、OFF", sc.JournalMode)
+		check(oneOf(strings.ToUpper(sc.Synchronous), "OFF", "NORMAL", "FULL", "EXTRA"), "database.sqlite.synchronous 不支持 %q，可选值：OFF、NORMAL、FULL、EXTRA", sc.Synchronous)
+		check(sc.BusyTimeout >= 0, "database.sqlite.busy_timeout 不能为负数")
+		check(sc.MaintenanceInterval >= 0, "database.sqlite.maintenance_interval 不能为负数")
 	}
 
-	if err := viper.Unmarshal(&config); err != nil {
-		return nil, err
+	check(oneOf(c.Log.Level, "debug", "info", "warn", "error"), "log.level 必须是 debug、info、warn 或 error，当前为 %q", c.Log.Level)
+	check(c.Log.Filename != "", "log.filename 不能为空")
+	check(c.Log.MaxSize > 0, "log.max_size 必须大于 0")
+	check(c.Log.MaxAge >= 0, "log.max_age 不能为负数")
+	check(c.Log.MaxBackups >= 0, "log.max_backups 不能为负数")
+
+	check(len(c.CORS.AllowOrigins) > 0, "cors.allow_origins 不能为空")
+
+	if c.RateLimit.Enabled {
+		check(c.RateLimit.RequestsPerSecond > 0, "rate_limit.requests_per_second 必须大于 0")
+		check(c.RateLimit.Burst > 0, "rate_limit.burst 必须大于 0")
 	}
 
-	return &config, nil
+	check(c.Vault.Dir != "", "vault.dir 不能为空")
+
+	check(c.Backup.Dir != "", "backup.dir 不能为空")
+	check(c.Backup.KeepLast >= 0, "backup.keep_last 不能为负数")
+	check(c.Backup.MaxAge >= 0, "backup.max_age 不能为负数")
+	if c.Backup.Enabled {
+		check(c.Backup.Interval > 0, "backup.interval 必须大于 0")
+		check(c.Database.IsSQLite(), "backup.enabled 仅支持 SQLite，请使用数据库自带的备份工具")
+	}
+
+	check(c.Export.Dir != "", "export.dir 不能为空")
+	check(c.Import.MaxUploadMB > 0, "import.max_upload_mb 必须大于 0")
+	check(c.Attachment.MaxSizeMB > 0, "attachment.max_size_mb 必须大于 0")
+	check(c.Attachment.GCInterval >= 0, "attachment.gc_interval 不能为负数")
+	check(c.Attachment.GracePeriod >= 0, "attachment.grace_period 不能为负数")
+	check(c.Attachment.Image.MaxDimension >= 0, "attachment.image.max_dimension 不能为负数")
+	check(c.Attachment.Image.JPEGQuality >= 1 && c.Attachment.Image.JPEGQuality <= 100, "attachment.image.jpeg_quality 必须在 1-100 之间，当前为 %d", c.Attachment.Image.JPEGQuality)
+	for _, size := range c.Attachment.Image.ThumbnailSizes {
+		check(size >= 16 && size <= 4096, "attachment.image.thumbnail_sizes 中的尺寸必须在 16-4096 之间，当前为 %d", size)
+	}
+
+	check(validPeriodicPath(c.Periodic.Daily.Path), "periodic.daily.path 必须以 .md 结尾，当前为 %q", c.Periodic.Daily.Path)
+	check(validPeriodicPath(c.Periodic.Weekly.Path), "periodic.weekly.path 必须以 .md 结尾，当前为 %q", c.Periodic.Weekly.Path)
+	check(validPeriodicPath(c.Periodic.Monthly.Path), "periodic.monthly.path 必须以 .md 结尾，当前为 %q", c.Periodic.Monthly.Path)
+
+	if c.Reminder.Enabled {
+		check(c.Reminder.CheckInterval > 0, "reminder.check_interval 必须大于 0")
+	}
+	check(validWebhookURL(c.Reminder.WebhookURL), "reminder.webhook_url 必须是 http 或 https 地址，当前为 %q", c.Reminder.WebhookURL)
+	check(c.Reminder.WebhookTimeout > 0, "reminder.webhook_timeout 必须大于 0")
+
+	check(c.Events.PollInterval > 0, "events.poll_interval 必须大于 0")
+	check(c.Events.Retention >= 0, "events.retention 不能为负数")
+
+	if c.Webhook.Enabled {
+		check(c.Webhook.Interval > 0, "webhook.interval 必须大于 0")
+	}
+	check(c.Webhook.Timeout > 0, "webhook.timeout 必须大于 0")
+	check(c.Webhook.MaxAttempts >= 1, "webhook.max_attempts 至少为 1，当前为 %d", c.Webhook.MaxAttempts)
+	check(c.Webhook.Backoff > 0, "webhook.backoff 必须大于 0")
+	check(c.Webhook.MaxBackoff >= c.Webhook.Backoff, "webhook.max_backoff 不能小于 webhook.backoff")
+	check(c.Webhook.Retention >= 0, "webhook.retention 不能为负数")
+
+	check(c.Sync.ChangeRetention >= 0, "sync.change_retention 不能为负数")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("配置校验失败: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Live 保存当前生效的配置，支持并发读取和热更新
+type Live struct {
+	current atomic.Pointer[Config]
+}
+
+// NewLive 创建热更新配置容器
+func NewLive(cfg *Config) *Live {
+	l := &Live{}
+	l.current.Store(cfg)
+	return l
+}
+
+// Load 返回当前生效的配置，调用方不得修改返回值
+func (l *Live) Load() *Config {
+	return l.current.Load()
+}
+
+// Watch 监听配置文件变化，只热更新日志级别、CORS 和限流配置
+// 新配置校验失败时保留原配置；其他配置项的变化需要重启才能生效
+func (l *Live) Watch(configPath string, logger *zap.Logger) {
+	if configPath == "" {
+		return
+	}
+
+	v := newViper(configPath)
+	v.OnConfigChange(func(e fsnotify.Event) {
+		if err := v.ReadInConfig(); err != nil {
+			logger.Error("Failed to reload config", zap.String("file", e.Name), zap.Error(err))
+			return
+		}
+		next, err := unmarshal(v)
+		if err != nil {
+			logger.Error("Invalid config ignored", zap.String("file", e.Name), zap.Error(err))
+			return
+		}
+
+		prev := l.Load()
+		applied := *prev
+		applied.Log.Level = next.Log.Level
+		applied.CORS = next.CORS
+		applied.RateLimit = next.RateLimit
+		if err := SetLogLevel(next.Log.Level); err != nil {
+			logger.Error("Failed to update log level", zap.Error(err))
+		}
+		l.current.Store(&applied)
+
+		if next.Server != prev.Server || !reflect.DeepEqual(next.Database, prev.Database) || !sameLogOutput(next.Log, prev.Log) {
+			logger.Warn("Server, database and log output settings changed; restart required to apply them")
+		}
+		logger.Info("Config reloaded",
+			zap.String("log_level", applied.Log.Level),
+			zap.Strings("cors_allow_origins", applied.CORS.AllowOrigins),
+			zap.Bool("rate_limit_enabled", applied.RateLimit.Enabled))
+	})
+	if err := v.ReadInConfig(); err != nil {
+		logger.Error("Failed to watch config", zap.String("file", configPath), zap.Error(err))
+		return
+	}
+	v.WatchConfig()
+}
+
+// sameLogOutput 比较除日志级别外的日志配置
+func sameLogOutput(a, b LogConfig) bool {
+	a.Level, b.Level = "", ""
+	return a == b
+}
+
+// validPeriodicPath 周期笔记路径为空（不启用）或以 .md 结尾；能否区分不同周期由服务创建时检查
+func validPeriodicPath(p string) bool {
+	return p == "" || strings.HasSuffix(p, ".md")
+}
+
+// validWebhookURL 判断 Webhook 地址是否为空或 http(s) 地址
+func validWebhookURL(s string) bool {
+	if s == "" {
+		return true
+	}
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// oneOf 判断 value 是否为候选值之一
+func oneOf(value string, candidates ...string) bool {
+	for _, c := range candidates {
+		if value == c {
+			return true
+		}
+	}
+	return false
 }
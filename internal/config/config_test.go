@@ -0,0 +1,167 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func writeConfig(t *testing.T, dir, content string) string {
+	path := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	cfg, err := LoadConfig("")
+	require.NoError(t, err)
+
+	assert.Equal(t, 8080, cfg.Server.Port)
+	assert.Equal(t, "release", cfg.Server.Mode)
+	assert.Equal(t, 15*time.Second, cfg.Server.ReadTimeout)
+	assert.Equal(t, "sqlite3", cfg.Database.Driver)
+	assert.Equal(t, "info", cfg.Log.Level)
+	assert.Equal(t, []string{"*"}, cfg.CORS.AllowOrigins)
+	assert.False(t, cfg.RateLimit.Enabled)
+	assert.True(t, cfg.Attachment.Image.StripMetadata)
+	assert.Equal(t, []int{256, 1024}, cfg.Attachment.Image.ThumbnailSizes)
+	assert.Equal(t, "日记/YYYY/YYYY-MM-DD.md", cfg.Periodic.Daily.Path)
+	assert.Equal(t, 30*time.Second, cfg.Reminder.CheckInterval)
+	assert.Equal(t, 500*time.Millisecond, cfg.Events.PollInterval)
+	assert.Equal(t, 720*time.Hour, cfg.Events.Retention)
+	assert.Equal(t, 8, cfg.Webhook.MaxAttempts)
+	assert.Equal(t, 30*time.Second, cfg.Webhook.Backoff)
+	assert.Equal(t, 720*time.Hour, cfg.Sync.ChangeRetention)
+}
+
+func TestLoadConfig_EnvOverride(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "server:\n  port: 9000\n")
+
+	t.Setenv("LEAFNOTE_SERVER_PORT", "9100")
+	t.Setenv("LEAFNOTE_LOG_LEVEL", "warn")
+	t.Setenv("LEAFNOTE_CORS_ALLOW_ORIGINS", "http://a.com,http://b.com")
+	t.Setenv("LEAFNOTE_RATE_LIMIT_ENABLED", "true")
+	t.Setenv("LEAFNOTE_ATTACHMENT_IMAGE_THUMBNAIL_SIZES", "128,512")
+
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, 9100, cfg.Server.Port)
+	assert.Equal(t, "warn", cfg.Log.Level)
+	assert.Equal(t, []string{"http://a.com", "http://b.com"}, cfg.CORS.AllowOrigins)
+	assert.True(t, cfg.RateLimit.Enabled)
+	assert.Equal(t, []int{128, 512}, cfg.Attachment.Image.ThumbnailSizes)
+}
+
+func TestLoadConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "端口超出范围",
+			content: "server:\n  port: 70000\n",
+			wantErr: "server.port",
+		},
+		{
+			name:    "不支持的数据库驱动",
+			content: "database:\n  driver: oracle\n",
+			wantErr: "database.driver",
+		},
+		{
+			name:    "PostgreSQL 缺少主机",
+			content: "database:\n  driver: postgres\n",
+			wantErr: "database.host",
+		},
+		{
+			name:    "非法日志级别",
+			content: "log:\n  level: verbose\n",
+			wantErr: "log.level",
+		},
+		{
+			name:    "启用限流但速率为零",
+			content: "rate_limit:\n  enabled: true\n  requests_per_second: 0\n",
+			wantErr: "rate_limit.requests_per_second",
+		},
+		{
+			name:    "缩略图尺寸超出范围",
+			content: "attachment:\n  image:\n    thumbnail_sizes: [8]\n",
+			wantErr: "attachment.image.thumbnail_sizes",
+		},
+		{
+			name:    "周期笔记路径不是 Markdown",
+			content: "periodic:\n  weekly:\n    path: 周记/GGGG-WW.txt\n",
+			wantErr: "periodic.weekly.path",
+		},
+		{
+			name:    "Webhook 地址不是 http",
+			content: "reminder:\n  webhook_url: ftp://example.com/hook\n",
+			wantErr: "reminder.webhook_url",
+		},
+		{
+			name:    "事件读取间隔为 0",
+			content: "events:\n  poll_interval: 0s\n",
+			wantErr: "events.poll_interval",
+		},
+		{
+			name:    "Webhook 退避上限小于初始值",
+			content: "webhook:\n  backoff: 1h\n  max_backoff: 10m\n",
+			wantErr: "webhook.max_backoff",
+		},
+		{
+			name:    "同步修改记录保留时长为负数",
+			content: "sync:\n  change_retention: -1h\n",
+			wantErr: "sync.change_retention",
+		},
+		{
+			name:    "时长格式错误",
+			content: "server:\n  read_timeout: soon\n",
+			wantErr: "解析配置失败",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, t.TempDir(), tt.content)
+			_, err := LoadConfig(path)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	t.Run("配置文件不存在", func(t *testing.T) {
+		_, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "missing.yaml")
+	})
+}
+
+func TestLive_Watch(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfig(t, dir, "log:\n  level: info\n")
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+
+	live := NewLive(cfg)
+	live.Watch(path, zap.NewNop())
+
+	// 非法配置不会生效
+	writeConfig(t, dir, "log:\n  level: verbose\n")
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, "info", live.Load().Log.Level)
+
+	// 可热更新的配置立即生效，端口等配置保持不变
+	writeConfig(t, dir, "server:\n  port: 9999\nlog:\n  level: error\nrate_limit:\n  enabled: true\n")
+	assert.Eventually(t, func() bool {
+		return live.Load().Log.Level == "error"
+	}, 2*time.Second, 20*time.Millisecond)
+	assert.True(t, live.Load().RateLimit.Enabled)
+	assert.Equal(t, 8080, live.Load().Server.Port)
+	assert.False(t, logLevel.Enabled(zap.WarnLevel))
+}
@@ -1,34 +1,167 @@
 package config
 
 import (
-	"leafnote/internal/model"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
 
+	"go.uber.org/zap"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
-// InitDB 初始化数据库连接
-func InitDB(cfg *DatabaseConfig) (*gorm.DB, error) {
-	// 配置 GORM
+// IsSQLite 判断是否使用 SQLite
+func (c *DatabaseConfig) IsSQLite() bool {
+	return c.Driver == "sqlite3" || c.Driver == "sqlite"
+}
+
+// InitDB 初始化数据库连接，表结构由 migration 包维护
+func InitDB(cfg *DatabaseConfig, logger *zap.Logger) (*gorm.DB, error) {
+	// 配置 GORM，SQL 日志输出到 zap
 	gormConfig := &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger: NewGormLogger(logger, cfg.LogLevel, cfg.SlowThreshold),
+	}
+
+	dialector, err := Dialector(cfg)
+	if err != nil {
+		return nil, err
 	}
 
 	// 连接数据库
-	db, err := gorm.Open(sqlite.Open(cfg.Name), gormConfig)
+	db, err := gorm.Open(dialector, gormConfig)
 	if err != nil {
 		return nil, err
 	}
 
-	// 自动迁移数据库结构
-	err = db.AutoMigrate(
-		&model.Note{},
-		&model.Category{},
-	)
+	// 配置连接池
+	sqlDB, err := db.DB()
 	if err != nil {
 		return nil, err
 	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	// 启动时检查 SQLite 数据库文件是否损坏
+	if cfg.IsSQLite() && cfg.SQLite.IntegrityCheck {
+		if err := CheckIntegrity(db); err != nil {
+			sqlDB.Close()
+			return nil, err
+		}
+	}
 
 	return db, nil
 }
+
+// Dialector 根据驱动创建 GORM 方言
+func Dialector(cfg *DatabaseConfig) (gorm.Dialector, error) {
+	switch {
+	case cfg.IsSQLite():
+		return sqlite.Open(sqliteDSN(cfg)), nil
+	case cfg.Driver == "postgres":
+		return postgres.Open(postgresDSN(cfg)), nil
+	case cfg.Driver == "mysql":
+		return mysql.Open(mysqlDSN(cfg)), nil
+	default:
+		return nil, fmt.Errorf("不支持的数据库驱动: %s", cfg.Driver)
+	}
+}
+
+// sqliteDSN 生成 SQLite 连接串，PRAGMA 配置和 params 作为 URI 查询参数
+func sqliteDSN(cfg *DatabaseConfig) string {
+	if cfg.DSN != "" {
+		return cfg.DSN
+	}
+	params := sqlitePragmaParams(&cfg.SQLite)
+	for k, v := range cfg.Params {
+		params[k] = v
+	}
+	return "file:" + cfg.Name + "?" + encodeParams(params)
+}
+
+// postgresDSN 生成 key=value 形式的 PostgreSQL 连接串
+func postgresDSN(cfg *DatabaseConfig) string {
+	if cfg.DSN != "" {
+		return cfg.DSN
+	}
+	parts := []string{
+		"host=" + quoteDSNValue(cfg.Host),
+		"dbname=" + quoteDSNValue(cfg.Name),
+	}
+	if cfg.Port > 0 {
+		parts = append(parts, "port="+strconv.Itoa(cfg.Port))
+	}
+	if cfg.User != "" {
+		parts = append(parts, "user="+quoteDSNValue(cfg.User))
+	}
+	if cfg.Password != "" {
+		parts = append(parts, "password="+quoteDSNValue(cfg.Password))
+	}
+	for _, k := range sortedKeys(cfg.Params) {
+		parts = append(parts, k+"="+quoteDSNValue(cfg.Params[k]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// mysqlDSN 生成 go-sql-driver 格式的 MySQL 连接串
+// 模型包含 time.Time 字段，默认开启 parseTime 并使用 utf8mb4
+func mysqlDSN(cfg *DatabaseConfig) string {
+	if cfg.DSN != "" {
+		return cfg.DSN
+	}
+	params := map[string]string{"parseTime": "true", "charset": "utf8mb4", "loc": "Local"}
+	for k, v := range cfg.Params {
+		if name, ok := mysqlParamNames[k]; ok {
+			k = name
+		}
+		params[k] = v
+	}
+	port := cfg.Port
+	if port == 0 {
+		port = 3306
+	}
+	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?%s", cfg.User, cfg.Password, cfg.Host, port, cfg.Name, encodeParams(params))
+}
+
+// mysqlParamNames 配置文件中的键会被转换为小写，这里还原 MySQL 驱动区分大小写的常用参数名
+var mysqlParamNames = map[string]string{
+	"parsetime":            "parseTime",
+	"readtimeout":          "readTimeout",
+	"writetimeout":         "writeTimeout",
+	"allownativepasswords": "allowNativePasswords",
+	"multistatements":      "multiStatements",
+	"interpolateparams":    "interpolateParams",
+	"maxallowedpacket":     "maxAllowedPacket",
+}
+
+// quoteDSNValue 按 libpq 规则为包含空格或引号的值加引号
+func quoteDSNValue(v string) string {
+	if v != "" && !strings.ContainsAny(v, ` '\`) {
+		return v
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
+
+// encodeParams 按键排序编码查询参数，保证生成的连接串稳定
+func encodeParams(params map[string]string) string {
+	values := make([]string, 0, len(params))
+	for _, k := range sortedKeys(params) {
+		values = append(values, url.QueryEscape(k)+"="+url.QueryEscape(params[k]))
+	}
+	return strings.Join(values, "&")
+}
+
+// sortedKeys 返回按字典序排列的键
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
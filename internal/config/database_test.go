@@ -0,0 +1,142 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func TestDialector(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      DatabaseConfig
+		wantName string
+		wantDSN  string
+	}{
+		{
+			name: "SQLite PRAGMA",
+			cfg: DatabaseConfig{Driver: "sqlite3", Name: "leafnote.db", SQLite: SQLiteConfig{
+				JournalMode: "wal", Synchronous: "normal", BusyTimeout: 5 * time.Second, ForeignKeys: true, CacheSize: -2000,
+			}},
+			wantName: "sqlite",
+			wantDSN:  "file:leafnote.db?_busy_timeout=5000&_cache_size=-2000&_foreign_keys=1&_journal_mode=WAL&_synchronous=NORMAL",
+		},
+		{
+			name:     "SQLite 附加参数",
+			cfg:      DatabaseConfig{Driver: "sqlite3", Name: "leafnote.db", Params: map[string]string{"mode": "rwc"}},
+			wantName: "sqlite",
+			wantDSN:  "file:leafnote.db?_busy_timeout=0&_foreign_keys=0&mode=rwc",
+		},
+		{
+			name: "PostgreSQL 连接选项",
+			cfg: DatabaseConfig{
+				Driver: "postgres", Host: "db", Port: 5432, Name: "leafnote", User: "leaf", Password: "p w'd",
+				Params: map[string]string{"sslmode": "disable"},
+			},
+			wantName: "postgres",
+			wantDSN:  `host=db dbname=leafnote port=5432 user=leaf password='p w\'d' sslmode=disable`,
+		},
+		{
+			name: "MySQL 默认参数和还原大小写",
+			cfg: DatabaseConfig{
+				Driver: "mysql", Host: "db", Name: "leafnote", User: "leaf", Password: "secret",
+				Params: map[string]string{"readtimeout": "5s"},
+			},
+			wantName: "mysql",
+			wantDSN:  "leaf:secret@tcp(db:3306)/leafnote?charset=utf8mb4&loc=Local&parseTime=true&readTimeout=5s",
+		},
+		{
+			name:     "DSN 优先",
+			cfg:      DatabaseConfig{Driver: "postgres", DSN: "postgres://leaf@db/leafnote", Host: "ignored"},
+			wantName: "postgres",
+			wantDSN:  "postgres://leaf@db/leafnote",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := Dialector(&tt.cfg)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantName, d.Name())
+
+			switch {
+			case tt.cfg.IsSQLite():
+				assert.Equal(t, tt.wantDSN, sqliteDSN(&tt.cfg))
+			case tt.cfg.Driver == "postgres":
+				assert.Equal(t, tt.wantDSN, postgresDSN(&tt.cfg))
+			default:
+				assert.Equal(t, tt.wantDSN, mysqlDSN(&tt.cfg))
+			}
+		})
+	}
+
+	_, err := Dialector(&DatabaseConfig{Driver: "oracle"})
+	assert.Error(t, err)
+}
+
+func TestInitDB_PoolSettings(t *testing.T) {
+	db, err := InitDB(&DatabaseConfig{Driver: "sqlite3", Name: ":memory:", MaxOpenConns: 3, MaxIdleConns: 1}, zap.NewNop())
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	assert.Equal(t, 3, sqlDB.Stats().MaxOpenConnections)
+}
+
+func TestInitDB_SQLitePragmas(t *testing.T) {
+	cfg, err := LoadConfig("")
+	require.NoError(t, err)
+	cfg.Database.Name = filepath.Join(t.TempDir(), "leafnote.db")
+
+	db, err := InitDB(&cfg.Database, zap.NewNop())
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	pragma := func(name string) string {
+		var v string
+		require.NoError(t, db.Raw("PRAGMA "+name).Scan(&v).Error)
+		return v
+	}
+	assert.Equal(t, "wal", pragma("journal_mode"))
+	assert.Equal(t, "1", pragma("synchronous")) // NORMAL
+	assert.Equal(t, "5000", pragma("busy_timeout"))
+	assert.Equal(t, "1", pragma("foreign_keys"))
+	assert.Equal(t, "-20000", pragma("cache_size"))
+
+	assert.NoError(t, CheckIntegrity(db))
+	assert.NoError(t, optimizeSQLite(context.Background(), db))
+}
+
+func TestGormLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := NewGormLogger(zap.New(core), "warn", 100*time.Millisecond)
+	ctx := context.Background()
+	sql := func() (string, int64) { return "SELECT 1", 1 }
+
+	l.Trace(ctx, time.Now(), sql, nil)
+	l.Trace(ctx, time.Now(), sql, gorm.ErrRecordNotFound)
+	assert.Equal(t, 0, logs.Len(), "普通查询和记录不存在不记录日志")
+
+	l.Trace(ctx, time.Now().Add(-time.Second), sql, nil)
+	l.Trace(ctx, time.Now(), sql, errors.New("boom"))
+	entries := logs.TakeAll()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "Slow SQL", entries[0].Message)
+	assert.Equal(t, "SQL error", entries[1].Message)
+
+	l.LogMode(gormlogger.Info).Trace(ctx, time.Now(), sql, nil)
+	assert.Equal(t, 1, logs.FilterMessage("SQL").Len())
+}
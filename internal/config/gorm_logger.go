@@ -0,0 +1,99 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// gormLogger 将 GORM 日志输出到 zap，记录执行错误和慢查询
+type gormLogger struct {
+	logger        *zap.Logger
+	level         gormlogger.LogLevel
+	slowThreshold time.Duration
+}
+
+// NewGormLogger 创建输出到 zap 的 GORM 日志记录器
+// info 级别下每条 SQL 以 debug 级别输出，是否可见还取决于 log.level
+func NewGormLogger(logger *zap.Logger, level string, slowThreshold time.Duration) gormlogger.Interface {
+	return &gormLogger{
+		logger:        logger.Named("gorm"),
+		level:         parseGormLevel(level),
+		slowThreshold: slowThreshold,
+	}
+}
+
+// parseGormLevel 解析 SQL 日志级别，未知值按 warn 处理
+func parseGormLevel(level string) gormlogger.LogLevel {
+	switch level {
+	case "silent":
+		return gormlogger.Silent
+	case "error":
+		return gormlogger.Error
+	case "info":
+		return gormlogger.Info
+	default:
+		return gormlogger.Warn
+	}
+}
+
+// LogMode 返回指定级别的日志记录器
+func (l *gormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *l
+	clone.level = level
+	return &clone
+}
+
+// Info 输出普通日志
+func (l *gormLogger) Info(_ context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Info {
+		l.logger.Info(fmt.Sprintf(msg, args...), zap.String("caller", utils.FileWithLineNum()))
+	}
+}
+
+// Warn 输出警告日志
+func (l *gormLogger) Warn(_ context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		l.logger.Warn(fmt.Sprintf(msg, args...), zap.String("caller", utils.FileWithLineNum()))
+	}
+}
+
+// Error 输出错误日志
+func (l *gormLogger) Error(_ context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Error {
+		l.logger.Error(fmt.Sprintf(msg, args...), zap.String("caller", utils.FileWithLineNum()))
+	}
+}
+
+// Trace 记录 SQL 执行结果，记录不存在不视为错误
+func (l *gormLogger) Trace(_ context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	fields := func() []zap.Field {
+		sql, rows := fc()
+		return []zap.Field{
+			zap.String("sql", sql),
+			zap.Int64("rows", rows),
+			zap.Duration("elapsed", elapsed),
+			zap.String("caller", utils.FileWithLineNum()),
+		}
+	}
+
+	switch {
+	case err != nil && l.level >= gormlogger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		l.logger.Error("SQL error", append(fields(), zap.Error(err))...)
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold && l.level >= gormlogger.Warn:
+		l.logger.Warn("Slow SQL", append(fields(), zap.Duration("threshold", l.slowThreshold))...)
+	case l.level >= gormlogger.Info:
+		l.logger.Debug("SQL", fields()...)
+	}
+}
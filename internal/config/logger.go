@@ -2,16 +2,20 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
+// logLevel 全局日志级别，支持热更新
+var logLevel = zap.NewAtomicLevel()
+
 // InitLogger 初始化日志系统
 func InitLogger(cfg *LogConfig) (*zap.Logger, error) {
 	// 确保日志目录存在
-	if err := os.MkdirAll("logs", 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(cfg.Filename), 0755); err != nil {
 		return nil, err
 	}
 
@@ -25,18 +29,8 @@ func InitLogger(cfg *LogConfig) (*zap.Logger, error) {
 	}
 
 	// 设置日志级别
-	var level zapcore.Level
-	switch cfg.Level {
-	case "debug":
-		level = zapcore.DebugLevel
-	case "info":
-		level = zapcore.InfoLevel
-	case "warn":
-		level = zapcore.WarnLevel
-	case "error":
-		level = zapcore.ErrorLevel
-	default:
-		level = zapcore.InfoLevel
+	if err := SetLogLevel(cfg.Level); err != nil {
+		return nil, err
 	}
 
 	// 编码器配置
@@ -58,10 +52,20 @@ func InitLogger(cfg *LogConfig) (*zap.Logger, error) {
 	core := zapcore.NewCore(
 		zapcore.NewJSONEncoder(encoderConfig),
 		zapcore.NewMultiWriteSyncer(zapcore.AddSync(writer), zapcore.AddSync(os.Stdout)),
-		level,
+		logLevel,
 	)
 
 	// 创建日志记录器
 	logger := zap.New(core, zap.AddCaller())
 	return logger, nil
 }
+
+// SetLogLevel 动态调整日志级别
+func SetLogLevel(level string) error {
+	var l zapcore.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return err
+	}
+	logLevel.SetLevel(l)
+	return nil
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// sqlitePragmaParams 将 SQLite 配置转换为驱动的连接参数，驱动会在每个新连接上执行对应的 PRAGMA
+func sqlitePragmaParams(cfg *SQLiteConfig) map[string]string {
+	foreignKeys := "0"
+	if cfg.ForeignKeys {
+		foreignKeys = "1"
+	}
+	params := map[string]string{
+		"_foreign_keys": foreignKeys,
+		"_busy_timeout": strconv.FormatInt(cfg.BusyTimeout.Milliseconds(), 10),
+	}
+	if cfg.JournalMode != "" {
+		params["_journal_mode"] = strings.ToUpper(cfg.JournalMode)
+	}
+	if cfg.Synchronous != "" {
+		params["_synchronous"] = strings.ToUpper(cfg.Synchronous)
+	}
+	if cfg.CacheSize != 0 {
+		params["_cache_size"] = strconv.Itoa(cfg.CacheSize)
+	}
+	return params
+}
+
+// CheckIntegrity 执行 PRAGMA integrity_check，数据库损坏时返回问题列表
+func CheckIntegrity(db *gorm.DB) error {
+	var results []string
+	if err := db.Raw("PRAGMA integrity_check").Scan(&results).Error; err != nil {
+		return fmt.Errorf("执行完整性检查失败: %w", err)
+	}
+	if len(results) == 1 && results[0] == "ok" {
+		return nil
+	}
+	return fmt.Errorf("数据库完整性检查未通过: %s", strings.Join(results, "; "))
+}
+
+// SQLiteMaintenance 返回定期执行 ANALYZE 和 VACUUM 的后台任务
+// 单次维护失败只记录日志，不影响服务运行
+func SQLiteMaintenance(db *gorm.DB, interval time.Duration, logger *zap.Logger) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				start := time.Now()
+				if err := optimizeSQLite(ctx, db); err != nil {
+					logger.Error("SQLite maintenance failed", zap.Error(err))
+					continue
+				}
+				logger.Info("SQLite maintenance finished", zap.Duration("cost", time.Since(start)))
+			}
+		}
+	}
+}
+
+// optimizeSQLite 更新查询统计信息并整理数据库文件
+func optimizeSQLite(ctx context.Context, db *gorm.DB) error {
+	tx := db.WithContext(ctx)
+	if err := tx.Exec("ANALYZE").Error; err != nil {
+		return fmt.Errorf("ANALYZE 失败: %w", err)
+	}
+	if err := tx.Exec("VACUUM").Error; err != nil {
+		return fmt.Errorf("VACUUM 失败: %w", err)
+	}
+	// WAL 模式下把日志写回主库并截断，避免 -wal 文件持续增长
+	return tx.Exec("PRAGMA wal_checkpoint(TRUNCATE)").Error
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"os"
+
+	"leafnote/internal/model"
+	"leafnote/internal/response"
+	"leafnote/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// multipartOverhead 上传附件时请求体中 multipart 边界和头部的余量
+const multipartOverhead = 64 << 10
+
+// UploadAttachment 上传附件，内容已存在时返回已有的附件；
+// 返回的 path 可以直接写入笔记正文，例如 ![图](attachments/<hash>.png)
+func (h *Handler) UploadAttachment(c *gin.Context) {
+	limit := h.maxUploadSize
+	if h.maxAttachmentSize > 0 {
+		limit = h.maxAttachmentSize + multipartOverhead
+	}
+	h.allowSlowUpload(c)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+	header, err := c.FormFile("file")
+	if err != nil {
+		h.logger.Error("Invalid attachment upload", zap.Error(err))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			response.Error(c, service.ErrAttachmentTooLarge)
+			return
+		}
+		response.Error(c, service.ErrAttachmentFileRequired)
+		return
+	}
+	f, err := header.Open()
+	if err != nil {
+		h.logger.Error("Failed to open attachment upload", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	defer f.Close()
+
+	attachment, err := h.attachmentService.Upload(c.Request.Context(), header.Filename, f)
+	if err != nil {
+		h.logger.Error("Failed to save attachment", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.Created(c, attachment)
+}
+
+// ListAttachments 获取附件列表，unreferenced=true 时只返回未被笔记引用的附件
+func (h *Handler) ListAttachments(c *gin.Context) {
+	var req struct {
+		Unreferenced bool `form:"unreferenced"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("Invalid request parameters", zap.Error(err))
+		response.BindError(c, err)
+		return
+	}
+	attachments, err := h.attachmentService.ListAttachments(c.Request.Context(), req.Unreferenced)
+	if err != nil {
+		h.logger.Error("Failed to list attachments", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.OK(c, attachments)
+}
+
+// DownloadAttachment 下载附件，以哈希作为 ETag
+func (h *Handler) DownloadAttachment(c *gin.Context) {
+	attachment, err := h.attachmentService.GetAttachment(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		h.logger.Error("Failed to get attachment", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	// HTML、SVG 等类型只允许下载，并禁止浏览器猜测类型和执行脚本
+	disposition := "attachment"
+	if service.AttachmentInline(attachment.MimeType) {
+		disposition = "inline"
+	}
+	h.serveAttachmentFile(c, attachment, h.attachmentService.FilePath(attachment), attachment.MimeType, disposition, `"`+attachment.Hash+`"`)
+}
+
+// GetAttachmentThumbnail 获取图片附件的缩略图，size 为长边像素，必须是配置的尺寸之一，默认为最小的尺寸
+func (h *Handler) GetAttachmentThumbnail(c *gin.Context) {
+	var req struct {
+		Size int `form:"size" binding:"omitempty,min=1"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("Invalid request parameters", zap.Error(err))
+		response.BindError(c, err)
+		return
+	}
+	attachment, thumb, err := h.attachmentService.Thumbnail(c.Request.Context(), c.Param("name"), req.Size)
+	if err != nil {
+		h.logger.Error("Failed to get attachment thumbnail", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	h.serveAttachmentFile(c, attachment, thumb.Path, thumb.MimeType, "inline", thumb.ETag)
+}
+
+// serveAttachmentFile 返回附件或缩略图文件；内容不可变，允许长期缓存并支持 Range 和条件请求
+func (h *Handler) serveAttachmentFile(c *gin.Context, attachment *model.Attachment, p, mimeType, disposition, etag string) {
+	f, err := os.Open(p)
+	if err != nil {
+		h.logger.Error("Failed to open attachment file", zap.String("path", p), zap.Error(err))
+		if errors.Is(err, os.ErrNotExist) {
+			response.Error(c, service.ErrAttachmentNotFound)
+			return
+		}
+		response.Error(c, err)
+		return
+	}
+	defer f.Close()
+
+	h.allowSlowDownload(c)
+	header := c.Writer.Header()
+	header.Set("Content-Type", mimeType)
+	header.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Name}))
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Content-Security-Policy", "sandbox")
+	header.Set("Cache-Control", "private, max-age=31536000, immutable")
+	header.Set("ETag", etag)
+	http.ServeContent(c.Writer, c.Request, "", attachment.CreatedAt, f)
+}
+
+// ListAttachmentNotes 获取引用附件的笔记
+func (h *Handler) ListAttachmentNotes(c *gin.Context) {
+	notes, err := h.attachmentService.ListNotes(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		h.logger.Error("Failed to list attachment notes", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.OK(c, notes)
+}
+
+// CollectAttachmentGarbage 立即清理超过保留期仍未被引用的附件
+func (h *Handler) CollectAttachmentGarbage(c *gin.Context) {
+	result, err := h.attachmentService.CollectGarbage(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to collect attachment garbage", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.OK(c, result)
+}
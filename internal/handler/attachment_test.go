@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"leafnote/internal/model"
+	"leafnote/internal/service"
+	"leafnote/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// uploadAttachment 以 multipart 上传附件，content 为 nil 时不包含文件字段
+func uploadAttachment(t *testing.T, r *gin.Engine, name string, content []byte) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	if content != nil {
+		w, err := mw.CreateFormFile("file", name)
+		require.NoError(t, err)
+		_, err = w.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, mw.Close())
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/attachments", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandler_Attachments(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	attachments := service.NewAttachmentService(db, zap.NewNop(), service.AttachmentOptions{
+		VaultDir: t.TempDir(),
+		MaxSize:  1 << 10,
+		Image:    service.ImageOptions{ThumbnailSizes: []int{64}},
+	})
+	h := NewHandler(zap.NewNop(), db, WithAttachmentService(attachments))
+	r := gin.New()
+	h.RegisterRoutes(r)
+
+	pngHeader := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	html := []byte("<html><script>alert(1)</script></html>")
+
+	tests := []struct {
+		name       string
+		fileName   string
+		content    []byte
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "上传图片", fileName: "截图.png", content: pngHeader, wantStatus: http.StatusCreated},
+		{name: "上传 HTML", fileName: "页面.html", content: html, wantStatus: http.StatusCreated},
+		{name: "未上传文件", wantStatus: http.StatusBadRequest, wantCode: "ATTACHMENT_FILE_REQUIRED"},
+		{name: "空文件", fileName: "空.txt", content: []byte{}, wantStatus: http.StatusBadRequest, wantCode: "ATTACHMENT_EMPTY"},
+		{name: "超过大小上限", fileName: "大.bin", content: make([]byte, 2<<10), wantStatus: http.StatusBadRequest, wantCode: "ATTACHMENT_TOO_LARGE"},
+	}
+
+	uploaded := make(map[string]model.Attachment)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := uploadAttachment(t, r, tt.fileName, tt.content)
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantCode != "" {
+				resp := decodeResponse(t, w.Body.Bytes(), nil)
+				assert.Equal(t, tt.wantCode, resp.Code)
+				return
+			}
+			var attachment model.Attachment
+			decodeResponse(t, w.Body.Bytes(), &attachment)
+			uploaded[tt.fileName] = attachment
+		})
+	}
+
+	screenshot := uploaded["截图.png"]
+	require.NotEmpty(t, screenshot.Hash)
+	assert.Equal(t, "attachments/"+screenshot.Hash+".png", screenshot.Path)
+	assert.Equal(t, "image/png", screenshot.MimeType)
+
+	t.Run("下载图片", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/"+screenshot.Path, nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, pngHeader, w.Body.Bytes())
+		assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+		assert.Equal(t, `inline; filename*=utf-8''%E6%88%AA%E5%9B%BE.png`, w.Header().Get("Content-Disposition"))
+		assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+
+		// 内容不变，带 ETag 的请求返回 304
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/"+screenshot.Path, nil)
+		req.Header.Set("If-None-Match", w.Header().Get("ETag"))
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+	})
+
+	t.Run("缩略图", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/attachments/"+screenshot.Hash+"/thumbnail?size=256", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		resp := decodeResponse(t, w.Body.Bytes(), nil)
+		assert.Equal(t, "THUMBNAIL_SIZE_INVALID", resp.Code)
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/attachments/"+uploaded["页面.html"].Hash+"/thumbnail", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		resp = decodeResponse(t, w.Body.Bytes(), nil)
+		assert.Equal(t, "ATTACHMENT_NOT_IMAGE", resp.Code)
+	})
+
+	t.Run("HTML 只能下载", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/"+uploaded["页面.html"].Path, nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment;")
+		assert.Equal(t, "sandbox", w.Header().Get("Content-Security-Policy"))
+	})
+
+	t.Run("附件不存在", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/attachments/missing.png", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		resp := decodeResponse(t, w.Body.Bytes(), nil)
+		assert.Equal(t, "ATTACHMENT_NOT_FOUND", resp.Code)
+	})
+
+	t.Run("引用附件的笔记", func(t *testing.T) {
+		_, err := service.NewNoteService(db, zap.NewNop()).CreateNote(service.CreateNoteInput{
+			Title:    "引用",
+			Content:  "![截图](" + screenshot.Path + ")",
+			FilePath: "/引用.md",
+		})
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/attachments/"+screenshot.Hash+"/notes", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		var notes []model.Note
+		decodeResponse(t, w.Body.Bytes(), &notes)
+		require.Len(t, notes, 1)
+		assert.Equal(t, "引用", notes[0].Title)
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/attachments?unreferenced=true", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		var list []model.Attachment
+		decodeResponse(t, w.Body.Bytes(), &list)
+		require.Len(t, list, 1)
+		assert.Equal(t, uploaded["页面.html"].ID, list[0].ID)
+	})
+
+	t.Run("清理未引用的附件", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/attachments/gc", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		var result service.AttachmentGCResult
+		decodeResponse(t, w.Body.Bytes(), &result)
+		assert.Equal(t, 1, result.Removed)
+	})
+
+	t.Run("请求体按附件上限截断", func(t *testing.T) {
+		unlimited := service.NewAttachmentService(db, zap.NewNop(), service.AttachmentOptions{VaultDir: t.TempDir()})
+		h := NewHandler(zap.NewNop(), db, WithAttachmentService(unlimited), WithMaxAttachmentSize(1<<10))
+		r := gin.New()
+		h.RegisterRoutes(r)
+
+		w := uploadAttachment(t, r, "大.bin", make([]byte, multipartOverhead+2<<10))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		resp := decodeResponse(t, w.Body.Bytes(), nil)
+		assert.Equal(t, "ATTACHMENT_TOO_LARGE", resp.Code)
+	})
+}
+
+func TestHandler_AttachmentThumbnail(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	attachments := service.NewAttachmentService(db, zap.NewNop(), service.AttachmentOptions{
+		VaultDir: t.TempDir(),
+		Image:    service.ImageOptions{ThumbnailSizes: []int{64}},
+	})
+	h := NewHandler(zap.NewNop(), db, WithAttachmentService(attachments))
+	r := gin.New()
+	h.RegisterRoutes(r)
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 200, 100))))
+	w := uploadAttachment(t, r, "图.png", buf.Bytes())
+	require.Equal(t, http.StatusCreated, w.Code)
+	var attachment model.Attachment
+	decodeResponse(t, w.Body.Bytes(), &attachment)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/attachments/"+attachment.Hash+"/thumbnail", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Equal(t, `"`+attachment.Hash+`-64"`, w.Header().Get("ETag"))
+	assert.Contains(t, w.Header().Get("Cache-Control"), "immutable")
+	cfg, err := png.DecodeConfig(bytes.NewReader(w.Body.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, 64, cfg.Width)
+	assert.Equal(t, 32, cfg.Height)
+}
+
+func TestHandler_UploadAttachmentSlowly(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	attachments := service.NewAttachmentService(db, zap.NewNop(), service.AttachmentOptions{VaultDir: t.TempDir()})
+	h := NewHandler(zap.NewNop(), db, WithAttachmentService(attachments))
+	r := gin.New()
+	h.RegisterRoutes(r)
+	srv := newTimeoutServer(t, r, 100*time.Millisecond)
+
+	// 上传耗时超过服务的读写超时
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", "慢.bin")
+	require.NoError(t, err)
+	_, err = fw.Write(bytes.Repeat([]byte("leafnote"), 1<<10))
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/api/v1/attachments", slowReader{r: &body, delay: 30 * time.Millisecond, size: 1 << 10})
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"leafnote/internal/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ListBackups 获取备份列表
+func (h *Handler) ListBackups(c *gin.Context) {
+	backups, err := h.backupService.ListBackups()
+	if err != nil {
+		h.logger.Error("Failed to list backups", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.OK(c, backups)
+}
+
+// CreateBackup 立即创建备份
+func (h *Handler) CreateBackup(c *gin.Context) {
+	backup, err := h.backupService.CreateBackup(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to create backup", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.Created(c, backup)
+}
+
+// DownloadBackup 下载备份文件
+func (h *Handler) DownloadBackup(c *gin.Context) {
+	name := c.Param("name")
+	p, err := h.backupService.BackupPath(name)
+	if err != nil {
+		h.logger.Error("Failed to get backup", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	h.allowSlowDownload(c)
+	c.FileAttachment(p, name)
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"leafnote/internal/service"
+	"leafnote/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestHandler_Backups(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	if db.Dialector.Name() != "sqlite" {
+		t.Skip("备份仅支持 SQLite")
+	}
+	logger := zap.NewNop()
+	dir := t.TempDir()
+	backups := service.NewBackupService(db, logger, service.BackupOptions{
+		Dir:      filepath.Join(dir, "backups"),
+		VaultDir: filepath.Join(dir, "vault"),
+	})
+	_, r := setupTestHandlerWithDB(t, db, WithBackupService(backups))
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	w := serve(http.MethodPost, "/api/v1/backups")
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created service.BackupInfo
+	decodeResponse(t, w.Body.Bytes(), &created)
+	assert.NotEmpty(t, created.Name)
+
+	w = serve(http.MethodGet, "/api/v1/backups")
+	require.Equal(t, http.StatusOK, w.Code)
+	var list []service.BackupInfo
+	decodeResponse(t, w.Body.Bytes(), &list)
+	require.Len(t, list, 1)
+	assert.Equal(t, created.Name, list[0].Name)
+
+	tests := []struct {
+		name       string
+		backup     string
+		wantStatus int
+	}{
+		{name: "下载备份", backup: created.Name, wantStatus: http.StatusOK},
+		{name: "备份不存在", backup: "leafnote-20000101-000000.zip", wantStatus: http.StatusNotFound},
+		{name: "名称不合法", backup: "config.yaml", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(http.MethodGet, "/api/v1/backups/"+tt.backup)
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, created.Size, int64(w.Body.Len()))
+			}
+		})
+	}
+}
+
+func TestHandler_BackupsDisabled(t *testing.T) {
+	_, r := setupTestHandler(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/backups", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandler_DownloadBackupSlowly(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	dir := t.TempDir()
+	backups := service.NewBackupService(db, zap.NewNop(), service.BackupOptions{Dir: dir})
+	h := NewHandler(zap.NewNop(), db, WithBackupService(backups))
+	r := gin.New()
+	h.RegisterRoutes(r)
+	srv := newTimeoutServer(t, r, 100*time.Millisecond)
+
+	// 文件超过套接字缓冲区，慢速读取时下载耗时超过服务的写超时
+	const name, size = "leafnote-20000101-000000.zip", 8 << 20
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), make([]byte, size), 0644))
+
+	resp, err := srv.Client().Get(srv.URL + "/api/v1/backups/" + name)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	n, err := io.Copy(io.Discard, slowReader{r: resp.Body, delay: 2 * time.Millisecond, size: 64 << 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(size), n)
+}
@@ -1,8 +1,9 @@
 package handler
 
 import (
+	"leafnote/internal/i18n"
 	"leafnote/internal/model"
-	"net/http"
+	"leafnote/internal/response"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -13,12 +14,10 @@ func (h *Handler) ListCategories(c *gin.Context) {
 	categories, err := h.categoryService.ListCategories(c.Request.Context())
 	if err != nil {
 		h.logger.Error("Failed to get categories", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "获取目录列表失败",
-		})
+		response.Error(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, categories)
+	response.OK(c, categories)
 }
 
 // CreateCategory 创建目录
@@ -26,45 +25,17 @@ func (h *Handler) CreateCategory(c *gin.Context) {
 	var category model.Category
 	if err := c.ShouldBindJSON(&category); err != nil {
 		h.logger.Error("Invalid request parameters", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status": "error",
-			"error":  "无效的请求参数",
-		})
+		response.BindError(c, err)
 		return
 	}
 
 	if err := h.categoryService.CreateCategory(c.Request.Context(), &category); err != nil {
 		h.logger.Error("Failed to create category", zap.Error(err))
-		// 根据错误类型返回不同的状态码
-		switch err.Error() {
-		case "目录名称不能为空":
-			c.JSON(http.StatusBadRequest, gin.H{
-				"status": "error",
-				"error":  err.Error(),
-			})
-		case "同级目录下已存在同名目录":
-			c.JSON(http.StatusBadRequest, gin.H{
-				"status": "error",
-				"error":  err.Error(),
-			})
-		case "父目录不存在":
-			c.JSON(http.StatusNotFound, gin.H{
-				"status": "error",
-				"error":  err.Error(),
-			})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"status": "error",
-				"error":  err.Error(),
-			})
-		}
+		response.Error(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"status": "success",
-		"data":   category,
-	})
+	response.Created(c, category)
 }
 
 // GetCategory 获取目录详情
@@ -73,12 +44,10 @@ func (h *Handler) GetCategory(c *gin.Context) {
 	category, err := h.categoryService.GetCategoryByID(c.Request.Context(), id)
 	if err != nil {
 		h.logger.Error("Failed to get category", zap.Error(err))
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "目录不存在",
-		})
+		response.Error(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, category)
+	response.OK(c, category)
 }
 
 // UpdateCategory 更新目录
@@ -87,28 +56,21 @@ func (h *Handler) UpdateCategory(c *gin.Context) {
 	var category model.Category
 	if err := c.ShouldBindJSON(&category); err != nil {
 		h.logger.Error("Invalid request parameters", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "无效的请求参数",
-		})
+		response.BindError(c, err)
 		return
 	}
 
 	// 先检查目录是否存在
-	_, err := h.categoryService.GetCategoryByID(c.Request.Context(), id)
-	if err != nil {
+	if _, err := h.categoryService.GetCategoryByID(c.Request.Context(), id); err != nil {
 		h.logger.Error("Failed to get category", zap.Error(err))
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "目录不存在",
-		})
+		response.Error(c, err)
 		return
 	}
 
 	category.BaseModel.ID = id
 	if err := h.categoryService.UpdateCategory(c.Request.Context(), &category); err != nil {
 		h.logger.Error("Failed to update category", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "更新目录失败",
-		})
+		response.Error(c, err)
 		return
 	}
 
@@ -116,33 +78,20 @@ func (h *Handler) UpdateCategory(c *gin.Context) {
 	updatedCategory, err := h.categoryService.GetCategoryByID(c.Request.Context(), id)
 	if err != nil {
 		h.logger.Error("Failed to get updated category", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "获取更新后的目录失败",
-		})
+		response.Error(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, updatedCategory)
+	response.OK(c, updatedCategory)
 }
 
 // DeleteCategory 删除目录
 func (h *Handler) DeleteCategory(c *gin.Context) {
 	id := c.Param("id")
 	if err := h.categoryService.DeleteCategory(c.Request.Context(), id); err != nil {
-		if err.Error() == "目录不存在" {
-			h.logger.Error("Category not found", zap.Error(err))
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": err.Error(),
-			})
-			return
-		}
 		h.logger.Error("Failed to delete category", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "删除目录失败",
-		})
+		response.Error(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{
-		"message": "删除成功",
-	})
+	response.Message(c, i18n.MsgDeleted)
 }
@@ -66,17 +66,15 @@ func TestHandler_CreateCategory(t *testing.T) {
 			r.ServeHTTP(w, req)
 
 			assert.Equal(t, tt.wantStatus, w.Code)
+			var category model.Category
+			resp := decodeResponse(t, w.Body.Bytes(), &category)
 			if tt.wantStatus == http.StatusCreated {
-				var response model.Category
-				err = json.Unmarshal(w.Body.Bytes(), &response)
-				assert.NoError(t, err)
-				assert.NotEmpty(t, response.BaseModel.ID)
-				assert.Equal(t, tt.wantResponse.(map[string]interface{})["name"], response.Name)
+				assert.Equal(t, "success", resp.Status)
+				assert.NotEmpty(t, category.BaseModel.ID)
+				assert.Equal(t, tt.wantResponse.(map[string]interface{})["name"], category.Name)
 			} else {
-				var response map[string]interface{}
-				err = json.Unmarshal(w.Body.Bytes(), &response)
-				assert.NoError(t, err)
-				assert.Equal(t, tt.wantResponse.(map[string]interface{})["error"], response["error"])
+				assert.Equal(t, "error", resp.Status)
+				assert.Equal(t, tt.wantResponse.(map[string]interface{})["error"], resp.Error)
 			}
 		})
 	}
@@ -124,15 +122,15 @@ func TestHandler_GetCategory(t *testing.T) {
 
 			assert.Equal(t, tt.wantStatus, w.Code)
 
-			var response map[string]interface{}
-			err := json.Unmarshal(w.Body.Bytes(), &response)
-			assert.NoError(t, err)
+			var data map[string]interface{}
+			resp := decodeResponse(t, w.Body.Bytes(), &data)
 
 			if tt.wantStatus == http.StatusOK {
-				assert.Equal(t, tt.wantResponse.(map[string]interface{})["name"], response["name"])
-				assert.NotEmpty(t, response["id"])
+				assert.Equal(t, tt.wantResponse.(map[string]interface{})["name"], data["name"])
+				assert.NotEmpty(t, data["id"])
 			} else {
-				assert.Equal(t, tt.wantResponse.(map[string]interface{})["error"], response["error"])
+				assert.Equal(t, tt.wantResponse.(map[string]interface{})["error"], resp.Error)
+				assert.Equal(t, "CATEGORY_NOT_FOUND", resp.Code)
 			}
 		})
 	}
@@ -191,15 +189,15 @@ func TestHandler_UpdateCategory(t *testing.T) {
 
 			assert.Equal(t, tt.wantStatus, w.Code)
 
-			var response map[string]interface{}
-			err = json.Unmarshal(w.Body.Bytes(), &response)
-			assert.NoError(t, err)
+			var data map[string]interface{}
+			resp := decodeResponse(t, w.Body.Bytes(), &data)
 
 			if tt.wantStatus == http.StatusOK {
-				assert.Equal(t, tt.wantResponse.(map[string]interface{})["name"], response["name"])
-				assert.NotEmpty(t, response["id"])
+				assert.Equal(t, tt.wantResponse.(map[string]interface{})["name"], data["name"])
+				assert.NotEmpty(t, data["id"])
 			} else {
-				assert.Equal(t, tt.wantResponse.(map[string]interface{})["error"], response["error"])
+				assert.Equal(t, tt.wantResponse.(map[string]interface{})["error"], resp.Error)
+				assert.Equal(t, "CATEGORY_NOT_FOUND", resp.Code)
 			}
 		})
 	}
@@ -247,14 +245,13 @@ func TestHandler_DeleteCategory(t *testing.T) {
 
 			assert.Equal(t, tt.wantStatus, w.Code)
 
-			var response map[string]interface{}
-			err := json.Unmarshal(w.Body.Bytes(), &response)
-			assert.NoError(t, err)
+			resp := decodeResponse(t, w.Body.Bytes(), nil)
 
 			if tt.wantStatus == http.StatusOK {
-				assert.Equal(t, tt.wantResponse.(map[string]interface{})["message"], response["message"])
+				assert.Equal(t, tt.wantResponse.(map[string]interface{})["message"], resp.Message)
 			} else {
-				assert.Equal(t, tt.wantResponse.(map[string]interface{})["error"], response["error"])
+				assert.Equal(t, tt.wantResponse.(map[string]interface{})["error"], resp.Error)
+				assert.Equal(t, "CATEGORY_NOT_FOUND", resp.Code)
 			}
 		})
 	}
@@ -284,8 +281,7 @@ func TestHandler_ListCategories(t *testing.T) {
 		assert.Equal(t, http.StatusOK, w.Code)
 
 		var response []map[string]interface{}
-		err := json.Unmarshal(w.Body.Bytes(), &response)
-		assert.NoError(t, err)
+		decodeResponse(t, w.Body.Bytes(), &response)
 
 		// 只返回顶级目录
 		assert.Len(t, response, 1)
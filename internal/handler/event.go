@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"leafnote/internal/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// eventPageSize 补发错过的事件时每次读取的数量
+const eventPageSize = 100
+
+// StreamEvents 以 SSE 推送笔记、标签和目录的变更事件，事件名为事件类型，id 为事件序号；
+// 通过 since 参数或 Last-Event-ID 请求头从指定序号之后继续，先补发错过的事件再推送新事件
+func (h *Handler) StreamEvents(c *gin.Context) {
+	var req struct {
+		Since *uint64 `form:"since"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("Invalid request parameters", zap.Error(err))
+		response.BindError(c, err)
+		return
+	}
+	// 浏览器的 EventSource 重连时自动携带最后收到的 id
+	if req.Since == nil {
+		if id, err := strconv.ParseUint(c.GetHeader("Last-Event-ID"), 10, 64); err == nil {
+			req.Since = &id
+		}
+	}
+
+	ctx := c.Request.Context()
+	// 先订阅再确定位置，期间提交的事件在补发或推送时都不会遗漏
+	events, unsubscribe := h.eventService.Subscribe()
+	defer unsubscribe()
+	cursor, reset, err := h.eventService.Resume(ctx, req.Since)
+	if err != nil {
+		h.logger.Error("Failed to resume events", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	// 长连接不受服务的写超时限制
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warn("Failed to clear write deadline", zap.Error(err))
+	}
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	if reset {
+		if err := writeSSE(c.Writer, cursor, "reset", gin.H{"seq": cursor}); err != nil {
+			return
+		}
+	}
+	// catchUp 补发 cursor 之后已提交的事件
+	catchUp := func() bool {
+		for {
+			page, err := h.eventService.ListEvents(ctx, cursor, eventPageSize)
+			if err != nil {
+				if ctx.Err() == nil {
+					h.logger.Error("Failed to list events", zap.Error(err))
+				}
+				return false
+			}
+			for _, e := range page {
+				if err := writeSSE(c.Writer, e.Seq, string(e.Type), e); err != nil {
+					return false
+				}
+				cursor = e.Seq
+			}
+			if len(page) < eventPageSize {
+				c.Writer.Flush()
+				return true
+			}
+		}
+	}
+	if !catchUp() {
+		return
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case e, ok := <-events:
+			if !ok {
+				return false
+			}
+			switch {
+			case e.Seq <= cursor:
+				// 补发时已经发送过
+				return true
+			case e.Seq == cursor+1:
+				cursor = e.Seq
+				return writeSSE(w, e.Seq, string(e.Type), e) == nil
+			default:
+				return catchUp()
+			}
+		case <-heartbeat.C:
+			_, err := fmt.Fprint(w, ": ping\n\n")
+			return err == nil
+		}
+	})
+}
+
+// writeSSE 写入一条带 id 的 SSE 事件，数据为 JSON
+func writeSSE(w io.Writer, id uint64, event string, data interface{}) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, body)
+	return err
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"leafnote/internal/model"
+	"leafnote/internal/service"
+	"leafnote/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// sseEvent 解析后的一条 SSE 事件
+type sseEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+// readSSE 读取下一条 SSE 事件，跳过心跳注释
+func readSSE(t *testing.T, scanner *bufio.Scanner) sseEvent {
+	t.Helper()
+	var e sseEvent
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if e.event != "" {
+				return e
+			}
+			continue
+		}
+		key, value, _ := strings.Cut(line, ": ")
+		switch key {
+		case "id":
+			e.id = value
+		case "event":
+			e.event = value
+		case "data":
+			e.data = value
+		}
+	}
+	require.NoError(t, scanner.Err())
+	t.Fatal("连接已关闭")
+	return e
+}
+
+func TestHandler_StreamEvents(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	events := service.NewEventService(db, zap.NewNop(), service.EventOptions{})
+	_, r := setupTestHandlerWithDB(t, db, WithEventService(events))
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := events.Watermark(ctx)
+	require.NoError(t, err)
+	notes := service.NewNoteService(db, zap.NewNop())
+	note, err := notes.CreateNote(service.CreateNoteInput{Title: "周报", FilePath: "/周报.md"})
+	require.NoError(t, err)
+	_, err = events.Poll(ctx, time.Now())
+	require.NoError(t, err)
+
+	connect := func(t *testing.T, query, lastEventID string) *bufio.Scanner {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/v1/events"+query, nil)
+		require.NoError(t, err)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+		return bufio.NewScanner(resp.Body)
+	}
+
+	t.Run("补发错过的事件后推送新事件", func(t *testing.T) {
+		scanner := connect(t, "?since=0", "")
+		e := readSSE(t, scanner)
+		assert.Equal(t, "1", e.id)
+		assert.Equal(t, "note.created", e.event)
+		var event model.Event
+		require.NoError(t, json.Unmarshal([]byte(e.data), &event))
+		assert.Equal(t, note.ID, event.EntityID)
+		assert.Equal(t, 1, event.Version)
+
+		require.NoError(t, notes.UpdateNote(note.ID, service.UpdateNoteInput{Title: "周报草稿"}))
+		_, err := events.Poll(ctx, time.Now())
+		require.NoError(t, err)
+		e = readSSE(t, scanner)
+		assert.Equal(t, "2", e.id)
+		assert.Equal(t, "note.updated", e.event)
+		assert.Contains(t, e.data, `"version":2`)
+	})
+
+	t.Run("按 Last-Event-ID 继续", func(t *testing.T) {
+		scanner := connect(t, "", "1")
+		e := readSSE(t, scanner)
+		assert.Equal(t, "2", e.id)
+		assert.Equal(t, "note.updated", e.event)
+	})
+
+	t.Run("序号超过当前位置", func(t *testing.T) {
+		scanner := connect(t, "?since=100", "")
+		e := readSSE(t, scanner)
+		assert.Equal(t, "2", e.id)
+		assert.Equal(t, "reset", e.event)
+		assert.Equal(t, `{"seq":2}`, e.data)
+	})
+
+	t.Run("序号格式错误", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/events?since=abc", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "INVALID_PARAMS")
+	})
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"leafnote/internal/response"
+	"leafnote/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Export 导出笔记，type 为 markdown（默认）时导出 Markdown 文件，为 site 时导出静态站点；
+// format 为 zip 时以附件形式流式返回，为 dir 时写入导出目录
+func (h *Handler) Export(c *gin.Context) {
+	var req struct {
+		Type         string   `json:"type" binding:"omitempty,oneof=markdown site"`
+		Format       string   `json:"format" binding:"omitempty,oneof=zip dir"`
+		Dir          string   `json:"dir"`
+		CategoryID   *string  `json:"category_id"`
+		TagIDs       []string `json:"tag_ids"`
+		NoteIDs      []string `json:"note_ids"`
+		IncludeTrash bool     `json:"include_trash"`
+	}
+	// 请求体为空时导出全部笔记
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.logger.Error("Invalid request parameters", zap.Error(err))
+		response.BindError(c, err)
+		return
+	}
+	filter := service.ExportFilter{
+		CategoryID:   req.CategoryID,
+		TagIDs:       req.TagIDs,
+		NoteIDs:      req.NoteIDs,
+		IncludeTrash: req.IncludeTrash,
+	}
+	// 导出大量笔记时生成和传输都可能超过服务的写超时
+	h.allowSlowDownload(c)
+	exportService := service.NewExportService(h.db, h.logger)
+	exportDir, exportZip, prefix := exportService.ExportDir, exportService.ExportZip, "leafnote-export"
+	if req.Type == "site" {
+		exportDir, exportZip, prefix = exportService.ExportSiteDir, exportService.ExportSiteZip, "leafnote-site"
+	}
+
+	if req.Format == "dir" {
+		if h.exportDir == "" {
+			response.Error(c, service.ErrExportDirDisabled)
+			return
+		}
+		dir, err := service.ExportDirPath(h.exportDir, req.Dir)
+		if err != nil {
+			response.Error(c, err)
+			return
+		}
+		result, err := exportDir(c.Request.Context(), dir, filter)
+		if err != nil {
+			h.logger.Error("Failed to export notes", zap.Error(err))
+			response.Error(c, err)
+			return
+		}
+		response.Created(c, result)
+		return
+	}
+
+	name := fmt.Sprintf("%s-%s.zip", prefix, time.Now().Format("20060102-150405"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	if _, err := exportZip(c.Request.Context(), c.Writer, filter); err != nil {
+		h.logger.Error("Failed to export notes", zap.Error(err))
+		// 已开始写入归档时无法再返回错误响应，客户端会收到不完整的文件
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			response.Error(c, err)
+		}
+	}
+}
+
+// ExportNote 导出单篇笔记，目前只支持 format=pdf
+func (h *Handler) ExportNote(c *gin.Context) {
+	h.exportPDF(c, service.NewPDFService(h.db, h.logger, h.pdfFonts).NotePDF)
+}
+
+// ExportCategory 将目录及其子目录下的笔记合并导出，目前只支持 format=pdf
+func (h *Handler) ExportCategory(c *gin.Context) {
+	h.exportPDF(c, service.NewPDFService(h.db, h.logger, h.pdfFonts).CategoryPDF)
+}
+
+// exportPDF 校验导出格式，调用 render 生成 PDF 并以附件形式返回
+func (h *Handler) exportPDF(c *gin.Context, render func(ctx context.Context, id string) (*service.PDFFile, error)) {
+	var req struct {
+		Format string `form:"format" binding:"omitempty,oneof=pdf"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("Invalid request parameters", zap.Error(err))
+		response.BindError(c, err)
+		return
+	}
+
+	h.allowSlowDownload(c)
+	file, err := render(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.logger.Error("Failed to export PDF", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	// 文件名可能包含中文，按 RFC 2231 编码
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": file.Name}))
+	c.Data(http.StatusOK, "application/pdf", file.Data)
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"leafnote/internal/model"
+	"leafnote/internal/service"
+	"leafnote/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestHandler_Export(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	exportDir := t.TempDir()
+	h := NewHandler(zap.NewNop(), db, WithExportDir(exportDir))
+	r := gin.New()
+	h.RegisterRoutes(r)
+
+	require.NoError(t, db.Create(&model.Note{Title: "笔记", Content: "内容", FilePath: "/笔记.md", Checksum: "x"}).Error)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCode   string
+		wantFile   string
+	}{
+		{name: "导出为 zip", body: "", wantStatus: http.StatusOK},
+		{name: "导出到目录", body: `{"format": "dir", "dir": "first"}`, wantStatus: http.StatusCreated, wantFile: "笔记.md"},
+		{name: "导出静态站点", body: `{"type": "site", "format": "dir", "dir": "site"}`, wantStatus: http.StatusCreated, wantFile: "笔记.html"},
+		{name: "类型不支持", body: `{"type": "pdf"}`, wantStatus: http.StatusBadRequest, wantCode: "INVALID_PARAMS"},
+		{name: "目录已存在", body: `{"format": "dir", "dir": "first"}`, wantStatus: http.StatusConflict, wantCode: "EXPORT_DIR_EXISTS"},
+		{name: "目录名不合法", body: `{"format": "dir", "dir": "../x"}`, wantStatus: http.StatusBadRequest, wantCode: "VALIDATION_FAILED"},
+		{name: "格式不支持", body: `{"format": "pdf"}`, wantStatus: http.StatusBadRequest, wantCode: "INVALID_PARAMS"},
+		{name: "标签不存在", body: `{"tag_ids": ["missing"]}`, wantStatus: http.StatusBadRequest, wantCode: "VALIDATION_FAILED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/export", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			switch {
+			case tt.wantCode != "":
+				resp := decodeResponse(t, w.Body.Bytes(), nil)
+				assert.Equal(t, tt.wantCode, resp.Code)
+				assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+				assert.Empty(t, w.Header().Get("Content-Disposition"))
+			case w.Code == http.StatusOK:
+				assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+				zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+				require.NoError(t, err)
+				require.Len(t, zr.File, 1)
+				assert.Equal(t, "笔记.md", zr.File[0].Name)
+			default:
+				var result service.ExportResult
+				decodeResponse(t, w.Body.Bytes(), &result)
+				assert.Equal(t, 1, result.Notes)
+				assert.FileExists(t, filepath.Join(exportDir, result.Dir, tt.wantFile))
+			}
+		})
+	}
+}
+
+func TestHandler_ExportDirDisabled(t *testing.T) {
+	_, r := setupTestHandler(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/export", strings.NewReader(`{"format": "dir"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "EXPORT_DIR_DISABLED", decodeResponse(t, w.Body.Bytes(), nil).Code)
+}
+
+func TestHandler_ExportPDF(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	h := NewHandler(zap.NewNop(), db)
+	r := gin.New()
+	h.RegisterRoutes(r)
+
+	category := &model.Category{Name: "会议"}
+	require.NoError(t, db.Create(category).Error)
+	note := &model.Note{Title: "周会", Content: "# 议题\n\n- 发布", FilePath: "/周会.md", Checksum: "x", CategoryID: &category.ID}
+	require.NoError(t, db.Create(note).Error)
+
+	tests := []struct {
+		name       string
+		url        string
+		wantStatus int
+		wantCode   string
+		wantFile   string
+	}{
+		{name: "导出笔记", url: "/api/v1/notes/" + note.ID + "/export?format=pdf", wantStatus: http.StatusOK, wantFile: "周会.pdf"},
+		{name: "默认格式", url: "/api/v1/notes/" + note.ID + "/export", wantStatus: http.StatusOK, wantFile: "周会.pdf"},
+		{name: "导出目录", url: "/api/v1/categories/" + category.ID + "/export?format=pdf", wantStatus: http.StatusOK, wantFile: "会议.pdf"},
+		{name: "格式不支持", url: "/api/v1/notes/" + note.ID + "/export?format=docx", wantStatus: http.StatusBadRequest, wantCode: "INVALID_PARAMS"},
+		{name: "笔记不存在", url: "/api/v1/notes/missing/export?format=pdf", wantStatus: http.StatusNotFound, wantCode: "NOTE_NOT_FOUND"},
+		{name: "目录不存在", url: "/api/v1/categories/missing/export?format=pdf", wantStatus: http.StatusNotFound, wantCode: "CATEGORY_NOT_FOUND"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, decodeResponse(t, w.Body.Bytes(), nil).Code)
+				return
+			}
+			assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+			_, params, err := mime.ParseMediaType(w.Header().Get("Content-Disposition"))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFile, params["filename"])
+			assert.True(t, bytes.HasPrefix(w.Body.Bytes(), []byte("%PDF-")))
+		})
+	}
+}
@@ -2,30 +2,151 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
+	"leafnote/internal/i18n"
 	"leafnote/internal/service"
 )
 
 // Handler 结构体包含所有处理器依赖
 type Handler struct {
-	logger          *zap.Logger
-	db              *gorm.DB
-	tagService      *service.TagService
-	categoryService *service.CategoryService
+	logger            *zap.Logger
+	db                *gorm.DB
+	tagService        *service.TagService
+	categoryService   *service.CategoryService
+	templateService   *service.TemplateService
+	taskService       *service.TaskService
+	backupService     *service.BackupService
+	jobService        *service.JobService
+	attachmentService *service.AttachmentService
+	periodicService   *service.PeriodicService
+	reminderService   *service.ReminderService
+	eventService      *service.EventService
+	webhookService    *service.WebhookService
+	pluginService     *service.PluginService
+	syncService       *service.SyncService
+	exportDir         string
+	pdfFonts          service.PDFFonts
+	maxUploadSize     int64
+	maxAttachmentSize int64
+}
+
+// Option 处理器的可选依赖
+type Option func(*Handler)
+
+// WithBackupService 启用备份接口
+func WithBackupService(s *service.BackupService) Option {
+	return func(h *Handler) {
+		h.backupService = s
+	}
+}
+
+// WithJobService 启用后台任务，导入接口以任务形式执行
+func WithJobService(s *service.JobService) Option {
+	return func(h *Handler) {
+		h.jobService = s
+	}
+}
+
+// WithAttachmentService 启用附件接口
+func WithAttachmentService(s *service.AttachmentService) Option {
+	return func(h *Handler) {
+		h.attachmentService = s
+	}
+}
+
+// WithPeriodicService 启用日记、周记、月记和日历接口
+func WithPeriodicService(s *service.PeriodicService) Option {
+	return func(h *Handler) {
+		h.periodicService = s
+	}
+}
+
+// WithReminderService 启用提醒接口
+func WithReminderService(s *service.ReminderService) Option {
+	return func(h *Handler) {
+		h.reminderService = s
+	}
+}
+
+// WithEventService 启用变更事件推送接口
+func WithEventService(s *service.EventService) Option {
+	return func(h *Handler) {
+		h.eventService = s
+	}
+}
+
+// WithWebhookService 启用 Webhook 接口
+func WithWebhookService(s *service.WebhookService) Option {
+	return func(h *Handler) {
+		h.webhookService = s
+	}
+}
+
+// WithPluginService 启用插件管理接口和插件提供的接口
+func WithPluginService(s *service.PluginService) Option {
+	return func(h *Handler) {
+		h.pluginService = s
+	}
+}
+
+// WithSyncService 启用客户端同步接口
+func WithSyncService(s *service.SyncService) Option {
+	return func(h *Handler) {
+		h.syncService = s
+	}
+}
+
+// WithExportDir 设置导出到目录时的根目录，未设置时只能导出为 zip
+func WithExportDir(dir string) Option {
+	return func(h *Handler) {
+		h.exportDir = dir
+	}
+}
+
+// WithPDFFonts 设置 PDF 导出使用的字体
+func WithPDFFonts(fonts service.PDFFonts) Option {
+	return func(h *Handler) {
+		h.pdfFonts = fonts
+	}
+}
+
+// WithMaxUploadSize 设置上传文件的大小上限（字节）
+func WithMaxUploadSize(n int64) Option {
+	return func(h *Handler) {
+		h.maxUploadSize = n
+	}
+}
+
+// WithMaxAttachmentSize 设置单个附件的大小上限（字节），与附件服务的上限一致；未设置时按上传文件的大小上限
+func WithMaxAttachmentSize(n int64) Option {
+	return func(h *Handler) {
+		h.maxAttachmentSize = n
+	}
 }
 
 // NewHandler 创建一个新的处理器实例
-func NewHandler(logger *zap.Logger, db *gorm.DB) *Handler {
-	return &Handler{
+func NewHandler(logger *zap.Logger, db *gorm.DB, opts ...Option) *Handler {
+	// 注册参数校验的多语言翻译
+	i18n.RegisterValidator()
+
+	h := &Handler{
 		logger:          logger,
 		db:              db,
 		tagService:      service.NewTagService(db),
 		categoryService: service.NewCategoryService(db),
+		templateService: service.NewTemplateService(db, logger),
+		taskService:     service.NewTaskService(db, logger),
+		maxUploadSize:   defaultMaxUploadSize,
+	}
+	for _, opt := range opts {
+		opt(h)
 	}
+	return h
 }
 
 // Health 健康检查处理器
@@ -35,6 +156,25 @@ func (h *Handler) Health(c *gin.Context) {
 	})
 }
 
+// allowSlowUpload 上传大文件时取消服务的读写超时，请求体大小仍受 MaxBytesReader 限制；
+// 响应在读完请求体后才写入，因此写超时也一并取消
+func (h *Handler) allowSlowUpload(c *gin.Context) {
+	rc := http.NewResponseController(c.Writer)
+	if err := rc.SetReadDeadline(time.Time{}); err != nil {
+		h.logger.Warn("Failed to clear read deadline", zap.Error(err))
+	}
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warn("Failed to clear write deadline", zap.Error(err))
+	}
+}
+
+// allowSlowDownload 下载导出文件、备份等大文件时取消服务的写超时
+func (h *Handler) allowSlowDownload(c *gin.Context) {
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warn("Failed to clear write deadline", zap.Error(err))
+	}
+}
+
 // RegisterRoutes 注册所有路由
 func (h *Handler) RegisterRoutes(r *gin.Engine) {
 	// API 版本组
@@ -48,7 +188,10 @@ func (h *Handler) RegisterRoutes(r *gin.Engine) {
 		{
 			notes.GET("", h.ListNotes)
 			notes.POST("", h.CreateNote)
+			notes.POST("/from-template", h.CreateNoteFromTemplate)
 			notes.GET("/:id", h.GetNote)
+			notes.GET("/:id/html", h.GetNoteHTML)
+			notes.GET("/:id/export", h.ExportNote)
 			notes.PUT("/:id", h.UpdateNote)
 			notes.DELETE("/:id", h.DeleteNote)
 		}
@@ -63,14 +206,125 @@ func (h *Handler) RegisterRoutes(r *gin.Engine) {
 			tags.DELETE("/:id", h.DeleteTag)
 		}
 
+		// 模板相关路由
+		templates := v1.Group("/templates")
+		{
+			templates.GET("", h.ListTemplates)
+			templates.POST("", h.CreateTemplate)
+			templates.GET("/:id", h.GetTemplate)
+			templates.PUT("/:id", h.UpdateTemplate)
+			templates.DELETE("/:id", h.DeleteTemplate)
+		}
+
+		// 待办相关路由
+		tasks := v1.Group("/tasks")
+		{
+			tasks.GET("", h.ListTasks)
+			tasks.POST("/rebuild", h.RebuildTasks)
+			tasks.POST("/:id/toggle", h.ToggleTask)
+		}
+
 		// 目录相关路由
 		categories := v1.Group("/categories")
 		{
 			categories.GET("", h.ListCategories)
 			categories.POST("", h.CreateCategory)
 			categories.GET("/:id", h.GetCategory)
+			categories.GET("/:id/export", h.ExportCategory)
 			categories.PUT("/:id", h.UpdateCategory)
 			categories.DELETE("/:id", h.DeleteCategory)
 		}
+
+		// 日记、周记、月记和日历
+		if h.periodicService != nil {
+			v1.GET("/periodic/:period", h.GetPeriodicNote)
+			v1.POST("/periodic/:period", h.EnsurePeriodicNote)
+			v1.GET("/calendar", h.GetCalendar)
+		}
+
+		// 提醒相关路由
+		if h.reminderService != nil {
+			reminders := v1.Group("/reminders")
+			{
+				reminders.GET("", h.ListReminders)
+				reminders.POST("", h.CreateReminder)
+				reminders.GET("/stream", h.StreamReminders)
+				reminders.GET("/:id", h.GetReminder)
+				reminders.POST("/:id/snooze", h.SnoozeReminder)
+				reminders.POST("/:id/dismiss", h.DismissReminder)
+				reminders.DELETE("/:id", h.DeleteReminder)
+			}
+		}
+
+		// 变更事件
+		if h.eventService != nil {
+			v1.GET("/events", h.StreamEvents)
+		}
+
+		// 客户端同步
+		if h.syncService != nil {
+			v1.POST("/sync", h.Sync)
+		}
+
+		// Webhook 相关路由
+		if h.webhookService != nil {
+			webhooks := v1.Group("/webhooks")
+			{
+				webhooks.GET("", h.ListWebhooks)
+				webhooks.POST("", h.CreateWebhook)
+				webhooks.GET("/:id", h.GetWebhook)
+				webhooks.PUT("/:id", h.UpdateWebhook)
+				webhooks.DELETE("/:id", h.DeleteWebhook)
+				webhooks.GET("/:id/deliveries", h.ListWebhookDeliveries)
+				webhooks.POST("/:id/deliveries/:delivery_id/retry", h.RetryWebhookDelivery)
+			}
+		}
+
+		// 插件相关路由
+		if h.pluginService != nil {
+			plugins := v1.Group("/plugins")
+			{
+				plugins.GET("", h.ListPlugins)
+				plugins.GET("/:name", h.GetPlugin)
+				plugins.PUT("/:name", h.UpdatePlugin)
+			}
+			h.registerPluginRoutes(plugins)
+		}
+
+		// 导出
+		v1.POST("/export", h.Export)
+
+		// 导入和后台任务
+		if h.jobService != nil {
+			v1.POST("/import", h.Import)
+			jobs := v1.Group("/jobs")
+			{
+				jobs.GET("", h.ListJobs)
+				jobs.GET("/:id", h.GetJob)
+			}
+		}
+
+		// 附件相关路由
+		if h.attachmentService != nil {
+			attachments := v1.Group("/attachments")
+			{
+				attachments.GET("", h.ListAttachments)
+				attachments.POST("", h.UploadAttachment)
+				attachments.POST("/gc", h.CollectAttachmentGarbage)
+				attachments.GET("/:name", h.DownloadAttachment)
+				attachments.GET("/:name/thumbnail", h.GetAttachmentThumbnail)
+				attachments.GET("/:name/notes", h.ListAttachmentNotes)
+			}
+		}
+
+		// 备份相关路由
+		if h.backupService != nil {
+			backups := v1.Group("/backups")
+			{
+				backups.GET("", h.ListBackups)
+				backups.POST("", h.CreateBackup)
+				backups.GET("/:name", h.DownloadBackup)
+			}
+		}
 	}
 }
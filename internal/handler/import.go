@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"leafnote/internal/response"
+	"leafnote/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// defaultMaxUploadSize 未配置时上传文件的大小上限
+const defaultMaxUploadSize = 256 << 20
+
+// importJobType 导入任务的类型
+const importJobType = "import"
+
+// Import 上传导入源并创建后台导入任务，返回 202 和任务，通过 /jobs/:id 查询进度和结果；
+// format 为 markdown（默认）或 notion 时上传 zip，为 enex 时上传 .enex 文件或包含多个 .enex 的 zip
+func (h *Handler) Import(c *gin.Context) {
+	h.allowSlowUpload(c)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxUploadSize)
+	header, err := c.FormFile("file")
+	if err != nil {
+		h.logger.Error("Invalid import upload", zap.Error(err))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			response.Error(c, service.ErrImportTooLarge)
+			return
+		}
+		response.Error(c, service.ErrImportFileRequired)
+		return
+	}
+
+	var req struct {
+		Format     string `form:"format" binding:"omitempty,oneof=markdown enex notion"`
+		CategoryID string `form:"category_id"`
+	}
+	if err := c.ShouldBind(&req); err != nil {
+		h.logger.Error("Invalid request parameters", zap.Error(err))
+		response.BindError(c, err)
+		return
+	}
+	var opts service.ImportOptions
+	if req.CategoryID != "" {
+		opts.CategoryID = &req.CategoryID
+	}
+
+	// 上传的临时文件在请求结束后删除，先复制到任务自己的临时目录
+	fsys, cleanup, err := saveImportUpload(header, req.Format)
+	if err != nil {
+		h.logger.Error("Invalid import archive", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	importService := service.NewImportService(h.db, h.logger, h.attachmentService)
+	format := req.Format
+	job, err := h.jobService.Enqueue(importJobType, func(ctx context.Context, progress func(done, total int)) (interface{}, error) {
+		defer cleanup()
+		opts.Progress = progress
+		return importService.Import(ctx, format, fsys, opts)
+	})
+	if err != nil {
+		cleanup()
+		h.logger.Error("Failed to create import job", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.Accepted(c, job)
+}
+
+// saveImportUpload 将上传的文件保存到临时目录并打开为导入源，cleanup 用于关闭并删除临时文件；
+// 格式为 enex 且不是 zip 时按单个 .enex 文件处理，以上传的文件名作为笔记本名
+func saveImportUpload(header *multipart.FileHeader, format string) (fs.FS, func(), error) {
+	src, err := header.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer src.Close()
+	magic := make([]byte, 4)
+	n, _ := io.ReadFull(src, magic)
+	isZip := string(magic[:n]) == "PK\x03\x04"
+	if !isZip && format != service.ImportFormatENEX {
+		return nil, nil, service.ErrImportInvalidArchive
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "leafnote-import-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	name := "upload.zip"
+	if !isZip {
+		name = strings.TrimSuffix(filepath.Base(filepath.FromSlash(header.Filename)), filepath.Ext(header.Filename))
+		if name == "" || name == "." || strings.HasPrefix(name, ".") {
+			name = "Evernote"
+		}
+		name += ".enex"
+	}
+	p := filepath.Join(dir, name)
+	if err := copyToFile(p, src); err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, err
+	}
+
+	if !isZip {
+		return os.DirFS(dir), func() { os.RemoveAll(dir) }, nil
+	}
+	zr, err := zip.OpenReader(p)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, service.ErrImportInvalidArchive
+	}
+	return zr, func() {
+		zr.Close()
+		os.RemoveAll(dir)
+	}, nil
+}
+
+// copyToFile 将 r 的内容写入新文件 p
+func copyToFile(p string, r io.Reader) error {
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"leafnote/internal/model"
+	"leafnote/internal/service"
+	"leafnote/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// multipartBody 生成包含 file 字段和其他表单字段的上传请求体，空值字段忽略
+func multipartBody(t *testing.T, content []byte, fields map[string]string) (*bytes.Buffer, string) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range fields {
+		if v != "" {
+			require.NoError(t, mw.WriteField(k, v))
+		}
+	}
+	if content != nil {
+		w, err := mw.CreateFormFile("file", "vault.zip")
+		require.NoError(t, err)
+		_, err = w.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, mw.Close())
+	return &body, mw.FormDataContentType()
+}
+
+// setupJobHandler 创建启用后台任务的处理器，任务在测试结束时停止
+func setupJobHandler(t *testing.T, opts ...Option) *gin.Engine {
+	db := testutil.NewTestDB(t)
+	jobs := service.NewJobService(db, zap.NewNop())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = jobs.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	opts = append([]Option{WithJobService(jobs), WithAttachmentService(service.NewAttachmentService(db, zap.NewNop(), service.AttachmentOptions{VaultDir: t.TempDir()}))}, opts...)
+	h := NewHandler(zap.NewNop(), db, opts...)
+	r := gin.New()
+	h.RegisterRoutes(r)
+	return r
+}
+
+// waitImportJob 轮询任务接口直到任务结束，返回导入结果
+func waitImportJob(t *testing.T, r *gin.Engine, id string) (model.Job, service.ImportResult) {
+	t.Helper()
+	var job model.Job
+	require.Eventually(t, func() bool {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/"+id, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		decodeResponse(t, w.Body.Bytes(), &job)
+		return job.Status == model.JobSucceeded || job.Status == model.JobFailed
+	}, 5*time.Second, 10*time.Millisecond)
+	var result service.ImportResult
+	if len(job.Result) > 0 {
+		require.NoError(t, json.Unmarshal(job.Result, &result))
+	}
+	return job, result
+}
+
+func TestHandler_Import(t *testing.T) {
+	r := setupJobHandler(t, WithMaxUploadSize(64<<10))
+
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	w, err := zw.Create("目录/笔记.md")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("内容 #标签"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	tests := []struct {
+		name       string
+		content    []byte
+		format     string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "导入成功", content: archive.Bytes(), wantStatus: http.StatusAccepted},
+		{name: "未上传文件", content: nil, wantStatus: http.StatusBadRequest, wantCode: "IMPORT_FILE_REQUIRED"},
+		{name: "不是 zip 文件", content: []byte("not a zip"), wantStatus: http.StatusBadRequest, wantCode: "IMPORT_INVALID_ARCHIVE"},
+		{name: "文件过大", content: make([]byte, 128<<10), wantStatus: http.StatusBadRequest, wantCode: "IMPORT_TOO_LARGE"},
+		{name: "不支持的格式", content: archive.Bytes(), format: "word", wantStatus: http.StatusBadRequest, wantCode: "INVALID_PARAMS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, contentType := multipartBody(t, tt.content, map[string]string{"format": tt.format})
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/import", body)
+			req.Header.Set("Content-Type", contentType)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			var job model.Job
+			resp := decodeResponse(t, w.Body.Bytes(), &job)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, resp.Code)
+				return
+			}
+			assert.Equal(t, "import", job.Type)
+
+			job, result := waitImportJob(t, r, job.ID)
+			assert.Equal(t, model.JobSucceeded, job.Status)
+			assert.Equal(t, 1, job.Done)
+			assert.Equal(t, 1, job.Total)
+			assert.Equal(t, 1, result.Created)
+			require.Len(t, result.Files, 1)
+			assert.Equal(t, "目录/笔记.md", result.Files[0].Path)
+		})
+	}
+}
+
+func TestHandler_ImportENEX(t *testing.T) {
+	r := setupJobHandler(t)
+
+	// 单个 .enex 文件以文件名作为笔记本
+	enex := `<?xml version="1.0" encoding="UTF-8"?>
+<en-export><note><title>笔记</title><content><![CDATA[<en-note><div>内容</div></en-note>]]></content></note></en-export>`
+	body, contentType := multipartBody(t, []byte(enex), map[string]string{"format": "enex"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/import", body)
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusAccepted, w.Code)
+
+	var job model.Job
+	decodeResponse(t, w.Body.Bytes(), &job)
+	job, result := waitImportJob(t, r, job.ID)
+	assert.Equal(t, model.JobSucceeded, job.Status)
+	require.Len(t, result.Files, 1)
+	assert.Equal(t, "vault/笔记.md", result.Files[0].Path)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/jobs", nil))
+	var jobs []model.Job
+	decodeResponse(t, w.Body.Bytes(), &jobs)
+	require.Len(t, jobs, 1)
+	assert.Empty(t, jobs[0].Result)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/missing", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "JOB_NOT_FOUND", decodeResponse(t, w.Body.Bytes(), nil).Code)
+}
+
+func TestHandler_ImportDisabled(t *testing.T) {
+	_, r := setupTestHandler(t)
+	for _, p := range []string{"/api/v1/import", "/api/v1/jobs"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, p, nil))
+		assert.Equal(t, http.StatusNotFound, w.Code, p)
+	}
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"leafnote/internal/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ListJobs 获取最近的后台任务列表，不含任务结果
+func (h *Handler) ListJobs(c *gin.Context) {
+	jobs, err := h.jobService.ListJobs()
+	if err != nil {
+		h.logger.Error("Failed to list jobs", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.OK(c, jobs)
+}
+
+// GetJob 获取后台任务的状态、进度和结果
+func (h *Handler) GetJob(c *gin.Context) {
+	job, err := h.jobService.GetJob(c.Param("id"))
+	if err != nil {
+		h.logger.Error("Failed to get job", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.OK(c, job)
+}
@@ -1,9 +1,12 @@
 package handler
 
 import (
-	"leafnote/internal/service"
 	"net/http"
 
+	"leafnote/internal/i18n"
+	"leafnote/internal/response"
+	"leafnote/internal/service"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -20,18 +23,17 @@ func (h *Handler) ListNotes(c *gin.Context) {
 	notes, err := noteService.ListNotes(categoryIDPtr)
 	if err != nil {
 		h.logger.Error("Failed to get notes", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "获取笔记列表失败",
-		})
+		response.Error(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, notes)
+	response.OK(c, notes)
 }
 
 // CreateNote 创建笔记
 func (h *Handler) CreateNote(c *gin.Context) {
 	var req struct {
+		ID         string   `json:"id"`
 		Title      string   `json:"title" binding:"required"`
 		Content    string   `json:"content"`
 		YAMLMeta   string   `json:"yaml_meta"`
@@ -41,25 +43,27 @@ func (h *Handler) CreateNote(c *gin.Context) {
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.Error("Invalid request parameters", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "无效的请求参数",
-		})
+		response.BindError(c, err)
 		return
 	}
 
 	noteService := service.NewNoteService(h.db, h.logger)
-	note, err := noteService.CreateNote(service.CreateNoteInput(req))
+	note, err := noteService.CreateNote(service.CreateNoteInput{
+		ID:         req.ID,
+		Title:      req.Title,
+		Content:    req.Content,
+		YAMLMeta:   req.YAMLMeta,
+		FilePath:   req.FilePath,
+		CategoryID: req.CategoryID,
+		TagIDs:     req.TagIDs,
+	})
 	if err != nil {
-		h.logger.Error("Failed to crete note", zap.Error(err))
-		if err.Error() == "文件路径已存在" {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "文件路径已存在",
-			})
-		}
+		h.logger.Error("Failed to create note", zap.Error(err))
+		response.Error(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, note)
+	response.Created(c, note)
 }
 
 // GetNote 获取单个笔记
@@ -69,47 +73,45 @@ func (h *Handler) GetNote(c *gin.Context) {
 	note, err := noteService.GetNote(id)
 	if err != nil {
 		h.logger.Error("Failed to get note", zap.Error(err))
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "笔记不存在",
-		})
+		response.Error(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, note)
+	response.OK(c, note)
+}
+
+// GetNoteHTML 将笔记渲染为独立的 HTML 页面
+func (h *Handler) GetNoteHTML(c *gin.Context) {
+	page, err := service.NewExportService(h.db, h.logger).RenderNotePage(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.logger.Error("Failed to render note", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page)
 }
 
 // UpdateNote 更新笔记
 func (h *Handler) UpdateNote(c *gin.Context) {
 	id := c.Param("id")
 	var req struct {
-		Title      string   `json:"title"`
-		Content    string   `json:"content"`
-		YAMLMeta   string   `json:"yaml_meta"`
-		CategoryID *string  `json:"category_id"`
-		TagIDs     []string `json:"tag_ids"`
+		Title       string   `json:"title"`
+		Content     string   `json:"content"`
+		YAMLMeta    string   `json:"yaml_meta"`
+		CategoryID  *string  `json:"category_id"`
+		TagIDs      []string `json:"tag_ids"`
+		BaseVersion int      `json:"base_version" binding:"min=0"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.Error("Invalid request parameters", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "无效的请求参数",
-		})
+		response.BindError(c, err)
 		return
 	}
 
 	noteService := service.NewNoteService(h.db, h.logger)
-	err := noteService.UpdateNote(id, service.UpdateNoteInput(req))
-	if err != nil {
-		if err.Error() == "笔记不存在" {
-			h.logger.Error("Note not found", zap.Error(err))
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": err.Error(),
-			})
-			return
-		}
+	if err := noteService.UpdateNote(id, service.UpdateNoteInput(req)); err != nil {
 		h.logger.Error("Failed to update note", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "更新笔记失败",
-		})
+		response.Error(c, err)
 		return
 	}
 
@@ -117,38 +119,24 @@ func (h *Handler) UpdateNote(c *gin.Context) {
 	note, err := noteService.GetNote(id)
 	if err != nil {
 		h.logger.Error("Failed to get updated note", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "获取更新后的笔记失败",
-		})
+		response.Error(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, note)
+	response.OK(c, note)
 }
 
 // DeleteNote 删除笔记
 func (h *Handler) DeleteNote(c *gin.Context) {
 	id := c.Param("id")
 	noteService := service.NewNoteService(h.db, h.logger)
-	err := noteService.DeleteNote(id)
-	if err != nil {
-		if err.Error() == "笔记不存在" {
-			h.logger.Error("Note not found", zap.Error(err))
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": err.Error(),
-			})
-			return
-		}
+	if err := noteService.DeleteNote(id); err != nil {
 		h.logger.Error("Failed to delete note", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "删除笔记失败",
-		})
+		response.Error(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "删除成功",
-	})
+	response.Message(c, i18n.MsgDeleted)
 }
 
 // RestoreNote 恢复已删除的笔记
@@ -158,13 +146,9 @@ func (h *Handler) RestoreNote(c *gin.Context) {
 
 	if err := noteService.RestoreNote(id); err != nil {
 		h.logger.Error("Failed to restore note", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "恢复笔记失败",
-		})
+		response.Error(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "笔记已恢复",
-	})
+	response.Message(c, i18n.MsgNoteRestored)
 }
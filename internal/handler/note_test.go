@@ -3,36 +3,32 @@ package handler
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 	"leafnote/internal/model"
+	"leafnote/internal/testutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
-func setupTestHandler(t *testing.T) (*Handler, *gin.Engine) {
-	// 设置测试数据库
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	if err != nil {
-		t.Fatalf("failed to connect database: %v", err)
-	}
-
-	// 自动迁移
-	err = db.AutoMigrate(&model.Note{}, &model.Tag{}, &model.Category{})
-	if err != nil {
-		t.Fatalf("failed to migrate database: %v", err)
-	}
+// setupTestHandler 使用新的测试数据库创建处理器并注册路由，opts 用于启用可选的服务
+func setupTestHandler(t *testing.T, opts ...Option) (*Handler, *gin.Engine) {
+	return setupTestHandlerWithDB(t, testutil.NewTestDB(t), opts...)
+}
 
+// setupTestHandlerWithDB 使用指定的数据库创建处理器，供需要先在同一数据库上创建服务的测试使用
+func setupTestHandlerWithDB(t *testing.T, db *gorm.DB, opts ...Option) (*Handler, *gin.Engine) {
 	// 设置日志
 	logger, _ := zap.NewDevelopment()
 
 	// 创建处理器
-	h := NewHandler(logger, db)
+	h := NewHandler(logger, db, opts...)
 
 	// 设置路由
 	r := gin.New()
@@ -42,6 +38,50 @@ func setupTestHandler(t *testing.T) (*Handler, *gin.Engine) {
 	return h, r
 }
 
+// newTimeoutServer 以很短的读写超时启动真实的 HTTP 服务，用于验证长时间的传输不受超时限制
+func newTimeoutServer(t *testing.T, r http.Handler, timeout time.Duration) *httptest.Server {
+	srv := httptest.NewUnstartedServer(r)
+	srv.Config.ReadTimeout = timeout
+	srv.Config.WriteTimeout = timeout
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// slowReader 每次读取前等待 delay，最多返回 size 字节，模拟慢速上传或下载
+type slowReader struct {
+	r     io.Reader
+	delay time.Duration
+	size  int
+}
+
+func (s slowReader) Read(p []byte) (int, error) {
+	time.Sleep(s.delay)
+	if len(p) > s.size {
+		p = p[:s.size]
+	}
+	return s.r.Read(p)
+}
+
+// testResponse 测试用的统一响应结构，data 延迟解析
+type testResponse struct {
+	Status  string          `json:"status"`
+	Data    json.RawMessage `json:"data"`
+	Message string          `json:"message"`
+	Error   string          `json:"error"`
+	Code    string          `json:"code"`
+}
+
+// decodeResponse 解析统一响应结构，并将 data 字段解析到 data 中
+func decodeResponse(t *testing.T, body []byte, data interface{}) testResponse {
+	var resp testResponse
+	assert.NoError(t, json.Unmarshal(body, &resp))
+	if data != nil && len(resp.Data) > 0 {
+		assert.NoError(t, json.Unmarshal(resp.Data, data))
+	}
+	return resp
+}
+
 func TestHandler_CreateNote(t *testing.T) {
 	_, r := setupTestHandler(t)
 
@@ -49,6 +89,7 @@ func TestHandler_CreateNote(t *testing.T) {
 		name         string
 		requestBody  map[string]interface{}
 		wantStatus   int
+		wantCode     string
 		wantResponse interface{}
 	}{
 		{
@@ -71,10 +112,35 @@ func TestHandler_CreateNote(t *testing.T) {
 				"content": "测试内容",
 			},
 			wantStatus: http.StatusBadRequest,
+			wantCode:   "INVALID_PARAMS",
 			wantResponse: map[string]interface{}{
 				"error": "无效的请求参数",
 			},
 		},
+		{
+			name: "文件路径越界",
+			requestBody: map[string]interface{}{
+				"title":     "越界笔记",
+				"file_path": "../outside.md",
+			},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "VALIDATION_FAILED",
+			wantResponse: map[string]interface{}{
+				"error": "参数校验失败",
+			},
+		},
+		{
+			name: "文件路径已存在",
+			requestBody: map[string]interface{}{
+				"title":     "重复笔记",
+				"file_path": "/test/note.md",
+			},
+			wantStatus: http.StatusConflict,
+			wantCode:   "NOTE_FILE_PATH_EXISTS",
+			wantResponse: map[string]interface{}{
+				"error": "文件路径已存在",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -90,17 +156,19 @@ func TestHandler_CreateNote(t *testing.T) {
 
 			assert.Equal(t, tt.wantStatus, w.Code)
 
-			var response map[string]interface{}
-			err = json.Unmarshal(w.Body.Bytes(), &response)
-			assert.NoError(t, err)
+			var data map[string]interface{}
+			resp := decodeResponse(t, w.Body.Bytes(), &data)
 
 			if tt.wantStatus == http.StatusCreated {
-				assert.Equal(t, tt.wantResponse.(map[string]interface{})["title"], response["title"])
-				assert.Equal(t, tt.wantResponse.(map[string]interface{})["content"], response["content"])
-				assert.Equal(t, tt.wantResponse.(map[string]interface{})["file_path"], response["file_path"])
-				assert.NotEmpty(t, response["id"])
+				assert.Equal(t, "success", resp.Status)
+				assert.Equal(t, tt.wantResponse.(map[string]interface{})["title"], data["title"])
+				assert.Equal(t, tt.wantResponse.(map[string]interface{})["content"], data["content"])
+				assert.Equal(t, tt.wantResponse.(map[string]interface{})["file_path"], data["file_path"])
+				assert.NotEmpty(t, data["id"])
 			} else {
-				assert.Equal(t, tt.wantResponse.(map[string]interface{})["error"], response["error"])
+				assert.Equal(t, "error", resp.Status)
+				assert.Equal(t, tt.wantResponse.(map[string]interface{})["error"], resp.Error)
+				assert.Equal(t, tt.wantCode, resp.Code)
 			}
 		})
 	}
@@ -121,6 +189,7 @@ func TestHandler_GetNote(t *testing.T) {
 		name         string
 		noteID       string
 		wantStatus   int
+		wantCode     string
 		wantResponse interface{}
 	}{
 		{
@@ -137,6 +206,7 @@ func TestHandler_GetNote(t *testing.T) {
 			name:       "获取不存在的笔记",
 			noteID:     "not-exist",
 			wantStatus: http.StatusNotFound,
+			wantCode:   "NOTE_NOT_FOUND",
 			wantResponse: map[string]interface{}{
 				"error": "笔记不存在",
 			},
@@ -152,17 +222,45 @@ func TestHandler_GetNote(t *testing.T) {
 
 			assert.Equal(t, tt.wantStatus, w.Code)
 
-			var response map[string]interface{}
-			err := json.Unmarshal(w.Body.Bytes(), &response)
-			assert.NoError(t, err)
+			var data map[string]interface{}
+			resp := decodeResponse(t, w.Body.Bytes(), &data)
 
 			if tt.wantStatus == http.StatusOK {
-				assert.Equal(t, tt.wantResponse.(map[string]interface{})["title"], response["title"])
-				assert.Equal(t, tt.wantResponse.(map[string]interface{})["content"], response["content"])
-				assert.Equal(t, tt.wantResponse.(map[string]interface{})["file_path"], response["file_path"])
-				assert.NotEmpty(t, response["id"])
+				assert.Equal(t, tt.wantResponse.(map[string]interface{})["title"], data["title"])
+				assert.Equal(t, tt.wantResponse.(map[string]interface{})["content"], data["content"])
+				assert.Equal(t, tt.wantResponse.(map[string]interface{})["file_path"], data["file_path"])
+				assert.NotEmpty(t, data["id"])
 			} else {
-				assert.Equal(t, tt.wantResponse.(map[string]interface{})["error"], response["error"])
+				assert.Equal(t, tt.wantResponse.(map[string]interface{})["error"], resp.Error)
+				assert.Equal(t, tt.wantCode, resp.Code)
+			}
+		})
+	}
+}
+
+func TestHandler_GetNoteHTML(t *testing.T) {
+	h, r := setupTestHandler(t)
+	note := &model.Note{Title: "测试笔记", Content: "# 标题\n\n| a |\n|---|\n| 1 |\n", FilePath: "/test/note.md"}
+	h.db.Create(note)
+
+	tests := []struct {
+		name       string
+		noteID     string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "渲染笔记", noteID: note.ID, wantStatus: http.StatusOK, wantBody: "<td>1</td>"},
+		{name: "笔记不存在", noteID: "not-exist", wantStatus: http.StatusNotFound, wantBody: "NOTE_NOT_FOUND"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/notes/"+tt.noteID+"/html", nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
 			}
 		})
 	}
@@ -184,6 +282,7 @@ func TestHandler_UpdateNote(t *testing.T) {
 		noteID       string
 		requestBody  map[string]interface{}
 		wantStatus   int
+		wantCode     string
 		wantResponse interface{}
 	}{
 		{
@@ -206,9 +305,10 @@ func TestHandler_UpdateNote(t *testing.T) {
 			requestBody: map[string]interface{}{
 				"title": "新标题",
 			},
-			wantStatus: http.StatusInternalServerError,
+			wantStatus: http.StatusNotFound,
+			wantCode:   "NOTE_NOT_FOUND",
 			wantResponse: map[string]interface{}{
-				"error": "更新笔记失败",
+				"error": "笔记不存在",
 			},
 		},
 	}
@@ -226,17 +326,17 @@ func TestHandler_UpdateNote(t *testing.T) {
 
 			assert.Equal(t, tt.wantStatus, w.Code)
 
-			var response map[string]interface{}
-			err = json.Unmarshal(w.Body.Bytes(), &response)
-			assert.NoError(t, err)
+			var data map[string]interface{}
+			resp := decodeResponse(t, w.Body.Bytes(), &data)
 
 			if tt.wantStatus == http.StatusOK {
-				assert.Equal(t, tt.wantResponse.(map[string]interface{})["title"], response["title"])
-				assert.Equal(t, tt.wantResponse.(map[string]interface{})["content"], response["content"])
-				assert.Equal(t, tt.wantResponse.(map[string]interface{})["file_path"], response["file_path"])
-				assert.NotEmpty(t, response["id"])
+				assert.Equal(t, tt.wantResponse.(map[string]interface{})["title"], data["title"])
+				assert.Equal(t, tt.wantResponse.(map[string]interface{})["content"], data["content"])
+				assert.Equal(t, tt.wantResponse.(map[string]interface{})["file_path"], data["file_path"])
+				assert.NotEmpty(t, data["id"])
 			} else {
-				assert.Equal(t, tt.wantResponse.(map[string]interface{})["error"], response["error"])
+				assert.Equal(t, tt.wantResponse.(map[string]interface{})["error"], resp.Error)
+				assert.Equal(t, tt.wantCode, resp.Code)
 			}
 		})
 	}
@@ -257,6 +357,7 @@ func TestHandler_DeleteNote(t *testing.T) {
 		name         string
 		noteID       string
 		wantStatus   int
+		wantCode     string
 		wantResponse interface{}
 	}{
 		{
@@ -270,9 +371,10 @@ func TestHandler_DeleteNote(t *testing.T) {
 		{
 			name:       "笔记不存在",
 			noteID:     "not-exist",
-			wantStatus: http.StatusInternalServerError,
+			wantStatus: http.StatusNotFound,
+			wantCode:   "NOTE_NOT_FOUND",
 			wantResponse: map[string]interface{}{
-				"error": "删除笔记失败",
+				"error": "笔记不存在",
 			},
 		},
 	}
@@ -286,14 +388,115 @@ func TestHandler_DeleteNote(t *testing.T) {
 
 			assert.Equal(t, tt.wantStatus, w.Code)
 
-			var response map[string]interface{}
-			err := json.Unmarshal(w.Body.Bytes(), &response)
-			assert.NoError(t, err)
+			resp := decodeResponse(t, w.Body.Bytes(), nil)
 
 			if tt.wantStatus == http.StatusOK {
-				assert.Equal(t, tt.wantResponse.(map[string]interface{})["message"], response["message"])
+				assert.Equal(t, tt.wantResponse.(map[string]interface{})["message"], resp.Message)
 			} else {
-				assert.Equal(t, tt.wantResponse.(map[string]interface{})["error"], response["error"])
+				assert.Equal(t, tt.wantResponse.(map[string]interface{})["error"], resp.Error)
+				assert.Equal(t, tt.wantCode, resp.Code)
+			}
+		})
+	}
+}
+
+func TestHandler_LocalizedErrors(t *testing.T) {
+	_, r := setupTestHandler(t)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		acceptLanguage string
+		wantStatus     int
+		wantError      string
+		wantFields     map[string]string
+	}{
+		{
+			name:           "英文-笔记不存在",
+			method:         http.MethodGet,
+			path:           "/api/v1/notes/not-exist",
+			acceptLanguage: "en-US,en;q=0.9",
+			wantStatus:     http.StatusNotFound,
+			wantError:      "Note not found",
+		},
+		{
+			name:       "默认中文-笔记不存在",
+			method:     http.MethodGet,
+			path:       "/api/v1/notes/not-exist",
+			wantStatus: http.StatusNotFound,
+			wantError:  "笔记不存在",
+		},
+		{
+			name:           "英文-字段校验",
+			method:         http.MethodPost,
+			path:           "/api/v1/notes",
+			body:           `{"content":"测试内容"}`,
+			acceptLanguage: "en",
+			wantStatus:     http.StatusBadRequest,
+			wantError:      "Invalid request parameters",
+			wantFields: map[string]string{
+				"title":     "title is a required field",
+				"file_path": "file_path is a required field",
+			},
+		},
+		{
+			name:           "英文-业务字段校验",
+			method:         http.MethodPost,
+			path:           "/api/v1/notes",
+			body:           `{"title":"笔记","file_path":"notes/a.txt","tag_ids":["missing"]}`,
+			acceptLanguage: "en",
+			wantStatus:     http.StatusBadRequest,
+			wantError:      "Validation failed",
+			wantFields: map[string]string{
+				"file_path": "file_path must end with .md",
+				"tag_ids":   "tag_ids references records that do not exist: missing",
+			},
+		},
+		{
+			name:       "中文-字段校验",
+			method:     http.MethodPost,
+			path:       "/api/v1/notes?lang=zh-CN",
+			body:       `{"content":"测试内容"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "无效的请求参数",
+			wantFields: map[string]string{
+				"title":     "title为必填字段",
+				"file_path": "file_path为必填字段",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+
+			var resp struct {
+				Error  string `json:"error"`
+				Fields []struct {
+					Field   string `json:"field"`
+					Message string `json:"message"`
+				} `json:"fields"`
+			}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.wantError, resp.Error)
+
+			fields := make(map[string]string)
+			for _, f := range resp.Fields {
+				fields[f.Field] = f.Message
+			}
+			if tt.wantFields != nil {
+				assert.Equal(t, tt.wantFields, fields)
 			}
 		})
 	}
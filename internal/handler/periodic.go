@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"errors"
+	"io"
+
+	"leafnote/internal/response"
+	"leafnote/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetPeriodicNote 获取日记、周记或月记，笔记不存在时 note 为 null，不会创建；
+// date 为周期中的任意一天，默认为今天
+func (h *Handler) GetPeriodicNote(c *gin.Context) {
+	result, err := h.periodicService.GetPeriodicNote(c.Request.Context(), service.Period(c.Param("period")), c.Query("date"))
+	if err != nil {
+		h.logger.Error("Failed to get periodic note", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.OK(c, result)
+}
+
+// EnsurePeriodicNote 获取日记、周记或月记，不存在时按配置的路径和模板创建，新建时返回 201
+func (h *Handler) EnsurePeriodicNote(c *gin.Context) {
+	var req struct {
+		Date      string            `json:"date"`
+		Variables map[string]string `json:"variables"`
+	}
+	// 请求体为空时使用今天
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.logger.Error("Invalid request parameters", zap.Error(err))
+		response.BindError(c, err)
+		return
+	}
+
+	result, created, err := h.periodicService.EnsurePeriodicNote(c.Request.Context(), service.Period(c.Param("period")), req.Date, req.Variables)
+	if err != nil {
+		h.logger.Error("Failed to ensure periodic note", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	if created {
+		response.Created(c, result)
+		return
+	}
+	response.OK(c, result)
+}
+
+// GetCalendar 获取一个月的日历，month 形如 2026-10，默认为本月
+func (h *Handler) GetCalendar(c *gin.Context) {
+	cal, err := h.periodicService.Calendar(c.Request.Context(), c.Query("month"))
+	if err != nil {
+		h.logger.Error("Failed to get calendar", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.OK(c, cal)
+}
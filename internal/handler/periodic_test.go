@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"leafnote/internal/service"
+	"leafnote/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestHandler_PeriodicNotes(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	periodic, err := service.NewPeriodicService(db, zap.NewNop(), service.PeriodicOptions{
+		Daily: service.PeriodicNoteOptions{Path: "日记/YYYY/YYYY-MM-DD.md"},
+	})
+	require.NoError(t, err)
+	_, r := setupTestHandlerWithDB(t, db, WithPeriodicService(periodic))
+
+	t.Run("查看不存在的日记", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/periodic/daily?date=2026-10-18", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		var result service.PeriodicNote
+		decodeResponse(t, w.Body.Bytes(), &result)
+		assert.Equal(t, "2026-10-18", result.Date)
+		assert.Equal(t, "/日记/2026/2026-10-18.md", result.FilePath)
+		assert.Nil(t, result.Note)
+	})
+
+	t.Run("创建日记", func(t *testing.T) {
+		w := doJSON(t, r, http.MethodPost, "/api/v1/periodic/daily", map[string]interface{}{"date": "2026-10-18"})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var result service.PeriodicNote
+		decodeResponse(t, w.Body.Bytes(), &result)
+		require.NotNil(t, result.Note)
+		assert.Equal(t, "2026-10-18", result.Note.Title)
+
+		// 已存在时返回 200
+		w = doJSON(t, r, http.MethodPost, "/api/v1/periodic/daily", map[string]interface{}{"date": "2026-10-18"})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("日历", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/calendar?month=2026-10", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		var cal service.Calendar
+		decodeResponse(t, w.Body.Bytes(), &cal)
+		require.Len(t, cal.Days, 31)
+		assert.NotNil(t, cal.Days[17].DailyNoteID)
+	})
+
+	errorTests := []struct {
+		name       string
+		method     string
+		url        string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "周期不支持", method: http.MethodGet, url: "/api/v1/periodic/yearly", wantStatus: http.StatusBadRequest, wantCode: "PERIOD_INVALID"},
+		{name: "周记未启用", method: http.MethodPost, url: "/api/v1/periodic/weekly", wantStatus: http.StatusBadRequest, wantCode: "PERIOD_DISABLED"},
+		{name: "日期格式错误", method: http.MethodGet, url: "/api/v1/periodic/daily?date=2026/10/18", wantStatus: http.StatusBadRequest, wantCode: "PERIOD_DATE_INVALID"},
+		{name: "月份格式错误", method: http.MethodGet, url: "/api/v1/calendar?month=10", wantStatus: http.StatusBadRequest, wantCode: "PERIOD_DATE_INVALID"},
+	}
+
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.url, nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			resp := decodeResponse(t, w.Body.Bytes(), nil)
+			assert.Equal(t, tt.wantCode, resp.Code)
+		})
+	}
+}
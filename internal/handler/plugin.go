@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"leafnote/internal/plugin"
+	"leafnote/internal/response"
+	"leafnote/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ListPlugins 获取已注册的插件及其启用状态和配置
+func (h *Handler) ListPlugins(c *gin.Context) {
+	plugins, err := h.pluginService.ListPlugins(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to list plugins", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.OK(c, plugins)
+}
+
+// GetPlugin 获取插件详情
+func (h *Handler) GetPlugin(c *gin.Context) {
+	info, err := h.pluginService.GetPlugin(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		h.logger.Error("Failed to get plugin", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.OK(c, info)
+}
+
+// UpdatePlugin 启用、停用插件或替换配置，未指定的字段保持不变
+func (h *Handler) UpdatePlugin(c *gin.Context) {
+	var req struct {
+		Enabled *bool                  `json:"enabled"`
+		Config  map[string]interface{} `json:"config"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Invalid request parameters", zap.Error(err))
+		response.BindError(c, err)
+		return
+	}
+
+	info, err := h.pluginService.UpdatePlugin(c.Request.Context(), c.Param("name"), service.UpdatePluginInput(req))
+	if err != nil {
+		h.logger.Error("Failed to update plugin", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.OK(c, info)
+}
+
+// registerPluginRoutes 将插件提供的接口注册在 /plugins/<名称> 下；
+// 路由在启动时全部注册，插件未启用时返回 PLUGIN_DISABLED
+func (h *Handler) registerPluginRoutes(plugins *gin.RouterGroup) {
+	for _, p := range plugin.All() {
+		rp, ok := p.(plugin.RouteProvider)
+		if !ok {
+			continue
+		}
+		rp.RegisterRoutes(plugins.Group("/"+p.Name(), requirePlugin(p.Name())))
+	}
+}
+
+// requirePlugin 拒绝访问未启用插件的接口
+func requirePlugin(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !plugin.IsEnabled(name) {
+			response.Error(c, service.ErrPluginDisabled)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"leafnote/internal/plugin"
+	"leafnote/internal/service"
+	"leafnote/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// echoPlugin 测试用插件，提供一个返回问候语的接口
+type echoPlugin struct{}
+
+func (echoPlugin) Name() string        { return "echo" }
+func (echoPlugin) Description() string { return "回显" }
+
+func (echoPlugin) RegisterRoutes(r gin.IRouter) {
+	r.GET("/hello", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "你好，" + c.Query("name")})
+	})
+}
+
+func init() {
+	plugin.Register(echoPlugin{})
+}
+
+func TestHandler_Plugins(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	_, r := setupTestHandlerWithDB(t, db, WithPluginService(service.NewPluginService(db, zap.NewNop())))
+	t.Cleanup(func() {
+		require.NoError(t, plugin.Apply("echo", false, nil))
+	})
+
+	w := doJSON(t, r, http.MethodGet, "/api/v1/plugins", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var plugins []service.PluginInfo
+	decodeResponse(t, w.Body.Bytes(), &plugins)
+	require.Len(t, plugins, 1)
+	assert.Equal(t, []string{service.PluginCapRoutes}, plugins[0].Capabilities)
+
+	tests := []struct {
+		name       string
+		method     string
+		url        string
+		body       interface{}
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "未启用的插件接口", method: http.MethodGet, url: "/api/v1/plugins/echo/hello", wantStatus: http.StatusNotFound, wantCode: "PLUGIN_DISABLED"},
+		{name: "启用插件", method: http.MethodPut, url: "/api/v1/plugins/echo", body: map[string]interface{}{"enabled": true, "config": map[string]interface{}{"greeting": "你好"}}, wantStatus: http.StatusOK},
+		{name: "调用插件接口", method: http.MethodGet, url: "/api/v1/plugins/echo/hello?name=叶子", wantStatus: http.StatusOK},
+		{name: "插件不存在", method: http.MethodGet, url: "/api/v1/plugins/missing", wantStatus: http.StatusNotFound, wantCode: "PLUGIN_NOT_FOUND"},
+		{name: "配置格式错误", method: http.MethodPut, url: "/api/v1/plugins/echo", body: map[string]interface{}{"config": []string{"a"}}, wantStatus: http.StatusBadRequest, wantCode: "INVALID_PARAMS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doJSON(t, r, tt.method, tt.url, tt.body)
+			assert.Equal(t, tt.wantStatus, w.Code)
+			resp := decodeResponse(t, w.Body.Bytes(), nil)
+			assert.Equal(t, tt.wantCode, resp.Code)
+		})
+	}
+
+	w = doJSON(t, r, http.MethodGet, "/api/v1/plugins/echo", nil)
+	var info service.PluginInfo
+	decodeResponse(t, w.Body.Bytes(), &info)
+	assert.True(t, info.Enabled)
+	assert.Equal(t, "你好", info.Config["greeting"])
+}
@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"leafnote/internal/i18n"
+	"leafnote/internal/model"
+	"leafnote/internal/response"
+	"leafnote/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// sseHeartbeatInterval SSE 心跳间隔，避免代理断开空闲连接
+const sseHeartbeatInterval = 30 * time.Second
+
+// ListReminders 获取提醒列表，支持按状态和笔记筛选
+func (h *Handler) ListReminders(c *gin.Context) {
+	var req struct {
+		Status string `form:"status" binding:"omitempty,oneof=pending fired dismissed"`
+		NoteID string `form:"note_id"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("Invalid request parameters", zap.Error(err))
+		response.BindError(c, err)
+		return
+	}
+
+	reminders, err := h.reminderService.ListReminders(c.Request.Context(), service.ReminderFilter{
+		Status: model.ReminderStatus(req.Status),
+		NoteID: req.NoteID,
+	})
+	if err != nil {
+		h.logger.Error("Failed to list reminders", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.OK(c, reminders)
+}
+
+// CreateReminder 为笔记创建提醒
+func (h *Handler) CreateReminder(c *gin.Context) {
+	var req struct {
+		NoteID   string    `json:"note_id" binding:"required"`
+		RemindAt time.Time `json:"remind_at" binding:"required"`
+		Message  string    `json:"message"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Invalid request parameters", zap.Error(err))
+		response.BindError(c, err)
+		return
+	}
+
+	reminder, err := h.reminderService.CreateReminder(c.Request.Context(), service.CreateReminderInput(req))
+	if err != nil {
+		h.logger.Error("Failed to create reminder", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.Created(c, reminder)
+}
+
+// GetReminder 获取提醒详情
+func (h *Handler) GetReminder(c *gin.Context) {
+	reminder, err := h.reminderService.GetReminder(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.logger.Error("Failed to get reminder", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.OK(c, reminder)
+}
+
+// SnoozeReminder 稍后提醒，until 优先于 minutes，都未指定时推迟 10 分钟
+func (h *Handler) SnoozeReminder(c *gin.Context) {
+	var req struct {
+		Until   *time.Time `json:"until"`
+		Minutes int        `json:"minutes" binding:"omitempty,min=1,max=10080"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.logger.Error("Invalid request parameters", zap.Error(err))
+		response.BindError(c, err)
+		return
+	}
+
+	until := time.Now().Add(service.DefaultSnooze)
+	switch {
+	case req.Until != nil:
+		until = *req.Until
+	case req.Minutes > 0:
+		until = time.Now().Add(time.Duration(req.Minutes) * time.Minute)
+	}
+	reminder, err := h.reminderService.SnoozeReminder(c.Request.Context(), c.Param("id"), until)
+	if err != nil {
+		h.logger.Error("Failed to snooze reminder", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.OK(c, reminder)
+}
+
+// DismissReminder 关闭提醒
+func (h *Handler) DismissReminder(c *gin.Context) {
+	reminder, err := h.reminderService.DismissReminder(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.logger.Error("Failed to dismiss reminder", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.OK(c, reminder)
+}
+
+// DeleteReminder 删除通过接口创建的提醒
+func (h *Handler) DeleteReminder(c *gin.Context) {
+	if err := h.reminderService.DeleteReminder(c.Request.Context(), c.Param("id")); err != nil {
+		h.logger.Error("Failed to delete reminder", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.Message(c, i18n.MsgDeleted)
+}
+
+// StreamReminders 以 SSE 推送触发的提醒，每个提醒为一个 reminder 事件，数据为 JSON
+func (h *Handler) StreamReminders(c *gin.Context) {
+	notifications, unsubscribe := h.reminderService.Subscribe()
+	defer unsubscribe()
+
+	// 长连接不受服务的写超时限制
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warn("Failed to clear write deadline", zap.Error(err))
+	}
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	// 立即发送响应头，客户端收到响应即表示订阅成功
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case n, ok := <-notifications:
+			if !ok {
+				return false
+			}
+			c.SSEvent("reminder", n)
+			return true
+		case <-heartbeat.C:
+			_, err := fmt.Fprint(w, ": ping\n\n")
+			return err == nil
+		}
+	})
+}
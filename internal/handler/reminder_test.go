@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"leafnote/internal/model"
+	"leafnote/internal/service"
+	"leafnote/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestHandler_Reminders(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	reminders := service.NewReminderService(db, zap.NewNop(), service.ReminderOptions{})
+	_, r := setupTestHandlerWithDB(t, db, WithReminderService(reminders))
+
+	note, err := service.NewNoteService(db, zap.NewNop()).CreateNote(service.CreateNoteInput{Title: "周会", FilePath: "/周会.md"})
+	require.NoError(t, err)
+
+	w := doJSON(t, r, http.MethodPost, "/api/v1/reminders", map[string]interface{}{
+		"note_id":   note.ID,
+		"remind_at": time.Now().Add(-time.Minute).Format(time.RFC3339),
+		"message":   "准备周会",
+	})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var reminder model.Reminder
+	decodeResponse(t, w.Body.Bytes(), &reminder)
+	assert.Equal(t, model.ReminderPending, reminder.Status)
+
+	t.Run("推送触发的提醒", func(t *testing.T) {
+		srv := httptest.NewServer(r)
+		defer srv.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/v1/reminders/stream", nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		fired, err := reminders.FireDue(ctx, time.Now())
+		require.NoError(t, err)
+		require.Equal(t, 1, fired)
+
+		scanner := bufio.NewScanner(resp.Body)
+		var lines []string
+		for scanner.Scan() && scanner.Text() != "" {
+			lines = append(lines, scanner.Text())
+		}
+		require.Len(t, lines, 2)
+		assert.Equal(t, "event:reminder", lines[0])
+		assert.True(t, strings.HasPrefix(lines[1], "data:"))
+		assert.Contains(t, lines[1], reminder.ID)
+		assert.Contains(t, lines[1], `"event":"reminder.fired"`)
+	})
+
+	t.Run("稍后提醒", func(t *testing.T) {
+		w := doJSON(t, r, http.MethodPost, "/api/v1/reminders/"+reminder.ID+"/snooze", map[string]interface{}{"minutes": 30})
+		require.Equal(t, http.StatusOK, w.Code)
+		var snoozed model.Reminder
+		decodeResponse(t, w.Body.Bytes(), &snoozed)
+		assert.Equal(t, model.ReminderPending, snoozed.Status)
+		assert.WithinDuration(t, time.Now().Add(30*time.Minute), snoozed.RemindAt, time.Minute)
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		url        string
+		body       interface{}
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "关闭提醒", method: http.MethodPost, url: "/api/v1/reminders/" + reminder.ID + "/dismiss", wantStatus: http.StatusOK},
+		{name: "稍后时间已过去", method: http.MethodPost, url: "/api/v1/reminders/" + reminder.ID + "/snooze", body: map[string]interface{}{"until": "2020-01-01T00:00:00Z"}, wantStatus: http.StatusBadRequest, wantCode: "REMINDER_TIME_INVALID"},
+		{name: "状态不支持", method: http.MethodGet, url: "/api/v1/reminders?status=done", wantStatus: http.StatusBadRequest, wantCode: "INVALID_PARAMS"},
+		{name: "缺少提醒时间", method: http.MethodPost, url: "/api/v1/reminders", body: map[string]interface{}{"note_id": note.ID}, wantStatus: http.StatusBadRequest, wantCode: "INVALID_PARAMS"},
+		{name: "笔记不存在", method: http.MethodPost, url: "/api/v1/reminders", body: map[string]interface{}{"note_id": "not-exist", "remind_at": "2030-01-01T09:00:00Z"}, wantStatus: http.StatusBadRequest, wantCode: "VALIDATION_FAILED"},
+		{name: "删除提醒", method: http.MethodDelete, url: "/api/v1/reminders/" + reminder.ID, wantStatus: http.StatusOK},
+		{name: "提醒不存在", method: http.MethodGet, url: "/api/v1/reminders/" + reminder.ID, wantStatus: http.StatusNotFound, wantCode: "REMINDER_NOT_FOUND"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doJSON(t, r, tt.method, tt.url, tt.body)
+			assert.Equal(t, tt.wantStatus, w.Code)
+			resp := decodeResponse(t, w.Body.Bytes(), nil)
+			assert.Equal(t, tt.wantCode, resp.Code)
+		})
+	}
+}
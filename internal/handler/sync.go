@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"leafnote/internal/response"
+	"leafnote/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// syncChangeRequest 同步时上传的一条笔记修改
+type syncChangeRequest struct {
+	ChangeID    string   `json:"change_id" binding:"required,max=64"`
+	Type        string   `json:"type" binding:"required,oneof=create update delete"`
+	NoteID      string   `json:"note_id" binding:"required,max=36"`
+	BaseVersion int      `json:"base_version" binding:"min=0"`
+	Title       string   `json:"title"`
+	Content     string   `json:"content"`
+	YAMLMeta    string   `json:"yaml_meta"`
+	FilePath    string   `json:"file_path"`
+	CategoryID  *string  `json:"category_id"`
+	TagIDs      []string `json:"tag_ids"`
+}
+
+// syncChangeResult 上传修改的处理结果，被拒绝时附带本地化的原因
+type syncChangeResult struct {
+	service.SyncChangeResult
+	Error *response.ErrorDetail `json:"error,omitempty"`
+}
+
+// Sync 增量同步：应用客户端上传的笔记修改，返回 cursor 之后的远端修改
+func (h *Handler) Sync(c *gin.Context) {
+	var req struct {
+		ClientID string              `json:"client_id" binding:"max=64"`
+		Cursor   *uint64             `json:"cursor"`
+		Changes  []syncChangeRequest `json:"changes" binding:"max=500,dive"`
+		Limit    int                 `json:"limit" binding:"min=0,max=1000"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Invalid request parameters", zap.Error(err))
+		response.BindError(c, err)
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = 500
+	}
+
+	input := service.SyncInput{ClientID: req.ClientID, Cursor: req.Cursor, Limit: req.Limit}
+	for _, change := range req.Changes {
+		input.Changes = append(input.Changes, service.SyncChangeInput(change))
+	}
+	result, err := h.syncService.Sync(c.Request.Context(), input)
+	if err != nil {
+		h.logger.Error("Failed to sync", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	changes := make([]syncChangeResult, 0, len(result.Changes))
+	for _, r := range result.Changes {
+		item := syncChangeResult{SyncChangeResult: r}
+		if r.Err != nil {
+			item.Error = response.Detail(c, r.Err)
+		}
+		changes = append(changes, item)
+	}
+	response.OK(c, gin.H{
+		"cursor":     result.Cursor,
+		"reset":      result.Reset,
+		"has_more":   result.HasMore,
+		"changes":    changes,
+		"notes":      result.Notes,
+		"tags":       result.Tags,
+		"categories": result.Categories,
+	})
+}
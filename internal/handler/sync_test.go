@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"leafnote/internal/service"
+	"leafnote/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestHandler_Sync(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	events := service.NewEventService(db, zap.NewNop(), service.EventOptions{})
+	syncs := service.NewSyncService(db, zap.NewNop(), events, service.SyncOptions{})
+	_, r := setupTestHandlerWithDB(t, db, WithSyncService(syncs))
+
+	note, err := service.NewNoteService(db, zap.NewNop()).CreateNote(service.CreateNoteInput{Title: "周报", FilePath: "/周报.md"})
+	require.NoError(t, err)
+
+	type result struct {
+		Cursor  uint64 `json:"cursor"`
+		Reset   bool   `json:"reset"`
+		Changes []struct {
+			ChangeID string `json:"change_id"`
+			Status   string `json:"status"`
+			Error    *struct {
+				Code string `json:"code"`
+			} `json:"error"`
+		} `json:"changes"`
+		Notes []service.SyncNote `json:"notes"`
+	}
+
+	w := doJSON(t, r, http.MethodPost, "/api/v1/sync", map[string]interface{}{})
+	require.Equal(t, http.StatusOK, w.Code)
+	var full result
+	decodeResponse(t, w.Body.Bytes(), &full)
+	assert.True(t, full.Reset)
+	require.Len(t, full.Notes, 1)
+
+	w = doJSON(t, r, http.MethodPost, "/api/v1/sync", map[string]interface{}{
+		"client_id": "desktop",
+		"cursor":    full.Cursor,
+		"changes": []map[string]interface{}{
+			{"change_id": "c1", "type": "update", "note_id": note.ID, "base_version": 1, "title": "周报（修订）"},
+			{"change_id": "c2", "type": "create", "note_id": "not-a-uuid", "title": "草稿", "file_path": "/草稿.md"},
+		},
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	var delta result
+	decodeResponse(t, w.Body.Bytes(), &delta)
+	require.Len(t, delta.Changes, 2)
+	assert.Equal(t, service.SyncStatusApplied, delta.Changes[0].Status)
+	assert.Nil(t, delta.Changes[0].Error)
+	assert.Equal(t, service.SyncStatusRejected, delta.Changes[1].Status)
+	require.NotNil(t, delta.Changes[1].Error)
+	assert.Equal(t, "VALIDATION_FAILED", delta.Changes[1].Error.Code)
+
+	t.Run("返回推送后的修改", func(t *testing.T) {
+		_, err := events.Poll(context.Background(), time.Now())
+		require.NoError(t, err)
+		w := doJSON(t, r, http.MethodPost, "/api/v1/sync", map[string]interface{}{"cursor": full.Cursor})
+		require.Equal(t, http.StatusOK, w.Code)
+		var res result
+		decodeResponse(t, w.Body.Bytes(), &res)
+		require.Len(t, res.Notes, 1)
+		assert.Equal(t, "周报（修订）", res.Notes[0].Title)
+		assert.Equal(t, 2, res.Notes[0].Version)
+
+		// 不返回该客户端自己上传的修改
+		w = doJSON(t, r, http.MethodPost, "/api/v1/sync", map[string]interface{}{"client_id": "desktop", "cursor": full.Cursor})
+		require.Equal(t, http.StatusOK, w.Code)
+		var own result
+		decodeResponse(t, w.Body.Bytes(), &own)
+		assert.Empty(t, own.Notes)
+		assert.Equal(t, res.Cursor, own.Cursor)
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		url        string
+		body       interface{}
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "修改类型不支持", method: http.MethodPost, url: "/api/v1/sync", body: map[string]interface{}{"changes": []map[string]interface{}{{"change_id": "c3", "type": "move", "note_id": note.ID}}}, wantStatus: http.StatusBadRequest, wantCode: "INVALID_PARAMS"},
+		{name: "缺少修改ID", method: http.MethodPost, url: "/api/v1/sync", body: map[string]interface{}{"changes": []map[string]interface{}{{"type": "delete", "note_id": note.ID}}}, wantStatus: http.StatusBadRequest, wantCode: "INVALID_PARAMS"},
+		{name: "客户端标识过长", method: http.MethodPost, url: "/api/v1/sync", body: map[string]interface{}{"client_id": strings.Repeat("a", 65)}, wantStatus: http.StatusBadRequest, wantCode: "INVALID_PARAMS"},
+		{name: "数量超过上限", method: http.MethodPost, url: "/api/v1/sync", body: map[string]interface{}{"limit": 5000}, wantStatus: http.StatusBadRequest, wantCode: "INVALID_PARAMS"},
+		{name: "基于过期版本修改笔记", method: http.MethodPut, url: "/api/v1/notes/" + note.ID, body: map[string]interface{}{"title": "旧的修改", "base_version": 1}, wantStatus: http.StatusConflict, wantCode: "NOTE_VERSION_CONFLICT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doJSON(t, r, tt.method, tt.url, tt.body)
+			assert.Equal(t, tt.wantStatus, w.Code)
+			resp := decodeResponse(t, w.Body.Bytes(), nil)
+			assert.Equal(t, tt.wantCode, resp.Code)
+		})
+	}
+}
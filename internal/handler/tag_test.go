@@ -10,7 +10,6 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
 	"leafnote/internal/model"
@@ -22,13 +21,8 @@ func setupTestRouter(t *testing.T) (*gin.Engine, *gorm.DB) {
 	// 设置测试模式
 	gin.SetMode(gin.TestMode)
 
-	// 创建内存数据库
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	assert.NoError(t, err)
-
-	// 自动迁移
-	err = db.AutoMigrate(&model.Tag{})
-	assert.NoError(t, err)
+	// 创建测试数据库
+	db := testutil.NewTestDB(t)
 
 	// 创建路由
 	r := gin.New()
@@ -71,9 +65,21 @@ func TestTagHandler_CreateTag(t *testing.T) {
 			requestBody: map[string]interface{}{
 				"name": "",
 			},
-			wantStatus: http.StatusInternalServerError,
+			wantStatus: http.StatusBadRequest,
+			wantResponse: map[string]interface{}{
+				"error": "参数校验失败",
+				"code":  "VALIDATION_FAILED",
+			},
+		},
+		{
+			name: "名称包含非法字符",
+			requestBody: map[string]interface{}{
+				"name": "a#b",
+			},
+			wantStatus: http.StatusBadRequest,
 			wantResponse: map[string]interface{}{
-				"error": "创建标签失败",
+				"error": "参数校验失败",
+				"code":  "VALIDATION_FAILED",
 			},
 		},
 	}
@@ -94,17 +100,14 @@ func TestTagHandler_CreateTag(t *testing.T) {
 			r.ServeHTTP(w, req)
 
 			assert.Equal(t, tt.wantStatus, w.Code)
+			var tag model.Tag
+			resp := decodeResponse(t, w.Body.Bytes(), &tag)
 			if tt.wantStatus == http.StatusCreated {
-				var response model.Tag
-				err = json.Unmarshal(w.Body.Bytes(), &response)
-				assert.NoError(t, err)
-				assert.NotEmpty(t, response.BaseModel.ID)
-				assert.Equal(t, tt.wantResponse.(map[string]interface{})["name"], response.Name)
+				assert.NotEmpty(t, tag.BaseModel.ID)
+				assert.Equal(t, tt.wantResponse.(map[string]interface{})["name"], tag.Name)
 			} else {
-				var response map[string]interface{}
-				err = json.Unmarshal(w.Body.Bytes(), &response)
-				assert.NoError(t, err)
-				assert.Equal(t, tt.wantResponse.(map[string]interface{})["error"], response["error"])
+				assert.Equal(t, tt.wantResponse.(map[string]interface{})["error"], resp.Error)
+				assert.Equal(t, tt.wantResponse.(map[string]interface{})["code"], resp.Code)
 			}
 		})
 	}
@@ -146,8 +149,7 @@ func TestTagHandler_GetTag(t *testing.T) {
 			assert.Equal(t, tt.wantStatus, w.Code)
 			if tt.wantStatus == http.StatusOK {
 				var response model.Tag
-				err = json.Unmarshal(w.Body.Bytes(), &response)
-				assert.NoError(t, err)
+				decodeResponse(t, w.Body.Bytes(), &response)
 				assert.Equal(t, tt.tagID, response.BaseModel.ID)
 			}
 		})
@@ -179,8 +181,7 @@ func TestTagHandler_ListTags(t *testing.T) {
 		assert.Equal(t, http.StatusOK, w.Code)
 
 		var response []model.Tag
-		err = json.Unmarshal(w.Body.Bytes(), &response)
-		assert.NoError(t, err)
+		decodeResponse(t, w.Body.Bytes(), &response)
 		assert.Len(t, response, 1) // 只返回顶级标签
 		assert.Equal(t, parent.BaseModel.ID, response[0].BaseModel.ID)
 		assert.Len(t, response[0].Children, 1) // 包含子标签
@@ -224,7 +225,7 @@ func TestTagHandler_UpdateTag(t *testing.T) {
 			updateTag: model.Tag{
 				Name: "",
 			},
-			wantStatus: http.StatusInternalServerError,
+			wantStatus: http.StatusBadRequest,
 		},
 	}
 
@@ -242,8 +243,7 @@ func TestTagHandler_UpdateTag(t *testing.T) {
 			assert.Equal(t, tt.wantStatus, w.Code)
 			if tt.wantStatus == http.StatusOK {
 				var response model.Tag
-				err = json.Unmarshal(w.Body.Bytes(), &response)
-				assert.NoError(t, err)
+				decodeResponse(t, w.Body.Bytes(), &response)
 				assert.Equal(t, tt.tagID, response.BaseModel.ID)
 				assert.Equal(t, tt.updateTag.Name, response.Name)
 			}
@@ -275,7 +275,7 @@ func TestTagHandler_DeleteTag(t *testing.T) {
 		{
 			name:       "删除失败-存在子标签",
 			tagID:      parent.BaseModel.ID,
-			wantStatus: http.StatusInternalServerError,
+			wantStatus: http.StatusConflict,
 		},
 		{
 			name:       "删除成功-子标签",
@@ -290,7 +290,7 @@ func TestTagHandler_DeleteTag(t *testing.T) {
 		{
 			name:       "标签不存在",
 			tagID:      "not-exist",
-			wantStatus: http.StatusOK,
+			wantStatus: http.StatusNotFound,
 		},
 	}
 
@@ -302,11 +302,11 @@ func TestTagHandler_DeleteTag(t *testing.T) {
 			r.ServeHTTP(w, req)
 
 			assert.Equal(t, tt.wantStatus, w.Code)
+			resp := decodeResponse(t, w.Body.Bytes(), nil)
 			if tt.wantStatus == http.StatusOK {
-				var response map[string]string
-				err = json.Unmarshal(w.Body.Bytes(), &response)
-				assert.NoError(t, err)
-				assert.Equal(t, "删除成功", response["message"])
+				assert.Equal(t, "删除成功", resp.Message)
+			} else {
+				assert.Equal(t, "error", resp.Status)
 			}
 		})
 	}
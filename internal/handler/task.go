@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"errors"
+	"io"
+
+	"leafnote/internal/response"
+	"leafnote/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ListTasks 获取所有笔记中的待办，支持按状态、笔记、目录、截止日期、优先级和文字筛选
+func (h *Handler) ListTasks(c *gin.Context) {
+	var req struct {
+		Done        *bool  `form:"done"`
+		NoteID      string `form:"note_id"`
+		CategoryID  string `form:"category_id"`
+		DueFrom     string `form:"due_from" binding:"omitempty,datetime=2006-01-02"`
+		DueTo       string `form:"due_to" binding:"omitempty,datetime=2006-01-02"`
+		HasDue      *bool  `form:"has_due"`
+		MinPriority int    `form:"priority" binding:"omitempty,min=1,max=5"`
+		Query       string `form:"q"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("Invalid request parameters", zap.Error(err))
+		response.BindError(c, err)
+		return
+	}
+
+	tasks, err := h.taskService.ListTasks(c.Request.Context(), service.TaskFilter(req))
+	if err != nil {
+		h.logger.Error("Failed to list tasks", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.OK(c, tasks)
+}
+
+// ToggleTask 勾选或取消勾选待办，同时改写笔记正文；请求体为空时切换当前状态
+func (h *Handler) ToggleTask(c *gin.Context) {
+	var req struct {
+		Done *bool `json:"done"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.logger.Error("Invalid request parameters", zap.Error(err))
+		response.BindError(c, err)
+		return
+	}
+
+	task, err := h.taskService.ToggleTask(c.Request.Context(), c.Param("id"), req.Done)
+	if err != nil {
+		h.logger.Error("Failed to toggle task", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.OK(c, task)
+}
+
+// RebuildTasks 按所有笔记的正文重建待办，升级后首次使用前调用一次
+func (h *Handler) RebuildTasks(c *gin.Context) {
+	result, err := h.taskService.RebuildTasks(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to rebuild tasks", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.OK(c, result)
+}
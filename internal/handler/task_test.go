@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"leafnote/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Tasks(t *testing.T) {
+	_, r := setupTestHandler(t)
+
+	w := doJSON(t, r, http.MethodPost, "/api/v1/notes", map[string]interface{}{
+		"title":     "本周",
+		"file_path": "/本周.md",
+		"content":   "- [ ] 发布 📅 2026-10-20 ⏫\n- [x] 评审",
+	})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var note model.Note
+	decodeResponse(t, w.Body.Bytes(), &note)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/tasks?done=false&priority=4", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var tasks []model.Task
+	decodeResponse(t, w.Body.Bytes(), &tasks)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "发布", tasks[0].Text)
+	assert.Equal(t, "本周", tasks[0].NoteTitle)
+
+	t.Run("勾选待办", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/tasks/"+tasks[0].ID+"/toggle", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		var task model.Task
+		decodeResponse(t, w.Body.Bytes(), &task)
+		assert.True(t, task.Done)
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/notes/"+note.ID, nil))
+		var got model.Note
+		decodeResponse(t, w.Body.Bytes(), &got)
+		assert.Equal(t, "- [x] 发布 📅 2026-10-20 ⏫\n- [x] 评审", got.Content)
+		assert.Equal(t, 2, got.Version)
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		url        string
+		body       interface{}
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "日期格式错误", method: http.MethodGet, url: "/api/v1/tasks?due_from=10/20", wantStatus: http.StatusBadRequest, wantCode: "INVALID_PARAMS"},
+		{name: "优先级超出范围", method: http.MethodGet, url: "/api/v1/tasks?priority=6", wantStatus: http.StatusBadRequest, wantCode: "INVALID_PARAMS"},
+		{name: "待办不存在", method: http.MethodPost, url: "/api/v1/tasks/not-exist/toggle", body: map[string]interface{}{"done": true}, wantStatus: http.StatusNotFound, wantCode: "TASK_NOT_FOUND"},
+		{name: "重建待办", method: http.MethodPost, url: "/api/v1/tasks/rebuild", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doJSON(t, r, tt.method, tt.url, tt.body)
+			assert.Equal(t, tt.wantStatus, w.Code)
+			resp := decodeResponse(t, w.Body.Bytes(), nil)
+			assert.Equal(t, tt.wantCode, resp.Code)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"leafnote/internal/i18n"
+	"leafnote/internal/model"
+	"leafnote/internal/response"
+	"leafnote/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ListTemplates 获取模板列表
+func (h *Handler) ListTemplates(c *gin.Context) {
+	templates, err := h.templateService.ListTemplates(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to list templates", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.OK(c, templates)
+}
+
+// CreateTemplate 创建模板
+func (h *Handler) CreateTemplate(c *gin.Context) {
+	var tpl model.Template
+	if err := c.ShouldBindJSON(&tpl); err != nil {
+		h.logger.Error("Invalid request parameters", zap.Error(err))
+		response.BindError(c, err)
+		return
+	}
+	if err := h.templateService.CreateTemplate(c.Request.Context(), &tpl); err != nil {
+		h.logger.Error("Failed to create template", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.Created(c, tpl)
+}
+
+// GetTemplate 获取模板详情
+func (h *Handler) GetTemplate(c *gin.Context) {
+	tpl, err := h.templateService.GetTemplate(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.logger.Error("Failed to get template", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.OK(c, tpl)
+}
+
+// UpdateTemplate 更新模板
+func (h *Handler) UpdateTemplate(c *gin.Context) {
+	var tpl model.Template
+	if err := c.ShouldBindJSON(&tpl); err != nil {
+		h.logger.Error("Invalid request parameters", zap.Error(err))
+		response.BindError(c, err)
+		return
+	}
+	tpl.ID = c.Param("id")
+	if err := h.templateService.UpdateTemplate(c.Request.Context(), &tpl); err != nil {
+		h.logger.Error("Failed to update template", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	updated, err := h.templateService.GetTemplate(c.Request.Context(), tpl.ID)
+	if err != nil {
+		h.logger.Error("Failed to get updated template", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.OK(c, updated)
+}
+
+// DeleteTemplate 删除模板
+func (h *Handler) DeleteTemplate(c *gin.Context) {
+	if err := h.templateService.DeleteTemplate(c.Request.Context(), c.Param("id")); err != nil {
+		h.logger.Error("Failed to delete template", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.Message(c, i18n.MsgDeleted)
+}
+
+// CreateNoteFromTemplate 渲染模板并创建笔记
+func (h *Handler) CreateNoteFromTemplate(c *gin.Context) {
+	var req struct {
+		TemplateID string            `json:"template_id" binding:"required"`
+		Title      string            `json:"title"`
+		FilePath   string            `json:"file_path"`
+		CategoryID *string           `json:"category_id"`
+		TagIDs     []string          `json:"tag_ids"`
+		Variables  map[string]string `json:"variables"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Invalid request parameters", zap.Error(err))
+		response.BindError(c, err)
+		return
+	}
+
+	note, err := h.templateService.CreateNote(c.Request.Context(), service.CreateFromTemplateInput{
+		TemplateID: req.TemplateID,
+		Title:      req.Title,
+		FilePath:   req.FilePath,
+		CategoryID: req.CategoryID,
+		TagIDs:     req.TagIDs,
+		Variables:  req.Variables,
+	})
+	if err != nil {
+		h.logger.Error("Failed to create note from template", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.Created(c, note)
+}
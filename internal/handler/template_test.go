@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"leafnote/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// doJSON 发送 JSON 请求
+func doJSON(t *testing.T, r *gin.Engine, method, url string, body interface{}) *httptest.ResponseRecorder {
+	data, err := json.Marshal(body)
+	require.NoError(t, err)
+	req := httptest.NewRequest(method, url, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandler_Templates(t *testing.T) {
+	_, r := setupTestHandler(t)
+
+	w := doJSON(t, r, http.MethodPost, "/api/v1/templates", map[string]interface{}{
+		"name":      "每日回顾",
+		"title":     "{{date}} 回顾",
+		"yaml_meta": "mood: {{prompt:心情|平静}}",
+		"content":   "# {{title}}\n\n今天完成：{{prompt:完成事项}}",
+	})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var tpl model.Template
+	decodeResponse(t, w.Body.Bytes(), &tpl)
+	assert.Len(t, tpl.Prompts, 2)
+
+	tests := []struct {
+		name       string
+		method     string
+		url        string
+		body       interface{}
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "模板名称重复", method: http.MethodPost, url: "/api/v1/templates", body: map[string]interface{}{"name": "每日回顾"}, wantStatus: http.StatusConflict, wantCode: "TEMPLATE_NAME_EXISTS"},
+		{name: "模板不存在", method: http.MethodGet, url: "/api/v1/templates/not-exist", wantStatus: http.StatusNotFound, wantCode: "TEMPLATE_NOT_FOUND"},
+		{name: "缺少模板ID", method: http.MethodPost, url: "/api/v1/notes/from-template", body: map[string]interface{}{}, wantStatus: http.StatusBadRequest, wantCode: "INVALID_PARAMS"},
+		{name: "缺少变量", method: http.MethodPost, url: "/api/v1/notes/from-template", body: map[string]interface{}{"template_id": tpl.ID}, wantStatus: http.StatusBadRequest, wantCode: "VALIDATION_FAILED"},
+		{name: "更新模板", method: http.MethodPut, url: "/api/v1/templates/" + tpl.ID, body: map[string]interface{}{"name": "每日回顾", "title": "回顾 {{date:YYYY/MM/DD}}", "content": "{{prompt:完成事项}}"}, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doJSON(t, r, tt.method, tt.url, tt.body)
+			assert.Equal(t, tt.wantStatus, w.Code)
+			resp := decodeResponse(t, w.Body.Bytes(), nil)
+			assert.Equal(t, tt.wantCode, resp.Code)
+		})
+	}
+
+	t.Run("从模板创建笔记", func(t *testing.T) {
+		w := doJSON(t, r, http.MethodPost, "/api/v1/notes/from-template", map[string]interface{}{
+			"template_id": tpl.ID,
+			"title":       "周日回顾",
+			"variables":   map[string]string{"完成事项": "整理笔记"},
+		})
+		require.Equal(t, http.StatusCreated, w.Code)
+		var note model.Note
+		decodeResponse(t, w.Body.Bytes(), &note)
+		assert.Equal(t, "周日回顾", note.Title)
+		assert.Equal(t, "/周日回顾.md", note.FilePath)
+		assert.Equal(t, "整理笔记", note.Content)
+	})
+
+	t.Run("删除模板", func(t *testing.T) {
+		w := doJSON(t, r, http.MethodDelete, "/api/v1/templates/"+tpl.ID, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		w = doJSON(t, r, http.MethodGet, "/api/v1/templates", nil)
+		var list []model.Template
+		decodeResponse(t, w.Body.Bytes(), &list)
+		assert.Empty(t, list)
+	})
+}
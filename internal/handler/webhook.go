@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"leafnote/internal/i18n"
+	"leafnote/internal/model"
+	"leafnote/internal/response"
+	"leafnote/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// webhookRequest 创建或更新 Webhook 的请求体
+type webhookRequest struct {
+	URL        string            `json:"url" binding:"required"`
+	Secret     string            `json:"secret"`
+	Events     []model.EventType `json:"events"`
+	CategoryID *string           `json:"category_id"`
+	TagID      *string           `json:"tag_id"`
+	Enabled    *bool             `json:"enabled"`
+}
+
+// ListWebhooks 获取 Webhook 列表
+func (h *Handler) ListWebhooks(c *gin.Context) {
+	hooks, err := h.webhookService.ListWebhooks(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to list webhooks", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.OK(c, hooks)
+}
+
+// CreateWebhook 创建 Webhook，未指定签名密钥时自动生成，仅在创建时返回
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Invalid request parameters", zap.Error(err))
+		response.BindError(c, err)
+		return
+	}
+
+	hook, err := h.webhookService.CreateWebhook(c.Request.Context(), service.WebhookInput(req))
+	if err != nil {
+		h.logger.Error("Failed to create webhook", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.Created(c, hook)
+}
+
+// GetWebhook 获取 Webhook 详情
+func (h *Handler) GetWebhook(c *gin.Context) {
+	hook, err := h.webhookService.GetWebhook(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.logger.Error("Failed to get webhook", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.OK(c, hook)
+}
+
+// UpdateWebhook 更新 Webhook，签名密钥和启用状态未指定时保持不变
+func (h *Handler) UpdateWebhook(c *gin.Context) {
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Invalid request parameters", zap.Error(err))
+		response.BindError(c, err)
+		return
+	}
+
+	hook, err := h.webhookService.UpdateWebhook(c.Request.Context(), c.Param("id"), service.WebhookInput(req))
+	if err != nil {
+		h.logger.Error("Failed to update webhook", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.OK(c, hook)
+}
+
+// DeleteWebhook 删除 Webhook 及其投递记录
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	if err := h.webhookService.DeleteWebhook(c.Request.Context(), c.Param("id")); err != nil {
+		h.logger.Error("Failed to delete webhook", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.Message(c, i18n.MsgDeleted)
+}
+
+// ListWebhookDeliveries 获取 Webhook 的投递记录，最新的在前，可按状态筛选以查看失败原因
+func (h *Handler) ListWebhookDeliveries(c *gin.Context) {
+	var req struct {
+		Status string `form:"status" binding:"omitempty,oneof=pending succeeded failed"`
+		Limit  int    `form:"limit,default=50" binding:"min=1,max=200"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("Invalid request parameters", zap.Error(err))
+		response.BindError(c, err)
+		return
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(c.Request.Context(), c.Param("id"), service.WebhookDeliveryFilter{
+		Status: model.WebhookDeliveryStatus(req.Status),
+		Limit:  req.Limit,
+	})
+	if err != nil {
+		h.logger.Error("Failed to list webhook deliveries", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.OK(c, deliveries)
+}
+
+// RetryWebhookDelivery 立即重新投递，重试次数从零开始计算
+func (h *Handler) RetryWebhookDelivery(c *gin.Context) {
+	delivery, err := h.webhookService.RetryDelivery(c.Request.Context(), c.Param("id"), c.Param("delivery_id"))
+	if err != nil {
+		h.logger.Error("Failed to retry webhook delivery", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.OK(c, delivery)
+}
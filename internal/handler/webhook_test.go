@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"leafnote/internal/model"
+	"leafnote/internal/service"
+	"leafnote/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestHandler_Webhooks(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	webhooks := service.NewWebhookService(db, zap.NewNop(), service.WebhookOptions{})
+	_, r := setupTestHandlerWithDB(t, db, WithWebhookService(webhooks))
+
+	w := doJSON(t, r, http.MethodPost, "/api/v1/webhooks", map[string]interface{}{
+		"url":    "https://example.com/hook",
+		"events": []string{"note.created"},
+	})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var hook model.Webhook
+	decodeResponse(t, w.Body.Bytes(), &hook)
+	assert.NotEmpty(t, hook.Secret)
+	assert.True(t, hook.Enabled)
+
+	_, err := service.NewNoteService(db, zap.NewNop()).CreateNote(service.CreateNoteInput{Title: "周报", FilePath: "/周报.md"})
+	require.NoError(t, err)
+
+	t.Run("查询时不返回签名密钥", func(t *testing.T) {
+		w := doJSON(t, r, http.MethodGet, "/api/v1/webhooks/"+hook.ID, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), `"secret"`)
+	})
+
+	t.Run("查看投递记录", func(t *testing.T) {
+		w := doJSON(t, r, http.MethodGet, "/api/v1/webhooks/"+hook.ID+"/deliveries?status=pending", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var deliveries []model.WebhookDelivery
+		decodeResponse(t, w.Body.Bytes(), &deliveries)
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, model.EventNoteCreated, deliveries[0].EventType)
+
+		w = doJSON(t, r, http.MethodPost, "/api/v1/webhooks/"+hook.ID+"/deliveries/"+deliveries[0].ID+"/retry", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		url        string
+		body       interface{}
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "停用", method: http.MethodPut, url: "/api/v1/webhooks/" + hook.ID, body: map[string]interface{}{"url": "https://example.com/hook", "enabled": false}, wantStatus: http.StatusOK},
+		{name: "缺少地址", method: http.MethodPost, url: "/api/v1/webhooks", body: map[string]interface{}{}, wantStatus: http.StatusBadRequest, wantCode: "INVALID_PARAMS"},
+		{name: "地址不合法", method: http.MethodPost, url: "/api/v1/webhooks", body: map[string]interface{}{"url": "example.com"}, wantStatus: http.StatusBadRequest, wantCode: "VALIDATION_FAILED"},
+		{name: "事件类型不支持", method: http.MethodPost, url: "/api/v1/webhooks", body: map[string]interface{}{"url": "https://example.com", "events": []string{"note.moved"}}, wantStatus: http.StatusBadRequest, wantCode: "VALIDATION_FAILED"},
+		{name: "投递状态不支持", method: http.MethodGet, url: "/api/v1/webhooks/" + hook.ID + "/deliveries?status=done", wantStatus: http.StatusBadRequest, wantCode: "INVALID_PARAMS"},
+		{name: "投递记录不存在", method: http.MethodPost, url: "/api/v1/webhooks/" + hook.ID + "/deliveries/not-exist/retry", wantStatus: http.StatusNotFound, wantCode: "WEBHOOK_DELIVERY_NOT_FOUND"},
+		{name: "删除", method: http.MethodDelete, url: "/api/v1/webhooks/" + hook.ID, wantStatus: http.StatusOK},
+		{name: "Webhook 不存在", method: http.MethodGet, url: "/api/v1/webhooks/" + hook.ID + "/deliveries", wantStatus: http.StatusNotFound, wantCode: "WEBHOOK_NOT_FOUND"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doJSON(t, r, tt.method, tt.url, tt.body)
+			assert.Equal(t, tt.wantStatus, w.Code)
+			resp := decodeResponse(t, w.Body.Bytes(), nil)
+			assert.Equal(t, tt.wantCode, resp.Code)
+		})
+	}
+}
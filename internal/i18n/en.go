@@ -0,0 +1,108 @@
+package i18n
+
+// en 英文消息目录
+var en = map[string]string{
+	// 通用
+	"INVALID_PARAMS":    "Invalid request parameters",
+	"VALIDATION_FAILED": "Validation failed",
+	"RATE_LIMITED":      "Too many requests, please try again later",
+	"INTERNAL_ERROR":    "Internal server error",
+	MsgDeleted:          "Deleted successfully",
+	MsgNoteRestored:     "Note restored",
+
+	// 字段校验
+	"FIELD_REQUIRED":          "{field} is a required field",
+	"FIELD_TOO_LONG":          "{field} must be at most {max} characters long",
+	"FIELD_INVALID_CHARS":     "{field} must not contain the characters: {chars}",
+	"FIELD_CONTROL_CHARS":     "{field} must not contain control characters",
+	"FIELD_WHITESPACE":        "{field} must not contain whitespace",
+	"FIELD_RESERVED_NAME":     "{field} must not use a reserved system name",
+	"FIELD_INVALID_NAME":      "names in {field} must not be . or end with a dot or space",
+	"FIELD_PATH_TRAVERSAL":    "{field} must not contain .. segments",
+	"FIELD_ABSOLUTE_PATH":     "{field} must be a path relative to the vault",
+	"FIELD_INVALID_EXTENSION": "{field} must end with {ext}",
+	"FIELD_NOT_FOUND":         "{field} references records that do not exist: {value}",
+	"FIELD_INVALID_YAML":      "{field} is not valid YAML",
+	"FIELD_INVALID_URL":       "{field} must be an http or https URL",
+	"FIELD_UNSUPPORTED":       "{field} contains unsupported values: {value}",
+	"FIELD_INVALID":           "{field} is invalid: {value}",
+	"FIELD_REJECTED":          "{field} rejected the operation: {value}",
+
+	// 笔记
+	"NOTE_NOT_FOUND":        "Note not found",
+	"NOTE_FILE_PATH_EXISTS": "File path already exists",
+	"NOTE_ID_EXISTS":        "Note ID already exists",
+	"NOTE_VERSION_CONFLICT": "The note has changed, please refresh and try again",
+
+	// 标签
+	"TAG_NOT_FOUND":        "Tag not found",
+	"TAG_ID_REQUIRED":      "Tag ID is required",
+	"TAG_PARENT_NOT_FOUND": "Parent tag not found",
+	"TAG_PARENT_SELF":      "A tag cannot be its own parent",
+	"TAG_HAS_CHILDREN":     "Delete the child tags first",
+
+	// 目录
+	"CATEGORY_NOT_FOUND":        "Category not found",
+	"CATEGORY_ID_REQUIRED":      "Category ID is required",
+	"CATEGORY_PARENT_NOT_FOUND": "Parent category not found",
+	"CATEGORY_PARENT_SELF":      "A category cannot be its own parent",
+	"CATEGORY_NAME_EXISTS":      "A category with the same name already exists at this level",
+	"CATEGORY_PATH_EXISTS":      "Category path already exists",
+	"CATEGORY_HAS_CHILDREN":     "Delete the subcategories first",
+	"CATEGORY_HAS_NOTES":        "Delete the notes in this category first",
+
+	// 备份
+	"BACKUP_NOT_FOUND":   "Backup not found",
+	"BACKUP_INVALID":     "Invalid backup archive",
+	"BACKUP_UNSUPPORTED": "Backups are only supported for SQLite databases",
+	"BACKUP_IN_PROGRESS": "A backup is already in progress",
+
+	// Export
+	"EXPORT_DIR_EXISTS":   "Export directory already exists and is not empty",
+	"EXPORT_DIR_DISABLED": "No export directory is configured, only zip export is available",
+
+	// Import
+	"IMPORT_FILE_REQUIRED":   "Please upload the file to import",
+	"IMPORT_INVALID_ARCHIVE": "Unable to read the zip file",
+	"IMPORT_TOO_LARGE":       "The uploaded file is too large",
+
+	// Attachments
+	"ATTACHMENT_NOT_FOUND":       "Attachment not found",
+	"ATTACHMENT_FILE_REQUIRED":   "Please upload an attachment",
+	"ATTACHMENT_EMPTY":           "The attachment is empty",
+	"ATTACHMENT_TOO_LARGE":       "The attachment exceeds the size limit",
+	"ATTACHMENT_NOT_IMAGE":       "The attachment is not a supported image",
+	"ATTACHMENT_IMAGE_TOO_LARGE": "The image has too many pixels to generate a thumbnail",
+	"THUMBNAIL_SIZE_INVALID":     "Unsupported thumbnail size",
+
+	// Templates
+	"TEMPLATE_NOT_FOUND":   "Template not found",
+	"TEMPLATE_NAME_EXISTS": "A template with this name already exists",
+
+	// Periodic notes
+	"PERIOD_INVALID":      "Unsupported period, expected daily, weekly or monthly",
+	"PERIOD_DISABLED":     "No path is configured for this kind of periodic note",
+	"PERIOD_DATE_INVALID": "Invalid date format",
+
+	// Tasks
+	"TASK_NOT_FOUND": "Task not found",
+	"TASK_OUTDATED":  "The note has changed, please refresh the tasks and try again",
+
+	// Reminders
+	"REMINDER_NOT_FOUND":    "Reminder not found",
+	"REMINDER_TIME_INVALID": "The reminder time must be in the future",
+	"REMINDER_FROM_YAML":    "This reminder comes from the note's YAML metadata, remove it in the note instead",
+
+	// Webhooks
+	"WEBHOOK_NOT_FOUND":          "Webhook not found",
+	"WEBHOOK_DELIVERY_NOT_FOUND": "Webhook delivery not found",
+
+	// Plugins
+	"PLUGIN_NOT_FOUND": "Plugin not found",
+	"PLUGIN_DISABLED":  "Plugin is not enabled",
+	"PLUGIN_REJECTED":  "The operation was rejected by a plugin",
+
+	// Jobs
+	"JOB_NOT_FOUND":  "Job not found",
+	"JOB_QUEUE_FULL": "Too many pending jobs, please try again later",
+}
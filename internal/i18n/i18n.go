@@ -0,0 +1,106 @@
+package i18n
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Locale 语言标识
+type Locale string
+
+const (
+	ZhCN Locale = "zh-CN" // 简体中文
+	En   Locale = "en"    // 英文
+)
+
+// DefaultLocale 无法协商时使用的默认语言
+const DefaultLocale = ZhCN
+
+// ContextKey 当前请求语言在 gin.Context 中的键
+const ContextKey = "locale"
+
+// 非错误类提示信息的消息键
+const (
+	MsgDeleted      = "DELETED"
+	MsgNoteRestored = "NOTE_RESTORED"
+)
+
+// catalogs 各语言的消息目录，键为错误码或消息键
+var catalogs = map[Locale]map[string]string{
+	ZhCN: zhCN,
+	En:   en,
+}
+
+// Parse 将语言标签（如 zh、zh-Hans-CN、en-US）解析为支持的语言
+func Parse(tag string) (Locale, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	switch {
+	case tag == "":
+		return "", false
+	case tag == "zh" || strings.HasPrefix(tag, "zh-") || strings.HasPrefix(tag, "zh_"):
+		return ZhCN, true
+	case tag == "en" || strings.HasPrefix(tag, "en-") || strings.HasPrefix(tag, "en_"):
+		return En, true
+	default:
+		return "", false
+	}
+}
+
+// Negotiate 根据 Accept-Language 请求头按权重协商语言
+func Negotiate(acceptLanguage string) Locale {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+		c := candidate{tag: strings.TrimSpace(fields[0]), q: 1}
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					c.q = q
+				}
+			}
+		}
+		if c.tag != "" && c.q > 0 {
+			candidates = append(candidates, c)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	for _, c := range candidates {
+		if locale, ok := Parse(c.tag); ok {
+			return locale
+		}
+	}
+	return DefaultLocale
+}
+
+// FromRequest 确定请求使用的语言
+// 优先级：lang 查询参数 > lang Cookie（用户偏好）> Accept-Language 请求头 > 默认语言
+func FromRequest(r *http.Request) Locale {
+	if locale, ok := Parse(r.URL.Query().Get("lang")); ok {
+		return locale
+	}
+	if cookie, err := r.Cookie("lang"); err == nil {
+		if locale, ok := Parse(cookie.Value); ok {
+			return locale
+		}
+	}
+	return Negotiate(r.Header.Get("Accept-Language"))
+}
+
+// Translate 返回指定语言下的消息，目录中不存在时返回 fallback
+func Translate(locale Locale, key, fallback string) string {
+	if msg, ok := catalogs[locale][key]; ok {
+		return msg
+	}
+	return fallback
+}
@@ -0,0 +1,67 @@
+package i18n
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		want           Locale
+	}{
+		{name: "空请求头", acceptLanguage: "", want: DefaultLocale},
+		{name: "英文", acceptLanguage: "en-US,en;q=0.9", want: En},
+		{name: "中文", acceptLanguage: "zh-CN,zh;q=0.9,en;q=0.8", want: ZhCN},
+		{name: "按权重排序", acceptLanguage: "zh;q=0.5,en;q=0.8", want: En},
+		{name: "跳过不支持的语言", acceptLanguage: "fr-FR,de;q=0.9,en;q=0.1", want: En},
+		{name: "权重为零", acceptLanguage: "en;q=0", want: DefaultLocale},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Negotiate(tt.acceptLanguage))
+		})
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		cookie string
+		header string
+		want   Locale
+	}{
+		{name: "查询参数优先", url: "/?lang=en", cookie: "zh-CN", header: "zh-CN", want: En},
+		{name: "Cookie 偏好", url: "/", cookie: "en", header: "zh-CN", want: En},
+		{name: "请求头", url: "/", header: "en", want: En},
+		{name: "无效的查询参数", url: "/?lang=xx", header: "zh", want: ZhCN},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "lang", Value: tt.cookie})
+			}
+			if tt.header != "" {
+				req.Header.Set("Accept-Language", tt.header)
+			}
+			assert.Equal(t, tt.want, FromRequest(req))
+		})
+	}
+}
+
+func TestCatalogsComplete(t *testing.T) {
+	for key := range catalogs[DefaultLocale] {
+		for locale, catalog := range catalogs {
+			_, ok := catalog[key]
+			assert.True(t, ok, "locale %s missing key %s", locale, key)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package i18n
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin/binding"
+	enLocale "github.com/go-playground/locales/en"
+	zhLocale "github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	zhTranslations "github.com/go-playground/validator/v10/translations/zh"
+)
+
+var (
+	validatorOnce sync.Once
+	translators   = map[Locale]ut.Translator{}
+)
+
+// FieldError 字段级错误
+type FieldError struct {
+	Field   string `json:"field"`   // 字段名（与 JSON 字段一致）
+	Code    string `json:"code"`    // 机器可读的错误码，例如 REQUIRED
+	Message string `json:"message"` // 本地化后的错误信息
+}
+
+// RegisterValidator 为 gin 的参数校验器注册中英文翻译，并使用 json 标签作为字段名
+// 必须在首次绑定请求之前调用，重复调用无副作用
+func RegisterValidator() {
+	validatorOnce.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			switch name {
+			case "-":
+				return ""
+			case "":
+				return field.Name
+			}
+			return name
+		})
+
+		zh := zhLocale.New()
+		uni := ut.New(zh, zh, enLocale.New())
+
+		zhTrans, _ := uni.GetTranslator("zh")
+		if err := zhTranslations.RegisterDefaultTranslations(v, zhTrans); err == nil {
+			translators[ZhCN] = zhTrans
+		}
+		enTrans, _ := uni.GetTranslator("en")
+		if err := enTranslations.RegisterDefaultTranslations(v, enTrans); err == nil {
+			translators[En] = enTrans
+		}
+	})
+}
+
+// TranslateValidation 将参数绑定返回的校验错误逐字段翻译，非校验错误返回 nil
+func TranslateValidation(locale Locale, err error) []FieldError {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil
+	}
+
+	RegisterValidator()
+	trans, ok := translators[locale]
+	if !ok {
+		trans = translators[DefaultLocale]
+	}
+
+	fields := make([]FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		message := fe.Error()
+		if trans != nil {
+			message = fe.Translate(trans)
+		}
+		fields = append(fields, FieldError{
+			Field:   fe.Field(),
+			Code:    strings.ToUpper(fe.Tag()),
+			Message: message,
+		})
+	}
+	return fields
+}
+
+// TranslateField 翻译业务层返回的字段错误，消息模板中的 {field} 和其他 {参数} 会被替换
+func TranslateField(locale Locale, field, code string, params map[string]string) FieldError {
+	message := Translate(locale, "FIELD_"+code, code)
+	replacements := []string{"{field}", field}
+	for k, v := range params {
+		replacements = append(replacements, "{"+k+"}", v)
+	}
+	return FieldError{
+		Field:   field,
+		Code:    code,
+		Message: strings.NewReplacer(replacements...).Replace(message),
+	}
+}
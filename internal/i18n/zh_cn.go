@@ -0,0 +1,108 @@
+package i18n
+
+// zhCN 简体中文消息目录
+var zhCN = map[string]string{
+	// 通用
+	"INVALID_PARAMS":    "无效的请求参数",
+	"VALIDATION_FAILED": "参数校验失败",
+	"RATE_LIMITED":      "请求过于频繁，请稍后再试",
+	"INTERNAL_ERROR":    "服务器内部错误",
+	MsgDeleted:          "删除成功",
+	MsgNoteRestored:     "笔记已恢复",
+
+	// 字段校验
+	"FIELD_REQUIRED":          "{field}为必填字段",
+	"FIELD_TOO_LONG":          "{field}长度不能超过{max}个字符",
+	"FIELD_INVALID_CHARS":     "{field}不能包含字符：{chars}",
+	"FIELD_CONTROL_CHARS":     "{field}不能包含控制字符",
+	"FIELD_WHITESPACE":        "{field}不能包含空白字符",
+	"FIELD_RESERVED_NAME":     "{field}不能使用系统保留名称",
+	"FIELD_INVALID_NAME":      "{field}中的名称不能为 . 或以点、空格结尾",
+	"FIELD_PATH_TRAVERSAL":    "{field}不能包含 .. 路径",
+	"FIELD_ABSOLUTE_PATH":     "{field}必须是笔记库内的相对路径",
+	"FIELD_INVALID_EXTENSION": "{field}必须以 {ext} 结尾",
+	"FIELD_NOT_FOUND":         "{field}引用的记录不存在：{value}",
+	"FIELD_INVALID_YAML":      "{field}不是合法的 YAML",
+	"FIELD_INVALID_URL":       "{field}必须是 http 或 https 地址",
+	"FIELD_UNSUPPORTED":       "{field}包含不支持的取值：{value}",
+	"FIELD_INVALID":           "{field}不合法：{value}",
+	"FIELD_REJECTED":          "{field}拒绝了该操作：{value}",
+
+	// 笔记
+	"NOTE_NOT_FOUND":        "笔记不存在",
+	"NOTE_FILE_PATH_EXISTS": "文件路径已存在",
+	"NOTE_ID_EXISTS":        "笔记ID已存在",
+	"NOTE_VERSION_CONFLICT": "笔记已被修改，请刷新后重试",
+
+	// 标签
+	"TAG_NOT_FOUND":        "标签不存在",
+	"TAG_ID_REQUIRED":      "标签ID不能为空",
+	"TAG_PARENT_NOT_FOUND": "父标签不存在",
+	"TAG_PARENT_SELF":      "父标签不能是自己",
+	"TAG_HAS_CHILDREN":     "请先删除子标签",
+
+	// 目录
+	"CATEGORY_NOT_FOUND":        "目录不存在",
+	"CATEGORY_ID_REQUIRED":      "目录ID不能为空",
+	"CATEGORY_PARENT_NOT_FOUND": "父目录不存在",
+	"CATEGORY_PARENT_SELF":      "父目录不能是自己",
+	"CATEGORY_NAME_EXISTS":      "同级目录下已存在同名目录",
+	"CATEGORY_PATH_EXISTS":      "目录路径已存在",
+	"CATEGORY_HAS_CHILDREN":     "请先删除子目录",
+	"CATEGORY_HAS_NOTES":        "请先删除目录下的笔记",
+
+	// 备份
+	"BACKUP_NOT_FOUND":   "备份不存在",
+	"BACKUP_INVALID":     "备份文件无效",
+	"BACKUP_UNSUPPORTED": "当前数据库不支持备份，仅支持 SQLite",
+	"BACKUP_IN_PROGRESS": "备份正在进行中",
+
+	// 导出
+	"EXPORT_DIR_EXISTS":   "导出目录已存在且不为空",
+	"EXPORT_DIR_DISABLED": "未配置导出目录，只能导出为 zip",
+
+	// 导入
+	"IMPORT_FILE_REQUIRED":   "请上传要导入的文件",
+	"IMPORT_INVALID_ARCHIVE": "无法读取 zip 文件",
+	"IMPORT_TOO_LARGE":       "上传的文件过大",
+
+	// 附件
+	"ATTACHMENT_NOT_FOUND":       "附件不存在",
+	"ATTACHMENT_FILE_REQUIRED":   "请上传附件",
+	"ATTACHMENT_EMPTY":           "附件不能为空",
+	"ATTACHMENT_TOO_LARGE":       "附件超过大小上限",
+	"ATTACHMENT_NOT_IMAGE":       "附件不是支持的图片格式",
+	"ATTACHMENT_IMAGE_TOO_LARGE": "图片像素过多，无法生成缩略图",
+	"THUMBNAIL_SIZE_INVALID":     "不支持的缩略图尺寸",
+
+	// 模板
+	"TEMPLATE_NOT_FOUND":   "模板不存在",
+	"TEMPLATE_NAME_EXISTS": "模板名称已存在",
+
+	// 周期笔记
+	"PERIOD_INVALID":      "不支持的周期，可选值：daily、weekly、monthly",
+	"PERIOD_DISABLED":     "未配置该周期笔记的路径",
+	"PERIOD_DATE_INVALID": "日期格式不正确",
+
+	// 待办
+	"TASK_NOT_FOUND": "待办不存在",
+	"TASK_OUTDATED":  "笔记已被修改，请刷新待办后重试",
+
+	// 提醒
+	"REMINDER_NOT_FOUND":    "提醒不存在",
+	"REMINDER_TIME_INVALID": "提醒时间必须晚于当前时间",
+	"REMINDER_FROM_YAML":    "该提醒来自笔记的 YAML 元数据，请在笔记中删除",
+
+	// Webhook
+	"WEBHOOK_NOT_FOUND":          "Webhook 不存在",
+	"WEBHOOK_DELIVERY_NOT_FOUND": "投递记录不存在",
+
+	// 插件
+	"PLUGIN_NOT_FOUND": "插件不存在",
+	"PLUGIN_DISABLED":  "插件未启用",
+	"PLUGIN_REJECTED":  "插件拒绝了该操作",
+
+	// 后台任务
+	"JOB_NOT_FOUND":  "任务不存在",
+	"JOB_QUEUE_FULL": "等待执行的任务过多，请稍后再试",
+}
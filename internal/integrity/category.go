@@ -0,0 +1,103 @@
+package integrity
+
+import (
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// categoryColumns 目录树检查需要的列
+var categoryColumns = []string{"id", "name", "parent_id", "path"}
+
+// danglingCategoryParents 查找父目录不存在或父级链成环的目录
+func danglingCategoryParents(tx *gorm.DB) ([]string, error) {
+	nodes, err := loadNodes(tx, "categories", categoryColumns...)
+	if err != nil {
+		return nil, err
+	}
+	return brokenParents(nodes), nil
+}
+
+// danglingTagParents 查找父标签不存在或父级链成环的标签
+func danglingTagParents(tx *gorm.DB) ([]string, error) {
+	nodes, err := loadNodes(tx, "tags", "id", "name", "parent_id")
+	if err != nil {
+		return nil, err
+	}
+	return brokenParents(nodes), nil
+}
+
+// detachCategories 将目录移到根目录，与已有根目录重名时在名称后追加 ID 前缀
+func detachCategories(tx *gorm.DB, ids []string) error {
+	nodes, err := loadNodes(tx, "categories", categoryColumns...)
+	if err != nil {
+		return err
+	}
+
+	rootNames := make(map[string]bool)
+	for _, n := range nodes {
+		if n.ParentID == nil {
+			rootNames[n.Name] = true
+		}
+	}
+
+	for _, id := range ids {
+		n := nodes[id]
+		name := n.Name
+		if rootNames[name] {
+			name = name + "-" + shortID(id)
+		}
+		rootNames[name] = true
+		if err := tx.Table("categories").Where("id = ?", id).
+			Updates(map[string]interface{}{"parent_id": nil, "name": name}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// staleCategoryPaths 查找路径与层级不一致的目录
+func staleCategoryPaths(tx *gorm.DB) ([]string, error) {
+	nodes, err := loadNodes(tx, "categories", categoryColumns...)
+	if err != nil {
+		return nil, err
+	}
+	var stale []string
+	for id, path := range expectedPaths(nodes) {
+		if nodes[id].Path != path {
+			stale = append(stale, id)
+		}
+	}
+	sort.Strings(stale)
+	return stale, nil
+}
+
+// rebuildCategoryPaths 重新计算目录路径
+// 先把待更新的路径改为临时值，避免更新过程中触发路径唯一索引冲突
+func rebuildCategoryPaths(tx *gorm.DB, ids []string) error {
+	nodes, err := loadNodes(tx, "categories", categoryColumns...)
+	if err != nil {
+		return err
+	}
+	paths := expectedPaths(nodes)
+
+	for _, id := range ids {
+		if err := tx.Table("categories").Where("id = ?", id).Update("path", "#rebuild/"+id).Error; err != nil {
+			return err
+		}
+	}
+	for _, id := range ids {
+		if err := tx.Table("categories").Where("id = ?", id).Update("path", paths[id]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shortID 返回 ID 的前 8 个字符
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
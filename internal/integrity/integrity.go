@@ -0,0 +1,260 @@
+package integrity
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Issue 一类引用完整性问题
+type Issue struct {
+	Check       string // 检查项，例如 note_tags.note_id
+	Description string // 问题说明
+	Repair      string // 修复方式
+	Count       int64  // 问题记录数
+}
+
+// Report 检查或修复结果，只包含存在问题的检查项
+type Report struct {
+	Issues []Issue
+}
+
+// OK 判断是否没有发现问题
+func (r *Report) OK() bool {
+	return len(r.Issues) == 0
+}
+
+// check 单个检查项，find 返回有问题的记录 ID，fix 修复这些记录
+type check struct {
+	name        string
+	description string
+	repair      string
+	find        func(tx *gorm.DB) ([]string, error)
+	fix         func(tx *gorm.DB, ids []string) error
+}
+
+// checks 按修复顺序排列：先修复父级引用，再清理关联记录
+var checks = []check{
+	{
+		name:        "categories.parent_id",
+		description: "父目录不存在或目录层级成环",
+		repair:      "移动到根目录，路径冲突时在名称后追加 ID 前缀",
+		find:        danglingCategoryParents,
+		fix:         detachCategories,
+	},
+	{
+		name:        "categories.path",
+		description: "路径与目录层级不一致",
+		repair:      "按目录层级重新计算路径",
+		find:        staleCategoryPaths,
+		fix:         rebuildCategoryPaths,
+	},
+	{
+		name:        "tags.parent_id",
+		description: "父标签不存在或标签层级成环",
+		repair:      "移动到顶级",
+		find:        danglingTagParents,
+		fix:         clearColumn("tags", "parent_id"),
+	},
+	{
+		name:        "notes.category_id",
+		description: "所属目录不存在",
+		repair:      "清空所属目录",
+		find:        danglingRefs("notes", "id", "category_id", "categories"),
+		fix:         clearColumn("notes", "category_id"),
+	},
+	{
+		name:        "templates.category_id",
+		description: "默认目录不存在",
+		repair:      "清空默认目录",
+		find:        danglingRefs("templates", "id", "category_id", "categories"),
+		fix:         clearColumn("templates", "category_id"),
+	},
+	{
+		name:        "note_tags.note_id",
+		description: "关联的笔记不存在",
+		repair:      "删除关联",
+		find:        danglingRefs("note_tags", "note_id", "note_id", "notes"),
+		fix:         deleteRows("note_tags", "note_id"),
+	},
+	{
+		name:        "note_tags.tag_id",
+		description: "关联的标签不存在",
+		repair:      "删除关联",
+		find:        danglingRefs("note_tags", "tag_id", "tag_id", "tags"),
+		fix:         deleteRows("note_tags", "tag_id"),
+	},
+	{
+		name:        "note_attachments.note_id",
+		description: "引用附件的笔记不存在",
+		repair:      "删除引用",
+		find:        danglingRefs("note_attachments", "note_id", "note_id", "notes"),
+		fix:         deleteRows("note_attachments", "note_id"),
+	},
+	{
+		name:        "note_attachments.attachment_id",
+		description: "引用的附件不存在",
+		repair:      "删除引用",
+		find:        danglingRefs("note_attachments", "attachment_id", "attachment_id", "attachments"),
+		fix:         deleteRows("note_attachments", "attachment_id"),
+	},
+	{
+		name:        "tasks.note_id",
+		description: "待办所在的笔记不存在",
+		repair:      "删除待办",
+		find:        danglingRefs("tasks", "id", "note_id", "notes"),
+		fix:         deleteRows("tasks", "id"),
+	},
+	{
+		name:        "reminders.note_id",
+		description: "提醒所属的笔记不存在",
+		repair:      "删除提醒",
+		find:        danglingRefs("reminders", "id", "note_id", "notes"),
+		fix:         deleteRows("reminders", "id"),
+	},
+	{
+		name:        "webhook_deliveries.webhook_id",
+		description: "所属的 Webhook 不存在",
+		repair:      "删除投递记录",
+		find:        danglingRefs("webhook_deliveries", "id", "webhook_id", "webhooks"),
+		fix:         deleteRows("webhook_deliveries", "id"),
+	},
+	{
+		name:        "search_index.note_id",
+		description: "关联的笔记不存在",
+		repair:      "删除索引",
+		find:        danglingRefs("search_index", "id", "note_id", "notes"),
+		fix:         deleteRows("search_index", "id"),
+	},
+}
+
+// Check 检查引用完整性，不修改数据
+// 软删除的记录仍然存在于表中，引用它们不视为问题
+func Check(db *gorm.DB) (*Report, error) {
+	report := &Report{}
+	for _, c := range checks {
+		if !c.applicable(db) {
+			continue
+		}
+		ids, err := c.find(db)
+		if err != nil {
+			return nil, fmt.Errorf("检查 %s 失败: %w", c.name, err)
+		}
+		report.add(c, len(ids))
+	}
+	return report, nil
+}
+
+// Repair 在一个事务中修复所有引用完整性问题，返回修复前发现的问题
+func Repair(db *gorm.DB) (*Report, error) {
+	report := &Report{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, c := range checks {
+			if !c.applicable(tx) {
+				continue
+			}
+			ids, err := c.find(tx)
+			if err != nil {
+				return fmt.Errorf("检查 %s 失败: %w", c.name, err)
+			}
+			if len(ids) == 0 {
+				continue
+			}
+			if err := c.fix(tx, ids); err != nil {
+				return fmt.Errorf("修复 %s 失败: %w", c.name, err)
+			}
+			report.add(c, len(ids))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// applicable 判断检查项涉及的表是否存在；数据库尚未迁移到最新版本时，之后的迁移新增的表还不存在
+func (c check) applicable(db *gorm.DB) bool {
+	table, _, _ := strings.Cut(c.name, ".")
+	return db.Migrator().HasTable(table)
+}
+
+// add 记录存在问题的检查项
+func (r *Report) add(c check, count int) {
+	if count == 0 {
+		return
+	}
+	r.Issues = append(r.Issues, Issue{
+		Check:       c.name,
+		Description: c.description,
+		Repair:      c.repair,
+		Count:       int64(count),
+	})
+}
+
+// danglingRefs 查找 table.column 引用的 parent.id 不存在的记录，返回 table.key
+func danglingRefs(table, key, column, parent string) func(tx *gorm.DB) ([]string, error) {
+	return func(tx *gorm.DB) ([]string, error) {
+		var ids []string
+		err := tx.Raw(fmt.Sprintf(
+			"SELECT t.%[2]s FROM %[1]s t LEFT JOIN %[4]s p ON p.id = t.%[3]s WHERE t.%[3]s IS NOT NULL AND p.id IS NULL",
+			table, key, column, parent,
+		)).Scan(&ids).Error
+		return ids, err
+	}
+}
+
+// clearColumn 将指定记录的列置空
+func clearColumn(table, column string) func(tx *gorm.DB, ids []string) error {
+	return func(tx *gorm.DB, ids []string) error {
+		return inBatches(ids, func(batch []string) error {
+			return tx.Table(table).Where("id IN ?", batch).Update(column, nil).Error
+		})
+	}
+}
+
+// deleteRows 按 key 删除记录
+func deleteRows(table, key string) func(tx *gorm.DB, ids []string) error {
+	return func(tx *gorm.DB, ids []string) error {
+		return inBatches(ids, func(batch []string) error {
+			return tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s IN ?", table, key), batch).Error
+		})
+	}
+}
+
+// batchSize 单条 IN 查询的最大参数个数，低于 SQLite 默认的变量上限
+const batchSize = 500
+
+// inBatches 分批处理 ID，避免超出数据库的参数个数限制
+func inBatches(ids []string, fn func(batch []string) error) error {
+	ids = unique(ids)
+	for start := 0; start < len(ids); start += batchSize {
+		end := start + batchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		if err := fn(ids[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unique 去除重复 ID，保持原有顺序
+func unique(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	result := ids[:0:0]
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// joinPath 拼接目录路径，与 model.Category 的路径规则一致
+func joinPath(parentPath, name string) string {
+	return strings.TrimSuffix(parentPath, "/") + "/" + name
+}
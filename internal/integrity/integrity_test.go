@@ -0,0 +1,115 @@
+package integrity_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"leafnote/internal/integrity"
+	"leafnote/internal/testutil"
+)
+
+// seedOrphans 关闭外键约束后写入各类悬空引用
+func seedOrphans(t *testing.T, db *gorm.DB) {
+	require.NoError(t, db.Exec("PRAGMA foreign_keys = OFF").Error)
+	defer db.Exec("PRAGMA foreign_keys = ON")
+
+	stmts := []string{
+		// 正常的目录树
+		"INSERT INTO categories (id, name, path, parent_id) VALUES ('c1', 'a', '/a', NULL)",
+		"INSERT INTO categories (id, name, path, parent_id) VALUES ('c2', 'b', '/a/b', 'c1')",
+		// 父目录不存在，且与已有根目录重名
+		"INSERT INTO categories (id, name, path, parent_id) VALUES ('c3', 'a', '/gone/a', 'missing')",
+		"INSERT INTO categories (id, name, path, parent_id) VALUES ('c4', 'x', '/gone/a/x', 'c3')",
+		// 路径与层级不一致
+		"INSERT INTO categories (id, name, path, parent_id) VALUES ('c5', 'c', '/wrong', 'c2')",
+		// 标签成环
+		"INSERT INTO tags (id, name, parent_id) VALUES ('t1', 't1', 't2')",
+		"INSERT INTO tags (id, name, parent_id) VALUES ('t2', 't2', 't1')",
+		"INSERT INTO notes (id, title, file_path, checksum, category_id) VALUES ('n1', 'n1', '/n1.md', '', 'missing')",
+		"INSERT INTO notes (id, title, file_path, checksum, category_id) VALUES ('n2', 'n2', '/n2.md', '', 'c1')",
+		"INSERT INTO note_tags (note_id, tag_id) VALUES ('n2', 't1')",
+		"INSERT INTO note_tags (note_id, tag_id) VALUES ('gone', 't1')",
+		"INSERT INTO note_tags (note_id, tag_id) VALUES ('n2', 'gone')",
+		"INSERT INTO search_index (id, note_id, content, type) VALUES ('s1', 'gone', 'x', 'title')",
+	}
+	for _, stmt := range stmts {
+		require.NoError(t, db.Exec(stmt).Error, stmt)
+	}
+}
+
+func TestCheckAndRepair(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	seedOrphans(t, db)
+
+	report, err := integrity.Check(db)
+	require.NoError(t, err)
+	counts := make(map[string]int64)
+	for _, issue := range report.Issues {
+		counts[issue.Check] = issue.Count
+	}
+	assert.Equal(t, map[string]int64{
+		"categories.parent_id": 1,
+		"categories.path":      1, // c3 的子树路径无法计算，不计入
+		"tags.parent_id":       1, // 每个环只断开一个节点
+		"notes.category_id":    1,
+		"note_tags.note_id":    1,
+		"note_tags.tag_id":     1,
+		"search_index.note_id": 1,
+	}, counts)
+
+	repaired, err := integrity.Repair(db)
+	require.NoError(t, err)
+	assert.False(t, repaired.OK())
+
+	report, err = integrity.Check(db)
+	require.NoError(t, err)
+	assert.True(t, report.OK(), "%+v", report.Issues)
+
+	// 悬空的目录移到根目录并避免重名，子目录路径随之更新
+	var c3, c4, c5 struct{ Name, Path string }
+	db.Table("categories").Where("id = ?", "c3").Take(&c3)
+	db.Table("categories").Where("id = ?", "c4").Take(&c4)
+	db.Table("categories").Where("id = ?", "c5").Take(&c5)
+	assert.Equal(t, "/a-c3", c3.Path)
+	assert.Equal(t, "/a-c3/x", c4.Path)
+	assert.Equal(t, "/a/b/c", c5.Path)
+
+	var noteTags int64
+	db.Table("note_tags").Count(&noteTags)
+	assert.Equal(t, int64(1), noteTags)
+
+	var categoryID *string
+	db.Table("notes").Select("category_id").Where("id = ?", "n1").Scan(&categoryID)
+	assert.Nil(t, categoryID)
+}
+
+func TestForeignKeys(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	stmts := []string{
+		"INSERT INTO categories (id, name, path) VALUES ('c1', 'a', '/a')",
+		"INSERT INTO tags (id, name) VALUES ('t1', 't1')",
+		"INSERT INTO notes (id, title, file_path, checksum, category_id) VALUES ('n1', 'n1', '/n1.md', '', 'c1')",
+		"INSERT INTO note_tags (note_id, tag_id) VALUES ('n1', 't1')",
+	}
+	for _, stmt := range stmts {
+		require.NoError(t, db.Exec(stmt).Error, stmt)
+	}
+
+	// 不能引用不存在的记录
+	assert.Error(t, db.Exec("INSERT INTO note_tags (note_id, tag_id) VALUES ('n1', 'gone')").Error)
+
+	// 删除目录时笔记的所属目录置空
+	require.NoError(t, db.Exec("DELETE FROM categories WHERE id = 'c1'").Error)
+	var categoryID *string
+	db.Table("notes").Select("category_id").Where("id = ?", "n1").Scan(&categoryID)
+	assert.Nil(t, categoryID)
+
+	// 物理删除笔记时清理标签关联
+	require.NoError(t, db.Exec("DELETE FROM notes WHERE id = 'n1'").Error)
+	var count int64
+	db.Table("note_tags").Count(&count)
+	assert.Zero(t, count)
+}
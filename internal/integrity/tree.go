@@ -0,0 +1,112 @@
+package integrity
+
+import (
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// node 目录或标签树中的一个节点
+type node struct {
+	ID       string
+	Name     string
+	ParentID *string
+	Path     string
+}
+
+// loadNodes 读取表中所有记录（包括软删除的记录）
+func loadNodes(tx *gorm.DB, table string, columns ...string) (map[string]*node, error) {
+	var rows []node
+	if err := tx.Table(table).Select(columns).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	nodes := make(map[string]*node, len(rows))
+	for i := range rows {
+		nodes[rows[i].ID] = &rows[i]
+	}
+	return nodes, nil
+}
+
+// brokenParents 返回父节点不存在的节点，以及每个环中 ID 最小的节点
+// 将这些节点移到顶级后，树中不再有悬空引用和环
+func brokenParents(nodes map[string]*node) []string {
+	var broken []string
+	inCycle := make(map[string]bool)
+	for _, n := range nodes {
+		if n.ParentID == nil {
+			continue
+		}
+		if _, ok := nodes[*n.ParentID]; !ok {
+			broken = append(broken, n.ID)
+			continue
+		}
+
+		// 沿父节点向上查找，回到起点说明该节点在环中
+		seen := map[string]bool{n.ID: true}
+		for p := nodes[*n.ParentID]; p != nil; {
+			if p.ID == n.ID {
+				inCycle[n.ID] = true
+				break
+			}
+			if seen[p.ID] || p.ParentID == nil {
+				break
+			}
+			seen[p.ID] = true
+			p = nodes[*p.ParentID]
+		}
+	}
+
+	// 每个环只断开 ID 最小的节点
+	for id := range inCycle {
+		minID := id
+		for p := nodes[*nodes[id].ParentID]; p.ID != id; p = nodes[*p.ParentID] {
+			if p.ID < minID {
+				minID = p.ID
+			}
+		}
+		if minID == id {
+			broken = append(broken, id)
+		}
+	}
+
+	sort.Strings(broken)
+	return broken
+}
+
+// expectedPaths 按层级计算每个目录的路径，父级链断开或成环的目录不在结果中
+func expectedPaths(nodes map[string]*node) map[string]string {
+	paths := make(map[string]string, len(nodes))
+	resolving := make(map[string]bool)
+
+	var resolve func(n *node) (string, bool)
+	resolve = func(n *node) (string, bool) {
+		if p, ok := paths[n.ID]; ok {
+			return p, true
+		}
+		if resolving[n.ID] {
+			return "", false
+		}
+		resolving[n.ID] = true
+		defer delete(resolving, n.ID)
+
+		if n.ParentID == nil {
+			paths[n.ID] = joinPath("", n.Name)
+			return paths[n.ID], true
+		}
+		parent, ok := nodes[*n.ParentID]
+		if !ok {
+			return "", false
+		}
+		parentPath, ok := resolve(parent)
+		if !ok {
+			return "", false
+		}
+		paths[n.ID] = joinPath(parentPath, n.Name)
+		return paths[n.ID], true
+	}
+
+	for _, n := range nodes {
+		resolve(n)
+	}
+	return paths
+}
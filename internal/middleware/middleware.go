@@ -2,10 +2,15 @@ package middleware
 
 import (
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+
+	"leafnote/internal/config"
+	"leafnote/internal/i18n"
+	"leafnote/internal/response"
 )
 
 // Logger 中间件用于记录请求日志
@@ -30,26 +35,39 @@ func Logger(logger *zap.Logger) gin.HandlerFunc {
 }
 
 // ErrorHandler 中间件用于统一错误处理
+// 处理器通过 c.Error 记录错误且尚未写入响应时，按错误类别输出统一的错误响应
 func ErrorHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Next()
 
-		if len(c.Errors) > 0 {
-			err := c.Errors.Last()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error(),
-			})
-			return
+		if len(c.Errors) > 0 && !c.Writer.Written() {
+			response.Error(c, c.Errors.Last().Err)
 		}
 	}
 }
 
-// CORS 中间件用于处理跨域请求
-func CORS() gin.HandlerFunc {
+// Locale 中间件用于协商请求语言，供错误信息本地化使用
+func Locale() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		locale := i18n.FromRequest(c.Request)
+		c.Set(i18n.ContextKey, locale)
+		c.Header("Content-Language", string(locale))
+		c.Next()
+	}
+}
+
+// CORS 中间件用于处理跨域请求，每次请求读取当前生效的配置以支持热更新
+func CORS(live *config.Live) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := live.Load().CORS
+		if origin := allowedOrigin(cfg.AllowOrigins, c.GetHeader("Origin")); origin != "" {
+			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+			if origin != "*" {
+				c.Writer.Header().Add("Vary", "Origin")
+			}
+		}
+		c.Writer.Header().Set("Access-Control-Allow-Methods", strings.Join(cfg.AllowMethods, ", "))
+		c.Writer.Header().Set("Access-Control-Allow-Headers", strings.Join(cfg.AllowHeaders, ", "))
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(http.StatusNoContent)
@@ -59,3 +77,16 @@ func CORS() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// allowedOrigin 返回应写入 Access-Control-Allow-Origin 的值，不允许时返回空串
+func allowedOrigin(allowed []string, origin string) string {
+	for _, o := range allowed {
+		if o == "*" {
+			return "*"
+		}
+		if origin != "" && strings.EqualFold(o, origin) {
+			return origin
+		}
+	}
+	return ""
+}
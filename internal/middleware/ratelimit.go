@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+
+	"leafnote/internal/config"
+	"leafnote/internal/response"
+	"leafnote/internal/service"
+)
+
+// limiterIdleTTL 客户端限流器闲置多久后被回收
+const limiterIdleTTL = 10 * time.Minute
+
+// clientLimiter 单个客户端的令牌桶
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// rateLimiter 按客户端 IP 限流，配置变化时重建所有令牌桶
+type rateLimiter struct {
+	mu        sync.Mutex
+	cfg       config.RateLimitConfig
+	clients   map[string]*clientLimiter
+	lastSweep time.Time
+}
+
+// RateLimit 中间件按客户端 IP 限流，每次请求读取当前生效的配置以支持热更新
+func RateLimit(live *config.Live) gin.HandlerFunc {
+	rl := &rateLimiter{clients: make(map[string]*clientLimiter)}
+	return func(c *gin.Context) {
+		cfg := live.Load().RateLimit
+		if !cfg.Enabled {
+			c.Next()
+			return
+		}
+
+		if !rl.allow(cfg, c.ClientIP(), time.Now()) {
+			response.Error(c, service.ErrRateLimited)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// allow 判断客户端在 now 时刻是否还有可用令牌
+func (rl *rateLimiter) allow(cfg config.RateLimitConfig, key string, now time.Time) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if cfg != rl.cfg {
+		rl.cfg = cfg
+		rl.clients = make(map[string]*clientLimiter)
+	}
+	if now.Sub(rl.lastSweep) > limiterIdleTTL {
+		for k, cl := range rl.clients {
+			if now.Sub(cl.lastSeen) > limiterIdleTTL {
+				delete(rl.clients, k)
+			}
+		}
+		rl.lastSweep = now
+	}
+
+	cl, ok := rl.clients[key]
+	if !ok {
+		cl = &clientLimiter{limiter: rate.NewLimiter(rate.Limit(cfg.RequestsPerSecond), cfg.Burst)}
+		rl.clients[key] = cl
+	}
+	cl.lastSeen = now
+	return cl.limiter.AllowN(now, 1)
+}
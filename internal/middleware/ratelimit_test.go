@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"leafnote/internal/config"
+)
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg, err := config.LoadConfig("")
+	assert.NoError(t, err)
+	cfg.RateLimit = config.RateLimitConfig{Enabled: true, RequestsPerSecond: 0.001, Burst: 2}
+
+	r := gin.New()
+	r.Use(RateLimit(config.NewLive(cfg)))
+	r.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	request := func(ip string) int {
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request("10.0.0.1"))
+	assert.Equal(t, http.StatusOK, request("10.0.0.1"))
+	assert.Equal(t, http.StatusTooManyRequests, request("10.0.0.1"))
+	// 不同客户端互不影响
+	assert.Equal(t, http.StatusOK, request("10.0.0.2"))
+}
@@ -0,0 +1,95 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 以下为 0001 迁移时的表结构快照，与 model 包解耦，后续模型变化不影响本迁移
+
+type category0001 struct {
+	ID        string `gorm:"type:varchar(36);primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	Name      string         `gorm:"not null"`
+	Path      string         `gorm:"not null;uniqueIndex"`
+	ParentID  *string        `gorm:"type:varchar(36)"`
+}
+
+func (category0001) TableName() string { return "categories" }
+
+type note0001 struct {
+	ID           string `gorm:"type:varchar(36);primary_key"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index"`
+	Title        string         `gorm:"not null"`
+	Content      string         `gorm:"type:text"`
+	YAMLMeta     string         `gorm:"column:yaml_meta;type:text"`
+	FilePath     string         `gorm:"not null"`
+	OriginalPath string
+	InTrash      bool `gorm:"default:false"`
+	TrashTime    time.Time
+	Version      int     `gorm:"not null;default:1"`
+	Checksum     string  `gorm:"not null"`
+	CategoryID   *string `gorm:"type:varchar(36)"`
+}
+
+func (note0001) TableName() string { return "notes" }
+
+type tag0001 struct {
+	ID        string `gorm:"type:varchar(36);primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	Name      string         `gorm:"not null"`
+	ParentID  *string        `gorm:"type:varchar(36)"`
+}
+
+func (tag0001) TableName() string { return "tags" }
+
+type noteTag0001 struct {
+	NoteID string `gorm:"type:varchar(36);primaryKey"`
+	TagID  string `gorm:"type:varchar(36);primaryKey"`
+}
+
+func (noteTag0001) TableName() string { return "note_tags" }
+
+type searchIndex0001 struct {
+	ID        string `gorm:"type:varchar(36);primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	NoteID    string         `gorm:"type:varchar(36);not null;index"`
+	Content   string         `gorm:"type:text;not null"`
+	Type      string         `gorm:"type:varchar(10);not null"`
+}
+
+func (searchIndex0001) TableName() string { return "search_index" }
+
+// initialSchema 创建全部基础表
+// 使用 AutoMigrate 兼容此前由 AutoMigrate 建出的数据库：已有的表只补齐缺失的列和索引
+var initialSchema = Migration{
+	Version: 1,
+	Name:    "initial_schema",
+	Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(
+			&category0001{},
+			&note0001{},
+			&tag0001{},
+			&noteTag0001{},
+			&searchIndex0001{},
+		)
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(
+			&searchIndex0001{},
+			&noteTag0001{},
+			&tag0001{},
+			&note0001{},
+			&category0001{},
+		)
+	},
+}
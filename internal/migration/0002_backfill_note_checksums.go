@@ -0,0 +1,33 @@
+package migration
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+
+	"gorm.io/gorm"
+)
+
+// backfillNoteChecksums 为校验和为空的旧笔记补算内容校验和
+// 算法与 NoteService.calculateChecksum 保持一致
+var backfillNoteChecksums = Migration{
+	Version: 2,
+	Name:    "backfill_note_checksums",
+	Up: func(tx *gorm.DB) error {
+		var notes []struct {
+			ID      string
+			Content string
+		}
+		if err := tx.Table("notes").Select("id", "content").Where("checksum = ? OR checksum IS NULL", "").Find(&notes).Error; err != nil {
+			return err
+		}
+		for _, n := range notes {
+			sum := md5.Sum([]byte(n.Content))
+			if err := tx.Table("notes").Where("id = ?", n.ID).Update("checksum", hex.EncodeToString(sum[:])).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	// 补算的校验和无需回滚
+	Down: func(tx *gorm.DB) error { return nil },
+}
@@ -0,0 +1,321 @@
+package migration
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// 以下为 0003 迁移时的表结构快照，在 0001 的基础上声明外键及删除规则
+
+type category0003 struct {
+	Base   category0001  `gorm:"embedded"`
+	Parent *category0003 `gorm:"foreignKey:ParentID;constraint:OnDelete:RESTRICT"`
+}
+
+func (category0003) TableName() string { return "categories" }
+
+type tag0003 struct {
+	Base   tag0001  `gorm:"embedded"`
+	Parent *tag0003 `gorm:"foreignKey:ParentID;constraint:OnDelete:RESTRICT"`
+}
+
+func (tag0003) TableName() string { return "tags" }
+
+type note0003 struct {
+	Base     note0001      `gorm:"embedded"`
+	Category *category0003 `gorm:"foreignKey:CategoryID;constraint:OnDelete:SET NULL"`
+}
+
+func (note0003) TableName() string { return "notes" }
+
+type noteTag0003 struct {
+	Base noteTag0001 `gorm:"embedded"`
+	Note *note0003   `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE"`
+	Tag  *tag0003    `gorm:"foreignKey:TagID;constraint:OnDelete:CASCADE"`
+}
+
+func (noteTag0003) TableName() string { return "note_tags" }
+
+type searchIndex0003 struct {
+	Base searchIndex0001 `gorm:"embedded"`
+	Note *note0003       `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE"`
+}
+
+func (searchIndex0003) TableName() string { return "search_index" }
+
+// foreignKeys0003 本迁移创建的外键，按被引用的表在前的顺序排列
+var foreignKeys0003 = []struct {
+	model interface{}
+	field string
+}{
+	{&category0003{}, "Parent"},
+	{&tag0003{}, "Parent"},
+	{&note0003{}, "Category"},
+	{&noteTag0003{}, "Note"},
+	{&noteTag0003{}, "Tag"},
+	{&searchIndex0003{}, "Note"},
+}
+
+// legacyForeignKeys0003 旧版本 AutoMigrate 根据模型关联创建的外键，没有删除规则，需要先删除
+var legacyForeignKeys0003 = []struct {
+	table string
+	name  string
+}{
+	{"categories", "fk_categories_children"},
+	{"notes", "fk_categories_notes"},
+	{"tags", "fk_tags_children"},
+	{"note_tags", "fk_note_tags_note"},
+	{"note_tags", "fk_note_tags_tag"},
+}
+
+// foreignKeys 修复已有的悬空引用后创建外键
+// 目录和标签的父级使用 RESTRICT，与服务层拒绝删除有子项的行为一致；
+// 目录被删除时笔记的所属目录置空；笔记或标签被物理删除时清理关联和搜索索引
+var foreignKeys = Migration{
+	Version: 3,
+	Name:    "foreign_keys",
+	Up: func(tx *gorm.DB) error {
+		// SQLite 添加外键需要重建表，推迟外键检查到事务提交时，避免删除旧表时触发检查
+		if tx.Dialector.Name() == "sqlite" {
+			if err := tx.Exec("PRAGMA defer_foreign_keys = ON").Error; err != nil {
+				return err
+			}
+		}
+
+		// 已有的悬空引用会导致创建外键失败
+		if err := repair0003(tx); err != nil {
+			return err
+		}
+
+		m := tx.Migrator()
+		for _, fk := range legacyForeignKeys0003 {
+			if m.HasConstraint(fk.table, fk.name) {
+				if err := m.DropConstraint(fk.table, fk.name); err != nil {
+					return err
+				}
+			}
+		}
+		for _, fk := range foreignKeys0003 {
+			if !m.HasConstraint(fk.model, fk.field) {
+				if err := m.CreateConstraint(fk.model, fk.field); err != nil {
+					return err
+				}
+			}
+		}
+
+		// SQLite 重建表会丢失索引，按快照补齐
+		return tx.AutoMigrate(&category0003{}, &tag0003{}, &note0003{}, &noteTag0003{}, &searchIndex0003{})
+	},
+	Down: func(tx *gorm.DB) error {
+		m := tx.Migrator()
+		for i := len(foreignKeys0003) - 1; i >= 0; i-- {
+			fk := foreignKeys0003[i]
+			if m.HasConstraint(fk.model, fk.field) {
+				if err := m.DropConstraint(fk.model, fk.field); err != nil {
+					return err
+				}
+			}
+		}
+		return tx.AutoMigrate(&category0001{}, &tag0001{}, &note0001{}, &noteTag0001{}, &searchIndex0001{})
+	},
+}
+
+// 以下为 0003 迁移时的引用修复，从 integrity 包复制而来；integrity 之后新增的检查项不影响本迁移，
+// 修复方式与当时的 integrity repair 一致
+
+// node0003 目录或标签树中的一个节点
+type node0003 struct {
+	ID       string
+	Name     string
+	ParentID *string
+	Path     string
+}
+
+// repair0003 修复目录、标签的父级和笔记、标签关联、搜索索引的悬空引用，按先父级后关联的顺序执行
+func repair0003(tx *gorm.DB) error {
+	// 父目录不存在或成环的目录移到根目录，与已有根目录重名时在名称后追加 ID 前 8 位
+	categories, err := loadNodes0003(tx, "categories", "id", "name", "parent_id", "path")
+	if err != nil {
+		return err
+	}
+	rootNames := make(map[string]bool)
+	for _, n := range categories {
+		if n.ParentID == nil {
+			rootNames[n.Name] = true
+		}
+	}
+	for _, id := range brokenParents0003(categories) {
+		n := categories[id]
+		name := n.Name
+		if rootNames[name] {
+			name = name + "-" + id[:min(len(id), 8)]
+		}
+		rootNames[name] = true
+		n.Name, n.ParentID = name, nil
+		if err := tx.Table("categories").Where("id = ?", id).
+			Updates(map[string]interface{}{"parent_id": nil, "name": name}).Error; err != nil {
+			return err
+		}
+	}
+
+	// 按层级重新计算路径，先改为临时值，避免更新过程中触发路径唯一索引冲突
+	paths := expectedPaths0003(categories)
+	var stale []string
+	for id, path := range paths {
+		if categories[id].Path != path {
+			stale = append(stale, id)
+		}
+	}
+	sort.Strings(stale)
+	for _, id := range stale {
+		if err := tx.Table("categories").Where("id = ?", id).Update("path", "#rebuild/"+id).Error; err != nil {
+			return err
+		}
+	}
+	for _, id := range stale {
+		if err := tx.Table("categories").Where("id = ?", id).Update("path", paths[id]).Error; err != nil {
+			return err
+		}
+	}
+
+	// 父标签不存在或成环的标签移到顶级
+	tags, err := loadNodes0003(tx, "tags", "id", "name", "parent_id")
+	if err != nil {
+		return err
+	}
+	if err := inBatches0003(brokenParents0003(tags), func(batch []string) error {
+		return tx.Table("tags").Where("id IN ?", batch).Update("parent_id", nil).Error
+	}); err != nil {
+		return err
+	}
+
+	// 所属目录不存在的笔记清空目录，引用不存在的笔记或标签的关联和索引删除
+	refs := []struct {
+		table, key, column, parent string
+		clear                      bool
+	}{
+		{"notes", "id", "category_id", "categories", true},
+		{"note_tags", "note_id", "note_id", "notes", false},
+		{"note_tags", "tag_id", "tag_id", "tags", false},
+		{"search_index", "id", "note_id", "notes", false},
+	}
+	for _, r := range refs {
+		var ids []string
+		if err := tx.Raw(fmt.Sprintf(
+			"SELECT t.%[2]s FROM %[1]s t LEFT JOIN %[4]s p ON p.id = t.%[3]s WHERE t.%[3]s IS NOT NULL AND p.id IS NULL",
+			r.table, r.key, r.column, r.parent,
+		)).Scan(&ids).Error; err != nil {
+			return err
+		}
+		err := inBatches0003(ids, func(batch []string) error {
+			if r.clear {
+				return tx.Table(r.table).Where("id IN ?", batch).Update(r.column, nil).Error
+			}
+			return tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s IN ?", r.table, r.key), batch).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadNodes0003 读取表中所有记录（包括软删除的记录）
+func loadNodes0003(tx *gorm.DB, table string, columns ...string) (map[string]*node0003, error) {
+	var rows []node0003
+	if err := tx.Table(table).Select(columns).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	nodes := make(map[string]*node0003, len(rows))
+	for i := range rows {
+		nodes[rows[i].ID] = &rows[i]
+	}
+	return nodes, nil
+}
+
+// brokenParents0003 返回父节点不存在的节点，以及每个环中 ID 最小的节点
+func brokenParents0003(nodes map[string]*node0003) []string {
+	var broken []string
+	inCycle := make(map[string]bool)
+	for _, n := range nodes {
+		if n.ParentID == nil {
+			continue
+		}
+		if _, ok := nodes[*n.ParentID]; !ok {
+			broken = append(broken, n.ID)
+			continue
+		}
+		seen := map[string]bool{n.ID: true}
+		for p := nodes[*n.ParentID]; p != nil; {
+			if p.ID == n.ID {
+				inCycle[n.ID] = true
+				break
+			}
+			if seen[p.ID] || p.ParentID == nil {
+				break
+			}
+			seen[p.ID] = true
+			p = nodes[*p.ParentID]
+		}
+	}
+	for id := range inCycle {
+		minID := id
+		for p := nodes[*nodes[id].ParentID]; p.ID != id; p = nodes[*p.ParentID] {
+			if p.ID < minID {
+				minID = p.ID
+			}
+		}
+		if minID == id {
+			broken = append(broken, id)
+		}
+	}
+	sort.Strings(broken)
+	return broken
+}
+
+// expectedPaths0003 按层级计算每个目录的路径，父级链断开或成环的目录不在结果中
+func expectedPaths0003(nodes map[string]*node0003) map[string]string {
+	paths := make(map[string]string, len(nodes))
+	resolving := make(map[string]bool)
+	var resolve func(n *node0003) (string, bool)
+	resolve = func(n *node0003) (string, bool) {
+		if p, ok := paths[n.ID]; ok {
+			return p, true
+		}
+		if resolving[n.ID] {
+			return "", false
+		}
+		resolving[n.ID] = true
+		defer delete(resolving, n.ID)
+
+		parentPath := ""
+		if n.ParentID != nil {
+			parent, ok := nodes[*n.ParentID]
+			if !ok {
+				return "", false
+			}
+			if parentPath, ok = resolve(parent); !ok {
+				return "", false
+			}
+		}
+		paths[n.ID] = strings.TrimSuffix(parentPath, "/") + "/" + n.Name
+		return paths[n.ID], true
+	}
+	for _, n := range nodes {
+		resolve(n)
+	}
+	return paths
+}
+
+// inBatches0003 每批最多 500 个 ID，低于 SQLite 默认的变量上限
+func inBatches0003(ids []string, fn func(batch []string) error) error {
+	for start := 0; start < len(ids); start += 500 {
+		if err := fn(ids[start:min(start+500, len(ids))]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
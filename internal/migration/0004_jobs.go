@@ -0,0 +1,38 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 以下为 0004 迁移时的表结构快照
+
+type job0004 struct {
+	ID         string `gorm:"type:varchar(36);primary_key"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	DeletedAt  gorm.DeletedAt `gorm:"index"`
+	Type       string         `gorm:"type:varchar(32);not null;index"`
+	Status     string         `gorm:"type:varchar(16);not null;index"`
+	Done       int            `gorm:"not null;default:0"`
+	Total      int            `gorm:"not null;default:0"`
+	Result     []byte
+	Error      string `gorm:"type:text"`
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+}
+
+func (job0004) TableName() string { return "jobs" }
+
+// jobs 新增后台任务表
+var jobs = Migration{
+	Version: 4,
+	Name:    "jobs",
+	Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&job0004{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&job0004{})
+	},
+}
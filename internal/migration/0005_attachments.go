@@ -0,0 +1,44 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 以下为 0005 迁移时的表结构快照
+
+type attachment0005 struct {
+	ID        string `gorm:"type:varchar(36);primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	Hash      string         `gorm:"type:varchar(64);not null;uniqueIndex"`
+	Name      string         `gorm:"not null"`
+	MimeType  string         `gorm:"type:varchar(128);not null"`
+	Size      int64          `gorm:"not null"`
+	Path      string         `gorm:"not null"`
+}
+
+func (attachment0005) TableName() string { return "attachments" }
+
+type noteAttachment0005 struct {
+	NoteID       string          `gorm:"type:varchar(36);primaryKey"`
+	AttachmentID string          `gorm:"type:varchar(36);primaryKey;index"`
+	Note         *note0003       `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE"`
+	Attachment   *attachment0005 `gorm:"foreignKey:AttachmentID;constraint:OnDelete:CASCADE"`
+}
+
+func (noteAttachment0005) TableName() string { return "note_attachments" }
+
+// attachments 新增附件表和笔记对附件的引用表，笔记或附件被物理删除时清理引用
+var attachments = Migration{
+	Version: 5,
+	Name:    "attachments",
+	Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&attachment0005{}, &noteAttachment0005{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&noteAttachment0005{}, &attachment0005{})
+	},
+}
@@ -0,0 +1,37 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 以下为 0006 迁移时的表结构快照
+
+type template0006 struct {
+	ID          string `gorm:"type:varchar(36);primary_key"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+	Name        string         `gorm:"type:varchar(128);not null;uniqueIndex"`
+	Description string         `gorm:"type:text"`
+	Title       string
+	Content     string        `gorm:"type:text"`
+	YAMLMeta    string        `gorm:"column:yaml_meta;type:text"`
+	CategoryID  *string       `gorm:"type:varchar(36)"`
+	Category    *category0003 `gorm:"foreignKey:CategoryID;constraint:OnDelete:SET NULL"`
+}
+
+func (template0006) TableName() string { return "templates" }
+
+// templates 新增笔记模板表，默认目录被物理删除时置空
+var templates = Migration{
+	Version: 6,
+	Name:    "templates",
+	Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&template0006{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&template0006{})
+	},
+}
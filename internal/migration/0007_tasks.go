@@ -0,0 +1,37 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 以下为 0007 迁移时的表结构快照
+
+type task0007 struct {
+	ID        string `gorm:"type:varchar(36);primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	NoteID    string         `gorm:"type:varchar(36);not null;index"`
+	Line      int            `gorm:"not null"`
+	Text      string         `gorm:"type:text;not null"`
+	Done      bool           `gorm:"not null;default:false;index"`
+	DueDate   *string        `gorm:"type:varchar(10);index"`
+	Priority  int            `gorm:"not null;default:0"`
+	Note      *note0003      `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE"`
+}
+
+func (task0007) TableName() string { return "tasks" }
+
+// tasks 新增待办表，笔记被物理删除时级联删除；已有笔记的待办通过 POST /api/v1/tasks/rebuild 重建
+var tasks = Migration{
+	Version: 7,
+	Name:    "tasks",
+	Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&task0007{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&task0007{})
+	},
+}
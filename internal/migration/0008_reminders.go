@@ -0,0 +1,38 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 以下为 0008 迁移时的表结构快照
+
+type reminder0008 struct {
+	ID        string `gorm:"type:varchar(36);primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	NoteID    string         `gorm:"type:varchar(36);not null;index"`
+	Source    string         `gorm:"type:varchar(16);not null;default:manual"`
+	Message   string         `gorm:"type:text"`
+	DueAt     time.Time      `gorm:"not null"`
+	RemindAt  time.Time      `gorm:"not null;index"`
+	Status    string         `gorm:"type:varchar(16);not null;default:pending;index"`
+	FiredAt   *time.Time
+	Note      *note0003 `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE"`
+}
+
+func (reminder0008) TableName() string { return "reminders" }
+
+// reminders 新增提醒表，笔记被物理删除时级联删除
+var reminders = Migration{
+	Version: 8,
+	Name:    "reminders",
+	Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&reminder0008{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&reminder0008{})
+	},
+}
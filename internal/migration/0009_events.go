@@ -0,0 +1,32 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 以下为 0009 迁移时的表结构快照
+
+type event0009 struct {
+	Seq        uint64    `gorm:"primaryKey;autoIncrement"`
+	Type       string    `gorm:"type:varchar(32);not null"`
+	EntityType string    `gorm:"type:varchar(16);not null"`
+	EntityID   string    `gorm:"type:varchar(36);not null;index"`
+	Version    int       `gorm:"not null;default:0"`
+	CreatedAt  time.Time `gorm:"not null;index"`
+}
+
+func (event0009) TableName() string { return "events" }
+
+// events 新增变更事件表，删除实体后事件仍然保留
+var events = Migration{
+	Version: 9,
+	Name:    "events",
+	Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&event0009{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&event0009{})
+	},
+}
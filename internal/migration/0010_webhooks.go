@@ -0,0 +1,57 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 以下为 0010 迁移时的表结构快照
+
+type webhook0010 struct {
+	ID         string `gorm:"type:varchar(36);primary_key"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	DeletedAt  gorm.DeletedAt `gorm:"index"`
+	URL        string         `gorm:"type:varchar(2048);not null"`
+	Secret     string         `gorm:"type:varchar(128);not null"`
+	Events     string         `gorm:"type:text"`
+	CategoryID *string        `gorm:"type:varchar(36)"`
+	TagID      *string        `gorm:"type:varchar(36)"`
+	Enabled    bool           `gorm:"not null"`
+}
+
+func (webhook0010) TableName() string { return "webhooks" }
+
+type webhookDelivery0010 struct {
+	ID             string `gorm:"type:varchar(36);primary_key"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
+	WebhookID      string         `gorm:"type:varchar(36);not null;index"`
+	EventSeq       uint64         `gorm:"not null"`
+	EventType      string         `gorm:"type:varchar(32);not null"`
+	Payload        []byte         `gorm:"not null"`
+	Status         string         `gorm:"type:varchar(16);not null;index"`
+	Attempts       int            `gorm:"not null;default:0"`
+	NextAttemptAt  *time.Time     `gorm:"index"`
+	LastAttemptAt  *time.Time
+	ResponseStatus int          `gorm:"not null;default:0"`
+	Error          string       `gorm:"type:text"`
+	Webhook        *webhook0010 `gorm:"foreignKey:WebhookID;constraint:OnDelete:CASCADE"`
+}
+
+func (webhookDelivery0010) TableName() string { return "webhook_deliveries" }
+
+// webhooks 新增 Webhook 订阅和投递记录表，删除 Webhook 时级联删除投递记录；
+// 过滤条件中的目录和标签不建外键，被删除后条件不再匹配任何笔记
+var webhooks = Migration{
+	Version: 10,
+	Name:    "webhooks",
+	Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&webhook0010{}, &webhookDelivery0010{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&webhookDelivery0010{}, &webhook0010{})
+	},
+}
@@ -0,0 +1,30 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 以下为 0011 迁移时的表结构快照
+
+type plugin0011 struct {
+	Name      string `gorm:"type:varchar(64);primaryKey"`
+	Enabled   bool   `gorm:"not null"`
+	Config    string `gorm:"type:text"`
+	UpdatedAt time.Time
+}
+
+func (plugin0011) TableName() string { return "plugins" }
+
+// plugins 新增插件状态表，没有记录的插件视为未启用
+var plugins = Migration{
+	Version: 11,
+	Name:    "plugins",
+	Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&plugin0011{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&plugin0011{})
+	},
+}
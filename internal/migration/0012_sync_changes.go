@@ -0,0 +1,33 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 以下为 0012 迁移时的表结构快照
+
+type syncChange0012 struct {
+	ChangeID  string    `gorm:"type:varchar(64);primaryKey"`
+	NoteID    string    `gorm:"type:varchar(36);not null"`
+	Type      string    `gorm:"type:varchar(16);not null"`
+	Version   int       `gorm:"not null;default:0"`
+	ClientID  string    `gorm:"type:varchar(64);not null;default:'';index:idx_sync_changes_client_seq"`
+	Seq       uint64    `gorm:"not null;default:0;index:idx_sync_changes_client_seq"`
+	CreatedAt time.Time `gorm:"not null;index"`
+}
+
+func (syncChange0012) TableName() string { return "sync_changes" }
+
+// syncChanges 新增同步修改记录表；笔记被彻底删除后记录仍保留到过期，不建外键
+var syncChanges = Migration{
+	Version: 12,
+	Name:    "sync_changes",
+	Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&syncChange0012{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&syncChange0012{})
+	},
+}
@@ -0,0 +1,40 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 以下为 0013 迁移时的表结构快照
+
+type category0013 struct {
+	ID        string `gorm:"type:varchar(36);primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	Name      string         `gorm:"not null"`
+	Path      string         `gorm:"size:768;not null;uniqueIndex"` // MySQL 唯一索引最长 3072 字节，utf8mb4 下为 768 个字符
+	ParentID  *string        `gorm:"type:varchar(36)"`
+}
+
+func (category0013) TableName() string { return "categories" }
+
+// categoriesPathSize 为 categories.path 指定长度，与 model.Category 一致；
+// SQLite 不区分字符串长度，不修改表结构
+var categoriesPathSize = Migration{
+	Version: 13,
+	Name:    "categories_path_size",
+	Up: func(tx *gorm.DB) error {
+		if tx.Dialector.Name() == "sqlite" {
+			return nil
+		}
+		return tx.Migrator().AlterColumn(&category0013{}, "Path")
+	},
+	Down: func(tx *gorm.DB) error {
+		if tx.Dialector.Name() == "sqlite" {
+			return nil
+		}
+		return tx.Migrator().AlterColumn(&category0001{}, "Path")
+	},
+}
@@ -0,0 +1,173 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ErrIrreversible 迁移没有提供回滚操作
+var ErrIrreversible = errors.New("迁移不可回滚")
+
+// Migration 一次版本化的数据库变更，版本号递增且不可复用
+type Migration struct {
+	Version int                     // 版本号
+	Name    string                  // 迁移名称
+	Up      func(tx *gorm.DB) error // 升级操作
+	Down    func(tx *gorm.DB) error // 回滚操作，为 nil 表示不可回滚
+}
+
+// SchemaMigration 已执行的迁移记录
+type SchemaMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName 指定表名
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Status 单个迁移的执行状态
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time // 为 nil 表示尚未执行
+}
+
+// Migrator 执行和回滚迁移
+type Migrator struct {
+	db         *gorm.DB
+	logger     *zap.Logger
+	migrations []Migration
+}
+
+// New 创建使用内置迁移列表的 Migrator
+func New(db *gorm.DB, logger *zap.Logger) *Migrator {
+	return &Migrator{db: db, logger: logger, migrations: migrations}
+}
+
+// Up 按版本顺序执行所有未执行的迁移，返回本次执行的迁移
+func (m *Migrator) Up() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, mig := range m.sorted() {
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := mig.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: mig.Version, Name: mig.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("执行迁移 %04d_%s 失败: %w", mig.Version, mig.Name, err)
+		}
+		m.logger.Info("Migration applied", zap.Int("version", mig.Version), zap.String("name", mig.Name))
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// Down 按版本倒序回滚最近执行的 steps 个迁移，返回本次回滚的迁移
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := m.sorted()
+	var done []Migration
+	for i := len(sorted) - 1; i >= 0 && len(done) < steps; i-- {
+		mig := sorted[i]
+		if _, ok := applied[mig.Version]; !ok {
+			continue
+		}
+		if mig.Down == nil {
+			return done, fmt.Errorf("回滚迁移 %04d_%s 失败: %w", mig.Version, mig.Name, ErrIrreversible)
+		}
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := mig.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, "version = ?", mig.Version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("回滚迁移 %04d_%s 失败: %w", mig.Version, mig.Name, err)
+		}
+		m.logger.Info("Migration rolled back", zap.Int("version", mig.Version), zap.String("name", mig.Name))
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// Status 返回所有迁移的执行状态，按版本升序排列
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.sorted() {
+		s := Status{Version: mig.Version, Name: mig.Name}
+		if record, ok := applied[mig.Version]; ok {
+			appliedAt := record.AppliedAt
+			s.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// applied 确保迁移记录表存在，并返回已执行的迁移
+func (m *Migrator) applied() (map[int]SchemaMigration, error) {
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	if err := m.db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("创建迁移记录表失败: %w", err)
+	}
+
+	var records []SchemaMigration
+	if err := m.db.Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("读取迁移记录失败: %w", err)
+	}
+	applied := make(map[int]SchemaMigration, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+// validate 检查迁移列表的版本号是否合法且唯一
+func (m *Migrator) validate() error {
+	seen := make(map[int]bool, len(m.migrations))
+	for _, mig := range m.migrations {
+		if mig.Version <= 0 || mig.Up == nil {
+			return fmt.Errorf("迁移 %04d_%s 定义不完整", mig.Version, mig.Name)
+		}
+		if seen[mig.Version] {
+			return fmt.Errorf("迁移版本号 %04d 重复", mig.Version)
+		}
+		seen[mig.Version] = true
+	}
+	return nil
+}
+
+// sorted 返回按版本升序排列的迁移列表
+func (m *Migrator) sorted() []Migration {
+	sorted := append([]Migration(nil), m.migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	return sorted
+}
@@ -0,0 +1,190 @@
+package migration
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"leafnote/internal/model"
+)
+
+func setupMigrationTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	return db
+}
+
+func TestMigrator_UpDownStatus(t *testing.T) {
+	db := setupMigrationTestDB(t)
+	m := New(db, zap.NewNop())
+
+	applied, err := m.Up()
+	require.NoError(t, err)
+	assert.Len(t, applied, len(migrations))
+	for _, table := range []string{"notes", "categories", "tags", "note_tags", "search_index", "schema_migrations"} {
+		assert.True(t, db.Migrator().HasTable(table), table)
+	}
+
+	// 再次执行不会重复迁移
+	applied, err = m.Up()
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	statuses, err := m.Status()
+	require.NoError(t, err)
+	for _, s := range statuses {
+		assert.NotNil(t, s.AppliedAt, s.Name)
+	}
+
+	// 回滚全部迁移
+	rolledBack, err := m.Down(len(migrations))
+	require.NoError(t, err)
+	assert.Len(t, rolledBack, len(migrations))
+	assert.Equal(t, 1, rolledBack[len(rolledBack)-1].Version)
+	assert.False(t, db.Migrator().HasTable("notes"))
+
+	statuses, err = m.Status()
+	require.NoError(t, err)
+	for _, s := range statuses {
+		assert.Nil(t, s.AppliedAt, s.Name)
+	}
+}
+
+func TestMigrator_LegacyDatabase(t *testing.T) {
+	db := setupMigrationTestDB(t)
+
+	// 旧版本只通过 AutoMigrate 创建了笔记和目录表
+	require.NoError(t, db.AutoMigrate(&model.Note{}, &model.Category{}))
+	require.NoError(t, db.Exec(
+		"INSERT INTO notes (id, title, file_path, checksum, version) VALUES (?, ?, ?, ?, ?)",
+		"n1", "旧笔记", "/old.md", "", 1,
+	).Error)
+
+	_, err := New(db, zap.NewNop()).Up()
+	require.NoError(t, err)
+	assert.True(t, db.Migrator().HasTable("tags"))
+	assert.True(t, db.Migrator().HasTable("note_tags"))
+
+	var checksum string
+	require.NoError(t, db.Table("notes").Select("checksum").Where("id = ?", "n1").Scan(&checksum).Error)
+	assert.Equal(t, "d41d8cd98f00b204e9800998ecf8427e", checksum) // 空内容的 md5
+}
+
+func TestForeignKeys_RepairsDanglingReferences(t *testing.T) {
+	db := setupMigrationTestDB(t)
+	m := &Migrator{db: db, logger: zap.NewNop(), migrations: migrations[:2]}
+	_, err := m.Up()
+	require.NoError(t, err)
+
+	for _, stmt := range []string{
+		"INSERT INTO categories (id, name, path, parent_id) VALUES ('c1', '工作', '/工作', NULL)",
+		"INSERT INTO categories (id, name, path, parent_id) VALUES ('c2', '工作', '/旧/工作', 'missing')",
+		"INSERT INTO notes (id, title, file_path, checksum, version, category_id) VALUES ('n1', '笔记', '/a.md', '', 1, 'missing')",
+		"INSERT INTO note_tags (note_id, tag_id) VALUES ('n1', 'missing')",
+		"INSERT INTO search_index (id, note_id, content, type) VALUES ('s1', 'missing', '', 'title')",
+	} {
+		require.NoError(t, db.Exec(stmt).Error)
+	}
+
+	m.migrations = migrations[:3]
+	_, err = m.Up()
+	require.NoError(t, err)
+
+	var category struct {
+		Name     string
+		Path     string
+		ParentID *string
+	}
+	require.NoError(t, db.Table("categories").Where("id = ?", "c2").Take(&category).Error)
+	assert.Nil(t, category.ParentID)
+	assert.Equal(t, "工作-c2", category.Name)
+	assert.Equal(t, "/工作-c2", category.Path)
+
+	var categoryID *string
+	require.NoError(t, db.Table("notes").Select("category_id").Where("id = ?", "n1").Scan(&categoryID).Error)
+	assert.Nil(t, categoryID)
+	for _, table := range []string{"note_tags", "search_index"} {
+		var count int64
+		require.NoError(t, db.Table(table).Count(&count).Error)
+		assert.Zero(t, count, table)
+	}
+	assert.True(t, db.Migrator().HasConstraint(&note0003{}, "Category"))
+}
+
+func TestMigrator_Errors(t *testing.T) {
+	failing := errors.New("boom")
+
+	tests := []struct {
+		name       string
+		migrations []Migration
+		down       bool
+		wantErr    error
+	}{
+		{
+			name: "版本号重复",
+			migrations: []Migration{
+				{Version: 1, Name: "a", Up: func(*gorm.DB) error { return nil }},
+				{Version: 1, Name: "b", Up: func(*gorm.DB) error { return nil }},
+			},
+		},
+		{
+			name: "升级失败",
+			migrations: []Migration{
+				{Version: 1, Name: "a", Up: func(*gorm.DB) error { return failing }},
+			},
+			wantErr: failing,
+		},
+		{
+			name: "不可回滚",
+			migrations: []Migration{
+				{Version: 1, Name: "a", Up: func(*gorm.DB) error { return nil }},
+			},
+			down:    true,
+			wantErr: ErrIrreversible,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Migrator{db: setupMigrationTestDB(t), logger: zap.NewNop(), migrations: tt.migrations}
+			_, err := m.Up()
+			if tt.down {
+				require.NoError(t, err)
+				_, err = m.Down(1)
+			}
+			require.Error(t, err)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMigrator_UpRollsBackFailedMigration(t *testing.T) {
+	db := setupMigrationTestDB(t)
+	m := &Migrator{db: db, logger: zap.NewNop(), migrations: []Migration{
+		{Version: 1, Name: "ok", Up: func(tx *gorm.DB) error { return tx.Exec("CREATE TABLE a (id INTEGER)").Error }},
+		{Version: 2, Name: "bad", Up: func(tx *gorm.DB) error {
+			if err := tx.Exec("CREATE TABLE b (id INTEGER)").Error; err != nil {
+				return err
+			}
+			return errors.New("boom")
+		}},
+	}}
+
+	applied, err := m.Up()
+	require.Error(t, err)
+	assert.Len(t, applied, 1)
+	assert.True(t, db.Migrator().HasTable("a"))
+	assert.False(t, db.Migrator().HasTable("b"))
+
+	statuses, err := m.Status()
+	require.NoError(t, err)
+	assert.NotNil(t, statuses[0].AppliedAt)
+	assert.Nil(t, statuses[1].AppliedAt)
+}
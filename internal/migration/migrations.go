@@ -0,0 +1,30 @@
+package migration
+
+// migrations 内置迁移列表，新增迁移时追加到末尾并使用下一个版本号
+// 已发布的迁移不得修改，需要调整表结构时新增迁移
+var migrations = []Migration{
+	initialSchema,
+	backfillNoteChecksums,
+	foreignKeys,
+	jobs,
+	attachments,
+	templates,
+	tasks,
+	reminders,
+	events,
+	webhooks,
+	plugins,
+	syncChanges,
+	categoriesPathSize,
+}
+
+// Latest 返回内置迁移的最高版本号
+func Latest() int {
+	latest := 0
+	for _, m := range migrations {
+		if m.Version > latest {
+			latest = m.Version
+		}
+	}
+	return latest
+}
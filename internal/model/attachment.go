@@ -0,0 +1,28 @@
+package model
+
+// Attachment 附件模型，文件按内容的 SHA-256 保存在笔记库中，相同内容只保存一份
+type Attachment struct {
+	BaseModel
+	Hash     string `gorm:"type:varchar(64);not null;uniqueIndex" json:"hash"` // 内容的 SHA-256（十六进制）
+	Name     string `gorm:"not null" json:"name"`                              // 首次上传时的文件名
+	MimeType string `gorm:"type:varchar(128);not null" json:"mime_type"`       // 按内容识别的 MIME 类型
+	Size     int64  `gorm:"not null" json:"size"`                              // 文件大小（字节）
+	Path     string `gorm:"not null" json:"path"`                              // 相对于笔记库根目录的路径，例如 attachments/<hash>.png
+	RefCount int64  `gorm:"->;-:migration" json:"ref_count"`                   // 引用该附件的笔记数，仅列表查询时填充
+}
+
+// TableName 指定表名
+func (Attachment) TableName() string {
+	return "attachments"
+}
+
+// NoteAttachment 笔记对附件的引用，保存笔记时根据正文中的附件路径更新
+type NoteAttachment struct {
+	NoteID       string `gorm:"type:varchar(36);primaryKey"`
+	AttachmentID string `gorm:"type:varchar(36);primaryKey;index"`
+}
+
+// TableName 指定表名
+func (NoteAttachment) TableName() string {
+	return "note_attachments"
+}
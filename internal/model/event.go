@@ -0,0 +1,59 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// EventType 变更事件类型，格式为 <实体>.<操作>
+type EventType string
+
+const (
+	EventNoteCreated     EventType = "note.created"     // 创建或恢复笔记
+	EventNoteUpdated     EventType = "note.updated"     // 修改笔记，版本号随之增加
+	EventNoteDeleted     EventType = "note.deleted"     // 删除笔记（移入回收站）
+	EventTagCreated      EventType = "tag.created"      // 创建标签
+	EventTagUpdated      EventType = "tag.updated"      // 修改标签
+	EventTagDeleted      EventType = "tag.deleted"      // 删除标签
+	EventCategoryCreated EventType = "category.created" // 创建目录
+	EventCategoryUpdated EventType = "category.updated" // 修改目录，包括上级目录改名导致的路径变化
+	EventCategoryDeleted EventType = "category.deleted" // 删除目录
+)
+
+// EventTypes 全部事件类型
+var EventTypes = []EventType{
+	EventNoteCreated, EventNoteUpdated, EventNoteDeleted,
+	EventTagCreated, EventTagUpdated, EventTagDeleted,
+	EventCategoryCreated, EventCategoryUpdated, EventCategoryDeleted,
+}
+
+// Valid 判断是否为支持的事件类型
+func (t EventType) Valid() bool {
+	for _, v := range EventTypes {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Entity 返回事件涉及的实体类型：note、tag 或 category
+func (t EventType) Entity() string {
+	entity, _, _ := strings.Cut(string(t), ".")
+	return entity
+}
+
+// Event 变更事件，与引起变更的修改在同一事务中写入，Seq 按写入顺序递增
+type Event struct {
+	Seq        uint64    `gorm:"primaryKey;autoIncrement" json:"seq"`              // 事件序号，断线后从该序号继续
+	Type       EventType `gorm:"type:varchar(32);not null" json:"type"`            // 事件类型
+	EntityType string    `gorm:"type:varchar(16);not null" json:"entity_type"`     // 实体类型
+	EntityID   string    `gorm:"type:varchar(36);not null;index" json:"entity_id"` // 实体ID
+	Version    int       `gorm:"not null;default:0" json:"version,omitempty"`      // 笔记变更后的版本号，标签和目录为 0
+	CreatedAt  time.Time `gorm:"not null;index" json:"created_at"`                 // 发生时间
+}
+
+// TableName 指定表名
+func (Event) TableName() string {
+	return "events"
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// JobStatus 后台任务状态
+type JobStatus string
+
+const (
+	JobPending   JobStatus = "pending"   // 等待执行
+	JobRunning   JobStatus = "running"   // 执行中
+	JobSucceeded JobStatus = "succeeded" // 执行成功
+	JobFailed    JobStatus = "failed"    // 执行失败
+)
+
+// Job 后台任务模型，例如导入
+type Job struct {
+	BaseModel
+	Type       string          `gorm:"type:varchar(32);not null;index" json:"type"`   // 任务类型
+	Status     JobStatus       `gorm:"type:varchar(16);not null;index" json:"status"` // 任务状态
+	Done       int             `gorm:"not null;default:0" json:"done"`                // 已处理的数量
+	Total      int             `gorm:"not null;default:0" json:"total"`               // 需要处理的总数，未知时为 0
+	Result     json.RawMessage `json:"result,omitempty"`                              // 任务结果（JSON）
+	Error      string          `gorm:"type:text" json:"error,omitempty"`              // 失败原因
+	StartedAt  *time.Time      `json:"started_at,omitempty"`                          // 开始执行时间
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`                         // 结束时间
+}
+
+// TableName 指定表名
+func (Job) TableName() string {
+	return "jobs"
+}
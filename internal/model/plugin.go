@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// PluginState 插件的启用状态和配置，插件本身与程序一同编译
+type PluginState struct {
+	Name      string                 `gorm:"type:varchar(64);primaryKey" json:"name"` // 插件名称
+	Enabled   bool                   `gorm:"not null" json:"enabled"`                 // 是否启用
+	Config    map[string]interface{} `gorm:"type:text;serializer:json" json:"config"` // 插件配置
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (PluginState) TableName() string {
+	return "plugins"
+}
@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// ReminderStatus 提醒状态
+type ReminderStatus string
+
+const (
+	ReminderPending   ReminderStatus = "pending"   // 等待触发
+	ReminderFired     ReminderStatus = "fired"     // 已触发
+	ReminderDismissed ReminderStatus = "dismissed" // 已关闭
+)
+
+// ReminderSource 提醒来源
+type ReminderSource string
+
+const (
+	ReminderSourceManual ReminderSource = "manual" // 通过接口创建
+	ReminderSourceYAML   ReminderSource = "yaml"   // 笔记 YAML 元数据中的 remind，保存笔记时同步
+)
+
+// Reminder 笔记提醒
+type Reminder struct {
+	BaseModel
+	NoteID       string         `gorm:"type:varchar(36);not null;index" json:"note_id"`                // 所属笔记ID
+	Source       ReminderSource `gorm:"type:varchar(16);not null;default:manual" json:"source"`        // 提醒来源
+	Message      string         `gorm:"type:text" json:"message"`                                      // 提醒内容，为空时客户端显示笔记标题
+	DueAt        time.Time      `gorm:"not null" json:"due_at"`                                        // 设定的提醒时间
+	RemindAt     time.Time      `gorm:"not null;index" json:"remind_at"`                               // 下次触发时间，稍后提醒时推迟
+	Status       ReminderStatus `gorm:"type:varchar(16);not null;default:pending;index" json:"status"` // 提醒状态
+	FiredAt      *time.Time     `json:"fired_at,omitempty"`                                            // 最近一次触发时间
+	NoteTitle    string         `gorm:"->;-:migration" json:"note_title,omitempty"`                    // 所属笔记的标题，仅查询时填充
+	NoteFilePath string         `gorm:"->;-:migration" json:"note_file_path,omitempty"`                // 所属笔记的文件路径，仅查询时填充
+}
+
+// TableName 指定表名
+func (Reminder) TableName() string {
+	return "reminders"
+}
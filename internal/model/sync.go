@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// 同步修改的类型
+const (
+	SyncChangeCreate = "create"
+	SyncChangeUpdate = "update"
+	SyncChangeDelete = "delete"
+)
+
+// SyncChange 客户端通过同步接口上传并已应用的修改，按修改ID去重，重复上传时返回保存的结果
+type SyncChange struct {
+	ChangeID  string    `gorm:"type:varchar(64);primaryKey" json:"change_id"`                                            // 客户端生成的修改ID
+	NoteID    string    `gorm:"type:varchar(36);not null" json:"note_id"`                                                // 修改的笔记ID
+	Type      string    `gorm:"type:varchar(16);not null" json:"type"`                                                   // 修改类型
+	Version   int       `gorm:"not null;default:0" json:"version"`                                                       // 应用后笔记的版本号
+	ClientID  string    `gorm:"type:varchar(64);not null;default:'';index:idx_sync_changes_client_seq" json:"client_id"` // 上传修改的客户端，未提供时为空
+	Seq       uint64    `gorm:"not null;default:0;index:idx_sync_changes_client_seq" json:"seq"`                         // 修改产生的变更事件序号，没有产生事件时为 0
+	CreatedAt time.Time `gorm:"not null;index" json:"created_at"`                                                        // 应用时间，过期后清理
+}
+
+// TableName 指定表名
+func (SyncChange) TableName() string {
+	return "sync_changes"
+}
@@ -0,0 +1,29 @@
+package model
+
+// 待办优先级，数值越大越紧急，与 Obsidian Tasks 的优先级符号对应
+const (
+	TaskPriorityNone    = 0
+	TaskPriorityLowest  = 1 // ⏬
+	TaskPriorityLow     = 2 // 🔽
+	TaskPriorityMedium  = 3 // 🔼
+	TaskPriorityHigh    = 4 // ⏫
+	TaskPriorityHighest = 5 // 🔺
+)
+
+// Task 从笔记正文的复选框中提取的待办事项，保存笔记时重建
+type Task struct {
+	BaseModel
+	NoteID       string  `gorm:"type:varchar(36);not null;index" json:"note_id"` // 所在笔记ID
+	Line         int     `gorm:"not null" json:"line"`                           // 所在行号，从 1 开始
+	Text         string  `gorm:"type:text;not null" json:"text"`                 // 去除复选框、截止日期和优先级后的文字
+	Done         bool    `gorm:"not null;default:false;index" json:"done"`       // 是否已完成
+	DueDate      *string `gorm:"type:varchar(10);index" json:"due_date"`         // 截止日期，YYYY-MM-DD
+	Priority     int     `gorm:"not null;default:0" json:"priority"`             // 优先级，0 表示未设置
+	NoteTitle    string  `gorm:"->;-:migration" json:"note_title,omitempty"`     // 所在笔记的标题，仅列表查询时填充
+	NoteFilePath string  `gorm:"->;-:migration" json:"note_file_path,omitempty"` // 所在笔记的文件路径，仅列表查询时填充
+}
+
+// TableName 指定表名
+func (Task) TableName() string {
+	return "tasks"
+}
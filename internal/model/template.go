@@ -0,0 +1,25 @@
+package model
+
+// Template 笔记模板，标题、正文和 YAML 元数据中可以使用 {{date}}、{{title}} 等占位符
+type Template struct {
+	BaseModel
+	Name        string           `gorm:"type:varchar(128);not null;uniqueIndex" json:"name"` // 模板名称
+	Description string           `gorm:"type:text" json:"description"`                       // 模板说明
+	Title       string           `json:"title"`                                              // 笔记标题，创建时未指定标题则使用
+	Content     string           `gorm:"type:text" json:"content"`                           // 笔记正文
+	YAMLMeta    string           `gorm:"column:yaml_meta;type:text" json:"yaml_meta"`        // YAML 元数据（不含 --- 分隔行）
+	CategoryID  *string          `gorm:"type:varchar(36)" json:"category_id"`                // 默认目录ID
+	Prompts     []TemplatePrompt `gorm:"-" json:"prompts"`                                   // 模板中需要填写的变量，按出现顺序
+}
+
+// TableName 指定表名
+func (Template) TableName() string {
+	return "templates"
+}
+
+// TemplatePrompt 模板中的 {{prompt:名称|默认值}} 变量
+type TemplatePrompt struct {
+	Name     string `json:"name"`     // 变量名称
+	Default  string `json:"default"`  // 默认值
+	Required bool   `json:"required"` // 没有默认值时必须填写
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Webhook 变更事件的 Webhook 订阅
+type Webhook struct {
+	BaseModel
+	URL        string      `gorm:"type:varchar(2048);not null" json:"url"`             // 接收事件的地址
+	Secret     string      `gorm:"type:varchar(128);not null" json:"secret,omitempty"` // 签名密钥，只在创建时返回
+	Events     []EventType `gorm:"type:text;serializer:json" json:"events"`            // 订阅的事件类型，为空表示全部
+	CategoryID *string     `gorm:"type:varchar(36)" json:"category_id"`                // 只投递该目录（含子目录）下笔记的事件
+	TagID      *string     `gorm:"type:varchar(36)" json:"tag_id"`                     // 只投递带有该标签的笔记的事件
+	Enabled    bool        `gorm:"not null" json:"enabled"`                            // 是否启用，停用后不再创建投递，等待中的投递暂停
+}
+
+// TableName 指定表名
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// Subscribes 判断是否订阅了事件类型，设置了目录或标签时只订阅笔记事件
+func (w *Webhook) Subscribes(t EventType) bool {
+	if (w.CategoryID != nil || w.TagID != nil) && t.Entity() != "note" {
+		return false
+	}
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryStatus 投递状态
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"   // 等待投递或重试
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded" // 投递成功
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"    // 重试次数用尽
+)
+
+// WebhookDelivery 一次事件投递及其结果
+type WebhookDelivery struct {
+	BaseModel
+	WebhookID      string                `gorm:"type:varchar(36);not null;index" json:"webhook_id"`   // 所属 Webhook
+	EventSeq       uint64                `gorm:"not null" json:"event_seq"`                           // 事件序号
+	EventType      EventType             `gorm:"type:varchar(32);not null" json:"event_type"`         // 事件类型
+	Payload        json.RawMessage       `gorm:"not null" json:"payload"`                             // 请求体，重试时不变
+	Status         WebhookDeliveryStatus `gorm:"type:varchar(16);not null;index" json:"status"`       // 投递状态
+	Attempts       int                   `gorm:"not null;default:0" json:"attempts"`                  // 已尝试的次数
+	NextAttemptAt  *time.Time            `gorm:"index" json:"next_attempt_at,omitempty"`              // 下次尝试时间，结束后为空
+	LastAttemptAt  *time.Time            `json:"last_attempt_at,omitempty"`                           // 最近一次尝试时间
+	ResponseStatus int                   `gorm:"not null;default:0" json:"response_status,omitempty"` // 最近一次响应的状态码，未收到响应时为 0
+	Error          string                `gorm:"type:text" json:"error,omitempty"`                    // 最近一次失败的原因
+}
+
+// TableName 指定表名
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
@@ -0,0 +1,159 @@
+// Package plugin 定义服务端插件的接口和注册表。
+//
+// 插件与程序一同编译，在 init 中调用 Register 注册，在 cmd/app/plugins.go 中以空导入的方式引入。
+// 插件按需实现 BeforeSaveHook、RouteProvider、MarkdownTransformer 等接口中的一个或多个，
+// 启用状态和配置保存在数据库中，通过 /api/v1/plugins 管理，默认不启用
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"leafnote/internal/model"
+)
+
+// Plugin 插件的基本信息，所有插件都必须实现
+type Plugin interface {
+	Name() string        // 插件名称，用于管理接口和路由，只能包含小写字母、数字和 -
+	Description() string // 插件说明
+}
+
+// Configurable 接受配置的插件；启用插件或修改已启用插件的配置时调用，返回错误时拒绝本次修改。
+// 可能与钩子并发调用
+type Configurable interface {
+	Configure(config map[string]interface{}) error
+}
+
+// Note 保存前的笔记，BeforeSaveNote 可以修改标题、正文和元数据
+type Note struct {
+	ID       string // 笔记ID，创建时为空
+	Title    string
+	Content  string
+	YAMLMeta string
+	FilePath string // 文件路径，修改不生效
+}
+
+// BeforeSaveHook 在创建、更新笔记时于校验之前调用，返回错误时拒绝保存，错误信息返回给客户端。
+// 更新时在数据库事务中执行，应尽快返回
+type BeforeSaveHook interface {
+	BeforeSaveNote(ctx context.Context, note *Note) error
+}
+
+// AfterSaveHook 在创建、更新笔记的事务提交后调用，note 包含目录和标签；返回的错误只记录日志
+type AfterSaveHook interface {
+	AfterSaveNote(ctx context.Context, note *model.Note) error
+}
+
+// BeforeDeleteHook 在删除笔记（移入回收站）前调用，返回错误时拒绝删除
+type BeforeDeleteHook interface {
+	BeforeDeleteNote(ctx context.Context, note *model.Note) error
+}
+
+// RouteProvider 提供自定义接口的插件，路由注册在 /api/v1/plugins/<名称> 下，
+// 插件未启用时返回 404；不能在分组的根路径上注册，根路径用于管理插件
+type RouteProvider interface {
+	RegisterRoutes(r gin.IRouter)
+}
+
+// MarkdownTransformer 在渲染 HTML、静态站点和 PDF 前转换笔记正文，不修改保存的内容；
+// 多个插件按名称顺序依次转换
+type MarkdownTransformer interface {
+	TransformMarkdown(content string) (string, error)
+}
+
+// namePattern 插件名称的格式
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+// entry 已注册的插件及其启用状态
+type entry struct {
+	plugin  Plugin
+	enabled bool
+}
+
+var (
+	mu       sync.RWMutex
+	registry = make(map[string]*entry)
+)
+
+// Register 注册插件，名称不合法或重复时 panic；应在 init 中调用
+func Register(p Plugin) {
+	mu.Lock()
+	defer mu.Unlock()
+	name := p.Name()
+	if !namePattern.MatchString(name) {
+		panic(fmt.Sprintf("plugin: 插件名称不合法: %q", name))
+	}
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("plugin: 插件重复注册: %s", name))
+	}
+	registry[name] = &entry{plugin: p}
+}
+
+// All 返回所有已注册的插件，按名称排序
+func All() []Plugin {
+	return list(false)
+}
+
+// Enabled 返回已启用的插件，按名称排序
+func Enabled() []Plugin {
+	return list(true)
+}
+
+// list 按名称排序返回插件，enabledOnly 为 true 时只返回已启用的插件
+func list(enabledOnly bool) []Plugin {
+	mu.RLock()
+	defer mu.RUnlock()
+	plugins := make([]Plugin, 0, len(registry))
+	for _, e := range registry {
+		if !enabledOnly || e.enabled {
+			plugins = append(plugins, e.plugin)
+		}
+	}
+	sort.Slice(plugins, func(i, j int) bool {
+		return plugins[i].Name() < plugins[j].Name()
+	})
+	return plugins
+}
+
+// Lookup 按名称查找已注册的插件
+func Lookup(name string) (Plugin, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	e, ok := registry[name]
+	if !ok {
+		return nil, false
+	}
+	return e.plugin, true
+}
+
+// IsEnabled 判断插件是否已注册并启用
+func IsEnabled(name string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	e, ok := registry[name]
+	return ok && e.enabled
+}
+
+// Apply 设置插件的启用状态；启用时先将 config 传给 Configure，失败时保持原来的状态
+func Apply(name string, enabled bool, config map[string]interface{}) error {
+	mu.Lock()
+	defer mu.Unlock()
+	e, ok := registry[name]
+	if !ok {
+		return fmt.Errorf("plugin: 插件未注册: %s", name)
+	}
+	if enabled {
+		if c, ok := e.plugin.(Configurable); ok {
+			if err := c.Configure(config); err != nil {
+				return err
+			}
+		}
+	}
+	e.enabled = enabled
+	return nil
+}
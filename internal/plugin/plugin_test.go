@@ -0,0 +1,91 @@
+package plugin
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPlugin 测试用插件，配置中 limit 必须为数字
+type testPlugin struct {
+	name  string
+	limit float64
+}
+
+func (p *testPlugin) Name() string        { return p.name }
+func (p *testPlugin) Description() string { return "测试插件" }
+
+func (p *testPlugin) Configure(config map[string]interface{}) error {
+	limit, ok := config["limit"].(float64)
+	if !ok {
+		return errors.New("limit 必须为数字")
+	}
+	p.limit = limit
+	return nil
+}
+
+func TestRegister(t *testing.T) {
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		registry = make(map[string]*entry)
+	})
+
+	Register(&testPlugin{name: "b"})
+	Register(&testPlugin{name: "a-1"})
+
+	tests := []struct {
+		name   string
+		plugin Plugin
+	}{
+		{name: "名称重复", plugin: &testPlugin{name: "b"}},
+		{name: "名称为空", plugin: &testPlugin{name: ""}},
+		{name: "包含大写字母", plugin: &testPlugin{name: "Word"}},
+		{name: "包含斜杠", plugin: &testPlugin{name: "a/b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Panics(t, func() { Register(tt.plugin) })
+		})
+	}
+
+	var names []string
+	for _, p := range All() {
+		names = append(names, p.Name())
+	}
+	assert.Equal(t, []string{"a-1", "b"}, names)
+	assert.Empty(t, Enabled())
+}
+
+func TestApply(t *testing.T) {
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		registry = make(map[string]*entry)
+	})
+	p := &testPlugin{name: "limit"}
+	Register(p)
+
+	// 配置不被接受时保持未启用
+	assert.Error(t, Apply("limit", true, map[string]interface{}{"limit": "十"}))
+	assert.False(t, IsEnabled("limit"))
+
+	require.NoError(t, Apply("limit", true, map[string]interface{}{"limit": 10.0}))
+	assert.True(t, IsEnabled("limit"))
+	assert.Equal(t, 10.0, p.limit)
+	assert.Len(t, Enabled(), 1)
+
+	// 已启用时配置不被接受，保持原来的配置
+	assert.Error(t, Apply("limit", true, nil))
+	assert.True(t, IsEnabled("limit"))
+	assert.Equal(t, 10.0, p.limit)
+
+	// 停用时不校验配置
+	require.NoError(t, Apply("limit", false, nil))
+	assert.False(t, IsEnabled("limit"))
+
+	assert.Error(t, Apply("missing", true, nil))
+}
@@ -0,0 +1,126 @@
+package response
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"leafnote/internal/i18n"
+	"leafnote/internal/service"
+)
+
+// 响应状态
+const (
+	StatusSuccess = "success"
+	StatusError   = "error"
+)
+
+// Body 统一响应结构
+type Body struct {
+	Status  string            `json:"status"`            // success 或 error
+	Data    interface{}       `json:"data,omitempty"`    // 响应数据
+	Message string            `json:"message,omitempty"` // 提示信息
+	Error   string            `json:"error,omitempty"`   // 错误信息
+	Code    string            `json:"code,omitempty"`    // 机器可读的错误码
+	Fields  []i18n.FieldError `json:"fields,omitempty"`  // 字段级错误
+}
+
+// OK 返回 200 成功响应
+func OK(c *gin.Context, data interface{}) {
+	c.JSON(http.StatusOK, Body{Status: StatusSuccess, Data: data})
+}
+
+// Created 返回 201 成功响应
+func Created(c *gin.Context, data interface{}) {
+	c.JSON(http.StatusCreated, Body{Status: StatusSuccess, Data: data})
+}
+
+// Accepted 返回 202 响应，用于已提交的后台任务
+func Accepted(c *gin.Context, data interface{}) {
+	c.JSON(http.StatusAccepted, Body{Status: StatusSuccess, Data: data})
+}
+
+// Message 返回只带提示信息的成功响应，key 为 i18n 消息键
+func Message(c *gin.Context, key string) {
+	c.JSON(http.StatusOK, Body{
+		Status:  StatusSuccess,
+		Message: i18n.Translate(Locale(c), key, key),
+	})
+}
+
+// Error 根据业务错误类别返回本地化的错误响应，未知错误统一按内部错误处理
+func Error(c *gin.Context, err error) {
+	e, ok := service.AsError(err)
+	if !ok {
+		e = service.ErrInternal
+	}
+	c.JSON(StatusCode(e), errorBody(Locale(c), e))
+}
+
+// BindError 返回参数绑定失败的响应，校验错误会逐字段翻译
+func BindError(c *gin.Context, err error) {
+	locale := Locale(c)
+	body := errorBody(locale, service.ErrInvalidParams)
+	body.Fields = i18n.TranslateValidation(locale, err)
+	c.JSON(http.StatusBadRequest, body)
+}
+
+// ErrorDetail 批量操作中单项失败的原因，字段与错误响应一致
+type ErrorDetail struct {
+	Error  string            `json:"error"`
+	Code   string            `json:"code"`
+	Fields []i18n.FieldError `json:"fields,omitempty"`
+}
+
+// Detail 将业务错误转换为本地化的单项错误，未知错误按内部错误处理
+func Detail(c *gin.Context, err error) *ErrorDetail {
+	e, ok := service.AsError(err)
+	if !ok {
+		e = service.ErrInternal
+	}
+	body := errorBody(Locale(c), e)
+	return &ErrorDetail{Error: body.Error, Code: body.Code, Fields: body.Fields}
+}
+
+// errorBody 构造本地化的错误响应体
+func errorBody(locale i18n.Locale, e *service.Error) Body {
+	body := Body{
+		Status: StatusError,
+		Error:  i18n.Translate(locale, e.Code, e.Message),
+		Code:   e.Code,
+	}
+	for _, fe := range e.Fields {
+		body.Fields = append(body.Fields, i18n.TranslateField(locale, fe.Field, fe.Code, fe.Params))
+	}
+	return body
+}
+
+// Locale 返回当前请求的语言，优先使用 Locale 中间件协商的结果
+func Locale(c *gin.Context) i18n.Locale {
+	if v, ok := c.Get(i18n.ContextKey); ok {
+		if locale, ok := v.(i18n.Locale); ok {
+			return locale
+		}
+	}
+	return i18n.FromRequest(c.Request)
+}
+
+// StatusCode 将业务错误映射为 HTTP 状态码
+func StatusCode(err error) int {
+	e, ok := service.AsError(err)
+	if !ok {
+		return http.StatusInternalServerError
+	}
+	switch e.Kind {
+	case service.KindValidation:
+		return http.StatusBadRequest
+	case service.KindNotFound:
+		return http.StatusNotFound
+	case service.KindConflict:
+		return http.StatusConflict
+	case service.KindRateLimited:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"leafnote/internal/config"
+)
+
+// 未配置超时时间时使用的默认值
+const (
+	defaultReadTimeout     = 15 * time.Second
+	defaultWriteTimeout    = 30 * time.Second
+	defaultIdleTimeout     = 60 * time.Second
+	defaultShutdownTimeout = 10 * time.Second
+)
+
+// WorkerFunc 后台任务，ctx 被取消时应尽快清理并返回
+type WorkerFunc func(ctx context.Context) error
+
+// worker 已注册的后台任务
+type worker struct {
+	name string
+	run  WorkerFunc
+}
+
+// Server 管理 HTTP 服务和后台任务的生命周期
+type Server struct {
+	httpServer      *http.Server
+	shutdownTimeout time.Duration
+	logger          *zap.Logger
+	workers         []worker
+}
+
+// New 创建服务实例
+func New(cfg *config.ServerConfig, handler http.Handler, logger *zap.Logger) *Server {
+	return &Server{
+		httpServer: &http.Server{
+			Addr:         fmt.Sprintf(":%d", cfg.Port),
+			Handler:      handler,
+			ReadTimeout:  durationOr(cfg.ReadTimeout, defaultReadTimeout),
+			WriteTimeout: durationOr(cfg.WriteTimeout, defaultWriteTimeout),
+			IdleTimeout:  durationOr(cfg.IdleTimeout, defaultIdleTimeout),
+			ErrorLog:     zap.NewStdLog(logger),
+		},
+		shutdownTimeout: durationOr(cfg.ShutdownTimeout, defaultShutdownTimeout),
+		logger:          logger,
+	}
+}
+
+// AddWorker 注册后台任务（文件监控、定时清理等），必须在 Run 之前调用
+func (s *Server) AddWorker(name string, run WorkerFunc) {
+	s.workers = append(s.workers, worker{name: name, run: run})
+}
+
+// OnShutdown 注册开始关闭时调用的函数，用于断开 SSE 等长连接，否则关闭时需要等到超时
+func (s *Server) OnShutdown(f func()) {
+	s.httpServer.RegisterOnShutdown(f)
+}
+
+// Run 启动 HTTP 服务和所有后台任务，直到 ctx 被取消或服务异常退出
+// 退出时先停止接收新请求并等待进行中的请求完成，再通知后台任务退出并等待其结束
+func (s *Server) Run(ctx context.Context) error {
+	workerCtx, cancelWorkers := context.WithCancel(context.Background())
+	defer cancelWorkers()
+
+	var wg sync.WaitGroup
+	for _, w := range s.workers {
+		wg.Add(1)
+		go func(w worker) {
+			defer wg.Done()
+			s.logger.Info("Worker started", zap.String("worker", w.name))
+			if err := w.run(workerCtx); err != nil && !errors.Is(err, context.Canceled) {
+				s.logger.Error("Worker exited with error", zap.String("worker", w.name), zap.Error(err))
+				return
+			}
+			s.logger.Info("Worker stopped", zap.String("worker", w.name))
+		}(w)
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		s.logger.Info("Server starting", zap.String("addr", s.httpServer.Addr))
+		if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+		close(serveErr)
+	}()
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		s.logger.Info("Shutdown signal received, draining requests",
+			zap.Duration("timeout", s.shutdownTimeout))
+	case err := <-serveErr:
+		runErr = err
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+	if err := s.httpServer.Shutdown(shutdownCtx); err != nil {
+		s.logger.Error("Server shutdown did not complete", zap.Error(err))
+		runErr = errors.Join(runErr, err)
+	}
+
+	// 请求处理完毕后再停止后台任务，避免请求依赖的任务提前退出
+	cancelWorkers()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-shutdownCtx.Done():
+		s.logger.Warn("Timed out waiting for workers to stop")
+	}
+
+	s.logger.Info("Server stopped")
+	return runErr
+}
+
+// durationOr 在 d 未配置时返回默认值
+func durationOr(d, fallback time.Duration) time.Duration {
+	if d <= 0 {
+		return fallback
+	}
+	return d
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"leafnote/internal/config"
+)
+
+func TestServer_RunStopsWorkersOnShutdown(t *testing.T) {
+	cfg := &config.ServerConfig{Port: 0, ShutdownTimeout: time.Second}
+	srv := New(cfg, http.NotFoundHandler(), zap.NewNop())
+
+	var started, stopped atomic.Bool
+	srv.AddWorker("test", func(ctx context.Context) error {
+		started.Store(true)
+		<-ctx.Done()
+		stopped.Store(true)
+		return ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Run(ctx)
+	}()
+
+	assert.Eventually(t, started.Load, time.Second, 10*time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("server did not stop")
+	}
+	assert.True(t, stopped.Load())
+}
+
+func TestNew_DefaultTimeouts(t *testing.T) {
+	srv := New(&config.ServerConfig{Port: 8080, ReadTimeout: 5 * time.Second}, http.NotFoundHandler(), zap.NewNop())
+
+	assert.Equal(t, ":8080", srv.httpServer.Addr)
+	assert.Equal(t, 5*time.Second, srv.httpServer.ReadTimeout)
+	assert.Equal(t, defaultWriteTimeout, srv.httpServer.WriteTimeout)
+	assert.Equal(t, defaultIdleTimeout, srv.httpServer.IdleTimeout)
+	assert.Equal(t, defaultShutdownTimeout, srv.shutdownTimeout)
+}
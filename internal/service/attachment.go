@@ -0,0 +1,555 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"leafnote/internal/model"
+)
+
+// attachmentDir 笔记库中保存附件的目录
+const attachmentDir = "attachments"
+
+// attachmentRef 匹配正文中对附件的引用：笔记库中的路径 attachments/<hash>.png 或下载地址 /api/v1/attachments/<hash>.png
+var attachmentRef = regexp.MustCompile(`attachments/([0-9a-f]{64})`)
+
+// attachmentHash 附件内容的 SHA-256
+var attachmentHash = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// attachmentTypes 扩展名对应的 MIME 类型，优先于系统的 MIME 表，保证各平台一致
+var attachmentTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".svg":  "image/svg+xml",
+	".pdf":  "application/pdf",
+	".md":   "text/markdown; charset=utf-8",
+	".txt":  "text/plain; charset=utf-8",
+	".csv":  "text/csv; charset=utf-8",
+	".json": "application/json",
+}
+
+// attachmentExts MIME 类型对应的扩展名，用于内容与原扩展名不符或没有扩展名时
+var attachmentExts = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"image/bmp":       ".bmp",
+	"image/svg+xml":   ".svg",
+	"application/pdf": ".pdf",
+	"application/zip": ".zip",
+	"audio/mpeg":      ".mp3",
+	"audio/wave":      ".wav",
+	"video/mp4":       ".mp4",
+	"video/webm":      ".webm",
+	"text/plain":      ".txt",
+}
+
+// AttachmentOptions 附件配置
+type AttachmentOptions struct {
+	VaultDir    string        // 笔记库根目录，附件保存在其中的 attachments 目录
+	MaxSize     int64         // 单个附件的大小上限（字节），0 表示不限制
+	GracePeriod time.Duration // 附件上传或最近一次被重复上传后，未被引用多久才会被清理
+	Image       ImageOptions  // 图片的处理和缩略图
+}
+
+// AttachmentGCResult 清理结果
+type AttachmentGCResult struct {
+	Removed int   `json:"removed"` // 删除的附件数
+	Freed   int64 `json:"freed"`   // 释放的空间（字节）
+}
+
+// AttachmentService 管理笔记库中的附件：按内容去重保存、记录笔记的引用、清理未被引用的附件
+type AttachmentService struct {
+	db     *gorm.DB
+	logger *zap.Logger
+	opts   AttachmentOptions
+	gcMu   sync.Mutex
+}
+
+// NewAttachmentService 创建附件服务实例
+func NewAttachmentService(db *gorm.DB, logger *zap.Logger, opts AttachmentOptions) *AttachmentService {
+	return &AttachmentService{db: db, logger: logger, opts: opts}
+}
+
+// Upload 保存附件，内容已存在时返回已有的附件；name 为原文件名，只用于推断类型和记录
+func (s *AttachmentService) Upload(ctx context.Context, name string, r io.Reader) (*model.Attachment, error) {
+	if s.opts.VaultDir == "" {
+		return nil, errors.New("未配置笔记库目录")
+	}
+	dir := filepath.Join(s.opts.VaultDir, attachmentDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	// 边写入临时文件边计算哈希，同时保留开头的内容用于识别类型
+	if s.opts.MaxSize > 0 {
+		r = io.LimitReader(r, s.opts.MaxSize+1)
+	}
+	h := sha256.New()
+	var head bytes.Buffer
+	size, err := io.Copy(io.MultiWriter(tmp, h, &limitedBuffer{buf: &head, max: 512}), r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if size == 0 {
+		return nil, ErrAttachmentEmpty
+	}
+	if s.opts.MaxSize > 0 && size > s.opts.MaxSize {
+		return nil, ErrAttachmentTooLarge
+	}
+
+	mimeType := detectMIME(name, head.Bytes())
+	hash := hex.EncodeToString(h.Sum(nil))
+	// 处理后的内容决定附件的哈希，相同的原图处理结果相同，仍然可以去重
+	if s.opts.Image.enabled() && (mediaType(mimeType) == "image/jpeg" || mediaType(mimeType) == "image/png") {
+		if hash, size, err = s.processUpload(tmp.Name(), mimeType); err != nil {
+			return nil, err
+		}
+	}
+
+	db := s.db.WithContext(ctx)
+	existing, err := s.findByHash(db, hash)
+	if err != nil && !errors.Is(err, ErrAttachmentNotFound) {
+		return nil, err
+	}
+	if existing != nil {
+		// 重新上传视为新的使用，推迟清理
+		if err := db.Model(existing).UpdateColumn("updated_at", time.Now()).Error; err != nil {
+			return nil, err
+		}
+		if err := s.ensureFile(existing, tmp.Name()); err != nil {
+			return nil, err
+		}
+		return existing, nil
+	}
+
+	att := &model.Attachment{
+		Hash:     hash,
+		Name:     attachmentName(name),
+		MimeType: mimeType,
+		Size:     size,
+		Path:     attachmentDir + "/" + hash + attachmentExt(name, mimeType),
+	}
+	if err := s.ensureFile(att, tmp.Name()); err != nil {
+		return nil, err
+	}
+	if err := db.Create(att).Error; err != nil {
+		// 并发上传相同内容时唯一索引冲突，返回先保存的附件
+		if existing, ferr := s.findByHash(db, hash); ferr == nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+	s.logger.Info("Attachment saved", zap.String("path", att.Path), zap.Int64("size", size))
+	return att, nil
+}
+
+// processUpload 处理上传的图片并写回临时文件，返回处理后内容的哈希和大小
+func (s *AttachmentService) processUpload(tmp, mimeType string) (string, int64, error) {
+	data, err := os.ReadFile(tmp)
+	if err != nil {
+		return "", 0, err
+	}
+	out, changed, err := processImage(data, mimeType, s.opts.Image)
+	if err != nil {
+		return "", 0, err
+	}
+	if changed {
+		if err := os.WriteFile(tmp, out, 0644); err != nil {
+			return "", 0, err
+		}
+	}
+	sum := sha256.Sum256(out)
+	return hex.EncodeToString(sum[:]), int64(len(out)), nil
+}
+
+// Save 保存内存中的附件，供导入使用
+func (s *AttachmentService) Save(ctx context.Context, name string, data []byte) (*model.Attachment, error) {
+	return s.Upload(ctx, name, bytes.NewReader(data))
+}
+
+// ensureFile 文件不存在时将临时文件移动到附件路径
+func (s *AttachmentService) ensureFile(att *model.Attachment, tmp string) error {
+	p := s.FilePath(att)
+	if _, err := os.Stat(p); err == nil {
+		return nil
+	}
+	return os.Rename(tmp, p)
+}
+
+// FilePath 返回附件在磁盘上的路径
+func (s *AttachmentService) FilePath(att *model.Attachment) string {
+	return filepath.Join(s.opts.VaultDir, filepath.FromSlash(att.Path))
+}
+
+// AttachmentThumbnail 缩略图文件
+type AttachmentThumbnail struct {
+	Path     string // 磁盘上的路径，图片不超过请求的尺寸时为原图
+	MimeType string
+	ETag     string
+}
+
+// Thumbnail 返回图片附件的缩略图，size 为 0 时使用最小的尺寸；
+// 首次请求时生成并缓存到附件目录下的 .thumbnails，JPEG 生成 JPEG 缩略图，其他格式生成 PNG
+func (s *AttachmentService) Thumbnail(ctx context.Context, name string, size int) (*model.Attachment, *AttachmentThumbnail, error) {
+	att, err := s.GetAttachment(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	sizes := s.opts.Image.ThumbnailSizes
+	if size == 0 && len(sizes) > 0 {
+		size = slices.Min(sizes)
+	}
+	if !slices.Contains(sizes, size) {
+		return nil, nil, ErrThumbnailSizeInvalid
+	}
+	format := mediaType(att.MimeType)
+	decode, ok := imageDecoders[format]
+	if !ok {
+		return nil, nil, ErrAttachmentNotImage
+	}
+
+	ext, thumbType := ".png", "image/png"
+	if format == "image/jpeg" {
+		ext, thumbType = ".jpg", "image/jpeg"
+	}
+	thumb := &AttachmentThumbnail{
+		Path:     filepath.Join(s.thumbnailDir(), fmt.Sprintf("%s-%d%s", att.Hash, size, ext)),
+		MimeType: thumbType,
+		ETag:     fmt.Sprintf(`"%s-%d"`, att.Hash, size),
+	}
+	if _, err := os.Stat(thumb.Path); err == nil {
+		return att, thumb, nil
+	}
+
+	data, err := os.ReadFile(s.FilePath(att))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, ErrAttachmentNotFound
+		}
+		return nil, nil, err
+	}
+	cfg, err := imageConfigDecoders[format](bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, ErrAttachmentNotImage
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return nil, nil, ErrAttachmentImageTooLarge
+	}
+	orientation := 1
+	if format == "image/jpeg" {
+		orientation = jpegOrientation(data)
+	}
+	// 足够小的图片直接使用原图，GIF 保留动画
+	if max(cfg.Width, cfg.Height) <= size && orientation == 1 && format != "image/bmp" {
+		thumb.Path, thumb.MimeType = s.FilePath(att), att.MimeType
+		return att, thumb, nil
+	}
+
+	img, err := decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, ErrAttachmentNotImage
+	}
+	img = applyOrientation(img, orientation)
+	if max(cfg.Width, cfg.Height) > size {
+		img = scaleImage(img, size)
+	}
+	out, err := encodeImage(img, thumbType, s.opts.Image.jpegQuality())
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := writeFileAtomic(thumb.Path, out); err != nil {
+		return nil, nil, err
+	}
+	return att, thumb, nil
+}
+
+// thumbnailDir 返回缩略图缓存目录
+func (s *AttachmentService) thumbnailDir() string {
+	return filepath.Join(s.opts.VaultDir, attachmentDir, ".thumbnails")
+}
+
+// removeThumbnails 删除附件的所有缩略图
+func (s *AttachmentService) removeThumbnails(att *model.Attachment) {
+	paths, _ := filepath.Glob(filepath.Join(s.thumbnailDir(), att.Hash+"-*"))
+	for _, p := range paths {
+		if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+			s.logger.Warn("Failed to remove thumbnail", zap.String("path", p), zap.Error(err))
+		}
+	}
+}
+
+// writeFileAtomic 先写入同目录的临时文件再重命名，避免并发读取到不完整的文件
+func writeFileAtomic(p string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+// GetAttachment 按文件名获取附件，name 为哈希或带扩展名的哈希，例如 <hash>.png
+func (s *AttachmentService) GetAttachment(ctx context.Context, name string) (*model.Attachment, error) {
+	hash := strings.TrimSuffix(name, path.Ext(name))
+	if !attachmentHash.MatchString(hash) {
+		return nil, ErrAttachmentNotFound
+	}
+	return s.findByHash(s.db.WithContext(ctx), hash)
+}
+
+func (s *AttachmentService) findByHash(db *gorm.DB, hash string) (*model.Attachment, error) {
+	var att model.Attachment
+	if err := db.First(&att, "hash = ?", hash).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAttachmentNotFound
+		}
+		return nil, err
+	}
+	return &att, nil
+}
+
+// ListAttachments 按上传时间倒序列出附件及引用数，unreferenced 为 true 时只列出未被引用的附件
+func (s *AttachmentService) ListAttachments(ctx context.Context, unreferenced bool) ([]model.Attachment, error) {
+	refs := "(SELECT COUNT(*) FROM note_attachments WHERE note_attachments.attachment_id = attachments.id)"
+	query := s.db.WithContext(ctx).Select("attachments.*, " + refs + " AS ref_count")
+	if unreferenced {
+		query = query.Where(refs + " = 0")
+	}
+	attachments := []model.Attachment{}
+	if err := query.Order("created_at DESC").Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// ListNotes 列出引用附件的笔记（不含已删除的笔记），按标题排序
+func (s *AttachmentService) ListNotes(ctx context.Context, name string) ([]model.Note, error) {
+	att, err := s.GetAttachment(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	notes := []model.Note{}
+	err = s.db.WithContext(ctx).
+		Where("id IN (?)", s.db.Table("note_attachments").Select("note_id").Where("attachment_id = ?", att.ID)).
+		Order("title").Find(&notes).Error
+	return notes, err
+}
+
+// syncAttachmentRefs 根据笔记正文更新笔记对附件的引用，正文中引用了不存在的附件时忽略
+func syncAttachmentRefs(tx *gorm.DB, noteID, content string) error {
+	if err := tx.Where("note_id = ?", noteID).Delete(&model.NoteAttachment{}).Error; err != nil {
+		return err
+	}
+	hashes := attachmentHashes(content)
+	if len(hashes) == 0 {
+		return nil
+	}
+	var ids []string
+	if err := tx.Model(&model.Attachment{}).Where("hash IN ?", hashes).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	refs := make([]model.NoteAttachment, 0, len(ids))
+	for _, id := range ids {
+		refs = append(refs, model.NoteAttachment{NoteID: noteID, AttachmentID: id})
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&refs).Error
+}
+
+// attachmentHashes 返回正文中引用的附件哈希，已去重
+func attachmentHashes(content string) []string {
+	var hashes []string
+	seen := make(map[string]bool)
+	for _, m := range attachmentRef.FindAllStringSubmatch(content, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			hashes = append(hashes, m[1])
+		}
+	}
+	return hashes
+}
+
+// Schedule 返回按 interval 定期清理未被引用的附件的后台任务，单次失败只记录日志
+func (s *AttachmentService) Schedule(interval time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				if _, err := s.CollectGarbage(ctx); err != nil {
+					s.logger.Error("Attachment garbage collection failed", zap.Error(err))
+				}
+			}
+		}
+	}
+}
+
+// CollectGarbage 删除超过保留期仍未被任何笔记引用的附件，回收站和已删除的笔记中的引用同样保留附件；
+// 删除前再按正文确认一次，避免引用记录缺失（例如直接修改数据库）时误删
+func (s *AttachmentService) CollectGarbage(ctx context.Context) (*AttachmentGCResult, error) {
+	s.gcMu.Lock()
+	defer s.gcMu.Unlock()
+
+	db := s.db.WithContext(ctx)
+	var candidates []model.Attachment
+	err := db.Where("updated_at < ?", time.Now().Add(-s.opts.GracePeriod)).
+		Where("NOT EXISTS (SELECT 1 FROM note_attachments WHERE note_attachments.attachment_id = attachments.id)").
+		Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AttachmentGCResult{}
+	for i := range candidates {
+		att := &candidates[i]
+		var noteIDs []string
+		if err := db.Unscoped().Model(&model.Note{}).
+			Where(likeCondition("content"), "%"+escapeLike(att.Hash)+"%").
+			Pluck("id", &noteIDs).Error; err != nil {
+			return nil, err
+		}
+		if len(noteIDs) > 0 {
+			refs := make([]model.NoteAttachment, 0, len(noteIDs))
+			for _, id := range noteIDs {
+				refs = append(refs, model.NoteAttachment{NoteID: id, AttachmentID: att.ID})
+			}
+			if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&refs).Error; err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if err := db.Unscoped().Delete(att).Error; err != nil {
+			return nil, err
+		}
+		if err := os.Remove(s.FilePath(att)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			s.logger.Warn("Failed to remove attachment file", zap.String("path", att.Path), zap.Error(err))
+		}
+		s.removeThumbnails(att)
+		result.Removed++
+		result.Freed += att.Size
+	}
+	if result.Removed > 0 {
+		s.logger.Info("Unreferenced attachments removed", zap.Int("removed", result.Removed), zap.Int64("freed", result.Freed))
+	}
+	return result, nil
+}
+
+// detectMIME 按内容识别 MIME 类型；内容为纯文本、XML 或无法识别时按扩展名判断（例如 SVG、Markdown）
+func detectMIME(name string, head []byte) string {
+	sniffed := http.DetectContentType(head)
+	base, _, _ := mime.ParseMediaType(sniffed)
+	switch base {
+	case "application/octet-stream", "text/plain", "text/xml":
+		ext := strings.ToLower(path.Ext(name))
+		if t, ok := attachmentTypes[ext]; ok && (base != "application/octet-stream" || !strings.HasPrefix(t, "text/")) {
+			return t
+		}
+	}
+	return sniffed
+}
+
+// attachmentExt 返回保存附件使用的扩展名：原扩展名与识别出的类型一致时保留，否则按类型选择
+func attachmentExt(name, mimeType string) string {
+	base, _, _ := mime.ParseMediaType(mimeType)
+	ext := strings.ToLower(path.Ext(name))
+	if t, ok := attachmentTypes[ext]; ok {
+		if tb, _, _ := mime.ParseMediaType(t); tb == base {
+			return ext
+		}
+	} else if t := mime.TypeByExtension(ext); ext != "" && t != "" {
+		if tb, _, _ := mime.ParseMediaType(t); tb == base && !strings.ContainsAny(ext, fileNameInvalidChars) && utf8.RuneCountInString(ext) <= 16 {
+			return ext
+		}
+	}
+	return attachmentExts[base]
+}
+
+// attachmentName 清理上传的文件名，只保留最后一段并限制长度
+func attachmentName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	if name == "." || name == "/" {
+		return "attachment"
+	}
+	if runes := []rune(name); len(runes) > MaxPathSegmentLength {
+		name = string(runes[:MaxPathSegmentLength])
+	}
+	return name
+}
+
+// limitedBuffer 只保留写入内容的前 max 个字节
+type limitedBuffer struct {
+	buf *bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if n := b.max - b.buf.Len(); n > 0 {
+		if len(p) < n {
+			n = len(p)
+		}
+		b.buf.Write(p[:n])
+	}
+	return len(p), nil
+}
+
+// AttachmentInline 判断附件能否在浏览器中直接打开；HTML、SVG 等可能执行脚本的类型只能作为下载
+func AttachmentInline(mimeType string) bool {
+	base, _, _ := mime.ParseMediaType(mimeType)
+	switch {
+	case base == "image/svg+xml":
+		return false
+	case strings.HasPrefix(base, "image/"), strings.HasPrefix(base, "audio/"), strings.HasPrefix(base, "video/"):
+		return true
+	}
+	return base == "application/pdf" || base == "text/plain"
+}
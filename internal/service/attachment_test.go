@@ -0,0 +1,180 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"leafnote/internal/model"
+	"leafnote/internal/testutil"
+)
+
+// pngData PNG 文件头，足以被识别为 image/png
+var pngData = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestAttachmentService_Upload(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	vault := t.TempDir()
+	s := NewAttachmentService(db, zap.NewNop(), AttachmentOptions{VaultDir: vault, MaxSize: 64})
+
+	tests := []struct {
+		name     string
+		fileName string
+		content  []byte
+		wantPath string
+		wantMIME string
+		wantName string
+		wantErr  error
+	}{
+		{name: "按内容识别类型", fileName: "截图.png", content: pngData, wantPath: "attachments/" + sha256Hex(pngData) + ".png", wantMIME: "image/png", wantName: "截图.png"},
+		{name: "扩展名与内容不符", fileName: "图.txt", content: []byte("GIF89a..."), wantPath: "attachments/" + sha256Hex([]byte("GIF89a...")) + ".gif", wantMIME: "image/gif", wantName: "图.txt"},
+		{name: "SVG 按扩展名识别", fileName: "图标.svg", content: []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`), wantPath: "attachments/" + sha256Hex([]byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`)) + ".svg", wantMIME: "image/svg+xml", wantName: "图标.svg"},
+		{name: "文件名只保留最后一段", fileName: `C:\docs\说明.md`, content: []byte("# 说明"), wantPath: "attachments/" + sha256Hex([]byte("# 说明")) + ".md", wantMIME: "text/markdown; charset=utf-8", wantName: "说明.md"},
+		{name: "无法识别的类型", fileName: "data", content: []byte{0x00, 0x01, 0x02}, wantPath: "attachments/" + sha256Hex([]byte{0x00, 0x01, 0x02}), wantMIME: "application/octet-stream", wantName: "data"},
+		{name: "空文件", fileName: "a.png", content: []byte{}, wantErr: ErrAttachmentEmpty},
+		{name: "超过大小上限", fileName: "a.bin", content: bytes.Repeat([]byte("a"), 65), wantErr: ErrAttachmentTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			att, err := s.Upload(context.Background(), tt.fileName, bytes.NewReader(tt.content))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPath, att.Path)
+			assert.Equal(t, tt.wantMIME, att.MimeType)
+			assert.Equal(t, tt.wantName, att.Name)
+			assert.Equal(t, int64(len(tt.content)), att.Size)
+			saved, err := os.ReadFile(filepath.Join(vault, filepath.FromSlash(att.Path)))
+			require.NoError(t, err)
+			assert.Equal(t, tt.content, saved)
+		})
+	}
+
+	// 临时文件不残留
+	entries, err := os.ReadDir(filepath.Join(vault, "attachments"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 5)
+}
+
+func TestAttachmentService_UploadDedup(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	s := NewAttachmentService(db, zap.NewNop(), AttachmentOptions{VaultDir: t.TempDir()})
+
+	first, err := s.Save(context.Background(), "a.png", pngData)
+	require.NoError(t, err)
+	second, err := s.Save(context.Background(), "b.png", pngData)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, second.ID)
+	assert.Equal(t, "a.png", second.Name)
+
+	got, err := s.GetAttachment(context.Background(), first.Hash+".jpg")
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, got.ID)
+	_, err = s.GetAttachment(context.Background(), "../"+first.Hash)
+	assert.ErrorIs(t, err, ErrAttachmentNotFound)
+}
+
+func TestAttachmentService_Refs(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	s := NewAttachmentService(db, zap.NewNop(), AttachmentOptions{VaultDir: t.TempDir()})
+	notes := NewNoteService(db, zap.NewNop())
+	ctx := context.Background()
+
+	image, err := s.Save(ctx, "a.png", pngData)
+	require.NoError(t, err)
+	doc, err := s.Save(ctx, "b.txt", []byte("文本"))
+	require.NoError(t, err)
+
+	// 笔记库路径和下载地址都视为引用，不存在的附件忽略
+	note, err := notes.CreateNote(CreateNoteInput{
+		Title:    "引用",
+		Content:  "![图](attachments/" + image.Hash + ".png) [下载](/api/v1/attachments/" + doc.Hash + ")\n![](attachments/" + strings.Repeat("0", 64) + ".png)",
+		FilePath: "/引用.md",
+	})
+	require.NoError(t, err)
+
+	linked, err := s.ListNotes(ctx, image.Hash)
+	require.NoError(t, err)
+	require.Len(t, linked, 1)
+	assert.Equal(t, note.ID, linked[0].ID)
+
+	list, err := s.ListAttachments(ctx, false)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, int64(1), list[0].RefCount)
+
+	// 修改正文后只保留新的引用
+	require.NoError(t, notes.UpdateNote(note.ID, UpdateNoteInput{Content: "![图](attachments/" + image.Hash + ".png)"}))
+	unreferenced, err := s.ListAttachments(ctx, true)
+	require.NoError(t, err)
+	require.Len(t, unreferenced, 1)
+	assert.Equal(t, doc.ID, unreferenced[0].ID)
+
+	// 删除到回收站的笔记保留引用
+	require.NoError(t, notes.DeleteNote(note.ID))
+	linked, err = s.ListNotes(ctx, image.Hash)
+	require.NoError(t, err)
+	assert.Empty(t, linked)
+	unreferenced, err = s.ListAttachments(ctx, true)
+	require.NoError(t, err)
+	assert.Len(t, unreferenced, 1)
+}
+
+func TestAttachmentService_CollectGarbage(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	vault := t.TempDir()
+	s := NewAttachmentService(db, zap.NewNop(), AttachmentOptions{VaultDir: vault, GracePeriod: time.Hour})
+	ctx := context.Background()
+
+	referenced, err := s.Save(ctx, "a.png", pngData)
+	require.NoError(t, err)
+	orphan, err := s.Save(ctx, "b.txt", []byte("未引用"))
+	require.NoError(t, err)
+	recent, err := s.Save(ctx, "c.txt", []byte("刚上传"))
+	require.NoError(t, err)
+	// 直接写入数据库的笔记没有引用记录，清理前按正文确认
+	unsynced, err := s.Save(ctx, "d.txt", []byte("缺少引用记录"))
+	require.NoError(t, err)
+
+	_, err = NewNoteService(db, zap.NewNop()).CreateNote(CreateNoteInput{
+		Title:    "引用",
+		Content:  "![图](" + referenced.Path + ")",
+		FilePath: "/引用.md",
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&model.Note{Title: "手动", Content: unsynced.Path, FilePath: "/手动.md", Checksum: "x"}).Error)
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, db.Model(&model.Attachment{}).Where("id <> ?", recent.ID).UpdateColumn("updated_at", old).Error)
+
+	result, err := s.CollectGarbage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Removed)
+	assert.Equal(t, orphan.Size, result.Freed)
+	assert.NoFileExists(t, s.FilePath(orphan))
+	assert.FileExists(t, s.FilePath(referenced))
+	assert.FileExists(t, s.FilePath(recent))
+	_, err = s.GetAttachment(ctx, orphan.Hash)
+	assert.ErrorIs(t, err, ErrAttachmentNotFound)
+
+	var refs int64
+	require.NoError(t, db.Model(&model.NoteAttachment{}).Where("attachment_id = ?", unsynced.ID).Count(&refs).Error)
+	assert.Equal(t, int64(1), refs)
+}
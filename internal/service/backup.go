@@ -0,0 +1,613 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"leafnote/internal/migration"
+)
+
+// 备份归档格式
+const (
+	backupFormat       = 1
+	backupPrefix       = "leafnote-"
+	backupExt          = ".zip"
+	backupTimeLayout   = "20060102-150405"
+	backupManifestName = "manifest.json"
+	backupDBName       = "leafnote.db"
+	backupVaultPrefix  = "vault/"
+)
+
+// BackupOptions 备份配置
+type BackupOptions struct {
+	Dir      string        // 备份文件目录
+	DBPath   string        // SQLite 数据库文件路径
+	VaultDir string        // 笔记库目录，不存在时只备份数据库
+	KeepLast int           // 最多保留的备份数，0 表示不限制
+	MaxAge   time.Duration // 备份最长保留时间，0 表示不限制
+}
+
+// BackupInfo 备份文件信息
+type BackupInfo struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BackupManifest 备份归档中的清单
+type BackupManifest struct {
+	Format         int       `json:"format"`          // 归档格式版本
+	CreatedAt      time.Time `json:"created_at"`      // 备份时间
+	SchemaVersion  int       `json:"schema_version"`  // 备份时的数据库迁移版本
+	DatabaseSHA256 string    `json:"database_sha256"` // 数据库快照的校验和
+	VaultFiles     int       `json:"vault_files"`     // 笔记库文件数
+}
+
+// BackupService 备份服务
+type BackupService struct {
+	db     *gorm.DB
+	logger *zap.Logger
+	opts   BackupOptions
+	mu     sync.Mutex // 同一时间只允许一个备份
+}
+
+// NewBackupService 创建备份服务实例
+func NewBackupService(db *gorm.DB, logger *zap.Logger, opts BackupOptions) *BackupService {
+	return &BackupService{db: db, logger: logger, opts: opts}
+}
+
+// Schedule 返回按 interval 定期备份的后台任务，单次失败只记录日志
+func (s *BackupService) Schedule(interval time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				if _, err := s.CreateBackup(ctx); err != nil {
+					s.logger.Error("Scheduled backup failed", zap.Error(err))
+				}
+			}
+		}
+	}
+}
+
+// CreateBackup 创建数据库快照和笔记库文件的归档，完成后按保留策略清理旧备份
+func (s *BackupService) CreateBackup(ctx context.Context) (*BackupInfo, error) {
+	if s.db.Dialector.Name() != "sqlite" {
+		return nil, ErrBackupUnsupported
+	}
+	if !s.mu.TryLock() {
+		return nil, ErrBackupInProgress
+	}
+	defer s.mu.Unlock()
+
+	start := time.Now()
+	if err := os.MkdirAll(s.opts.Dir, 0755); err != nil {
+		return nil, err
+	}
+	tmpDir, err := os.MkdirTemp(s.opts.Dir, ".backup-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// 使用 SQLite 在线备份 API 生成一致的快照，备份期间不阻塞写入
+	snapshot := filepath.Join(tmpDir, backupDBName)
+	if err := s.snapshotSQLite(ctx, snapshot); err != nil {
+		return nil, fmt.Errorf("备份数据库失败: %w", err)
+	}
+
+	manifest := BackupManifest{Format: backupFormat, CreatedAt: start}
+	if manifest.DatabaseSHA256, err = fileSHA256(snapshot); err != nil {
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).Model(&migration.SchemaMigration{}).
+		Select("COALESCE(MAX(version), 0)").Scan(&manifest.SchemaVersion).Error; err != nil {
+		return nil, err
+	}
+
+	name := s.newBackupName(start)
+	archivePath := filepath.Join(s.opts.Dir, name)
+	tmpArchive := filepath.Join(tmpDir, name)
+	if err := writeBackupArchive(tmpArchive, snapshot, s.opts.VaultDir, &manifest); err != nil {
+		return nil, fmt.Errorf("写入备份归档失败: %w", err)
+	}
+	if err := os.Rename(tmpArchive, archivePath); err != nil {
+		return nil, err
+	}
+
+	info, err := backupInfo(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("Backup created",
+		zap.String("name", info.Name),
+		zap.Int64("size", info.Size),
+		zap.Int("vault_files", manifest.VaultFiles),
+		zap.Duration("cost", time.Since(start)))
+
+	if err := s.prune(time.Now()); err != nil {
+		s.logger.Error("Failed to prune backups", zap.Error(err))
+	}
+	return info, nil
+}
+
+// ListBackups 按创建时间倒序列出备份
+func (s *BackupService) ListBackups() ([]BackupInfo, error) {
+	entries, err := os.ReadDir(s.opts.Dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return []BackupInfo{}, nil
+		}
+		return nil, err
+	}
+
+	backups := make([]BackupInfo, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !isBackupName(entry.Name()) {
+			continue
+		}
+		info, err := backupInfo(filepath.Join(s.opts.Dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, *info)
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		if backups[i].CreatedAt.Equal(backups[j].CreatedAt) {
+			return backupSeq(backups[i].Name) > backupSeq(backups[j].Name)
+		}
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+// BackupPath 返回备份文件的完整路径，名称不合法或文件不存在时返回 ErrBackupNotFound
+func (s *BackupService) BackupPath(name string) (string, error) {
+	if !isBackupName(name) || filepath.Base(name) != name {
+		return "", ErrBackupNotFound
+	}
+	p := filepath.Join(s.opts.Dir, name)
+	if _, err := os.Stat(p); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", ErrBackupNotFound
+		}
+		return "", err
+	}
+	return p, nil
+}
+
+// prune 删除超出数量或超过保留时间的备份，最新的备份始终保留
+func (s *BackupService) prune(now time.Time) error {
+	backups, err := s.ListBackups()
+	if err != nil {
+		return err
+	}
+	for i, b := range backups {
+		if i == 0 {
+			continue
+		}
+		tooMany := s.opts.KeepLast > 0 && i >= s.opts.KeepLast
+		tooOld := s.opts.MaxAge > 0 && now.Sub(b.CreatedAt) > s.opts.MaxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.opts.Dir, b.Name)); err != nil {
+			return err
+		}
+		s.logger.Info("Backup pruned", zap.String("name", b.Name))
+	}
+	return nil
+}
+
+// newBackupName 生成带时间戳的备份文件名，同一秒内多次备份时追加序号
+func (s *BackupService) newBackupName(t time.Time) string {
+	base := backupPrefix + t.Format(backupTimeLayout)
+	name := base + backupExt
+	for i := 2; ; i++ {
+		if _, err := os.Stat(filepath.Join(s.opts.Dir, name)); errors.Is(err, fs.ErrNotExist) {
+			return name
+		}
+		name = fmt.Sprintf("%s-%d%s", base, i, backupExt)
+	}
+}
+
+// snapshotSQLite 使用 SQLite 在线备份 API 将当前数据库复制到 dest
+func (s *BackupService) snapshotSQLite(ctx context.Context, dest string) error {
+	srcDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	srcConn, err := srcDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	destDB, err := sql.Open("sqlite3", dest)
+	if err != nil {
+		return err
+	}
+	defer destDB.Close()
+	destConn, err := destDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+
+	err = destConn.Raw(func(destDriverConn interface{}) error {
+		return srcConn.Raw(func(srcDriverConn interface{}) error {
+			destSQLite, ok := destDriverConn.(*sqlite3.SQLiteConn)
+			srcSQLite, ok2 := srcDriverConn.(*sqlite3.SQLiteConn)
+			if !ok || !ok2 {
+				return ErrBackupUnsupported
+			}
+			backup, err := destSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return err
+			}
+			// 一次复制全部页面，保证快照对应同一时间点
+			if _, err := backup.Step(-1); err != nil {
+				backup.Finish()
+				return err
+			}
+			return backup.Finish()
+		})
+	})
+	if err != nil {
+		return err
+	}
+	// 快照沿用源库的 WAL 模式，切换回 DELETE 使快照成为不依赖 -wal、-shm 的单个文件
+	_, err = destConn.ExecContext(ctx, "PRAGMA journal_mode = DELETE")
+	return err
+}
+
+// writeBackupArchive 写入包含清单、数据库快照和笔记库文件的 zip 归档
+func writeBackupArchive(archivePath, snapshot, vaultDir string, manifest *BackupManifest) error {
+	f, err := os.Create(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	if err := addFileToZip(zw, backupDBName, snapshot); err != nil {
+		return err
+	}
+
+	// 笔记库目录不存在时只备份数据库
+	err = filepath.WalkDir(vaultDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && p == vaultDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(vaultDir, p)
+		if err != nil {
+			return err
+		}
+		manifest.VaultFiles++
+		return addFileToZip(zw, backupVaultPrefix+filepath.ToSlash(rel), p)
+	})
+	if err != nil {
+		return err
+	}
+
+	w, err := zw.Create(backupManifestName)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return err
+	}
+
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// addFileToZip 将文件写入 zip 归档
+func addFileToZip(zw *zip.Writer, name, src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	stat, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(stat)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, in)
+	return err
+}
+
+// ValidateBackup 校验备份归档：清单格式、条目路径、数据库校验和、迁移版本和数据库完整性
+func ValidateBackup(archivePath string) (*BackupManifest, error) {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrBackupNotFound
+		}
+		return nil, invalidBackup("无法打开归档: %v", err)
+	}
+	defer zr.Close()
+
+	var manifest *BackupManifest
+	var dbFile *zip.File
+	for _, f := range zr.File {
+		switch {
+		case f.Name == backupManifestName:
+			if manifest, err = readManifest(f); err != nil {
+				return nil, err
+			}
+		case f.Name == backupDBName:
+			dbFile = f
+		case strings.HasPrefix(f.Name, backupVaultPrefix):
+			if !safeArchivePath(strings.TrimPrefix(f.Name, backupVaultPrefix)) {
+				return nil, invalidBackup("不安全的文件路径 %q", f.Name)
+			}
+		default:
+			return nil, invalidBackup("未知的条目 %q", f.Name)
+		}
+	}
+	if manifest == nil || dbFile == nil {
+		return nil, invalidBackup("缺少清单或数据库快照")
+	}
+	if manifest.Format != backupFormat {
+		return nil, invalidBackup("不支持的归档格式 %d", manifest.Format)
+	}
+	if manifest.SchemaVersion > migration.Latest() {
+		return nil, invalidBackup("备份来自更新的版本（迁移版本 %d，当前最高 %d）", manifest.SchemaVersion, migration.Latest())
+	}
+
+	// 解压数据库快照到临时文件，校验和完整性检查都通过才算有效
+	tmp, err := os.CreateTemp("", "leafnote-restore-*.db")
+	if err != nil {
+		return nil, err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	if err := extractZipFile(dbFile, tmp.Name()); err != nil {
+		return nil, err
+	}
+	if err := verifySnapshot(tmp.Name(), manifest.DatabaseSHA256); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// RestoreBackup 校验备份后替换数据库文件和笔记库目录
+// 必须在服务停止、数据库未打开时执行；被替换的文件保留为 *.pre-restore-<时间戳>
+func RestoreBackup(archivePath string, opts BackupOptions) (*BackupManifest, error) {
+	manifest, err := ValidateBackup(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	// 先解压到目标位置旁的临时路径，全部成功后再替换
+	suffix := ".restore-" + time.Now().Format(backupTimeLayout)
+	newDB := opts.DBPath + suffix
+	newVault := strings.TrimSuffix(opts.VaultDir, string(filepath.Separator)) + suffix
+	defer os.Remove(newDB)
+	defer os.RemoveAll(newVault)
+
+	if err := os.MkdirAll(newVault, 0755); err != nil {
+		return nil, err
+	}
+	for _, f := range zr.File {
+		switch {
+		case f.Name == backupDBName:
+			err = extractZipFile(f, newDB)
+		case strings.HasPrefix(f.Name, backupVaultPrefix) && !f.FileInfo().IsDir():
+			rel := filepath.FromSlash(strings.TrimPrefix(f.Name, backupVaultPrefix))
+			err = extractZipFile(f, filepath.Join(newVault, rel))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := verifySnapshot(newDB, manifest.DatabaseSHA256); err != nil {
+		return nil, err
+	}
+
+	aside := ".pre-restore-" + time.Now().Format(backupTimeLayout)
+	// WAL 和共享内存文件属于旧数据库，随旧数据库一起移走
+	for _, ext := range []string{"", "-wal", "-shm"} {
+		if err := moveAside(opts.DBPath+ext, opts.DBPath+ext+aside); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.Rename(newDB, opts.DBPath); err != nil {
+		return nil, err
+	}
+	if err := moveAside(opts.VaultDir, strings.TrimSuffix(opts.VaultDir, string(filepath.Separator))+aside); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(newVault, opts.VaultDir); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// verifySnapshot 校验数据库快照的校验和与完整性
+func verifySnapshot(p, wantSHA256 string) error {
+	sum, err := fileSHA256(p)
+	if err != nil {
+		return err
+	}
+	if sum != wantSHA256 {
+		return invalidBackup("数据库快照校验和不匹配")
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+p+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	var result string
+	if err := db.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return invalidBackup("无法读取数据库快照: %v", err)
+	}
+	if result != "ok" {
+		return invalidBackup("数据库快照完整性检查未通过: %s", result)
+	}
+	return nil
+}
+
+// readManifest 读取归档清单
+func readManifest(f *zip.File) (*BackupManifest, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var manifest BackupManifest
+	if err := json.NewDecoder(r).Decode(&manifest); err != nil {
+		return nil, invalidBackup("清单格式错误: %v", err)
+	}
+	return &manifest, nil
+}
+
+// extractZipFile 将归档条目解压到 dest
+func extractZipFile(f *zip.File, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	r, err := f.Open()
+	if err != nil {
+		return invalidBackup("无法读取条目 %q: %v", f.Name, err)
+	}
+	defer r.Close()
+
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return invalidBackup("解压条目 %q 失败: %v", f.Name, err)
+	}
+	return out.Close()
+}
+
+// moveAside 将文件或目录重命名，源不存在时忽略
+func moveAside(src, dest string) error {
+	if err := os.Rename(src, dest); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// safeArchivePath 判断归档内的相对路径不会逃出目标目录
+func safeArchivePath(p string) bool {
+	if p == "" || strings.Contains(p, `\`) || path.IsAbs(p) {
+		return false
+	}
+	clean := path.Clean(p)
+	return clean == strings.TrimSuffix(p, "/") && clean != ".." && !strings.HasPrefix(clean, "../")
+}
+
+// invalidBackup 创建带具体原因的备份无效错误
+func invalidBackup(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrBackupInvalid, fmt.Sprintf(format, args...))
+}
+
+// backupInfo 读取备份文件信息，创建时间取自文件名中的时间戳
+func backupInfo(p string) (*BackupInfo, error) {
+	stat, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	name := filepath.Base(p)
+	createdAt := stat.ModTime()
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), backupExt)
+	if len(stamp) >= len(backupTimeLayout) {
+		if t, err := time.ParseInLocation(backupTimeLayout, stamp[:len(backupTimeLayout)], time.Local); err == nil {
+			createdAt = t
+		}
+	}
+	return &BackupInfo{Name: name, Size: stat.Size(), CreatedAt: createdAt}, nil
+}
+
+// backupSeq 返回同一秒内备份的序号，没有序号的为 1
+func backupSeq(name string) int {
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), backupExt)
+	if len(stamp) <= len(backupTimeLayout)+1 {
+		return 1
+	}
+	seq, err := strconv.Atoi(stamp[len(backupTimeLayout)+1:])
+	if err != nil {
+		return 1
+	}
+	return seq
+}
+
+// isBackupName 判断文件名是否为备份归档
+func isBackupName(name string) bool {
+	return strings.HasPrefix(name, backupPrefix) && strings.HasSuffix(name, backupExt)
+}
+
+// fileSHA256 计算文件的 SHA-256
+func fileSHA256(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"leafnote/internal/migration"
+	"leafnote/internal/model"
+)
+
+// setupBackupTest 创建基于文件的数据库和笔记库目录
+func setupBackupTest(t *testing.T) (*gorm.DB, BackupOptions) {
+	dir := t.TempDir()
+	opts := BackupOptions{
+		Dir:      filepath.Join(dir, "backups"),
+		DBPath:   filepath.Join(dir, "leafnote.db"),
+		VaultDir: filepath.Join(dir, "vault"),
+	}
+
+	db, err := gorm.Open(sqlite.Open(opts.DBPath), &gorm.Config{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	_, err = migration.New(db, zap.NewNop()).Up()
+	require.NoError(t, err)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(opts.VaultDir, "assets"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(opts.VaultDir, "assets", "a.txt"), []byte("attachment"), 0644))
+	return db, opts
+}
+
+func TestBackupService_CreateAndRestore(t *testing.T) {
+	db, opts := setupBackupTest(t)
+	s := NewBackupService(db, zap.NewNop(), opts)
+	ctx := context.Background()
+
+	require.NoError(t, db.Create(&model.Note{Title: "备份前", FilePath: "/a.md", Checksum: "x"}).Error)
+
+	backup, err := s.CreateBackup(ctx)
+	require.NoError(t, err)
+	assert.Positive(t, backup.Size)
+
+	manifest, err := ValidateBackup(filepath.Join(opts.Dir, backup.Name))
+	require.NoError(t, err)
+	assert.Equal(t, migration.Latest(), manifest.SchemaVersion)
+	assert.Equal(t, 1, manifest.VaultFiles)
+
+	// 备份之后的修改在恢复后消失
+	require.NoError(t, db.Create(&model.Note{Title: "备份后", FilePath: "/b.md", Checksum: "x"}).Error)
+	require.NoError(t, os.WriteFile(filepath.Join(opts.VaultDir, "new.txt"), []byte("new"), 0644))
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+
+	_, err = RestoreBackup(filepath.Join(opts.Dir, backup.Name), opts)
+	require.NoError(t, err)
+
+	restored, err := gorm.Open(sqlite.Open(opts.DBPath), &gorm.Config{})
+	require.NoError(t, err)
+	defer func() {
+		if sqlDB, err := restored.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}()
+	var titles []string
+	require.NoError(t, restored.Model(&model.Note{}).Pluck("title", &titles).Error)
+	assert.Equal(t, []string{"备份前"}, titles)
+
+	content, err := os.ReadFile(filepath.Join(opts.VaultDir, "assets", "a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "attachment", string(content))
+	assert.NoFileExists(t, filepath.Join(opts.VaultDir, "new.txt"))
+
+	// 被替换的文件保留一份
+	aside, err := filepath.Glob(opts.DBPath + ".pre-restore-*")
+	require.NoError(t, err)
+	assert.Len(t, aside, 1)
+}
+
+func TestBackupService_Retention(t *testing.T) {
+	db, opts := setupBackupTest(t)
+	opts.KeepLast = 2
+	s := NewBackupService(db, zap.NewNop(), opts)
+
+	var names []string
+	for i := 0; i < 3; i++ {
+		backup, err := s.CreateBackup(context.Background())
+		require.NoError(t, err)
+		names = append(names, backup.Name)
+	}
+
+	backups, err := s.ListBackups()
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	assert.Equal(t, names[2], backups[0].Name)
+	assert.Equal(t, names[1], backups[1].Name)
+
+	// 超过保留时间的备份被删除，最新的备份始终保留
+	s.opts.MaxAge = time.Minute
+	require.NoError(t, s.prune(time.Now().Add(time.Hour)))
+	backups, err = s.ListBackups()
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	assert.Equal(t, names[2], backups[0].Name)
+}
+
+func TestValidateBackup_Invalid(t *testing.T) {
+	db, opts := setupBackupTest(t)
+	s := NewBackupService(db, zap.NewNop(), opts)
+	backup, err := s.CreateBackup(context.Background())
+	require.NoError(t, err)
+	valid := filepath.Join(opts.Dir, backup.Name)
+
+	// rewrite 复制有效备份，按需替换或追加条目
+	rewrite := func(t *testing.T, replace map[string]string) string {
+		src, err := zip.OpenReader(valid)
+		require.NoError(t, err)
+		defer src.Close()
+
+		p := filepath.Join(t.TempDir(), "bad.zip")
+		f, err := os.Create(p)
+		require.NoError(t, err)
+		zw := zip.NewWriter(f)
+		for _, entry := range src.File {
+			if _, ok := replace[entry.Name]; ok {
+				continue
+			}
+			require.NoError(t, zw.Copy(entry))
+		}
+		for name, content := range replace {
+			w, err := zw.Create(name)
+			require.NoError(t, err)
+			_, err = w.Write([]byte(content))
+			require.NoError(t, err)
+		}
+		require.NoError(t, zw.Close())
+		require.NoError(t, f.Close())
+		return p
+	}
+
+	tests := []struct {
+		name    string
+		replace map[string]string
+	}{
+		{name: "数据库被篡改", replace: map[string]string{backupDBName: "not a database"}},
+		{name: "路径穿越", replace: map[string]string{"vault/../../evil.txt": "x"}},
+		{name: "清单格式错误", replace: map[string]string{backupManifestName: "{"}},
+		{name: "来自更新的版本", replace: map[string]string{backupManifestName: `{"format": 1, "schema_version": 9999}`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateBackup(rewrite(t, tt.replace))
+			assert.ErrorIs(t, err, ErrBackupInvalid)
+		})
+	}
+
+	_, err = ValidateBackup(filepath.Join(opts.Dir, "missing.zip"))
+	assert.ErrorIs(t, err, ErrBackupNotFound)
+}
@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -22,8 +24,8 @@ func NewCategoryService(db *gorm.DB) *CategoryService {
 
 // CreateCategory 创建目录
 func (s *CategoryService) CreateCategory(ctx context.Context, category *model.Category) error {
-	if category.Name == "" {
-		return errors.New("目录名称不能为空")
+	if fe := validateCategoryName("name", category.Name); fe != nil {
+		return newValidationError([]FieldError{*fe})
 	}
 
 	// 检查同级目录下是否存在同名目录
@@ -38,7 +40,7 @@ func (s *CategoryService) CreateCategory(ctx context.Context, category *model.Ca
 		var parent model.Category
 		if err := s.db.First(&parent, "id = ?", *category.ParentID).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return errors.New("父目录不存在")
+				return ErrCategoryParentNotFound
 			}
 			return err
 		}
@@ -55,7 +57,7 @@ func (s *CategoryService) CreateCategory(ctx context.Context, category *model.Ca
 		return err
 	}
 	if count > 0 {
-		return errors.New("同级目录下已存在同名目录")
+		return ErrCategoryNameExists
 	}
 
 	// 检查路径是否已存在（使用 Unscoped 忽略软删除）
@@ -64,12 +66,17 @@ func (s *CategoryService) CreateCategory(ctx context.Context, category *model.Ca
 		return err
 	}
 	if count > 0 {
-		return errors.New("目录路径已存在")
+		return ErrCategoryPathExists
 	}
 
 	// 生成UUID
 	category.BaseModel.ID = uuid.New().String()
-	return s.db.Create(category).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(category).Error; err != nil {
+			return err
+		}
+		return recordEvent(tx, model.EventCategoryCreated, category.ID, 0)
+	})
 }
 
 // GetCategoryByID 根据ID获取目录
@@ -78,7 +85,7 @@ func (s *CategoryService) GetCategoryByID(ctx context.Context, id string) (*mode
 	err := s.db.Preload("Children").Preload("Notes").First(&category, "id = ?", id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("目录不存在")
+			return nil, ErrCategoryNotFound
 		}
 		return nil, err
 	}
@@ -126,17 +133,17 @@ func (s *CategoryService) loadChildren(ctx context.Context, category *model.Cate
 // UpdateCategory 更新目录
 func (s *CategoryService) UpdateCategory(ctx context.Context, category *model.Category) error {
 	if category.BaseModel.ID == "" {
-		return errors.New("目录ID不能为空")
+		return ErrCategoryIDRequired
 	}
-	if category.Name == "" {
-		return errors.New("目录名称不能为空")
+	if fe := validateCategoryName("name", category.Name); fe != nil {
+		return newValidationError([]FieldError{*fe})
 	}
 
 	// 获取原始目录信息
 	var oldCategory model.Category
 	if err := s.db.First(&oldCategory, "id = ?", category.BaseModel.ID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("目录不存在")
+			return ErrCategoryNotFound
 		}
 		return err
 	}
@@ -144,12 +151,12 @@ func (s *CategoryService) UpdateCategory(ctx context.Context, category *model.Ca
 	// 如果有父目录ID，检查父目录是否存在且不能是自己
 	if category.ParentID != nil {
 		if *category.ParentID == category.BaseModel.ID {
-			return errors.New("父目录不能是自己")
+			return ErrCategoryParentSelf
 		}
 		var parent model.Category
 		if err := s.db.First(&parent, "id = ?", *category.ParentID).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return errors.New("父目录不存在")
+				return ErrCategoryParentNotFound
 			}
 			return err
 		}
@@ -177,34 +184,48 @@ func (s *CategoryService) UpdateCategory(ctx context.Context, category *model.Ca
 			return err
 		}
 		if count > 0 {
-			return errors.New("目录路径已存在")
+			return ErrCategoryPathExists
 		}
+	}
 
+	return s.db.Transaction(func(tx *gorm.DB) error {
 		// 更新所有子目录的路径
-		if err := s.updateChildrenPaths(oldCategory.Path, category.Path); err != nil {
+		if category.Path != oldCategory.Path {
+			if err := updateChildrenPaths(tx, oldCategory.Path, category.Path); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(category).Updates(map[string]interface{}{
+			"name":      category.Name,
+			"parent_id": category.ParentID,
+			"path":      category.Path,
+		}).Error; err != nil {
 			return err
 		}
-	}
-
-	return s.db.Model(category).Updates(map[string]interface{}{
-		"name":      category.Name,
-		"parent_id": category.ParentID,
-		"path":      category.Path,
-	}).Error
+		return recordEvent(tx, model.EventCategoryUpdated, category.ID, 0)
+	})
 }
 
-// updateChildrenPaths 更新所有子目录的路径
-func (s *CategoryService) updateChildrenPaths(oldParentPath, newParentPath string) error {
+// updateChildrenPaths 更新所有子目录的路径，每个子目录记录一条修改事件
+func updateChildrenPaths(tx *gorm.DB, oldParentPath, newParentPath string) error {
 	// 查找所有以oldParentPath开头的目录
+	prefix := oldParentPath + "/"
 	var categories []model.Category
-	if err := s.db.Where("path LIKE ?", oldParentPath+"/%").Find(&categories).Error; err != nil {
+	if err := tx.Where(likeCondition("path"), escapeLike(prefix)+"%").Find(&categories).Error; err != nil {
 		return err
 	}
 
 	// 更新每个子目录的路径
 	for _, category := range categories {
+		// LIKE 不区分大小写，这里再按字节精确过滤
+		if !strings.HasPrefix(category.Path, prefix) {
+			continue
+		}
 		newPath := newParentPath + category.Path[len(oldParentPath):]
-		if err := s.db.Model(&category).Update("path", newPath).Error; err != nil {
+		if err := tx.Model(&category).Update("path", newPath).Error; err != nil {
+			return err
+		}
+		if err := recordEvent(tx, model.EventCategoryUpdated, category.ID, 0); err != nil {
 			return err
 		}
 	}
@@ -212,7 +233,7 @@ func (s *CategoryService) updateChildrenPaths(oldParentPath, newParentPath strin
 	return nil
 }
 
-// DeleteCategory 删除目录
+// DeleteCategory 删除目录，目录下存在子目录或笔记时拒绝删除
 func (s *CategoryService) DeleteCategory(ctx context.Context, id string) error {
 	// 开启事务
 	return s.db.Transaction(func(tx *gorm.DB) error {
@@ -220,48 +241,62 @@ func (s *CategoryService) DeleteCategory(ctx context.Context, id string) error {
 		var category model.Category
 		if err := tx.First(&category, "id = ?", id).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return errors.New("目录不存在")
+				return ErrCategoryNotFound
 			}
 			return err
 		}
 
-		// 递归删除所有子目录及其笔记
-		if err := s.recursiveDelete(tx, id); err != nil {
+		// 检查是否有子目录
+		var count int64
+		if err := tx.Model(&model.Category{}).Where("parent_id = ?", id).Count(&count).Error; err != nil {
 			return err
 		}
+		if count > 0 {
+			return ErrCategoryHasChildren
+		}
+
+		// 检查目录下是否有笔记
+		if err := tx.Model(&model.Note{}).Where("category_id = ?", id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrCategoryHasNotes
+		}
 
 		// 删除当前目录
 		if err := tx.Unscoped().Delete(&category).Error; err != nil {
 			return err
 		}
-
-		return nil
+		return recordEvent(tx, model.EventCategoryDeleted, category.ID, 0)
 	})
 }
 
-// recursiveDelete 递归删除目录及其所有子目录和笔记
-func (s *CategoryService) recursiveDelete(tx *gorm.DB, categoryID string) error {
-	// 获取所有子目录
-	var children []model.Category
-	if err := tx.Where("parent_id = ?", categoryID).Find(&children).Error; err != nil {
-		return err
-	}
-
-	// 递归删除每个子目录
-	for _, child := range children {
-		if err := s.recursiveDelete(tx, child.ID); err != nil {
-			return err
+// ensureCategoryPath 在 rootID 目录（路径为 rootPath，为空表示根目录）下按文件夹路径（例如 /日记/2026）
+// 逐级查找或创建目录，返回最末一级目录的ID，dir 为空时返回 rootID；cache 按完整路径缓存目录ID，可为 nil
+func ensureCategoryPath(ctx context.Context, db *gorm.DB, rootID *string, rootPath, dir string, cache map[string]*string) (*string, error) {
+	parentID, fullPath := rootID, rootPath
+	for _, name := range strings.Split(strings.Trim(dir, "/"), "/") {
+		if name == "" || name == "." {
+			continue
 		}
-		// 删除子目录
-		if err := tx.Unscoped().Delete(&child).Error; err != nil {
-			return err
+		fullPath += "/" + name
+		if id, ok := cache[fullPath]; ok {
+			parentID = id
+			continue
 		}
+		var category model.Category
+		err := db.WithContext(ctx).Where("path = ?", fullPath).Take(&category).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			category = model.Category{Name: name, ParentID: parentID}
+			err = NewCategoryService(db.WithContext(ctx)).CreateCategory(ctx, &category)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("创建目录 %s 失败: %w", fullPath, err)
+		}
+		if cache != nil {
+			cache[fullPath] = &category.ID
+		}
+		parentID = &category.ID
 	}
-
-	// 删除当前目录下的所有笔记
-	if err := tx.Unscoped().Where("category_id = ?", categoryID).Delete(&model.Note{}).Error; err != nil {
-		return err
-	}
-
-	return nil
+	return parentID, nil
 }
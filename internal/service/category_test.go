@@ -5,7 +5,6 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
 	"leafnote/internal/model"
@@ -13,14 +12,7 @@ import (
 )
 
 func setupCategoryTestDB(t *testing.T) *gorm.DB {
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	assert.NoError(t, err)
-
-	// 自动迁移
-	err = db.AutoMigrate(&model.Category{}, &model.Note{})
-	assert.NoError(t, err)
-
-	return db
+	return testutil.NewTestDB(t)
 }
 
 func TestCategoryService_CreateCategory(t *testing.T) {
@@ -296,40 +288,40 @@ func TestCategoryService_DeleteCategory(t *testing.T) {
 	tests := []struct {
 		name    string
 		id      string
-		wantErr bool
+		wantErr error
 	}{
 		{
 			name:    "删除失败-存在子目录",
 			id:      parent.BaseModel.ID,
-			wantErr: true,
+			wantErr: ErrCategoryHasChildren,
 		},
 		{
 			name:    "删除失败-存在笔记",
 			id:      categoryWithNote.BaseModel.ID,
-			wantErr: true,
+			wantErr: ErrCategoryHasNotes,
 		},
 		{
 			name:    "删除成功-子目录",
 			id:      child.BaseModel.ID,
-			wantErr: false,
+			wantErr: nil,
 		},
 		{
 			name:    "删除成功-父目录",
 			id:      parent.BaseModel.ID,
-			wantErr: false,
+			wantErr: nil,
 		},
 		{
 			name:    "目录不存在",
 			id:      "not-exist",
-			wantErr: true,
+			wantErr: ErrCategoryNotFound,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			err := s.DeleteCategory(ctx, tt.id)
-			if tt.wantErr {
-				assert.Error(t, err)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
 			} else {
 				assert.NoError(t, err)
 				// 验证删除结果
@@ -339,3 +331,49 @@ func TestCategoryService_DeleteCategory(t *testing.T) {
 		})
 	}
 }
+
+func TestCategoryService_UpdateCategory_ChildrenPaths(t *testing.T) {
+	db := setupCategoryTestDB(t)
+	s := NewCategoryService(db)
+	ctx := context.Background()
+
+	// a_b 中的下划线不能被当作 LIKE 通配符，axb 下的目录不受影响
+	target := &model.Category{Name: "a_b"}
+	assert.NoError(t, s.CreateCategory(ctx, target))
+	other := &model.Category{Name: "axb"}
+	assert.NoError(t, s.CreateCategory(ctx, other))
+	child := &model.Category{Name: "child", ParentID: &target.ID}
+	assert.NoError(t, s.CreateCategory(ctx, child))
+	otherChild := &model.Category{Name: "child", ParentID: &other.ID}
+	assert.NoError(t, s.CreateCategory(ctx, otherChild))
+
+	err := s.UpdateCategory(ctx, &model.Category{BaseModel: model.BaseModel{ID: target.ID}, Name: "renamed"})
+	assert.NoError(t, err)
+
+	got, err := s.GetCategoryByID(ctx, child.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "/renamed/child", got.Path)
+
+	got, err = s.GetCategoryByID(ctx, otherChild.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "/axb/child", got.Path)
+}
+
+func TestCategoryService_DeleteCategory_TrashedNotes(t *testing.T) {
+	db := setupCategoryTestDB(t)
+	s := NewCategoryService(db)
+	ctx := context.Background()
+
+	category := &model.Category{Name: "目录"}
+	assert.NoError(t, s.CreateCategory(ctx, category))
+	note := &model.Note{Title: "已删除的笔记", FilePath: "/a.md", CategoryID: &category.ID}
+	assert.NoError(t, db.Create(note).Error)
+	assert.NoError(t, db.Delete(note).Error)
+
+	// 只有软删除的笔记时允许删除目录，外键将笔记的所属目录置空
+	assert.NoError(t, s.DeleteCategory(ctx, category.ID))
+
+	var got model.Note
+	assert.NoError(t, db.Unscoped().First(&got, "id = ?", note.ID).Error)
+	assert.Nil(t, got.CategoryID)
+}
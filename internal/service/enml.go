@@ -0,0 +1,282 @@
+package service
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// enmlNode ENML 文档节点，name 为空时为文本节点
+type enmlNode struct {
+	name     string
+	attrs    map[string]string
+	text     string
+	children []*enmlNode
+}
+
+// parseENML 解析 ENML（Evernote 使用的 XHTML 子集），兼容 HTML 实体和未闭合的标签
+func parseENML(content string) (*enmlNode, error) {
+	dec := xml.NewDecoder(strings.NewReader(content))
+	dec.Strict = false
+	dec.AutoClose = xml.HTMLAutoClose
+	dec.Entity = xml.HTMLEntity
+
+	root := &enmlNode{name: "#root"}
+	stack := []*enmlNode{root}
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ENML 格式错误: %w", err)
+		}
+		parent := stack[len(stack)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			node := &enmlNode{name: strings.ToLower(t.Name.Local), attrs: make(map[string]string, len(t.Attr))}
+			for _, a := range t.Attr {
+				node.attrs[strings.ToLower(a.Name.Local)] = a.Value
+			}
+			parent.children = append(parent.children, node)
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			parent.children = append(parent.children, &enmlNode{text: string(t)})
+		}
+	}
+	return root, nil
+}
+
+// enmlResource ENEX 中的附件，键为内容的 MD5
+type enmlResource struct {
+	Name string // 文件名
+	Mime string // MIME 类型
+	Path string // 保存后的路径，为空表示未保存
+}
+
+// enmlConverter 将 ENML 转换为 Markdown
+type enmlConverter struct {
+	resources map[string]enmlResource
+	missing   int // 找不到的附件数
+}
+
+var (
+	enmlSpaces     = regexp.MustCompile(`[ \t\r\n]+`)
+	enmlBlankLines = regexp.MustCompile(`\n{3,}`)
+	enmlTrailing   = regexp.MustCompile(`[ \t]+\n`)
+)
+
+// convertENML 将 ENML 转换为 Markdown，resources 用于解析 en-media 引用的附件
+func convertENML(content string, resources map[string]enmlResource) (string, int, error) {
+	root, err := parseENML(content)
+	if err != nil {
+		return "", 0, err
+	}
+	c := &enmlConverter{resources: resources}
+	md := c.children(root)
+	md = enmlTrailing.ReplaceAllString(md, "\n")
+	md = enmlBlankLines.ReplaceAllString(md, "\n\n")
+	return strings.TrimSpace(md) + "\n", c.missing, nil
+}
+
+// children 依次转换子节点
+func (c *enmlConverter) children(n *enmlNode) string {
+	var b strings.Builder
+	for _, child := range n.children {
+		b.WriteString(c.node(child))
+	}
+	return b.String()
+}
+
+// node 转换单个节点
+func (c *enmlConverter) node(n *enmlNode) string {
+	if n.name == "" {
+		return enmlSpaces.ReplaceAllString(n.text, " ")
+	}
+
+	switch n.name {
+	case "br":
+		return "\n"
+	case "hr":
+		return "\n\n---\n\n"
+	case "p":
+		return "\n\n" + strings.TrimSpace(c.children(n)) + "\n\n"
+	case "div":
+		if strings.Contains(n.attrs["style"], "-en-codeblock") {
+			return codeBlock(enmlText(n))
+		}
+		return "\n" + strings.TrimSpace(c.children(n)) + "\n"
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level, _ := strconv.Atoi(n.name[1:])
+		return "\n\n" + strings.Repeat("#", level) + " " + strings.TrimSpace(c.children(n)) + "\n\n"
+	case "b", "strong":
+		return wrapInline(c.children(n), "**")
+	case "i", "em":
+		return wrapInline(c.children(n), "*")
+	case "s", "strike", "del":
+		return wrapInline(c.children(n), "~~")
+	case "code":
+		return wrapInline(enmlText(n), "`")
+	case "pre":
+		return codeBlock(enmlText(n))
+	case "a":
+		text := strings.TrimSpace(c.children(n))
+		href := n.attrs["href"]
+		if href == "" {
+			return text
+		}
+		if text == "" {
+			text = href
+		}
+		return "[" + text + "](" + href + ")"
+	case "img":
+		return "![" + n.attrs["alt"] + "](" + n.attrs["src"] + ")"
+	case "en-todo":
+		// en-todo 和 en-media 在非严格模式下可能包含本应是兄弟节点的内容
+		mark := "- [ ] "
+		if n.attrs["checked"] == "true" {
+			mark = "- [x] "
+		}
+		return mark + c.children(n)
+	case "en-media":
+		return c.media(n) + c.children(n)
+	case "ul", "ol":
+		return "\n\n" + c.list(n) + "\n\n"
+	case "blockquote":
+		inner := strings.TrimSpace(c.children(n))
+		return "\n\n> " + strings.ReplaceAll(inner, "\n", "\n> ") + "\n\n"
+	case "table":
+		return "\n\n" + c.table(n) + "\n\n"
+	case "script", "style", "title", "head":
+		return ""
+	}
+	return c.children(n)
+}
+
+// media 将 en-media 转换为附件链接，图片使用图片语法
+func (c *enmlConverter) media(n *enmlNode) string {
+	res, ok := c.resources[strings.ToLower(n.attrs["hash"])]
+	if !ok || res.Path == "" {
+		c.missing++
+		return ""
+	}
+	name := res.Name
+	if name == "" {
+		name = "附件"
+	}
+	if strings.HasPrefix(res.Mime, "image/") || strings.HasPrefix(n.attrs["type"], "image/") {
+		return "![" + name + "](" + res.Path + ")"
+	}
+	return "[" + name + "](" + res.Path + ")"
+}
+
+// list 转换列表，嵌套内容按列表标记宽度缩进
+func (c *enmlConverter) list(n *enmlNode) string {
+	var lines []string
+	index := 1
+	for _, item := range n.children {
+		if item.name != "li" {
+			continue
+		}
+		mark := "- "
+		if n.name == "ol" {
+			mark = strconv.Itoa(index) + ". "
+			index++
+		}
+		inner := strings.TrimSpace(enmlBlankLines.ReplaceAllString(c.children(item), "\n\n"))
+		indent := strings.Repeat(" ", len(mark))
+		inner = strings.ReplaceAll(inner, "\n", "\n"+indent)
+		lines = append(lines, mark+inner)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// table 转换为 GFM 表格，第一行作为表头
+func (c *enmlConverter) table(n *enmlNode) string {
+	var rows [][]string
+	var collect func(*enmlNode)
+	collect = func(node *enmlNode) {
+		for _, child := range node.children {
+			if child.name != "tr" {
+				collect(child)
+				continue
+			}
+			var row []string
+			for _, cell := range child.children {
+				if cell.name == "td" || cell.name == "th" {
+					text := strings.TrimSpace(c.children(cell))
+					text = strings.ReplaceAll(strings.ReplaceAll(text, "\n", " "), "|", `\|`)
+					row = append(row, text)
+				}
+			}
+			rows = append(rows, row)
+		}
+	}
+	collect(n)
+	return markdownTable(rows)
+}
+
+// markdownTable 生成 GFM 表格，第一行作为表头，列数不足的行补空单元格
+func markdownTable(rows [][]string) string {
+	if len(rows) == 0 {
+		return ""
+	}
+	cols := 0
+	for _, row := range rows {
+		cols = max(cols, len(row))
+	}
+	if cols == 0 {
+		return ""
+	}
+	line := func(cells []string) string {
+		padded := make([]string, cols)
+		copy(padded, cells)
+		return "| " + strings.Join(padded, " | ") + " |"
+	}
+	lines := []string{line(rows[0]), "|" + strings.Repeat(" --- |", cols)}
+	for _, row := range rows[1:] {
+		lines = append(lines, line(row))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// enmlText 返回节点中的原始文本，div 和 br 转换为换行，用于代码块
+func enmlText(n *enmlNode) string {
+	if n.name == "" {
+		return n.text
+	}
+	if n.name == "br" {
+		return "\n"
+	}
+	var b strings.Builder
+	for _, child := range n.children {
+		b.WriteString(enmlText(child))
+	}
+	if n.name == "div" || n.name == "p" {
+		return b.String() + "\n"
+	}
+	return b.String()
+}
+
+// wrapInline 用 mark 包裹行内文本，首尾空白保留在标记之外
+func wrapInline(text, mark string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	start := strings.Index(text, trimmed)
+	return text[:start] + mark + trimmed + mark + text[start+len(trimmed):]
+}
+
+// codeBlock 生成围栏代码块
+func codeBlock(text string) string {
+	return "\n\n```\n" + strings.Trim(text, "\n") + "\n```\n\n"
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrorKind 业务错误类别，决定对外暴露的 HTTP 状态码
+type ErrorKind string
+
+const (
+	KindValidation  ErrorKind = "validation"   // 请求参数不合法
+	KindNotFound    ErrorKind = "not_found"    // 资源不存在
+	KindConflict    ErrorKind = "conflict"     // 资源冲突（重复、存在依赖等）
+	KindRateLimited ErrorKind = "rate_limited" // 请求过于频繁
+	KindInternal    ErrorKind = "internal"     // 内部错误
+)
+
+// Error 业务错误，携带错误类别和机器可读的错误码
+type Error struct {
+	Kind    ErrorKind    // 错误类别
+	Code    string       // 机器可读的错误码，例如 NOTE_NOT_FOUND
+	Message string       // 面向用户的错误信息
+	Fields  []FieldError // 字段级错误，仅校验错误携带
+}
+
+// Error 实现 error 接口
+func (e *Error) Error() string {
+	if len(e.Fields) == 0 {
+		return e.Message
+	}
+	details := make([]string, 0, len(e.Fields))
+	for _, fe := range e.Fields {
+		details = append(details, fe.Field+": "+fe.Code)
+	}
+	return e.Message + ": " + strings.Join(details, ", ")
+}
+
+// Is 按错误码判断是否为同一类业务错误
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// newError 创建业务错误
+func newError(kind ErrorKind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// newValidationError 创建携带字段级错误的校验错误
+func newValidationError(fields []FieldError) *Error {
+	return &Error{
+		Kind:    KindValidation,
+		Code:    ErrValidationFailed.Code,
+		Message: ErrValidationFailed.Message,
+		Fields:  fields,
+	}
+}
+
+// AsError 从错误链中提取业务错误
+func AsError(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
+// 通用错误
+var (
+	ErrInvalidParams    = newError(KindValidation, "INVALID_PARAMS", "无效的请求参数")
+	ErrValidationFailed = newError(KindValidation, "VALIDATION_FAILED", "参数校验失败")
+	ErrRateLimited      = newError(KindRateLimited, "RATE_LIMITED", "请求过于频繁，请稍后再试")
+	ErrInternal         = newError(KindInternal, "INTERNAL_ERROR", "服务器内部错误")
+)
+
+// 笔记相关错误
+var (
+	ErrNoteNotFound        = newError(KindNotFound, "NOTE_NOT_FOUND", "笔记不存在")
+	ErrNoteFilePathExists  = newError(KindConflict, "NOTE_FILE_PATH_EXISTS", "文件路径已存在")
+	ErrNoteIDExists        = newError(KindConflict, "NOTE_ID_EXISTS", "笔记ID已存在")
+	ErrNoteVersionConflict = newError(KindConflict, "NOTE_VERSION_CONFLICT", "笔记已被修改，请刷新后重试")
+)
+
+// 标签相关错误
+var (
+	ErrTagNotFound       = newError(KindNotFound, "TAG_NOT_FOUND", "标签不存在")
+	ErrTagIDRequired     = newError(KindValidation, "TAG_ID_REQUIRED", "标签ID不能为空")
+	ErrTagParentNotFound = newError(KindNotFound, "TAG_PARENT_NOT_FOUND", "父标签不存在")
+	ErrTagParentSelf     = newError(KindValidation, "TAG_PARENT_SELF", "父标签不能是自己")
+	ErrTagHasChildren    = newError(KindConflict, "TAG_HAS_CHILDREN", "请先删除子标签")
+)
+
+// 目录相关错误
+var (
+	ErrCategoryNotFound       = newError(KindNotFound, "CATEGORY_NOT_FOUND", "目录不存在")
+	ErrCategoryIDRequired     = newError(KindValidation, "CATEGORY_ID_REQUIRED", "目录ID不能为空")
+	ErrCategoryParentNotFound = newError(KindNotFound, "CATEGORY_PARENT_NOT_FOUND", "父目录不存在")
+	ErrCategoryParentSelf     = newError(KindValidation, "CATEGORY_PARENT_SELF", "父目录不能是自己")
+	ErrCategoryNameExists     = newError(KindConflict, "CATEGORY_NAME_EXISTS", "同级目录下已存在同名目录")
+	ErrCategoryPathExists     = newError(KindConflict, "CATEGORY_PATH_EXISTS", "目录路径已存在")
+	ErrCategoryHasChildren    = newError(KindConflict, "CATEGORY_HAS_CHILDREN", "请先删除子目录")
+	ErrCategoryHasNotes       = newError(KindConflict, "CATEGORY_HAS_NOTES", "请先删除目录下的笔记")
+)
+
+// 备份相关错误
+var (
+	ErrBackupNotFound    = newError(KindNotFound, "BACKUP_NOT_FOUND", "备份不存在")
+	ErrBackupInvalid     = newError(KindValidation, "BACKUP_INVALID", "备份文件无效")
+	ErrBackupUnsupported = newError(KindValidation, "BACKUP_UNSUPPORTED", "当前数据库不支持备份，仅支持 SQLite")
+	ErrBackupInProgress  = newError(KindConflict, "BACKUP_IN_PROGRESS", "备份正在进行中")
+)
+
+// 导出相关错误
+var (
+	ErrExportDirExists   = newError(KindConflict, "EXPORT_DIR_EXISTS", "导出目录已存在且不为空")
+	ErrExportDirDisabled = newError(KindValidation, "EXPORT_DIR_DISABLED", "未配置导出目录，只能导出为 zip")
+)
+
+// 导入相关错误
+var (
+	ErrImportFileRequired   = newError(KindValidation, "IMPORT_FILE_REQUIRED", "请上传要导入的文件")
+	ErrImportInvalidArchive = newError(KindValidation, "IMPORT_INVALID_ARCHIVE", "无法读取 zip 文件")
+	ErrImportTooLarge       = newError(KindValidation, "IMPORT_TOO_LARGE", "上传的文件过大")
+)
+
+// 附件相关错误
+var (
+	ErrAttachmentNotFound      = newError(KindNotFound, "ATTACHMENT_NOT_FOUND", "附件不存在")
+	ErrAttachmentFileRequired  = newError(KindValidation, "ATTACHMENT_FILE_REQUIRED", "请上传附件")
+	ErrAttachmentEmpty         = newError(KindValidation, "ATTACHMENT_EMPTY", "附件不能为空")
+	ErrAttachmentTooLarge      = newError(KindValidation, "ATTACHMENT_TOO_LARGE", "附件超过大小上限")
+	ErrAttachmentNotImage      = newError(KindValidation, "ATTACHMENT_NOT_IMAGE", "附件不是支持的图片格式")
+	ErrAttachmentImageTooLarge = newError(KindValidation, "ATTACHMENT_IMAGE_TOO_LARGE", "图片像素过多，无法生成缩略图")
+	ErrThumbnailSizeInvalid    = newError(KindValidation, "THUMBNAIL_SIZE_INVALID", "不支持的缩略图尺寸")
+)
+
+// 模板相关错误
+var (
+	ErrTemplateNotFound   = newError(KindNotFound, "TEMPLATE_NOT_FOUND", "模板不存在")
+	ErrTemplateNameExists = newError(KindConflict, "TEMPLATE_NAME_EXISTS", "模板名称已存在")
+)
+
+// 周期笔记相关错误
+var (
+	ErrPeriodInvalid     = newError(KindValidation, "PERIOD_INVALID", "不支持的周期，可选值：daily、weekly、monthly")
+	ErrPeriodDisabled    = newError(KindValidation, "PERIOD_DISABLED", "未配置该周期笔记的路径")
+	ErrPeriodDateInvalid = newError(KindValidation, "PERIOD_DATE_INVALID", "日期格式不正确")
+)
+
+// 待办相关错误
+var (
+	ErrTaskNotFound = newError(KindNotFound, "TASK_NOT_FOUND", "待办不存在")
+	ErrTaskOutdated = newError(KindConflict, "TASK_OUTDATED", "笔记已被修改，请刷新待办后重试")
+)
+
+// 提醒相关错误
+var (
+	ErrReminderNotFound    = newError(KindNotFound, "REMINDER_NOT_FOUND", "提醒不存在")
+	ErrReminderTimeInvalid = newError(KindValidation, "REMINDER_TIME_INVALID", "提醒时间必须晚于当前时间")
+	ErrReminderFromYAML    = newError(KindConflict, "REMINDER_FROM_YAML", "该提醒来自笔记的 YAML 元数据，请在笔记中删除")
+)
+
+// Webhook 相关错误
+var (
+	ErrWebhookNotFound         = newError(KindNotFound, "WEBHOOK_NOT_FOUND", "Webhook 不存在")
+	ErrWebhookDeliveryNotFound = newError(KindNotFound, "WEBHOOK_DELIVERY_NOT_FOUND", "投递记录不存在")
+)
+
+// 插件相关错误
+var (
+	ErrPluginNotFound = newError(KindNotFound, "PLUGIN_NOT_FOUND", "插件不存在")
+	ErrPluginDisabled = newError(KindNotFound, "PLUGIN_DISABLED", "插件未启用")
+	ErrPluginRejected = newError(KindValidation, "PLUGIN_REJECTED", "插件拒绝了该操作")
+)
+
+// 后台任务相关错误
+var (
+	ErrJobNotFound  = newError(KindNotFound, "JOB_NOT_FOUND", "任务不存在")
+	ErrJobQueueFull = newError(KindRateLimited, "JOB_QUEUE_FULL", "等待执行的任务过多，请稍后再试")
+)
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"leafnote/internal/model"
+)
+
+// eventBatchSize 每次读取事件的数量上限
+const eventBatchSize = 100
+
+// eventGapTimeout 序号出现空缺时等待的最长时间；PostgreSQL、MySQL 上并发的事务可能不按序号顺序提交，
+// 超过该时间仍未出现的序号视为事务已回滚
+const eventGapTimeout = 5 * time.Second
+
+// eventPruneInterval 清理过期事件的间隔
+const eventPruneInterval = time.Hour
+
+// EventOptions 变更事件配置
+type EventOptions struct {
+	Retention time.Duration // 事件保留时长，0 表示永久保留
+}
+
+// EventService 变更事件服务：各服务在修改数据的事务中写入事件，
+// 后台任务按序号顺序读出新提交的事件并推送给订阅者
+type EventService struct {
+	db     *gorm.DB
+	logger *zap.Logger
+	opts   EventOptions
+
+	mu          sync.Mutex
+	subscribers map[chan model.Event]struct{}
+	closed      bool
+	ready       bool   // last 是否已从数据库加载
+	last        uint64 // 已推送的最大序号，小于等于该序号的事件均已提交
+}
+
+// NewEventService 创建变更事件服务实例
+func NewEventService(db *gorm.DB, logger *zap.Logger, opts EventOptions) *EventService {
+	return &EventService{
+		db:          db,
+		logger:      logger,
+		opts:        opts,
+		subscribers: make(map[chan model.Event]struct{}),
+	}
+}
+
+// recordEvent 在 tx 中写入变更事件并为订阅了该事件的 Webhook 创建投递，与引起变更的修改一同提交或回滚；
+// version 只对笔记有意义
+func recordEvent(tx *gorm.DB, typ model.EventType, entityID string, version int) error {
+	e := &model.Event{
+		Type:       typ,
+		EntityType: typ.Entity(),
+		EntityID:   entityID,
+		Version:    version,
+	}
+	if err := tx.Create(e).Error; err != nil {
+		return err
+	}
+	return enqueueWebhookDeliveries(tx, e)
+}
+
+// Watermark 返回已推送的最大序号，首次调用时取数据库中的最大序号
+func (s *EventService) Watermark(ctx context.Context) (uint64, error) {
+	s.mu.Lock()
+	if s.ready {
+		defer s.mu.Unlock()
+		return s.last, nil
+	}
+	s.mu.Unlock()
+
+	var latest uint64
+	if err := s.db.WithContext(ctx).Model(&model.Event{}).Select("COALESCE(MAX(seq), 0)").Scan(&latest).Error; err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ready {
+		s.ready, s.last = true, latest
+	}
+	return s.last, nil
+}
+
+// Resume 确定从 since 之后继续推送的位置；since 为 nil 时从当前位置开始。
+// since 之后的事件已被清理，或 since 超过当前序号（例如从备份恢复了数据库）时返回 reset，
+// 客户端需要重新加载全部数据，之后从返回的位置继续
+func (s *EventService) Resume(ctx context.Context, since *uint64) (cursor uint64, reset bool, err error) {
+	watermark, err := s.Watermark(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	if since == nil {
+		return watermark, false, nil
+	}
+	if *since > watermark {
+		return watermark, true, nil
+	}
+	var oldest uint64
+	if err := s.db.WithContext(ctx).Model(&model.Event{}).Select("COALESCE(MIN(seq), 0)").Scan(&oldest).Error; err != nil {
+		return 0, false, err
+	}
+	if oldest > 0 && *since+1 < oldest {
+		return watermark, true, nil
+	}
+	return *since, false, nil
+}
+
+// ListEvents 按序号顺序返回 since 之后已推送的事件，最多 limit 条
+func (s *EventService) ListEvents(ctx context.Context, since uint64, limit int) ([]model.Event, error) {
+	watermark, err := s.Watermark(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var events []model.Event
+	err = s.db.WithContext(ctx).
+		Where("seq > ? AND seq <= ?", since, watermark).
+		Order("seq").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+// Subscribe 订阅新提交的事件，事件按序号顺序推送；返回的 cancel 用于取消订阅。
+// 服务关闭或接收过慢时通道被关闭，客户端应重新连接并从收到的最后一个序号继续
+func (s *EventService) Subscribe() (<-chan model.Event, func()) {
+	ch := make(chan model.Event, eventBatchSize)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	s.subscribers[ch] = struct{}{}
+	return ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.subscribers[ch]; ok {
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Close 关闭所有订阅，服务停止时调用以断开长连接
+func (s *EventService) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for ch := range s.subscribers {
+		delete(s.subscribers, ch)
+		close(ch)
+	}
+}
+
+// Schedule 返回按 interval 读取新事件并推送的后台任务，同时定期清理过期事件；单次失败只记录日志
+func (s *EventService) Schedule(interval time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var pruned time.Time
+		for {
+			if _, err := s.Poll(ctx, time.Now()); err != nil && ctx.Err() == nil {
+				s.logger.Error("Failed to poll events", zap.Error(err))
+			}
+			if s.opts.Retention > 0 && time.Since(pruned) >= eventPruneInterval {
+				pruned = time.Now()
+				if _, err := s.Prune(ctx, pruned.Add(-s.opts.Retention)); err != nil && ctx.Err() == nil {
+					s.logger.Error("Failed to prune events", zap.Error(err))
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	}
+}
+
+// Poll 读取新提交的事件并推送给订阅者，返回推送的数量。
+// 序号出现空缺时停在空缺处，等待未提交的事务，超过 eventGapTimeout 后跳过
+func (s *EventService) Poll(ctx context.Context, now time.Time) (int, error) {
+	last, err := s.Watermark(ctx)
+	if err != nil {
+		return 0, err
+	}
+	published := 0
+	for {
+		var events []model.Event
+		if err := s.db.WithContext(ctx).Where("seq > ?", last).Order("seq").Limit(eventBatchSize).Find(&events).Error; err != nil {
+			return published, err
+		}
+		for _, e := range events {
+			if e.Seq != last+1 && now.Sub(e.CreatedAt) < eventGapTimeout {
+				return published, nil
+			}
+			last = e.Seq
+			s.publish(e)
+			published++
+		}
+		if len(events) < eventBatchSize {
+			return published, nil
+		}
+	}
+}
+
+// publish 推进已推送的序号并推送给所有订阅者，接收过慢的订阅者被断开
+func (s *EventService) publish(e model.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.last = e.Seq
+	for ch := range s.subscribers {
+		select {
+		case ch <- e:
+		default:
+			s.logger.Warn("Event subscriber is too slow, disconnected", zap.Uint64("seq", e.Seq))
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Prune 删除 before 之前的事件，返回删除的数量；始终保留最新的事件，避免序号重新开始。
+// 子查询多包一层以兼容 MySQL 不允许在 DELETE 中直接查询同一张表的限制
+func (s *EventService) Prune(ctx context.Context, before time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).
+		Where("created_at < ?", before).
+		Where("seq < (SELECT max_seq FROM (SELECT MAX(seq) AS max_seq FROM events) AS latest)").
+		Delete(&model.Event{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"leafnote/internal/model"
+	"leafnote/internal/testutil"
+)
+
+func TestEventService_Record(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	ctx := context.Background()
+	s := NewEventService(db, zap.NewNop(), EventOptions{})
+	// 启动前已有的事件不再推送
+	_, err := s.Watermark(ctx)
+	require.NoError(t, err)
+	events, cancel := s.Subscribe()
+	defer cancel()
+
+	notes := NewNoteService(db, zap.NewNop())
+	note, err := notes.CreateNote(CreateNoteInput{Title: "周报", FilePath: "/周报.md", Content: "- [ ] 写周报"})
+	require.NoError(t, err)
+	require.NoError(t, notes.UpdateNote(note.ID, UpdateNoteInput{Content: "- [ ] 写周报\n- [ ] 发邮件"}))
+	tasks, err := NewTaskService(db, zap.NewNop()).ListTasks(ctx, TaskFilter{NoteID: note.ID})
+	require.NoError(t, err)
+	_, err = NewTaskService(db, zap.NewNop()).ToggleTask(ctx, tasks[0].ID, nil)
+	require.NoError(t, err)
+	require.NoError(t, notes.DeleteNote(note.ID))
+	require.NoError(t, notes.RestoreNote(note.ID))
+
+	tags := NewTagService(db)
+	tag := &model.Tag{Name: "工作"}
+	require.NoError(t, tags.CreateTag(ctx, tag))
+	tag.Name = "日常"
+	require.NoError(t, tags.UpdateTag(ctx, tag))
+	require.NoError(t, tags.DeleteTag(ctx, tag.ID))
+
+	categories := NewCategoryService(db)
+	parent := &model.Category{Name: "项目"}
+	require.NoError(t, categories.CreateCategory(ctx, parent))
+	child := &model.Category{Name: "进行中", ParentID: &parent.ID}
+	require.NoError(t, categories.CreateCategory(ctx, child))
+	parent.Name = "项目集"
+	require.NoError(t, categories.UpdateCategory(ctx, parent))
+	require.NoError(t, categories.DeleteCategory(ctx, child.ID))
+
+	// 校验失败时事务回滚，不产生事件
+	assert.Error(t, tags.CreateTag(ctx, &model.Tag{Name: ""}))
+
+	published, err := s.Poll(ctx, time.Now())
+	require.NoError(t, err)
+
+	want := []struct {
+		typ      model.EventType
+		entityID string
+		version  int
+	}{
+		{model.EventNoteCreated, note.ID, 1},
+		{model.EventNoteUpdated, note.ID, 2},
+		{model.EventNoteUpdated, note.ID, 3},
+		{model.EventNoteDeleted, note.ID, 3},
+		{model.EventNoteCreated, note.ID, 3},
+		{model.EventTagCreated, tag.ID, 0},
+		{model.EventTagUpdated, tag.ID, 0},
+		{model.EventTagDeleted, tag.ID, 0},
+		{model.EventCategoryCreated, parent.ID, 0},
+		{model.EventCategoryCreated, child.ID, 0},
+		{model.EventCategoryUpdated, child.ID, 0}, // 上级目录改名后子目录的路径随之变化
+		{model.EventCategoryUpdated, parent.ID, 0},
+		{model.EventCategoryDeleted, child.ID, 0},
+	}
+	require.Equal(t, len(want), published)
+	for i, w := range want {
+		e := <-events
+		assert.Equal(t, uint64(i+1), e.Seq)
+		assert.Equal(t, w.typ, e.Type, "第 %d 个事件", i+1)
+		assert.Equal(t, w.typ.Entity(), e.EntityType)
+		assert.Equal(t, w.entityID, e.EntityID, "第 %d 个事件", i+1)
+		assert.Equal(t, w.version, e.Version, "第 %d 个事件", i+1)
+	}
+
+	listed, err := s.ListEvents(ctx, 10, 2)
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+	assert.Equal(t, uint64(11), listed[0].Seq)
+}
+
+func TestEventService_Poll(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	ctx := context.Background()
+	s := NewEventService(db, zap.NewNop(), EventOptions{})
+	_, err := s.Watermark(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, db.Create(&model.Event{Seq: 1, Type: model.EventTagCreated, EntityType: "tag", EntityID: "a"}).Error)
+	// 序号 2 的事务尚未提交
+	require.NoError(t, db.Create(&model.Event{Seq: 3, Type: model.EventTagCreated, EntityType: "tag", EntityID: "b"}).Error)
+
+	now := time.Now()
+	published, err := s.Poll(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+	watermark, err := s.Watermark(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), watermark)
+
+	// 等待超时后视为已回滚，跳过空缺
+	published, err = s.Poll(ctx, now.Add(eventGapTimeout))
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+	watermark, err = s.Watermark(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), watermark)
+}
+
+func TestEventService_Resume(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	ctx := context.Background()
+	s := NewEventService(db, zap.NewNop(), EventOptions{Retention: time.Hour})
+
+	old := time.Now().Add(-2 * time.Hour)
+	for i := 0; i < 5; i++ {
+		e := &model.Event{Type: model.EventTagCreated, EntityType: "tag", EntityID: "t"}
+		if i < 3 {
+			e.CreatedAt = old
+		}
+		require.NoError(t, db.Create(e).Error)
+	}
+	pruned, err := s.Prune(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), pruned)
+
+	tests := []struct {
+		name       string
+		since      *uint64
+		wantCursor uint64
+		wantReset  bool
+	}{
+		{name: "从当前位置开始", since: nil, wantCursor: 5},
+		{name: "从保留的事件继续", since: uint64Ptr(3), wantCursor: 3},
+		{name: "已是最新", since: uint64Ptr(5), wantCursor: 5},
+		{name: "事件已被清理", since: uint64Ptr(2), wantCursor: 5, wantReset: true},
+		{name: "序号超过当前位置", since: uint64Ptr(9), wantCursor: 5, wantReset: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor, reset, err := s.Resume(ctx, tt.since)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCursor, cursor)
+			assert.Equal(t, tt.wantReset, reset)
+		})
+	}
+
+	t.Run("始终保留最新的事件", func(t *testing.T) {
+		pruned, err := s.Prune(ctx, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), pruned)
+		var count int64
+		require.NoError(t, db.Model(&model.Event{}).Count(&count).Error)
+		assert.Equal(t, int64(1), count)
+	})
+}
+
+func uint64Ptr(v uint64) *uint64 {
+	return &v
+}
@@ -0,0 +1,354 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+
+	"leafnote/internal/model"
+)
+
+// exportBatchSize 每批加载的笔记数
+const exportBatchSize = 200
+
+// ExportFilter 导出笔记的筛选条件，条件之间为且的关系
+type ExportFilter struct {
+	CategoryID   *string  // 只导出该目录及其子目录下的笔记
+	TagIDs       []string // 只导出带有其中任意一个标签的笔记
+	NoteIDs      []string // 只导出指定的笔记
+	IncludeTrash bool     // 是否包含回收站（已软删除）中的笔记，导出时使用删除前的路径
+}
+
+// ExportResult 导出结果
+type ExportResult struct {
+	Notes int    `json:"notes"`         // 导出的笔记数
+	Dir   string `json:"dir,omitempty"` // 导出到目录时的目录名
+}
+
+// ExportService 将笔记导出为 Markdown 文件或静态站点
+type ExportService struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+// NewExportService 创建导出服务实例
+func NewExportService(db *gorm.DB, logger *zap.Logger) *ExportService {
+	return &ExportService{db: db, logger: logger}
+}
+
+// exportTarget 导出文件的写入目标
+type exportTarget interface {
+	writeFile(name string, modTime time.Time, data []byte) error
+}
+
+// ExportZip 将笔记导出为 zip 归档写入 w，校验失败时不会向 w 写入任何内容
+func (s *ExportService) ExportZip(ctx context.Context, w io.Writer, filter ExportFilter) (*ExportResult, error) {
+	query, err := s.filterQuery(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	zw := zip.NewWriter(w)
+	result, err := s.export(query, zipTarget{zw})
+	if err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ExportDir 将笔记导出到目录 dir，目录必须不存在或为空
+func (s *ExportService) ExportDir(ctx context.Context, dir string, filter ExportFilter) (*ExportResult, error) {
+	query, err := s.filterQuery(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := prepareExportDir(dir); err != nil {
+		return nil, err
+	}
+
+	result, err := s.export(query, dirTarget(dir))
+	if err != nil {
+		return nil, err
+	}
+	result.Dir = filepath.Base(dir)
+	return result, nil
+}
+
+// prepareExportDir 创建导出目录，目录已存在且不为空时返回 ErrExportDirExists
+func prepareExportDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if len(entries) > 0 {
+		return ErrExportDirExists
+	}
+	return os.MkdirAll(dir, 0755)
+}
+
+// ExportDirPath 返回导出根目录 root 下名为 name 的目录，name 为空时按当前时间生成
+func ExportDirPath(root, name string) (string, error) {
+	if name == "" {
+		name = "leafnote-export-" + time.Now().Format(backupTimeLayout)
+	}
+	if fe := validateFileName("dir", name, MaxPathSegmentLength); fe != nil {
+		return "", newValidationError([]FieldError{*fe})
+	}
+	return filepath.Join(root, name), nil
+}
+
+// filterQuery 校验筛选条件并返回待导出笔记的查询
+func (s *ExportService) filterQuery(ctx context.Context, filter ExportFilter) (*gorm.DB, error) {
+	db := s.db.WithContext(ctx)
+
+	var errs fieldErrors
+	fe, err := validateCategoryRef(db, "category_id", filter.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	errs.addIf(fe)
+	_, fe, err = loadTags(db, "tag_ids", filter.TagIDs)
+	if err != nil {
+		return nil, err
+	}
+	errs.addIf(fe)
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+
+	query := db.Model(&model.Note{})
+	if filter.IncludeTrash {
+		query = db.Unscoped().Model(&model.Note{})
+	}
+	query = query.Preload("Category").Preload("Tags")
+	if filter.CategoryID != nil && *filter.CategoryID != "" {
+		var category model.Category
+		if err := db.First(&category, "id = ?", *filter.CategoryID).Error; err != nil {
+			return nil, err
+		}
+		// 子目录的路径以父目录路径加 / 开头，LIKE 不区分大小写，在程序中再精确比较
+		prefix := category.Path + "/"
+		var children []model.Category
+		if err := db.Select("id", "path").Where(likeCondition("path"), escapeLike(prefix)+"%").Find(&children).Error; err != nil {
+			return nil, err
+		}
+		ids := []string{category.ID}
+		for _, child := range children {
+			if strings.HasPrefix(child.Path, prefix) {
+				ids = append(ids, child.ID)
+			}
+		}
+		query = query.Where("category_id IN ?", ids)
+	}
+	if ids := uniqueStrings(filter.TagIDs); len(ids) > 0 {
+		tagged := db.Table("note_tags").Select("note_id").Where("tag_id IN ?", ids)
+		query = query.Where("id IN (?)", tagged)
+	}
+	if ids := uniqueStrings(filter.NoteIDs); len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	return query, nil
+}
+
+// export 分批加载笔记并写入 target
+func (s *ExportService) export(query *gorm.DB, target exportTarget) (*ExportResult, error) {
+	tagNames, err := s.tagNames()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ExportResult{}
+	used := make(map[string]bool)
+	var notes []model.Note
+	err = query.FindInBatches(&notes, exportBatchSize, func(tx *gorm.DB, batch int) error {
+		for i := range notes {
+			note := &notes[i]
+			data, err := s.renderNote(note, tagNames)
+			if err != nil {
+				return err
+			}
+			name := exportFileName(note, used)
+			if err := target.writeFile(name, note.UpdatedAt, data); err != nil {
+				return fmt.Errorf("写入 %s 失败: %w", name, err)
+			}
+			result.Notes++
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// tagNames 返回标签ID到完整标签名的映射，子标签写作 父标签/子标签，与 Obsidian 的嵌套标签一致
+func (s *ExportService) tagNames() (map[string]string, error) {
+	var tags []model.Tag
+	if err := s.db.Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[string]model.Tag, len(tags))
+	for _, tag := range tags {
+		byID[tag.ID] = tag
+	}
+
+	names := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		parts := []string{tag.Name}
+		seen := map[string]bool{tag.ID: true}
+		for p := tag.ParentID; p != nil && !seen[*p]; {
+			parent, ok := byID[*p]
+			if !ok {
+				break
+			}
+			seen[parent.ID] = true
+			parts = append([]string{parent.Name}, parts...)
+			p = parent.ParentID
+		}
+		names[tag.ID] = strings.Join(parts, "/")
+	}
+	return names, nil
+}
+
+// renderNote 生成带前置元数据的 Markdown 文件内容
+func (s *ExportService) renderNote(note *model.Note, tagNames map[string]string) ([]byte, error) {
+	meta := s.frontMatter(note, tagNames)
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(meta); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	buf.WriteString("---\n\n")
+	buf.WriteString(note.Content)
+	return buf.Bytes(), nil
+}
+
+// frontMatter 生成前置元数据：先写入 ID、标题、时间和标签，再追加笔记原有元数据中的其他字段
+func (s *ExportService) frontMatter(note *model.Note, tagNames map[string]string) *yaml.Node {
+	meta := &yaml.Node{Kind: yaml.MappingNode}
+	keys := make(map[string]bool)
+	add := func(key string, value *yaml.Node) {
+		keys[key] = true
+		meta.Content = append(meta.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+	}
+
+	add("id", stringNode(note.ID))
+	add("title", stringNode(note.Title))
+	add("created", timeNode(note.CreatedAt))
+	add("updated", timeNode(note.UpdatedAt))
+	if len(note.Tags) > 0 {
+		tags := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, tag := range note.Tags {
+			tags.Content = append(tags.Content, stringNode(tagNames[tag.ID]))
+		}
+		add("tags", tags)
+	}
+
+	if strings.TrimSpace(note.YAMLMeta) == "" {
+		return meta
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(note.YAMLMeta), &doc); err != nil ||
+		len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		s.logger.Warn("Skipped invalid YAML metadata on export", zap.String("note_id", note.ID), zap.Error(err))
+		return meta
+	}
+	original := doc.Content[0].Content
+	for i := 0; i+1 < len(original); i += 2 {
+		if !keys[original[i].Value] {
+			meta.Content = append(meta.Content, original[i], original[i+1])
+		}
+	}
+	return meta
+}
+
+// stringNode 返回字符串标量节点
+func stringNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// timeNode 返回时间标量节点，输出为不带引号的 RFC 3339 时间
+func timeNode(t time.Time) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!timestamp", Value: t.Format(time.RFC3339)}
+}
+
+// exportFileName 返回笔记在导出目录中的相对路径：有目录的笔记放在目录路径下，
+// 没有目录的笔记保持原文件路径；重名（不区分大小写）时在文件名后追加 ID 前 8 位
+func exportFileName(note *model.Note, used map[string]bool) string {
+	filePath := note.FilePath
+	if note.DeletedAt.Valid {
+		filePath = trashedNotePath(filePath)
+	}
+	name := cleanExportPath(filePath)
+	if note.Category != nil {
+		name = cleanExportPath(note.Category.Path + "/" + path.Base(filePath))
+	}
+	if used[strings.ToLower(name)] {
+		ext := path.Ext(name)
+		name = fmt.Sprintf("%s-%.8s%s", strings.TrimSuffix(name, ext), note.ID, ext)
+	}
+	used[strings.ToLower(name)] = true
+	return name
+}
+
+// cleanExportPath 将路径转换为不含 .. 的相对路径，避免旧数据中的异常路径写到导出目录之外
+func cleanExportPath(p string) string {
+	var segments []string
+	for _, segment := range strings.Split(p, "/") {
+		if segment != "" && segment != "." && segment != ".." {
+			segments = append(segments, segment)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// zipTarget 写入 zip 归档
+type zipTarget struct {
+	zw *zip.Writer
+}
+
+func (t zipTarget) writeFile(name string, modTime time.Time, data []byte) error {
+	w, err := t.zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modTime})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// dirTarget 写入目录，文件修改时间设为笔记的更新时间
+type dirTarget string
+
+func (t dirTarget) writeFile(name string, modTime time.Time, data []byte) error {
+	p := filepath.Join(string(t), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(p, data, 0644); err != nil {
+		return err
+	}
+	return os.Chtimes(p, modTime, modTime)
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"leafnote/internal/model"
+	"leafnote/internal/testutil"
+)
+
+// exportFixture 导出测试数据
+type exportFixture struct {
+	work, project *model.Category
+	parent, child *model.Tag
+	plan, readme  *model.Note
+}
+
+// setupExportTest 创建目录 /工作/项目、标签 语言/Go 和三篇笔记
+func setupExportTest(t *testing.T, db *gorm.DB) *exportFixture {
+	ctx := context.Background()
+	categories := NewCategoryService(db)
+	f := &exportFixture{
+		work:   &model.Category{Name: "工作"},
+		parent: &model.Tag{Name: "语言"},
+	}
+	require.NoError(t, categories.CreateCategory(ctx, f.work))
+	f.project = &model.Category{Name: "项目", ParentID: &f.work.ID}
+	require.NoError(t, categories.CreateCategory(ctx, f.project))
+	require.NoError(t, db.Create(f.parent).Error)
+	f.child = &model.Tag{Name: "Go", ParentID: &f.parent.ID}
+	require.NoError(t, db.Create(f.child).Error)
+
+	notes := NewNoteService(db, zap.NewNop())
+	var err error
+	f.plan, err = notes.CreateNote(CreateNoteInput{
+		Title:      "计划",
+		Content:    "# 计划\n",
+		YAMLMeta:   "aliases: [plan]\nid: ignored\n",
+		FilePath:   "/旧目录/计划.md",
+		CategoryID: &f.project.ID,
+		TagIDs:     []string{f.child.ID},
+	})
+	require.NoError(t, err)
+	f.readme, err = notes.CreateNote(CreateNoteInput{Title: "说明", Content: "说明", FilePath: "/说明.md"})
+	require.NoError(t, err)
+	trashed, err := notes.CreateNote(CreateNoteInput{Title: "废弃", FilePath: "/废弃.md", CategoryID: &f.work.ID})
+	require.NoError(t, err)
+	require.NoError(t, notes.DeleteNote(trashed.ID))
+	return f
+}
+
+// readZip 读取 zip 中的全部文件
+func readZip(t *testing.T, data []byte) map[string]string {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(r)
+		require.NoError(t, err)
+		r.Close()
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+func TestExportService_ExportZip(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	f := setupExportTest(t, db)
+	s := NewExportService(db, zap.NewNop())
+
+	var buf bytes.Buffer
+	result, err := s.ExportZip(context.Background(), &buf, ExportFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Notes)
+
+	files := readZip(t, buf.Bytes())
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{"工作/项目/计划.md", "说明.md"}, names)
+
+	want := "---\n" +
+		"id: " + f.plan.ID + "\n" +
+		"title: 计划\n" +
+		"created: " + f.plan.CreatedAt.Format(time.RFC3339) + "\n" +
+		"updated: " + f.plan.UpdatedAt.Format(time.RFC3339) + "\n" +
+		"tags:\n" +
+		"  - 语言/Go\n" +
+		"aliases: [plan]\n" +
+		"---\n\n" +
+		"# 计划\n"
+	assert.Equal(t, want, files["工作/项目/计划.md"])
+}
+
+func TestExportService_Filter(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	f := setupExportTest(t, db)
+	s := NewExportService(db, zap.NewNop())
+	missing := "missing"
+
+	tests := []struct {
+		name      string
+		filter    ExportFilter
+		wantFiles []string
+		wantErr   error
+	}{
+		{name: "按目录筛选包含子目录", filter: ExportFilter{CategoryID: &f.work.ID}, wantFiles: []string{"工作/项目/计划.md"}},
+		{name: "包含回收站", filter: ExportFilter{CategoryID: &f.work.ID, IncludeTrash: true}, wantFiles: []string{"工作/废弃.md", "工作/项目/计划.md"}},
+		{name: "按标签筛选", filter: ExportFilter{TagIDs: []string{f.child.ID}}, wantFiles: []string{"工作/项目/计划.md"}},
+		{name: "按笔记筛选", filter: ExportFilter{NoteIDs: []string{f.readme.ID}}, wantFiles: []string{"说明.md"}},
+		{name: "目录不存在", filter: ExportFilter{CategoryID: &missing}, wantErr: ErrValidationFailed},
+		{name: "标签不存在", filter: ExportFilter{TagIDs: []string{missing}}, wantErr: ErrValidationFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			_, err := s.ExportZip(context.Background(), &buf, tt.filter)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Zero(t, buf.Len())
+				return
+			}
+			require.NoError(t, err)
+			var names []string
+			for name := range readZip(t, buf.Bytes()) {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			assert.Equal(t, tt.wantFiles, names)
+		})
+	}
+
+	t.Run("目录路径区分大小写", func(t *testing.T) {
+		ctx := context.Background()
+		upper, lower := &model.Category{Name: "Docs"}, &model.Category{Name: "docs"}
+		require.NoError(t, NewCategoryService(db).CreateCategory(ctx, upper))
+		require.NoError(t, NewCategoryService(db).CreateCategory(ctx, lower))
+		child := &model.Category{Name: "api", ParentID: &upper.ID}
+		require.NoError(t, NewCategoryService(db).CreateCategory(ctx, child))
+		_, err := NewNoteService(db, zap.NewNop()).CreateNote(CreateNoteInput{Title: "接口", FilePath: "/Docs/api/接口.md", CategoryID: &child.ID})
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		result, err := s.ExportZip(ctx, &buf, ExportFilter{CategoryID: &lower.ID})
+		require.NoError(t, err)
+		assert.Zero(t, result.Notes)
+	})
+}
+
+func TestExportService_ExportDir(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	f := setupExportTest(t, db)
+	s := NewExportService(db, zap.NewNop())
+	dir := filepath.Join(t.TempDir(), "export")
+
+	result, err := s.ExportDir(context.Background(), dir, ExportFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Notes)
+	assert.Equal(t, "export", result.Dir)
+
+	info, err := os.Stat(filepath.Join(dir, "工作", "项目", "计划.md"))
+	require.NoError(t, err)
+	assert.WithinDuration(t, f.plan.UpdatedAt, info.ModTime(), time.Second)
+	assert.FileExists(t, filepath.Join(dir, "说明.md"))
+
+	// 目录不为空时拒绝导出，避免覆盖文件
+	_, err = s.ExportDir(context.Background(), dir, ExportFilter{})
+	assert.ErrorIs(t, err, ErrExportDirExists)
+}
+
+func TestExportFileName(t *testing.T) {
+	category := &model.Category{Path: "/工作/../项目"}
+	used := make(map[string]bool)
+
+	tests := []struct {
+		name string
+		note *model.Note
+		want string
+	}{
+		{name: "无目录保持原路径", note: &model.Note{BaseModel: model.BaseModel{ID: "11111111-a"}, FilePath: "/a/b.md"}, want: "a/b.md"},
+		{name: "按目录路径放置并去除 ..", note: &model.Note{BaseModel: model.BaseModel{ID: "22222222-b"}, FilePath: "/x/c.md", Category: category}, want: "工作/项目/c.md"},
+		{name: "重名追加 ID", note: &model.Note{BaseModel: model.BaseModel{ID: "33333333-c"}, FilePath: "/A/B.md"}, want: "A/B-33333333.md"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, exportFileName(tt.note, used))
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+// maxImagePixels 处理图片的像素上限，避免解码超大图片耗尽内存
+const maxImagePixels = 50_000_000
+
+// defaultJPEGQuality 未配置时重新编码 JPEG 的质量
+const defaultJPEGQuality = 85
+
+// ImageOptions 图片附件的处理选项，只处理 JPEG、PNG
+type ImageOptions struct {
+	StripMetadata  bool  // 上传时去除 EXIF、XMP 等元数据，并按 EXIF 方向旋转图片
+	MaxDimension   int   // 上传的图片长边超过该像素时缩小，0 表示不缩小
+	Recompress     bool  // 上传时重新编码，结果更小时才替换原图
+	JPEGQuality    int   // 重新编码 JPEG 的质量，0 表示使用默认值
+	ThumbnailSizes []int // 可请求的缩略图尺寸（长边像素），为空时不提供缩略图
+}
+
+func (o ImageOptions) jpegQuality() int {
+	if o.JPEGQuality <= 0 || o.JPEGQuality > 100 {
+		return defaultJPEGQuality
+	}
+	return o.JPEGQuality
+}
+
+// enabled 上传时是否需要处理图片
+func (o ImageOptions) enabled() bool {
+	return o.StripMetadata || o.MaxDimension > 0 || o.Recompress
+}
+
+// imageDecoders 可以生成缩略图的图片格式
+var imageDecoders = map[string]func(io.Reader) (image.Image, error){
+	"image/jpeg": jpeg.Decode,
+	"image/png":  png.Decode,
+	"image/gif":  gif.Decode,
+	"image/webp": webp.Decode,
+	"image/bmp":  bmp.Decode,
+}
+
+// imageConfigDecoders 读取图片尺寸，不解码像素
+var imageConfigDecoders = map[string]func(io.Reader) (image.Config, error){
+	"image/jpeg": jpeg.DecodeConfig,
+	"image/png":  png.DecodeConfig,
+	"image/gif":  gif.DecodeConfig,
+	"image/webp": webp.DecodeConfig,
+	"image/bmp":  bmp.DecodeConfig,
+}
+
+// mediaType 返回不含参数的 MIME 类型
+func mediaType(mimeType string) string {
+	base, _, _ := mime.ParseMediaType(mimeType)
+	return base
+}
+
+// processImage 按配置处理上传的 JPEG、PNG：去除元数据、按方向旋转、缩小和重新编码；
+// 其他格式、无法解码或超过像素上限的图片原样返回，changed 为 false
+func processImage(data []byte, mimeType string, opts ImageOptions) (out []byte, changed bool, err error) {
+	format := mediaType(mimeType)
+	if format != "image/jpeg" && format != "image/png" {
+		return data, false, nil
+	}
+	cfg, err := imageConfigDecoders[format](bytes.NewReader(data))
+	if err != nil || cfg.Width*cfg.Height > maxImagePixels {
+		return data, false, nil
+	}
+
+	orientation := 1
+	if format == "image/jpeg" {
+		orientation = jpegOrientation(data)
+	}
+	resize := opts.MaxDimension > 0 && max(cfg.Width, cfg.Height) > opts.MaxDimension
+	rotate := opts.StripMetadata && orientation > 1
+
+	original := data
+	if opts.StripMetadata {
+		data = stripImageMetadata(data, format)
+	}
+	if !resize && !rotate && !opts.Recompress {
+		return data, len(data) != len(original), nil
+	}
+
+	img, err := imageDecoders[format](bytes.NewReader(data))
+	if err != nil {
+		return original, false, nil
+	}
+	// 重新编码会丢失 EXIF，因此总是按方向旋转
+	img = applyOrientation(img, orientation)
+	if resize {
+		img = scaleImage(img, opts.MaxDimension)
+	}
+	encoded, err := encodeImage(img, format, opts.jpegQuality())
+	if err != nil {
+		return nil, false, err
+	}
+	// 只是重新压缩时，结果更大则保留原图
+	if !resize && !rotate && len(encoded) >= len(data) {
+		return data, len(data) != len(original), nil
+	}
+	return encoded, true, nil
+}
+
+// encodeImage 以 JPEG 或 PNG 编码图片
+func encodeImage(img image.Image, format string, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if format == "image/jpeg" {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	} else {
+		enc := png.Encoder{CompressionLevel: png.BestCompression}
+		err = enc.Encode(&buf, img)
+	}
+	return buf.Bytes(), err
+}
+
+// scaleImage 等比缩小图片，使长边不超过 size
+func scaleImage(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w >= h {
+		h = max(1, h*size/w)
+		w = size
+	} else {
+		w = max(1, w*size/h)
+		h = size
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+// applyOrientation 按 EXIF 方向（1-8）变换图片，使其以正确的方向显示
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	src := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	w, h := b.Dx(), b.Dy()
+
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // 水平翻转
+				dx, dy = w-1-x, y
+			case 3: // 旋转 180°
+				dx, dy = w-1-x, h-1-y
+			case 4: // 垂直翻转
+				dx, dy = x, h-1-y
+			case 5: // 沿主对角线翻转
+				dx, dy = y, x
+			case 6: // 顺时针旋转 90°
+				dx, dy = h-1-y, x
+			case 7: // 沿副对角线翻转
+				dx, dy = h-1-y, w-1-x
+			case 8: // 逆时针旋转 90°
+				dx, dy = y, w-1-x
+			}
+			copy(dst.Pix[dst.PixOffset(dx, dy):][:4], src.Pix[src.PixOffset(x, y):][:4])
+		}
+	}
+	return dst
+}
+
+// stripImageMetadata 无损去除 JPEG、PNG 中的元数据，文件格式无法识别时原样返回
+func stripImageMetadata(data []byte, format string) []byte {
+	switch format {
+	case "image/jpeg":
+		return rewriteJPEG(data, func(marker byte, _ []byte) bool {
+			// APP1 为 EXIF 和 XMP，APP13 为 Photoshop/IPTC，0xFE 为注释；保留 ICC 颜色配置（APP2）等
+			return marker != 0xE1 && marker != 0xED && marker != 0xFE
+		})
+	case "image/png":
+		return rewritePNG(data, func(chunk string) bool {
+			switch chunk {
+			case "tEXt", "zTXt", "iTXt", "tIME", "eXIf":
+				return false
+			}
+			return true
+		})
+	}
+	return data
+}
+
+// rewriteJPEG 遍历图像数据（SOS）之前的段，丢弃 keep 返回 false 的段
+func rewriteJPEG(data []byte, keep func(marker byte, payload []byte) bool) []byte {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return data
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:2]...)
+	i := 2
+	for i+1 < len(data) {
+		if data[i] != 0xFF {
+			return data
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xFF: // 填充字节
+			i++
+			continue
+		case marker == 0xDA || marker == 0xD9: // 图像数据或文件结束，之后原样保留
+			return append(out, data[i:]...)
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7): // 没有长度的标记
+			out = append(out, data[i:i+2]...)
+			i += 2
+			continue
+		}
+		if i+4 > len(data) {
+			return data
+		}
+		end := i + 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+		if end > len(data) || end < i+4 {
+			return data
+		}
+		if keep(marker, data[i+4:end]) {
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+	return data
+}
+
+// pngSignature PNG 文件头
+const pngSignature = "\x89PNG\r\n\x1a\n"
+
+// rewritePNG 遍历 PNG 的数据块，丢弃 keep 返回 false 的块；每个块有独立的 CRC，无需重新计算
+func rewritePNG(data []byte, keep func(chunk string) bool) []byte {
+	if !bytes.HasPrefix(data, []byte(pngSignature)) {
+		return data
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+	i := len(pngSignature)
+	for i < len(data) {
+		if i+12 > len(data) {
+			return data
+		}
+		end := i + 12 + int(binary.BigEndian.Uint32(data[i:]))
+		if end > len(data) || end < i+12 {
+			return data
+		}
+		chunk := string(data[i+4 : i+8])
+		if keep(chunk) {
+			out = append(out, data[i:end]...)
+		}
+		i = end
+		if chunk == "IEND" {
+			break
+		}
+	}
+	return out
+}
+
+// jpegOrientation 读取 JPEG 中 EXIF 的方向，没有时返回 1
+func jpegOrientation(data []byte) int {
+	orientation := 1
+	rewriteJPEG(data, func(marker byte, payload []byte) bool {
+		if marker == 0xE1 && bytes.HasPrefix(payload, []byte("Exif\x00\x00")) && orientation == 1 {
+			orientation = exifOrientation(payload[6:])
+		}
+		return true
+	})
+	return orientation
+}
+
+// exifOrientation 从 EXIF 的 TIFF 结构中读取 IFD0 的方向标签（0x0112）
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	offset := int(order.Uint32(tiff[4:]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if v := int(order.Uint16(tiff[entry+8:])); v >= 1 && v <= 8 {
+				return v
+			}
+			break
+		}
+	}
+	return 1
+}
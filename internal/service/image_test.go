@@ -0,0 +1,233 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"leafnote/internal/model"
+	"leafnote/internal/testutil"
+)
+
+// testImage 生成 w×h 的灰色图片，左上角 10×10 为红色，用于检查旋转方向
+func testImage(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{128, 128, 128, 255}
+			if x < 10 && y < 10 {
+				c = color.RGBA{255, 0, 0, 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// testJPEG 生成带 EXIF 方向的 JPEG，orientation 为 0 时不含 EXIF
+func testJPEG(t *testing.T, w, h, orientation int) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, testImage(w, h), &jpeg.Options{Quality: 95}))
+	if orientation == 0 {
+		return buf.Bytes()
+	}
+	// 大端 TIFF，IFD0 只有方向一项
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01")
+	tiff = binary.BigEndian.AppendUint16(tiff, 0x0112)
+	tiff = append(tiff, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01)
+	tiff = binary.BigEndian.AppendUint16(tiff, uint16(orientation))
+	tiff = append(tiff, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00)
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := binary.BigEndian.AppendUint16([]byte{0xFF, 0xE1}, uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:2]...), segment...), data[2:]...)
+}
+
+// testPNG 生成 PNG，text 不为空时在 IHDR 后插入 tEXt 块
+func testPNG(t *testing.T, w, h int, text string) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, testImage(w, h)))
+	data := buf.Bytes()
+	if text == "" {
+		return data
+	}
+	body := append([]byte("tEXt"), text...)
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(text)))
+	chunk = append(chunk, body...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(body))
+	ihdrEnd := 8 + 12 + 13
+	return append(append(append([]byte{}, data[:ihdrEnd]...), chunk...), data[ihdrEnd:]...)
+}
+
+func TestProcessImage(t *testing.T) {
+	var gifData bytes.Buffer
+	require.NoError(t, gif.Encode(&gifData, testImage(400, 200), nil))
+
+	tests := []struct {
+		name        string
+		data        []byte
+		mimeType    string
+		opts        ImageOptions
+		wantChanged bool
+		wantSize    [2]int
+		excludes    string
+		redAt       [2]int // 处理后应为红色的像素
+	}{
+		{name: "去除 EXIF", data: testJPEG(t, 40, 20, 1), mimeType: "image/jpeg", opts: ImageOptions{StripMetadata: true}, wantChanged: true, wantSize: [2]int{40, 20}, excludes: "Exif", redAt: [2]int{2, 2}},
+		{name: "按方向旋转", data: testJPEG(t, 40, 20, 6), mimeType: "image/jpeg", opts: ImageOptions{StripMetadata: true}, wantChanged: true, wantSize: [2]int{20, 40}, excludes: "Exif", redAt: [2]int{17, 2}},
+		{name: "不去除元数据时不旋转", data: testJPEG(t, 40, 20, 6), mimeType: "image/jpeg", opts: ImageOptions{}, wantSize: [2]int{40, 20}, redAt: [2]int{2, 2}},
+		{name: "去除 PNG 文本块", data: testPNG(t, 40, 20, "Author\x00张三"), mimeType: "image/png", opts: ImageOptions{StripMetadata: true}, wantChanged: true, wantSize: [2]int{40, 20}, excludes: "tEXt", redAt: [2]int{2, 2}},
+		{name: "没有元数据", data: testPNG(t, 40, 20, ""), mimeType: "image/png", opts: ImageOptions{StripMetadata: true}, wantSize: [2]int{40, 20}, redAt: [2]int{2, 2}},
+		{name: "缩小", data: testPNG(t, 400, 200, ""), mimeType: "image/png", opts: ImageOptions{MaxDimension: 100}, wantChanged: true, wantSize: [2]int{100, 50}, redAt: [2]int{1, 1}},
+		{name: "不超过尺寸不缩小", data: testPNG(t, 40, 20, ""), mimeType: "image/png", opts: ImageOptions{MaxDimension: 100}, wantSize: [2]int{40, 20}, redAt: [2]int{2, 2}},
+		{name: "GIF 原样保存", data: gifData.Bytes(), mimeType: "image/gif", opts: ImageOptions{StripMetadata: true, MaxDimension: 100}, wantSize: [2]int{400, 200}, redAt: [2]int{2, 2}},
+		{name: "无法解码", data: []byte("\x89PNG\r\n\x1a\nbroken"), mimeType: "image/png", opts: ImageOptions{StripMetadata: true, MaxDimension: 100}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, changed, err := processImage(tt.data, tt.mimeType, tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantChanged, changed)
+			if !changed {
+				assert.Equal(t, tt.data, out)
+			}
+			if tt.excludes != "" {
+				assert.NotContains(t, string(out), tt.excludes)
+			}
+			if tt.wantSize == [2]int{} {
+				return
+			}
+			img, _, err := image.Decode(bytes.NewReader(out))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSize, [2]int{img.Bounds().Dx(), img.Bounds().Dy()})
+			r, g, _, _ := img.At(tt.redAt[0], tt.redAt[1]).RGBA()
+			assert.Greater(t, r>>8, uint32(200))
+			assert.Less(t, g>>8, uint32(60))
+		})
+	}
+}
+
+func TestProcessImage_Recompress(t *testing.T) {
+	// 未压缩的 PNG 重新编码后更小
+	var raw bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.NoCompression}
+	require.NoError(t, enc.Encode(&raw, testImage(200, 200)))
+	out, changed, err := processImage(raw.Bytes(), "image/png", ImageOptions{Recompress: true})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Less(t, len(out), raw.Len())
+
+	// 重新编码后更大时保留原图
+	photo := testJPEG(t, 200, 200, 0)
+	out, changed, err = processImage(photo, "image/jpeg", ImageOptions{Recompress: true, JPEGQuality: 100})
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, photo, out)
+}
+
+func TestAttachmentService_UploadImage(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	s := NewAttachmentService(db, zap.NewNop(), AttachmentOptions{
+		VaultDir: t.TempDir(),
+		Image:    ImageOptions{StripMetadata: true, MaxDimension: 100},
+	})
+	ctx := context.Background()
+
+	original := testJPEG(t, 400, 200, 1)
+	att, err := s.Save(ctx, "照片.jpg", original)
+	require.NoError(t, err)
+	saved, err := os.ReadFile(s.FilePath(att))
+	require.NoError(t, err)
+	assert.Equal(t, sha256Hex(saved), att.Hash)
+	assert.Equal(t, int64(len(saved)), att.Size)
+	assert.NotContains(t, string(saved), "Exif")
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(saved))
+	require.NoError(t, err)
+	assert.Equal(t, 100, cfg.Width)
+
+	// 同一张原图再次上传时处理结果相同，仍然去重
+	again, err := s.Save(ctx, "照片副本.jpg", original)
+	require.NoError(t, err)
+	assert.Equal(t, att.ID, again.ID)
+}
+
+func TestAttachmentService_Thumbnail(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	s := NewAttachmentService(db, zap.NewNop(), AttachmentOptions{
+		VaultDir:    t.TempDir(),
+		GracePeriod: time.Minute,
+		Image:       ImageOptions{ThumbnailSizes: []int{128, 64}},
+	})
+	ctx := context.Background()
+
+	large, err := s.Save(ctx, "大图.png", testPNG(t, 400, 200, ""))
+	require.NoError(t, err)
+	small, err := s.Save(ctx, "小图.png", testPNG(t, 40, 20, ""))
+	require.NoError(t, err)
+	rotated, err := s.Save(ctx, "旋转.jpg", testJPEG(t, 40, 20, 8))
+	require.NoError(t, err)
+	text, err := s.Save(ctx, "说明.txt", []byte("文本"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		file     string
+		size     int
+		wantSize [2]int
+		wantMIME string
+		original bool
+		wantErr  error
+	}{
+		{name: "默认最小尺寸", file: large.Path, wantSize: [2]int{64, 32}, wantMIME: "image/png"},
+		{name: "指定尺寸", file: large.Path, size: 128, wantSize: [2]int{128, 64}, wantMIME: "image/png"},
+		{name: "小图使用原图", file: small.Path, size: 128, wantSize: [2]int{40, 20}, wantMIME: "image/png", original: true},
+		{name: "按 EXIF 方向旋转", file: rotated.Path, wantSize: [2]int{20, 40}, wantMIME: "image/jpeg"},
+		{name: "尺寸不支持", file: large.Path, size: 100, wantErr: ErrThumbnailSizeInvalid},
+		{name: "不是图片", file: text.Path, wantErr: ErrAttachmentNotImage},
+		{name: "附件不存在", file: "attachments/missing.png", wantErr: ErrAttachmentNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			att, thumb, err := s.Thumbnail(ctx, tt.file[len("attachments/"):], tt.size)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantMIME, thumb.MimeType)
+			assert.Equal(t, tt.original, thumb.Path == s.FilePath(att))
+			data, err := os.ReadFile(thumb.Path)
+			require.NoError(t, err)
+			img, _, err := image.Decode(bytes.NewReader(data))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSize, [2]int{img.Bounds().Dx(), img.Bounds().Dy()})
+		})
+	}
+
+	// 清理附件时一并删除缩略图
+	_, thumb, err := s.Thumbnail(ctx, large.Hash, 64)
+	require.NoError(t, err)
+	require.FileExists(t, thumb.Path)
+	require.NoError(t, db.Model(&model.Attachment{}).Where("id = ?", large.ID).UpdateColumn("updated_at", time.Now().Add(-time.Hour)).Error)
+	result, err := s.CollectGarbage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Removed)
+	assert.NoFileExists(t, thumb.Path)
+}
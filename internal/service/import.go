@@ -0,0 +1,617 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+
+	"leafnote/internal/model"
+)
+
+// maxImportFileSize 单个导入文件的大小上限
+const maxImportFileSize = 16 << 20
+
+// 单个文件的导入状态
+const (
+	ImportCreated = "created" // 已创建笔记
+	ImportSkipped = "skipped" // 已跳过，例如内容重复或不是 Markdown 文件
+	ImportFailed  = "failed"  // 导入失败
+)
+
+// ImportOptions 导入选项
+type ImportOptions struct {
+	CategoryID *string               // 导入到该目录下，为空时导入到根目录
+	Progress   func(done, total int) // 每处理一个文件或笔记后调用，可以为空
+}
+
+// ImportResult 导入结果，逐个列出每个文件的处理情况
+type ImportResult struct {
+	Total   int                `json:"total"`
+	Created int                `json:"created"`
+	Skipped int                `json:"skipped"`
+	Failed  int                `json:"failed"`
+	Files   []ImportFileResult `json:"files"`
+}
+
+// ImportFileResult 单个文件的导入结果
+type ImportFileResult struct {
+	Path     string   `json:"path"`               // 文件在导入源中的路径
+	Status   string   `json:"status"`             // created、skipped 或 failed
+	NoteID   string   `json:"note_id,omitempty"`  // 创建的笔记，内容重复时为已有笔记
+	Message  string   `json:"message,omitempty"`  // 跳过或失败的原因
+	Warnings []string `json:"warnings,omitempty"` // 不影响导入的问题，例如忽略的非法标签
+}
+
+// add 记录单个文件的结果并更新计数
+func (r *ImportResult) add(file ImportFileResult) {
+	r.Total++
+	switch file.Status {
+	case ImportCreated:
+		r.Created++
+	case ImportSkipped:
+		r.Skipped++
+	case ImportFailed:
+		r.Failed++
+	}
+	r.Files = append(r.Files, file)
+}
+
+// importedNote 从导入源解析出的笔记
+type importedNote struct {
+	ID        string    // 前置元数据中的笔记ID，为空时由服务端生成
+	Path      string    // 相对于导入根目录的文件路径，所在文件夹对应目录
+	Title     string    // 标题
+	Content   string    // 正文，不含前置元数据
+	YAMLMeta  string    // 未被识别的前置元数据
+	Tags      []string  // 标签，子标签写作 父标签/子标签
+	CreatedAt time.Time // 创建时间，为零值时使用当前时间
+	UpdatedAt time.Time // 更新时间，为零值时使用创建时间
+}
+
+// ImportService 从外部笔记导入
+type ImportService struct {
+	db          *gorm.DB
+	logger      *zap.Logger
+	attachments *AttachmentService // 导入的附件与上传的附件一样保存和处理，为空时忽略附件
+}
+
+// NewImportService 创建导入服务实例，attachments 为空时导入的附件会被忽略
+func NewImportService(db *gorm.DB, logger *zap.Logger, attachments *AttachmentService) *ImportService {
+	return &ImportService{db: db, logger: logger, attachments: attachments}
+}
+
+// 导入源的格式
+const (
+	ImportFormatMarkdown = "markdown" // Obsidian 库或 Markdown 文件夹
+	ImportFormatENEX     = "enex"     // Evernote 导出的 ENEX 文件
+	ImportFormatNotion   = "notion"   // Notion 导出的 Markdown & CSV
+)
+
+// Import 按格式导入，format 为空时按 Markdown 导入
+func (s *ImportService) Import(ctx context.Context, format string, fsys fs.FS, opts ImportOptions) (*ImportResult, error) {
+	switch format {
+	case ImportFormatMarkdown, "":
+		return s.ImportMarkdown(ctx, fsys, opts)
+	case ImportFormatENEX:
+		return s.ImportENEX(ctx, fsys, opts)
+	case ImportFormatNotion:
+		return s.ImportNotion(ctx, fsys, opts)
+	}
+	return nil, fmt.Errorf("不支持的导入格式: %s", format)
+}
+
+// ImportMarkdown 导入 Obsidian 库或 Markdown 文件夹，fsys 可以是 os.DirFS 或 zip.Reader；
+// 文件夹转换为目录，前置元数据和正文中的 #标签 转换为标签，以 . 开头的文件和文件夹被忽略
+func (s *ImportService) ImportMarkdown(ctx context.Context, fsys fs.FS, opts ImportOptions) (*ImportResult, error) {
+	imp, err := s.newImporter(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := walkImportFiles(fsys, func(string, fs.DirEntry) error {
+		imp.total++
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	err = walkImportFiles(fsys, func(p string, d fs.DirEntry) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !strings.EqualFold(path.Ext(p), ".md") {
+			imp.add(ImportFileResult{Path: p, Status: ImportSkipped, Message: "不是 Markdown 文件"})
+			return nil
+		}
+
+		note, err := readMarkdownFile(fsys, p, d)
+		if err != nil {
+			imp.add(ImportFileResult{Path: p, Status: ImportFailed, Message: err.Error()})
+			return nil
+		}
+		imp.importNote(note)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Markdown import finished",
+		zap.Int("created", imp.result.Created),
+		zap.Int("skipped", imp.result.Skipped),
+		zap.Int("failed", imp.result.Failed))
+	return imp.result, nil
+}
+
+// walkImportFiles 遍历导入源中的文件，忽略以 . 开头的文件和文件夹以及 macOS 压缩时生成的 __MACOSX
+func walkImportFiles(fsys fs.FS, fn func(p string, d fs.DirEntry) error) error {
+	return fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != "." && (strings.HasPrefix(d.Name(), ".") || d.Name() == "__MACOSX") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		return fn(p, d)
+	})
+}
+
+// readMarkdownFile 读取并解析 Markdown 文件，没有前置元数据中的时间时使用文件修改时间
+func readMarkdownFile(fsys fs.FS, p string, d fs.DirEntry) (*importedNote, error) {
+	info, err := d.Info()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxImportFileSize {
+		return nil, fmt.Errorf("文件超过 %d MiB", maxImportFileSize>>20)
+	}
+	f, err := fsys.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxImportFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(data) {
+		return nil, errors.New("不是 UTF-8 编码的文本文件")
+	}
+
+	note, err := parseMarkdown(p, data)
+	if err != nil {
+		return nil, err
+	}
+	if note.CreatedAt.IsZero() {
+		note.CreatedAt = info.ModTime()
+	}
+	if note.UpdatedAt.IsZero() {
+		note.UpdatedAt = info.ModTime()
+	}
+	return note, nil
+}
+
+// importer 一次导入过程的状态，缓存已创建的目录和标签
+type importer struct {
+	s          *ImportService
+	ctx        context.Context
+	rootPath   string             // 导入目标目录的路径，根目录为空
+	rootID     *string            // 导入目标目录
+	categories map[string]*string // 目录路径到目录ID
+	tags       map[string]string  // 完整标签名到标签ID
+	checksums  map[string]string  // 本次已导入内容的校验和到笔记ID
+	progress   func(done, total int)
+	total      int // 预计处理的文件或笔记数
+	result     *ImportResult
+}
+
+// newImporter 校验导入选项并创建 importer
+func (s *ImportService) newImporter(ctx context.Context, opts ImportOptions) (*importer, error) {
+	imp := &importer{
+		s:          s,
+		ctx:        ctx,
+		categories: make(map[string]*string),
+		tags:       make(map[string]string),
+		checksums:  make(map[string]string),
+		progress:   opts.Progress,
+		result:     &ImportResult{Files: []ImportFileResult{}},
+	}
+	if opts.CategoryID != nil && *opts.CategoryID != "" {
+		var category model.Category
+		if err := s.db.WithContext(ctx).First(&category, "id = ?", *opts.CategoryID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, newValidationError([]FieldError{{
+					Field: "category_id", Code: FieldNotFound, Params: map[string]string{"value": *opts.CategoryID},
+				}})
+			}
+			return nil, err
+		}
+		imp.rootPath = category.Path
+		imp.rootID = &category.ID
+	}
+	return imp, nil
+}
+
+// add 记录单个文件的结果并报告进度
+func (imp *importer) add(file ImportFileResult) {
+	imp.result.add(file)
+	if imp.progress != nil {
+		imp.progress(imp.result.Total, max(imp.total, imp.result.Total))
+	}
+}
+
+// importNote 创建单篇笔记并记录结果，内容与已有笔记相同时跳过；warnings 为转换时产生的警告
+func (imp *importer) importNote(in *importedNote, warnings ...string) {
+	file := ImportFileResult{Path: in.Path, Status: ImportFailed, Warnings: warnings}
+	note, err := imp.createNote(in, &file)
+	switch {
+	case err == nil:
+		file.Status = ImportCreated
+		file.NoteID = note.ID
+	case errors.Is(err, errDuplicateNote):
+		file.Status = ImportSkipped
+		file.Message = "内容与已有笔记重复"
+	default:
+		file.Message = importErrorMessage(err)
+	}
+	imp.add(file)
+}
+
+// errDuplicateNote 导入的笔记与已有笔记内容相同
+var errDuplicateNote = errors.New("duplicate note")
+
+// createNote 创建目录、标签和笔记，标签与笔记在同一事务中创建
+func (imp *importer) createNote(in *importedNote, file *ImportFileResult) (*model.Note, error) {
+	notes := NewNoteService(imp.s.db.WithContext(imp.ctx), imp.s.logger)
+	checksum := notes.calculateChecksum(in.Content)
+	// 空笔记不按内容去重
+	if strings.TrimSpace(in.Content) != "" {
+		if id, ok := imp.checksums[checksum]; ok {
+			file.NoteID = id
+			return nil, errDuplicateNote
+		}
+		var existing model.Note
+		err := notes.db.Select("id").Where("checksum = ?", checksum).Take(&existing).Error
+		if err == nil {
+			file.NoteID = existing.ID
+			return nil, errDuplicateNote
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	filePath, fe := NormalizeFilePath("file_path", imp.rootPath+"/"+in.Path)
+	if fe != nil {
+		return nil, newValidationError([]FieldError{*fe})
+	}
+	categoryID, err := ensureCategoryPath(imp.ctx, imp.s.db, imp.rootID, imp.rootPath, path.Dir(strings.TrimPrefix(filePath, imp.rootPath)), imp.categories)
+	if err != nil {
+		return nil, err
+	}
+	id, err := imp.noteID(in.ID, file)
+	if err != nil {
+		return nil, err
+	}
+
+	var note *model.Note
+	err = notes.inTransaction(func(tx *gorm.DB, notes *NoteService) error {
+		tagIDs, err := imp.ensureTags(tx, in.Tags, file)
+		if err != nil {
+			return err
+		}
+		note, err = notes.CreateNote(CreateNoteInput{
+			ID:         id,
+			Title:      in.Title,
+			Content:    in.Content,
+			YAMLMeta:   in.YAMLMeta,
+			FilePath:   filePath,
+			CategoryID: categoryID,
+			TagIDs:     tagIDs,
+			CreatedAt:  in.CreatedAt,
+			UpdatedAt:  in.UpdatedAt,
+			UniquePath: true,
+		})
+		return err
+	})
+	if err != nil {
+		// 事务回滚后新建的标签不存在，清空缓存避免后续文件引用
+		imp.tags = make(map[string]string)
+		return nil, err
+	}
+	imp.checksums[checksum] = note.ID
+	return note, nil
+}
+
+// noteID 返回前置元数据中的笔记ID，不是合法的 UUID 或已被其他笔记占用时记为警告，由服务端生成新的ID
+func (imp *importer) noteID(id string, file *ImportFileResult) (string, error) {
+	if id == "" {
+		return "", nil
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		file.Warnings = append(file.Warnings, fmt.Sprintf("忽略非法的笔记ID %q", id))
+		return "", nil
+	}
+	// 回收站中的笔记仍占用ID
+	var count int64
+	if err := imp.s.db.WithContext(imp.ctx).Unscoped().Model(&model.Note{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return "", err
+	}
+	if count > 0 {
+		file.Warnings = append(file.Warnings, fmt.Sprintf("笔记ID %s 已被占用，使用新的ID", id))
+		return "", nil
+	}
+	return id, nil
+}
+
+// ensureTags 按完整标签名逐级查找或创建标签，返回标签ID；非法的标签记为警告并忽略
+func (imp *importer) ensureTags(tx *gorm.DB, names []string, file *ImportFileResult) ([]string, error) {
+	var tags []string
+	for _, name := range uniqueStrings(names) {
+		id, err := imp.ensureTag(tx, name)
+		if err != nil {
+			if e, ok := AsError(err); ok && e.Kind == KindValidation {
+				file.Warnings = append(file.Warnings, fmt.Sprintf("忽略非法标签 %q", name))
+				continue
+			}
+			return nil, err
+		}
+		tags = append(tags, id)
+	}
+	return tags, nil
+}
+
+// ensureTag 查找或创建标签 name 及其所有父标签，返回最末一级标签的ID
+func (imp *importer) ensureTag(tx *gorm.DB, name string) (string, error) {
+	if id, ok := imp.tags[name]; ok {
+		return id, nil
+	}
+
+	var parentID *string
+	tagName := name
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		id, err := imp.ensureTag(tx, name[:i])
+		if err != nil {
+			return "", err
+		}
+		parentID = &id
+		tagName = name[i+1:]
+	}
+	if fe := validateTagName("tags", tagName); fe != nil {
+		return "", newValidationError([]FieldError{*fe})
+	}
+
+	var tag model.Tag
+	query := tx.Where("name = ?", tagName)
+	if parentID != nil {
+		query = query.Where("parent_id = ?", *parentID)
+	} else {
+		query = query.Where("parent_id IS NULL")
+	}
+	err := query.Take(&tag).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		tag = model.Tag{Name: tagName, ParentID: parentID}
+		err = NewTagService(tx).CreateTag(imp.ctx, &tag)
+	}
+	if err != nil {
+		return "", err
+	}
+	imp.tags[name] = tag.ID
+	return tag.ID, nil
+}
+
+// importErrorMessage 返回写入导入结果的错误信息，字段校验错误展开为字段和错误码
+func importErrorMessage(err error) string {
+	e, ok := AsError(err)
+	if !ok || len(e.Fields) == 0 {
+		return err.Error()
+	}
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Field+": "+f.Code)
+	}
+	return e.Message + "（" + strings.Join(parts, "，") + "）"
+}
+
+// frontMatterKeys 导入时从前置元数据中识别的字段，其余字段原样保留在 YAMLMeta 中
+var frontMatterKeys = map[string]bool{
+	"id": true, "title": true, "tags": true, "tag": true,
+	"created": true, "updated": true, "modified": true,
+}
+
+// parseMarkdown 解析 Markdown 文件的前置元数据和正文
+func parseMarkdown(p string, data []byte) (*importedNote, error) {
+	note := &importedNote{
+		Path:  p,
+		Title: strings.TrimSuffix(path.Base(p), path.Ext(p)),
+	}
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	meta, body, ok := splitFrontMatter(text)
+	note.Content = body
+	if ok {
+		if err := note.applyFrontMatter(meta); err != nil {
+			return nil, err
+		}
+	}
+	note.Tags = append(note.Tags, inlineTags(note.Content)...)
+	return note, nil
+}
+
+// splitFrontMatter 拆分以 --- 开头、以 --- 或 ... 结尾的前置元数据
+func splitFrontMatter(text string) (meta, body string, ok bool) {
+	normalized := strings.ReplaceAll(text, "\r\n", "\n")
+	if !strings.HasPrefix(normalized, "---\n") {
+		return "", text, false
+	}
+	rest := normalized[len("---\n"):]
+	for offset := 0; offset <= len(rest); {
+		end := strings.IndexByte(rest[offset:], '\n')
+		line := rest[offset:]
+		if end >= 0 {
+			line = rest[offset : offset+end]
+		}
+		if line == "---" || line == "..." {
+			body = ""
+			if end >= 0 {
+				body = strings.TrimLeft(rest[offset+end+1:], "\n")
+			}
+			return rest[:offset], body, true
+		}
+		if end < 0 {
+			break
+		}
+		offset += end + 1
+	}
+	return "", text, false
+}
+
+// applyFrontMatter 从前置元数据中读取标题、标签和时间
+func (n *importedNote) applyFrontMatter(meta string) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(meta), &doc); err != nil {
+		return fmt.Errorf("前置元数据格式错误: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	mapping := doc.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return errors.New("前置元数据格式错误: 不是键值对")
+	}
+
+	rest := &yaml.Node{Kind: yaml.MappingNode}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		name := strings.ToLower(key.Value)
+		if !frontMatterKeys[name] {
+			rest.Content = append(rest.Content, key, value)
+			continue
+		}
+		switch name {
+		case "id":
+			if value.Kind == yaml.ScalarNode {
+				n.ID = strings.TrimSpace(value.Value)
+			}
+		case "title":
+			if value.Kind == yaml.ScalarNode && strings.TrimSpace(value.Value) != "" {
+				n.Title = strings.TrimSpace(value.Value)
+			}
+		case "tags", "tag":
+			n.Tags = append(n.Tags, yamlTags(value)...)
+		case "created":
+			n.CreatedAt = parseImportTime(value)
+		case "updated", "modified":
+			n.UpdatedAt = parseImportTime(value)
+		}
+	}
+
+	if len(rest.Content) > 0 {
+		out, err := yaml.Marshal(rest)
+		if err != nil {
+			return err
+		}
+		n.YAMLMeta = string(out)
+	}
+	return nil
+}
+
+// yamlTags 读取列表或以逗号、空白分隔的字符串形式的标签
+func yamlTags(value *yaml.Node) []string {
+	var raw []string
+	switch value.Kind {
+	case yaml.SequenceNode:
+		for _, item := range value.Content {
+			if item.Kind == yaml.ScalarNode {
+				raw = append(raw, item.Value)
+			}
+		}
+	case yaml.ScalarNode:
+		raw = strings.FieldsFunc(value.Value, func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		})
+	}
+
+	tags := make([]string, 0, len(raw))
+	for _, tag := range raw {
+		if tag = normalizeTag(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// normalizeTag 去除标签的 # 前缀和首尾的 /
+func normalizeTag(tag string) string {
+	return strings.Trim(strings.TrimPrefix(strings.TrimSpace(tag), "#"), "/")
+}
+
+// importTimeLayouts 前置元数据中支持的时间格式
+var importTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseImportTime 解析前置元数据中的时间，无法解析时返回零值
+func parseImportTime(value *yaml.Node) time.Time {
+	if value.Kind != yaml.ScalarNode {
+		return time.Time{}
+	}
+	for _, layout := range importTimeLayouts {
+		if t, err := time.ParseInLocation(layout, strings.TrimSpace(value.Value), time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+var (
+	// inlineTagPattern 正文中的 #标签，# 前须为行首或空白，纯数字的不是标签
+	inlineTagPattern = regexp.MustCompile(`(?:^|[\s(])#([\p{L}\p{N}_\-/]+)`)
+	// inlineCodePattern 行内代码
+	inlineCodePattern = regexp.MustCompile("`[^`\n]*`")
+)
+
+// inlineTags 提取正文中的 #标签，忽略代码块和行内代码
+func inlineTags(content string) []string {
+	var tags []string
+	inFence := false
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		line = inlineCodePattern.ReplaceAllString(line, "")
+		for _, m := range inlineTagPattern.FindAllStringSubmatch(line, -1) {
+			tag := normalizeTag(m[1])
+			if tag != "" && strings.TrimFunc(tag, unicode.IsDigit) != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
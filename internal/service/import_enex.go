@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// enexTimeLayout ENEX 中的时间格式
+const enexTimeLayout = "20060102T150405Z"
+
+// enexNote ENEX 中的一篇笔记
+type enexNote struct {
+	Title     string         `xml:"title"`
+	Content   string         `xml:"content"`
+	Created   string         `xml:"created"`
+	Updated   string         `xml:"updated"`
+	Tags      []string       `xml:"tag"`
+	Author    string         `xml:"note-attributes>author"`
+	SourceURL string         `xml:"note-attributes>source-url"`
+	Resources []enexResource `xml:"resource"`
+}
+
+// enexResource ENEX 中的附件
+type enexResource struct {
+	Data     string `xml:"data"`
+	Mime     string `xml:"mime"`
+	FileName string `xml:"resource-attributes>file-name"`
+}
+
+// ImportENEX 导入 Evernote 导出的 ENEX 文件，fsys 中的每个 .enex 文件对应一个笔记本，
+// 以文件名（不含扩展名）作为目录；ENML 转换为 Markdown，附件保存到笔记库的 attachments 目录
+func (s *ImportService) ImportENEX(ctx context.Context, fsys fs.FS, opts ImportOptions) (*ImportResult, error) {
+	imp, err := s.newImporter(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	if err := walkImportFiles(fsys, func(p string, d fs.DirEntry) error {
+		if strings.EqualFold(path.Ext(p), ".enex") {
+			files = append(files, p)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	for _, p := range files {
+		n, err := countENEXNotes(fsys, p)
+		if err != nil {
+			return nil, fmt.Errorf("读取 %s 失败: %w", p, err)
+		}
+		imp.total += n
+	}
+
+	for _, p := range files {
+		if err := s.importENEXFile(imp, fsys, p); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			imp.add(ImportFileResult{Path: p, Status: ImportFailed, Message: err.Error()})
+		}
+	}
+
+	s.logger.Info("ENEX import finished",
+		zap.Int("created", imp.result.Created),
+		zap.Int("skipped", imp.result.Skipped),
+		zap.Int("failed", imp.result.Failed))
+	return imp.result, nil
+}
+
+// countENEXNotes 统计 ENEX 文件中的笔记数，用于报告进度
+func countENEXNotes(fsys fs.FS, p string) (int, error) {
+	f, err := fsys.Open(p)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	count := 0
+	dec := xml.NewDecoder(f)
+	dec.Strict = false
+	for {
+		tok, err := dec.RawToken()
+		if errors.Is(err, io.EOF) {
+			return count, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Local == "note" {
+			count++
+		}
+	}
+}
+
+// importENEXFile 逐篇解析并导入 ENEX 文件，单篇笔记的错误记录在结果中
+func (s *ImportService) importENEXFile(imp *importer, fsys fs.FS, p string) error {
+	f, err := fsys.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	notebook := strings.TrimSuffix(p, path.Ext(p))
+	dec := xml.NewDecoder(f)
+	dec.Strict = false
+	index := 0
+	for {
+		if err := imp.ctx.Err(); err != nil {
+			return err
+		}
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "note" {
+			continue
+		}
+
+		index++
+		var en enexNote
+		if err := dec.DecodeElement(&en, &start); err != nil {
+			return err
+		}
+		source := fmt.Sprintf("%s#%d", p, index)
+		note, warnings, err := s.convertENEXNote(imp.ctx, &en, notebook)
+		if err != nil {
+			imp.add(ImportFileResult{Path: source, Status: ImportFailed, Message: err.Error()})
+			continue
+		}
+		imp.importNote(note, warnings...)
+	}
+}
+
+// convertENEXNote 将 ENEX 笔记转换为待导入的笔记
+func (s *ImportService) convertENEXNote(ctx context.Context, en *enexNote, notebook string) (*importedNote, []string, error) {
+	var warnings []string
+	resources := make(map[string]enmlResource, len(en.Resources))
+	for _, r := range en.Resources {
+		data, err := base64.StdEncoding.DecodeString(strings.Map(dropSpace, r.Data))
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("附件 %q 无法解码", r.FileName))
+			continue
+		}
+		sum := md5.Sum(data)
+		res := enmlResource{Name: r.FileName, Mime: r.Mime}
+		if res.Path, err = s.saveAttachment(ctx, r.FileName, r.Mime, data); err != nil {
+			warnings = append(warnings, fmt.Sprintf("附件 %q 保存失败: %v", r.FileName, err))
+		}
+		resources[hex.EncodeToString(sum[:])] = res
+	}
+
+	content, missing, err := convertENML(en.Content, resources)
+	if err != nil {
+		return nil, nil, err
+	}
+	if missing > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d 个附件未能导入", missing))
+	}
+
+	title := strings.TrimSpace(en.Title)
+	note := &importedNote{
+		Path:    notebook + "/" + safeFileName(title) + ".md",
+		Title:   title,
+		Content: content,
+	}
+	if note.Title == "" {
+		note.Title = "未命名"
+	}
+	note.CreatedAt, _ = time.Parse(enexTimeLayout, strings.TrimSpace(en.Created))
+	note.UpdatedAt, _ = time.Parse(enexTimeLayout, strings.TrimSpace(en.Updated))
+	// Evernote 标签允许空格，转换为 - 以便作为 #标签 使用
+	for _, tag := range en.Tags {
+		if tag = strings.Join(strings.Fields(tag), "-"); tag != "" {
+			note.Tags = append(note.Tags, tag)
+		}
+	}
+
+	meta := make(map[string]string)
+	if en.Author != "" {
+		meta["author"] = en.Author
+	}
+	if en.SourceURL != "" {
+		meta["source"] = en.SourceURL
+	}
+	if len(meta) > 0 {
+		out, err := yaml.Marshal(meta)
+		if err != nil {
+			return nil, nil, err
+		}
+		note.YAMLMeta = string(out)
+	}
+	return note, warnings, nil
+}
+
+// saveAttachment 将附件保存到笔记库的 attachments 目录，相同内容只保存一份；
+// 返回相对于笔记库根目录的路径
+func (s *ImportService) saveAttachment(ctx context.Context, name, mimeType string, data []byte) (string, error) {
+	if s.attachments == nil {
+		return "", errors.New("未配置笔记库目录")
+	}
+	if path.Ext(name) == "" && mimeType != "" {
+		// 没有扩展名时按 ENEX 中声明的类型补上，便于识别 SVG 等无法按内容判断的类型
+		if ext := attachmentExts[mimeType]; ext != "" {
+			name += ext
+		} else if exts, err := mime.ExtensionsByType(mimeType); err == nil && len(exts) > 0 {
+			name += exts[0]
+		}
+	}
+	att, err := s.attachments.Save(ctx, name, data)
+	if err != nil {
+		return "", err
+	}
+	return att.Path, nil
+}
+
+// safeFileName 将标题转换为各平台都合法的文件名（不含扩展名）
+func safeFileName(title string) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(fileNameInvalidChars, r) {
+			return '-'
+		}
+		return r
+	}, title)
+	if runes := []rune(name); len(runes) > 200 {
+		name = string(runes[:200])
+	}
+	name = strings.TrimRight(strings.TrimSpace(name), ". ")
+	if name == "" {
+		return "未命名"
+	}
+	if reservedFileNames[strings.ToUpper(strings.SplitN(name, ".", 2)[0])] {
+		name += "_"
+	}
+	return name
+}
+
+// dropSpace 用于 strings.Map，删除空白字符
+func dropSpace(r rune) rune {
+	if unicode.IsSpace(r) {
+		return -1
+	}
+	return r
+}
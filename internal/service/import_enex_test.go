@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"leafnote/internal/model"
+	"leafnote/internal/testutil"
+)
+
+func TestConvertENML(t *testing.T) {
+	resources := map[string]enmlResource{
+		"aaa": {Name: "图.png", Mime: "image/png", Path: "attachments/aaa.png"},
+		"bbb": {Name: "说明.pdf", Mime: "application/pdf", Path: "attachments/bbb.pdf"},
+	}
+	tests := []struct {
+		name        string
+		content     string
+		want        string
+		wantMissing int
+	}{
+		{
+			name:    "段落和行内格式",
+			content: `<en-note><div>第一行 <b>粗体</b> <i>斜体</i> <s>删除</s></div><div>第二行<br/>换行</div></en-note>`,
+			want:    "第一行 **粗体** *斜体* ~~删除~~\n\n第二行\n换行\n",
+		},
+		{
+			name:    "标题和链接",
+			content: `<en-note><h2>标题</h2><p><a href="https://example.com">链接</a></p></en-note>`,
+			want:    "## 标题\n\n[链接](https://example.com)\n",
+		},
+		{
+			name:    "待办事项",
+			content: `<en-note><div><en-todo checked="true"/>已完成</div><div><en-todo/>未完成</div></en-note>`,
+			want:    "- [x] 已完成\n\n- [ ] 未完成\n",
+		},
+		{
+			name:    "嵌套列表",
+			content: `<en-note><ul><li>一<ol><li>甲</li><li>乙</li></ol></li><li>二</li></ul></en-note>`,
+			want:    "- 一\n\n  1. 甲\n  2. 乙\n- 二\n",
+		},
+		{
+			name:    "代码块",
+			content: `<en-note><div style="-en-codeblock: true;"><div>a := 1</div><div>b := a &lt; 2</div></div></en-note>`,
+			want:    "```\na := 1\nb := a < 2\n```\n",
+		},
+		{
+			name:    "表格",
+			content: `<en-note><table><tr><td>名称</td><td>值</td></tr><tr><td>a|b</td><td>1</td></tr></table></en-note>`,
+			want:    "| 名称 | 值 |\n| --- | --- |\n| a\\|b | 1 |\n",
+		},
+		{
+			name:        "附件",
+			content:     `<en-note><div><en-media hash="AAA" type="image/png"/></div><div><en-media hash="bbb" type="application/pdf"/></div><en-media hash="ccc"/></en-note>`,
+			want:        "![图.png](attachments/aaa.png)\n\n[说明.pdf](attachments/bbb.pdf)\n",
+			wantMissing: 1,
+		},
+		{
+			name:    "HTML 实体和未闭合标签",
+			content: `<en-note><div>A&nbsp;&amp;&nbsp;B<br></div></en-note>`,
+			want:    "A & B\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, missing, err := convertENML(tt.content, resources)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantMissing, missing)
+		})
+	}
+}
+
+func TestImportService_ImportENEX(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	vault := t.TempDir()
+	s := NewImportService(db, zap.NewNop(), NewAttachmentService(db, zap.NewNop(), AttachmentOptions{VaultDir: vault}))
+
+	image := []byte("fake png data")
+	sum := md5.Sum(image)
+	hash := hex.EncodeToString(sum[:])
+	stored := sha256.Sum256(image)
+	file := hex.EncodeToString(stored[:]) + ".png"
+	resource := `<resource>
+    <data encoding="base64">
+` + base64.StdEncoding.EncodeToString(image) + `
+    </data>
+    <mime>image/png</mime>
+    <resource-attributes><file-name>截图.png</file-name></resource-attributes>
+  </resource>`
+	// 第二篇笔记内容与第一篇相同，导入时跳过
+	enex := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-export SYSTEM "http://xml.evernote.com/pub/evernote-export4.dtd">
+<en-export>
+<note>
+  <title>会议记录</title>
+  <content><![CDATA[<?xml version="1.0" encoding="UTF-8"?><!DOCTYPE en-note SYSTEM "http://xml.evernote.com/pub/enml2.dtd"><en-note><div>议题</div><en-media hash="` + hash + `" type="image/png"/></en-note>]]></content>
+  <created>20230102T030405Z</created>
+  <updated>20230203T040506Z</updated>
+  <tag>工作</tag>
+  <tag>待 办</tag>
+  <note-attributes><author>张三</author></note-attributes>
+  ` + resource + `
+</note>
+<note>
+  <title>会议记录副本</title>
+  <content><![CDATA[<en-note><div>议题</div><en-media hash="` + hash + `" type="image/png"/></en-note>]]></content>
+  ` + resource + `
+</note>
+</en-export>`
+
+	var progress [][2]int
+	result, err := s.ImportENEX(context.Background(), fstest.MapFS{
+		"我的笔记本.enex": {Data: []byte(enex)},
+	}, ImportOptions{Progress: func(done, total int) {
+		progress = append(progress, [2]int{done, total})
+	}})
+	require.NoError(t, err)
+	require.Len(t, result.Files, 2)
+	assert.Equal(t, 1, result.Created)
+	assert.Equal(t, 1, result.Skipped)
+	assert.Equal(t, result.Files[0].NoteID, result.Files[1].NoteID)
+	assert.Equal(t, [][2]int{{1, 2}, {2, 2}}, progress)
+
+	var note model.Note
+	require.NoError(t, db.Preload("Category").Preload("Tags").First(&note, "id = ?", result.Files[0].NoteID).Error)
+	assert.Equal(t, "会议记录", note.Title)
+	assert.Equal(t, "/我的笔记本/会议记录.md", note.FilePath)
+	assert.Equal(t, "/我的笔记本", note.Category.Path)
+	assert.Equal(t, "议题\n![截图.png](attachments/"+file+")\n", note.Content)
+	assert.Equal(t, "author: 张三\n", note.YAMLMeta)
+	assert.True(t, note.CreatedAt.Equal(time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)))
+	assert.True(t, note.UpdatedAt.Equal(time.Date(2023, 2, 3, 4, 5, 6, 0, time.UTC)))
+	var tags []string
+	for _, tag := range note.Tags {
+		tags = append(tags, tag.Name)
+	}
+	assert.ElementsMatch(t, []string{"工作", "待-办"}, tags)
+
+	saved, err := os.ReadFile(filepath.Join(vault, "attachments", file))
+	require.NoError(t, err)
+	assert.Equal(t, image, saved)
+	// 导入的附件记录被笔记引用，重复的资源只保存一份
+	var refs []model.NoteAttachment
+	require.NoError(t, db.Find(&refs).Error)
+	require.Len(t, refs, 1)
+	assert.Equal(t, note.ID, refs[0].NoteID)
+}
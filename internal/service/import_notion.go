@@ -0,0 +1,260 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+// maxNotionArchiveSize Notion 导出中嵌套 zip 的大小上限，嵌套 zip 需要读入内存
+const maxNotionArchiveSize = 1 << 30
+
+var (
+	// notionIDSuffix Notion 在文件和文件夹名后（扩展名前）追加的 32 位十六进制页面 ID
+	notionIDSuffix = regexp.MustCompile(`\s+[0-9a-f]{32}(\.\w+)?$`)
+	// markdownLink Markdown 链接和图片，不含带标题的链接
+	markdownLink = regexp.MustCompile(`(!?\[[^\]]*\])\(([^)\s]+)\)`)
+)
+
+// ImportNotion 导入 Notion 导出的 Markdown & CSV，fsys 为解压后的导出文件；
+// 去除名称中的页面 ID，文件夹转换为目录，数据库 CSV 转换为表格笔记，
+// 页面引用的其他文件保存为附件，导出中嵌套的 zip 会被展开
+func (s *ImportService) ImportNotion(ctx context.Context, fsys fs.FS, opts ImportOptions) (*ImportResult, error) {
+	imp, err := s.newImporter(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.importNotionFS(imp, fsys); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Notion import finished",
+		zap.Int("created", imp.result.Created),
+		zap.Int("skipped", imp.result.Skipped),
+		zap.Int("failed", imp.result.Failed))
+	return imp.result, nil
+}
+
+// importNotionFS 导入 fsys 中的页面和数据库，嵌套的 zip 递归导入
+func (s *ImportService) importNotionFS(imp *importer, fsys fs.FS) error {
+	var pages, archives []string
+	if err := walkImportFiles(fsys, func(p string, d fs.DirEntry) error {
+		switch ext := strings.ToLower(path.Ext(p)); {
+		case ext == ".md":
+			pages = append(pages, p)
+		case ext == ".csv" && !strings.HasSuffix(strings.TrimSuffix(p, path.Ext(p)), "_all"):
+			// 同一数据库还会导出包含所有行的 _all.csv，只导入当前视图
+			pages = append(pages, p)
+		case ext == ".zip":
+			archives = append(archives, p)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	imp.total += len(pages)
+
+	for _, p := range pages {
+		if err := imp.ctx.Err(); err != nil {
+			return err
+		}
+		note, warnings, err := s.readNotionPage(imp.ctx, fsys, p)
+		if err != nil {
+			imp.add(ImportFileResult{Path: p, Status: ImportFailed, Message: err.Error()})
+			continue
+		}
+		imp.importNote(note, warnings...)
+	}
+
+	for _, p := range archives {
+		zr, err := openNestedZip(fsys, p)
+		if err != nil {
+			imp.add(ImportFileResult{Path: p, Status: ImportFailed, Message: err.Error()})
+			continue
+		}
+		if err := s.importNotionFS(imp, zr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openNestedZip 打开导出中嵌套的 zip
+func openNestedZip(fsys fs.FS, p string) (*zip.Reader, error) {
+	f, err := fsys.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxNotionArchiveSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxNotionArchiveSize {
+		return nil, fmt.Errorf("文件超过 %d MiB", maxNotionArchiveSize>>20)
+	}
+	return zip.NewReader(bytes.NewReader(data), int64(len(data)))
+}
+
+// readNotionPage 读取 Notion 页面或数据库 CSV
+func (s *ImportService) readNotionPage(ctx context.Context, fsys fs.FS, p string) (*importedNote, []string, error) {
+	f, err := fsys.Open(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(f, maxImportFileSize+1))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(data) > maxImportFileSize {
+		return nil, nil, fmt.Errorf("文件超过 %d MiB", maxImportFileSize>>20)
+	}
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	if !utf8.Valid(data) {
+		return nil, nil, errors.New("不是 UTF-8 编码的文本文件")
+	}
+
+	target := strings.TrimSuffix(stripNotionPath(p), path.Ext(p)) + ".md"
+	var note *importedNote
+	var warnings []string
+	if strings.EqualFold(path.Ext(p), ".csv") {
+		content, err := csvToMarkdown(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		note = &importedNote{Content: content}
+	} else {
+		content, title := notionTitle(string(data))
+		content, warnings = s.rewriteNotionLinks(ctx, fsys, p, content)
+		if note, err = parseMarkdown(target, []byte(content)); err != nil {
+			return nil, nil, err
+		}
+		if title != "" {
+			note.Title = title
+		}
+	}
+	note.Path = target
+	if note.Title == "" {
+		note.Title = strings.TrimSuffix(path.Base(target), ".md")
+	}
+	note.CreatedAt = info.ModTime()
+	note.UpdatedAt = info.ModTime()
+	return note, warnings, nil
+}
+
+// stripNotionPath 去除路径中每一级名称后的页面 ID
+func stripNotionPath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		segments[i] = notionIDSuffix.ReplaceAllString(segment, "$1")
+	}
+	return strings.Join(segments, "/")
+}
+
+// notionTitle 取出 Notion 页面开头的一级标题作为笔记标题，并从正文中移除
+func notionTitle(content string) (string, string) {
+	first, rest, _ := strings.Cut(content, "\n")
+	first = strings.TrimRight(first, "\r")
+	if !strings.HasPrefix(first, "# ") {
+		return content, ""
+	}
+	return strings.TrimLeft(rest, "\r\n"), strings.TrimSpace(first[2:])
+}
+
+// rewriteNotionLinks 改写页面中的相对链接：指向其他页面的链接去除页面 ID，
+// 指向其他文件的链接保存为附件并改为附件路径
+func (s *ImportService) rewriteNotionLinks(ctx context.Context, fsys fs.FS, page, content string) (string, []string) {
+	var warnings []string
+	dir := path.Dir(page)
+	content = markdownLink.ReplaceAllStringFunc(content, func(link string) string {
+		m := markdownLink.FindStringSubmatch(link)
+		label, target := m[1], m[2]
+		if strings.Contains(target, "://") || strings.HasPrefix(target, "#") || strings.HasPrefix(target, "mailto:") {
+			return link
+		}
+		decoded, err := url.PathUnescape(target)
+		if err != nil {
+			return link
+		}
+
+		if strings.EqualFold(path.Ext(decoded), ".md") {
+			return label + "(" + strings.ReplaceAll(stripNotionPath(decoded), " ", "%20") + ")"
+		}
+		data, err := s.readLinkedFile(fsys, path.Join(dir, decoded))
+		if errors.Is(err, fs.ErrNotExist) {
+			warnings = append(warnings, fmt.Sprintf("找不到链接的文件 %q", decoded))
+			return link
+		}
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("附件 %q 读取失败: %v", decoded, err))
+			return link
+		}
+		rel, err := s.saveAttachment(ctx, path.Base(decoded), "", data)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("附件 %q 保存失败: %v", decoded, err))
+			return link
+		}
+		return label + "(" + rel + ")"
+	})
+	return content, warnings
+}
+
+// readLinkedFile 读取页面链接的文件，最多读取附件的大小上限（未设置时为单个导入文件的上限），
+// 超过时返回 ErrAttachmentTooLarge，避免压缩率极高的文件占满内存
+func (s *ImportService) readLinkedFile(fsys fs.FS, p string) ([]byte, error) {
+	limit := int64(maxImportFileSize)
+	if s.attachments != nil && s.attachments.opts.MaxSize > 0 {
+		limit = s.attachments.opts.MaxSize
+	}
+	f, err := fsys.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, ErrAttachmentTooLarge
+	}
+	return data, nil
+}
+
+// csvToMarkdown 将 Notion 数据库导出的 CSV 转换为 Markdown 表格
+func csvToMarkdown(data []byte) (string, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return "", fmt.Errorf("CSV 格式错误: %w", err)
+	}
+	for _, row := range records {
+		for i, cell := range row {
+			cell = strings.ReplaceAll(strings.ReplaceAll(cell, "\r\n", "\n"), "\n", "<br>")
+			row[i] = strings.ReplaceAll(cell, "|", `\|`)
+		}
+	}
+	table := markdownTable(records)
+	if table == "" {
+		return "", nil
+	}
+	return table + "\n", nil
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"leafnote/internal/model"
+	"leafnote/internal/testutil"
+)
+
+func TestStripNotionPath(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "文件和文件夹", path: "工作 0123456789abcdef0123456789abcdef/计划 fedcba9876543210fedcba9876543210", want: "工作/计划"},
+		{name: "没有页面 ID", path: "工作/计划", want: "工作/计划"},
+		{name: "扩展名前的 ID", path: "工作/计划 fedcba9876543210fedcba9876543210.md", want: "工作/计划.md"},
+		{name: "ID 长度不符", path: "计划 0123456789abcdef", want: "计划 0123456789abcdef"},
+		{name: "大写十六进制", path: "计划 0123456789ABCDEF0123456789ABCDEF", want: "计划 0123456789ABCDEF0123456789ABCDEF"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, stripNotionPath(tt.path))
+		})
+	}
+}
+
+func TestImportService_ImportNotion(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	vault := t.TempDir()
+	s := NewImportService(db, zap.NewNop(), NewAttachmentService(db, zap.NewNop(), AttachmentOptions{VaultDir: vault, MaxSize: 64}))
+
+	const (
+		workID = "0123456789abcdef0123456789abcdef"
+		planID = "fedcba9876543210fedcba9876543210"
+		taskID = "00000000000000000000000000000001"
+	)
+	image := []byte("fake png data")
+	sum := sha256.Sum256(image)
+
+	var part bytes.Buffer
+	zw := zip.NewWriter(&part)
+	w, err := zw.Create("其他 00000000000000000000000000000002.md")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("# 其他\n\n第二部分"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	fsys := fstest.MapFS{
+		"工作 " + workID + ".md": {Data: []byte("# 工作\n\n见 [计划](%E5%B7%A5%E4%BD%9C%20" + workID + "/%E8%AE%A1%E5%88%92%20" + planID + ".md)" +
+			" ![图](%E5%B7%A5%E4%BD%9C%20" + workID + "/image.png) [外链](https://example.com/a%20b) ![丢失](missing.png)\n")},
+		"工作 " + workID + "/计划 " + planID + ".md": {Data: []byte("# 计划\n\n内容 #待办 [大文件](%E5%A4%A7.bin)\n")},
+		"工作 " + workID + "/大.bin":                {Data: make([]byte, 65)},
+		"工作 " + workID + "/image.png":            {Data: image},
+		"任务 " + taskID + ".csv":                  {Data: []byte("名称,状态\n写文档,\"进行|中\"\n")},
+		"任务 " + taskID + "_all.csv":              {Data: []byte("名称,状态\n")},
+		"Part-2.zip":                             {Data: part.Bytes()},
+	}
+
+	result, err := s.ImportNotion(context.Background(), fsys, ImportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 4, result.Created)
+	assert.Zero(t, result.Failed)
+
+	notes := make(map[string]model.Note)
+	var all []model.Note
+	require.NoError(t, db.Preload("Category").Find(&all).Error)
+	for _, n := range all {
+		notes[n.FilePath] = n
+	}
+
+	work := notes["/工作.md"]
+	assert.Equal(t, "工作", work.Title)
+	assert.Equal(t, "见 [计划](工作/计划.md) ![图](attachments/"+hex.EncodeToString(sum[:])+".png)"+
+		" [外链](https://example.com/a%20b) ![丢失](missing.png)\n", work.Content)
+	assert.Equal(t, "计划", notes["/工作/计划.md"].Title)
+	assert.Equal(t, "/工作", notes["/工作/计划.md"].Category.Path)
+	assert.Equal(t, "| 名称 | 状态 |\n| --- | --- |\n| 写文档 | 进行\\|中 |\n", notes["/任务.md"].Content)
+	assert.Equal(t, "第二部分", notes["/其他.md"].Content)
+
+	warnings := make(map[string][]string)
+	for _, f := range result.Files {
+		warnings[f.NoteID] = f.Warnings
+	}
+	assert.Len(t, warnings[work.ID], 1)
+	// 附件与上传的附件一样受大小上限限制
+	require.Len(t, warnings[notes["/工作/计划.md"].ID], 1)
+	assert.Contains(t, warnings[notes["/工作/计划.md"].ID][0], "大.bin")
+	_, err = s.readLinkedFile(fsys, "工作 "+workID+"/大.bin")
+	assert.ErrorIs(t, err, ErrAttachmentTooLarge)
+
+	saved, err := os.ReadFile(filepath.Join(vault, "attachments", hex.EncodeToString(sum[:])+".png"))
+	require.NoError(t, err)
+	assert.Equal(t, image, saved)
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"leafnote/internal/model"
+	"leafnote/internal/testutil"
+)
+
+func TestParseMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want importedNote
+	}{
+		{
+			name: "无前置元数据",
+			data: "正文 #待办 和 #项目/leafnote\n",
+			want: importedNote{Title: "笔记", Content: "正文 #待办 和 #项目/leafnote\n", Tags: []string{"待办", "项目/leafnote"}},
+		},
+		{
+			name: "前置元数据",
+			data: "---\nid: 0b6f3c2e-5a1d-4e8f-9c7b-2d4a6e8f0a1c\ntitle: 标题\ntags: [a, \"#b/c\"]\ncreated: 2024-01-02\nupdated: 2024-03-04T05:06:07Z\naliases: [x]\n---\n\n正文\n",
+			want: importedNote{
+				ID:        "0b6f3c2e-5a1d-4e8f-9c7b-2d4a6e8f0a1c",
+				Title:     "标题",
+				Content:   "正文\n",
+				YAMLMeta:  "aliases: [x]\n",
+				Tags:      []string{"a", "b/c"},
+				CreatedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local),
+				UpdatedAt: time.Date(2024, 3, 4, 5, 6, 7, 0, time.UTC),
+			},
+		},
+		{
+			name: "字符串形式的标签",
+			data: "---\ntags: a, b c\n---\n",
+			want: importedNote{Title: "笔记", Tags: []string{"a", "b", "c"}},
+		},
+		{
+			name: "忽略代码、标题和纯数字",
+			data: "# 标题\n`#code` #123 issue#1\n```\n#fenced\n```\n(#ok)\n",
+			want: importedNote{Title: "笔记", Content: "# 标题\n`#code` #123 issue#1\n```\n#fenced\n```\n(#ok)\n", Tags: []string{"ok"}},
+		},
+		{
+			name: "未闭合的前置元数据视为正文",
+			data: "---\ntitle: x\n",
+			want: importedNote{Title: "笔记", Content: "---\ntitle: x\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMarkdown("目录/笔记.md", []byte(tt.data))
+			require.NoError(t, err)
+			tt.want.Path = "目录/笔记.md"
+			assert.Equal(t, tt.want.ID, got.ID)
+			assert.Equal(t, tt.want.Title, got.Title)
+			assert.Equal(t, tt.want.Content, got.Content)
+			assert.Equal(t, tt.want.YAMLMeta, got.YAMLMeta)
+			assert.Equal(t, tt.want.Tags, got.Tags)
+			assert.True(t, tt.want.CreatedAt.Equal(got.CreatedAt), "created: %v", got.CreatedAt)
+			assert.True(t, tt.want.UpdatedAt.Equal(got.UpdatedAt), "updated: %v", got.UpdatedAt)
+		})
+	}
+
+	_, err := parseMarkdown("a.md", []byte("---\n- list\n---\n"))
+	assert.Error(t, err)
+}
+
+func TestImportService_ImportMarkdown(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	s := NewImportService(db, zap.NewNop(), nil)
+	modTime := time.Date(2023, 5, 6, 7, 8, 9, 0, time.UTC)
+	const noteID = "0b6f3c2e-5a1d-4e8f-9c7b-2d4a6e8f0a1c"
+
+	fsys := fstest.MapFS{
+		"工作/项目/计划.md":      {Data: []byte("---\ntags: [语言/Go]\n---\n计划 #待办\n"), ModTime: modTime},
+		"工作/周报.md":         {Data: []byte("---\ntags: ['非法\\标签']\n---\n周报 #待办\n"), ModTime: modTime},
+		"工作/周报副本.md":       {Data: []byte("周报 #待办\n"), ModTime: modTime},
+		"根目录.md":           {Data: []byte("根目录"), ModTime: modTime},
+		"带ID.md":           {Data: []byte("---\nid: " + noteID + "\n---\n带ID\n")},
+		"重复ID.md":          {Data: []byte("---\nid: " + noteID + "\n---\n重复ID\n")},
+		"非法ID.md":          {Data: []byte("---\nid: abc\n---\n非法ID\n")},
+		"图片.png":           {Data: []byte{0x89, 'P', 'N', 'G'}},
+		"坏:名.md":           {Data: []byte("非法文件名")},
+		"二进制.md":           {Data: []byte{0xff, 0xfe}},
+		".obsidian/app.md": {Data: []byte("配置")},
+	}
+
+	result, err := s.ImportMarkdown(context.Background(), fsys, ImportOptions{})
+	require.NoError(t, err)
+
+	status := make(map[string]ImportFileResult)
+	for _, f := range result.Files {
+		status[f.Path] = f
+	}
+	assert.Equal(t, 10, result.Total)
+	assert.Equal(t, 6, result.Created)
+	assert.Equal(t, 2, result.Skipped)
+	assert.Equal(t, 2, result.Failed)
+	assert.NotContains(t, status, ".obsidian/app.md")
+	assert.Equal(t, ImportSkipped, status["图片.png"].Status)
+	assert.Equal(t, ImportFailed, status["坏:名.md"].Status)
+	assert.Equal(t, ImportFailed, status["二进制.md"].Status)
+	assert.NotEmpty(t, status["工作/周报.md"].Warnings)
+
+	// 前置元数据中的ID可用时保留，非法或已被占用时生成新的ID
+	assert.Equal(t, noteID, status["带ID.md"].NoteID)
+	assert.Empty(t, status["带ID.md"].Warnings)
+	for _, p := range []string{"重复ID.md", "非法ID.md"} {
+		assert.Equal(t, ImportCreated, status[p].Status, p)
+		assert.NotEqual(t, noteID, status[p].NoteID, p)
+		assert.Len(t, status[p].Warnings, 1, p)
+	}
+
+	// 内容重复的文件跳过并指向已导入的笔记
+	dup := status["工作/周报副本.md"]
+	assert.Equal(t, ImportSkipped, dup.Status)
+	assert.Equal(t, status["工作/周报.md"].NoteID, dup.NoteID)
+
+	var plan model.Note
+	require.NoError(t, db.Preload("Category").Preload("Tags").First(&plan, "id = ?", status["工作/项目/计划.md"].NoteID).Error)
+	assert.Equal(t, "计划", plan.Title)
+	assert.Equal(t, "/工作/项目/计划.md", plan.FilePath)
+	assert.Equal(t, "/工作/项目", plan.Category.Path)
+	assert.True(t, plan.CreatedAt.Equal(modTime))
+	assert.True(t, plan.UpdatedAt.Equal(modTime))
+	var tagIDs []string
+	for _, tag := range plan.Tags {
+		tagIDs = append(tagIDs, tag.ID)
+	}
+	names, err := NewExportService(db, zap.NewNop()).tagNames()
+	require.NoError(t, err)
+	var tagNames []string
+	for _, id := range tagIDs {
+		tagNames = append(tagNames, names[id])
+	}
+	assert.ElementsMatch(t, []string{"语言/Go", "待办"}, tagNames)
+
+	// 再次导入时全部跳过，不会重复创建目录和标签
+	again, err := s.ImportMarkdown(context.Background(), fsys, ImportOptions{})
+	require.NoError(t, err)
+	assert.Zero(t, again.Created)
+	var categories, tags int64
+	db.Model(&model.Category{}).Count(&categories)
+	db.Model(&model.Tag{}).Count(&tags)
+	assert.Equal(t, int64(2), categories)
+	assert.Equal(t, int64(3), tags)
+}
+
+func TestImportService_IntoCategory(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	parent := &model.Category{Name: "导入"}
+	require.NoError(t, NewCategoryService(db).CreateCategory(context.Background(), parent))
+	s := NewImportService(db, zap.NewNop(), nil)
+
+	result, err := s.ImportMarkdown(context.Background(), fstest.MapFS{
+		"a/b.md": {Data: []byte("内容")},
+	}, ImportOptions{CategoryID: &parent.ID})
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Created)
+
+	var note model.Note
+	require.NoError(t, db.Preload("Category").First(&note, "id = ?", result.Files[0].NoteID).Error)
+	assert.Equal(t, "/导入/a/b.md", note.FilePath)
+	assert.Equal(t, "/导入/a", note.Category.Path)
+	assert.Equal(t, parent.ID, *note.Category.ParentID)
+
+	missing := "missing"
+	_, err = s.ImportMarkdown(context.Background(), fstest.MapFS{}, ImportOptions{CategoryID: &missing})
+	assert.ErrorIs(t, err, ErrValidationFailed)
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"leafnote/internal/model"
+)
+
+const (
+	// jobQueueSize 等待执行的任务数上限
+	jobQueueSize = 64
+	// jobProgressInterval 任务进度写入数据库的最小间隔
+	jobProgressInterval = 500 * time.Millisecond
+	// maxListJobs 任务列表最多返回的数量
+	maxListJobs = 100
+)
+
+// JobFunc 后台任务的执行函数，通过 progress 报告进度，返回值序列化为 JSON 保存为任务结果
+type JobFunc func(ctx context.Context, progress func(done, total int)) (interface{}, error)
+
+// queuedJob 等待执行的任务
+type queuedJob struct {
+	id string
+	fn JobFunc
+}
+
+// JobService 按提交顺序逐个执行后台任务，并在数据库中记录状态和进度
+type JobService struct {
+	db     *gorm.DB
+	logger *zap.Logger
+	queue  chan queuedJob
+	since  time.Time // 服务创建时间，之前创建的未完成任务属于上次运行
+}
+
+// NewJobService 创建任务服务实例，需要通过 Run 启动执行
+func NewJobService(db *gorm.DB, logger *zap.Logger) *JobService {
+	return &JobService{
+		db:     db,
+		logger: logger,
+		queue:  make(chan queuedJob, jobQueueSize),
+		since:  time.Now(),
+	}
+}
+
+// Enqueue 创建任务并加入队列，队列已满时返回 ErrJobQueueFull
+func (s *JobService) Enqueue(jobType string, fn JobFunc) (*model.Job, error) {
+	job := &model.Job{Type: jobType, Status: model.JobPending}
+	if err := s.db.Create(job).Error; err != nil {
+		return nil, err
+	}
+	select {
+	case s.queue <- queuedJob{id: job.ID, fn: fn}:
+		return job, nil
+	default:
+		s.finish(job.ID, nil, ErrJobQueueFull)
+		return nil, ErrJobQueueFull
+	}
+}
+
+// GetJob 获取任务
+func (s *JobService) GetJob(id string) (*model.Job, error) {
+	var job model.Job
+	if err := s.db.First(&job, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJobNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ListJobs 按创建时间倒序列出最近的任务
+func (s *JobService) ListJobs() ([]model.Job, error) {
+	var jobs []model.Job
+	err := s.db.Omit("result").Order("created_at DESC").Limit(maxListJobs).Find(&jobs).Error
+	return jobs, err
+}
+
+// Run 逐个执行队列中的任务，ctx 取消时中断当前任务并返回；
+// 启动时将上次运行未执行完的任务标记为失败
+func (s *JobService) Run(ctx context.Context) error {
+	if err := s.db.Model(&model.Job{}).
+		Where("status IN ? AND created_at < ?", []model.JobStatus{model.JobPending, model.JobRunning}, s.since).
+		Updates(map[string]interface{}{
+			"status":      model.JobFailed,
+			"error":       "服务重启，任务中断",
+			"finished_at": time.Now(),
+		}).Error; err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case job := <-s.queue:
+			s.run(ctx, job)
+		}
+	}
+}
+
+// run 执行单个任务并记录结果，任务中的 panic 记为失败
+func (s *JobService) run(ctx context.Context, job queuedJob) {
+	start := time.Now()
+	if err := s.db.Model(&model.Job{}).Where("id = ?", job.id).Updates(map[string]interface{}{
+		"status":     model.JobRunning,
+		"started_at": start,
+	}).Error; err != nil {
+		s.logger.Error("Failed to start job", zap.String("job_id", job.id), zap.Error(err))
+		return
+	}
+
+	var lastUpdate time.Time
+	progress := func(done, total int) {
+		if time.Since(lastUpdate) < jobProgressInterval && (total == 0 || done < total) {
+			return
+		}
+		lastUpdate = time.Now()
+		if err := s.db.Model(&model.Job{}).Where("id = ?", job.id).
+			Updates(map[string]interface{}{"done": done, "total": total}).Error; err != nil {
+			s.logger.Warn("Failed to update job progress", zap.String("job_id", job.id), zap.Error(err))
+		}
+	}
+
+	result, err := func() (result interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("任务异常: %v", r)
+			}
+		}()
+		return job.fn(ctx, progress)
+	}()
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	s.finish(job.id, result, err)
+	s.logger.Info("Job finished",
+		zap.String("job_id", job.id),
+		zap.Bool("success", err == nil),
+		zap.Duration("cost", time.Since(start)))
+}
+
+// finish 保存任务结果和最终状态
+func (s *JobService) finish(id string, result interface{}, jobErr error) {
+	updates := map[string]interface{}{
+		"status":      model.JobSucceeded,
+		"finished_at": time.Now(),
+	}
+	if result != nil {
+		data, err := json.Marshal(result)
+		if err != nil {
+			jobErr = errors.Join(jobErr, err)
+		} else {
+			updates["result"] = data
+		}
+	}
+	if jobErr != nil {
+		updates["status"] = model.JobFailed
+		updates["error"] = jobErr.Error()
+		if errors.Is(jobErr, context.Canceled) {
+			updates["error"] = "服务停止，任务中断"
+		}
+	}
+	if err := s.db.Model(&model.Job{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		s.logger.Error("Failed to save job result", zap.String("job_id", id), zap.Error(err))
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"leafnote/internal/model"
+	"leafnote/internal/testutil"
+)
+
+// waitJob 等待任务结束并返回最终状态
+func waitJob(t *testing.T, s *JobService, id string) *model.Job {
+	t.Helper()
+	var job *model.Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = s.GetJob(id)
+		require.NoError(t, err)
+		return job.Status == model.JobSucceeded || job.Status == model.JobFailed
+	}, 5*time.Second, 10*time.Millisecond)
+	return job
+}
+
+func TestJobService_Run(t *testing.T) {
+	db := testutil.NewTestDB(t)
+
+	// 上次运行遗留的未完成任务
+	stale := &model.Job{Type: "import", Status: model.JobRunning}
+	require.NoError(t, db.Create(stale).Error)
+	require.NoError(t, db.Model(stale).UpdateColumn("created_at", time.Now().Add(-time.Hour)).Error)
+
+	s := NewJobService(db, zap.NewNop())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+	defer func() {
+		cancel()
+		require.NoError(t, <-done)
+	}()
+
+	tests := []struct {
+		name       string
+		fn         JobFunc
+		wantStatus model.JobStatus
+		wantResult string
+		wantError  string
+	}{
+		{
+			name: "执行成功",
+			fn: func(ctx context.Context, progress func(done, total int)) (interface{}, error) {
+				progress(1, 2)
+				progress(2, 2)
+				return map[string]int{"created": 2}, nil
+			},
+			wantStatus: model.JobSucceeded,
+			wantResult: `{"created":2}`,
+		},
+		{
+			name: "执行失败",
+			fn: func(ctx context.Context, progress func(done, total int)) (interface{}, error) {
+				return nil, errors.New("读取失败")
+			},
+			wantStatus: model.JobFailed,
+			wantError:  "读取失败",
+		},
+		{
+			name: "任务 panic",
+			fn: func(ctx context.Context, progress func(done, total int)) (interface{}, error) {
+				panic("意外错误")
+			},
+			wantStatus: model.JobFailed,
+			wantError:  "任务异常: 意外错误",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job, err := s.Enqueue("test", tt.fn)
+			require.NoError(t, err)
+			assert.Equal(t, model.JobPending, job.Status)
+
+			got := waitJob(t, s, job.ID)
+			assert.Equal(t, tt.wantStatus, got.Status)
+			assert.Equal(t, tt.wantError, got.Error)
+			assert.NotNil(t, got.StartedAt)
+			assert.NotNil(t, got.FinishedAt)
+			if tt.wantResult != "" {
+				assert.JSONEq(t, tt.wantResult, string(got.Result))
+				assert.Equal(t, 2, got.Done)
+				assert.Equal(t, 2, got.Total)
+			}
+		})
+	}
+
+	got, err := s.GetJob(stale.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.JobFailed, got.Status)
+	assert.Equal(t, "服务重启，任务中断", got.Error)
+
+	jobs, err := s.ListJobs()
+	require.NoError(t, err)
+	assert.Len(t, jobs, 4)
+	for _, job := range jobs {
+		assert.Empty(t, job.Result)
+	}
+
+	_, err = s.GetJob("missing")
+	assert.ErrorIs(t, err, ErrJobNotFound)
+}
+
+func TestJobService_QueueFull(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	s := NewJobService(db, zap.NewNop())
+	noop := func(ctx context.Context, progress func(done, total int)) (interface{}, error) {
+		return nil, nil
+	}
+
+	// 未启动 Run 时任务只进入队列
+	for i := 0; i < jobQueueSize; i++ {
+		_, err := s.Enqueue("test", noop)
+		require.NoError(t, err)
+	}
+	_, err := s.Enqueue("test", noop)
+	assert.ErrorIs(t, err, ErrJobQueueFull)
+
+	var failed model.Job
+	require.NoError(t, db.Where("status = ?", model.JobFailed).First(&failed).Error)
+	assert.Equal(t, ErrJobQueueFull.Error(), failed.Error)
+}
@@ -6,10 +6,12 @@ import (
 	"errors"
 	"fmt"
 	"leafnote/internal/model"
-	"os"
+	"leafnote/internal/plugin"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -18,6 +20,7 @@ import (
 type NoteService struct {
 	db     *gorm.DB
 	logger *zap.Logger
+	saved  *[]string // 在外层事务中使用时记录保存的笔记，事务提交后再调用插件，见 inTransaction
 }
 
 // NewNoteService 创建笔记服务实例
@@ -30,21 +33,26 @@ func NewNoteService(db *gorm.DB, logger *zap.Logger) *NoteService {
 
 // CreateNoteInput 创建笔记的输入参数
 type CreateNoteInput struct {
+	ID         string // 客户端生成的 UUID，为空时由服务端生成；离线创建的笔记同步时使用
 	Title      string
 	Content    string
 	YAMLMeta   string
 	FilePath   string
 	CategoryID *string
 	TagIDs     []string
+	CreatedAt  time.Time // 创建时间，为零值时使用当前时间；导入时保留原笔记的时间
+	UpdatedAt  time.Time // 更新时间，为零值时使用创建时间
+	UniquePath bool      // 路径被占用时在文件名后追加序号，而不是返回 ErrNoteFilePathExists
 }
 
 // UpdateNoteInput 更新笔记的输入参数
 type UpdateNoteInput struct {
-	Title      string
-	Content    string
-	YAMLMeta   string
-	CategoryID *string
-	TagIDs     []string
+	Title       string
+	Content     string
+	YAMLMeta    string
+	CategoryID  *string
+	TagIDs      []string
+	BaseVersion int // 修改所基于的版本号，不为 0 时与当前版本不一致返回 ErrNoteVersionConflict
 }
 
 // ListNotes 获取笔记列表
@@ -63,43 +71,104 @@ func (s *NoteService) ListNotes(categoryID *string) ([]model.Note, error) {
 
 // CreateNote 创建笔记
 func (s *NoteService) CreateNote(input CreateNoteInput) (*model.Note, error) {
+	// 插件可以在校验之前修改标题、正文和元数据
+	ctx := s.db.Statement.Context
+	draft := &plugin.Note{Title: input.Title, Content: input.Content, YAMLMeta: input.YAMLMeta, FilePath: input.FilePath}
+	if err := beforeSaveNote(ctx, draft); err != nil {
+		return nil, err
+	}
+	input.Title, input.Content, input.YAMLMeta = draft.Title, draft.Content, draft.YAMLMeta
+
+	// 校验输入参数
+	var errs fieldErrors
+	if input.ID != "" {
+		if _, err := uuid.Parse(input.ID); err != nil {
+			errs.add("id", FieldInvalid, map[string]string{"value": input.ID})
+		}
+	}
+	errs.addIf(validateTitle("title", input.Title, true))
+	filePath, fe := NormalizeFilePath("file_path", input.FilePath)
+	errs.addIf(fe)
+	if input.CategoryID != nil && *input.CategoryID == "" {
+		input.CategoryID = nil
+	}
+	fe, err := validateCategoryRef(s.db, "category_id", input.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	errs.addIf(fe)
+	tags, fe, err := loadTags(s.db, "tag_ids", input.TagIDs)
+	if err != nil {
+		return nil, err
+	}
+	errs.addIf(fe)
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+
+	if input.UpdatedAt.IsZero() {
+		input.UpdatedAt = input.CreatedAt
+	}
 	note := &model.Note{
+		BaseModel:  model.BaseModel{ID: input.ID, CreatedAt: input.CreatedAt, UpdatedAt: input.UpdatedAt},
 		Title:      input.Title,
 		Content:    input.Content,
 		YAMLMeta:   input.YAMLMeta,
-		FilePath:   input.FilePath,
+		FilePath:   filePath,
 		CategoryID: input.CategoryID,
 		Version:    1,
 		Checksum:   s.calculateChecksum(input.Content),
 	}
 	// 检查文件是否存在
 	var count int64
-	if err := s.db.Model(&model.Note{}).Where("file_path = ?", input.FilePath).Count(&count).Error; err != nil {
+	if input.UniquePath {
+		note.FilePath = s.generateUniqueFilePath(filePath)
+	} else if err := s.db.Model(&model.Note{}).Where("file_path = ?", filePath).Count(&count).Error; err != nil {
 		return nil, err
+	} else if count > 0 {
+		return nil, ErrNoteFilePathExists
 	}
-	if count > 0 {
-		return nil, errors.New("文件路径已存在")
+	// 回收站中的笔记仍占用ID
+	if input.ID != "" {
+		if err := s.db.Unscoped().Model(&model.Note{}).Where("id = ?", input.ID).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, ErrNoteIDExists
+		}
 	}
 
-	err := s.db.Transaction(func(tx *gorm.DB) error {
+	err = s.db.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(note).Error; err != nil {
 			return err
 		}
+		if err := syncAttachmentRefs(tx, note.ID, note.Content); err != nil {
+			return err
+		}
+		if err := syncNoteTasks(tx, note.ID, note.Content); err != nil {
+			return err
+		}
+		if err := syncYAMLReminders(tx, note.ID, note.YAMLMeta); err != nil {
+			return err
+		}
 
-		if len(input.TagIDs) > 0 {
-			var tags []model.Tag
-			if err := tx.Find(&tags, "id IN ?", input.TagIDs).Error; err != nil {
-				return err
-			}
+		if len(tags) > 0 {
 			if err := tx.Model(note).Association("Tags").Replace(tags); err != nil {
 				return err
 			}
+			// 关联标签时 gorm 会把更新时间设为当前时间，保留指定的更新时间
+			if !input.UpdatedAt.IsZero() {
+				if err := tx.Model(note).UpdateColumn("updated_at", input.UpdatedAt).Error; err != nil {
+					return err
+				}
+			}
 		}
-		return nil
+		return recordEvent(tx, model.EventNoteCreated, note.ID, note.Version)
 	})
 	if err != nil {
 		return nil, err
 	}
+	s.afterSaveNote(note.ID)
 	return note, nil
 }
 
@@ -108,6 +177,9 @@ func (s *NoteService) GetNote(id string) (*model.Note, error) {
 	var note model.Note
 	err := s.db.Preload("Category").Preload("Tags").First(&note, "id = ?", id).Error
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNoteNotFound
+		}
 		return nil, err
 	}
 	return &note, nil
@@ -115,14 +187,56 @@ func (s *NoteService) GetNote(id string) (*model.Note, error) {
 
 // UpdateNote 更新笔记
 func (s *NoteService) UpdateNote(id string, input UpdateNoteInput) error {
-	return s.db.Transaction(func(tx *gorm.DB) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
 		var note model.Note
 		if err := tx.First(&note, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNoteNotFound
+			}
 			return err
 		}
+		if input.BaseVersion != 0 && input.BaseVersion != note.Version {
+			return ErrNoteVersionConflict
+		}
 
+		// 插件看到的是更新后的完整笔记，修改后与原值相同的字段视为未修改
+		draft := &plugin.Note{ID: note.ID, Title: note.Title, Content: note.Content, YAMLMeta: note.YAMLMeta, FilePath: note.FilePath}
+		if input.Title != "" {
+			draft.Title = input.Title
+		}
+		if input.Content != "" {
+			draft.Content = input.Content
+		}
+		if input.YAMLMeta != "" {
+			draft.YAMLMeta = input.YAMLMeta
+		}
+		if err := beforeSaveNote(s.db.Statement.Context, draft); err != nil {
+			return err
+		}
+		input.Title = changedField(draft.Title, note.Title)
+		input.Content = changedField(draft.Content, note.Content)
+		input.YAMLMeta = changedField(draft.YAMLMeta, note.YAMLMeta)
+
+		// 校验输入参数
+		var errs fieldErrors
+		errs.addIf(validateTitle("title", input.Title, false))
+		fe, err := validateCategoryRef(tx, "category_id", input.CategoryID)
+		if err != nil {
+			return err
+		}
+		errs.addIf(fe)
+		tags, fe, err := loadTags(tx, "tag_ids", input.TagIDs)
+		if err != nil {
+			return err
+		}
+		errs.addIf(fe)
+		if err := errs.err(); err != nil {
+			return err
+		}
+
+		version := note.Version + 1
 		updates := map[string]interface{}{
-			"version": note.Version + 1,
+			"version": version,
 		}
 		if input.Title != "" {
 			updates["title"] = input.Title
@@ -135,37 +249,68 @@ func (s *NoteService) UpdateNote(id string, input UpdateNoteInput) error {
 			updates["yaml_meta"] = input.YAMLMeta
 		}
 		if input.CategoryID != nil {
-			updates["category_id"] = input.CategoryID
+			// 空字符串表示移出目录
+			if *input.CategoryID == "" {
+				updates["category_id"] = nil
+			} else {
+				updates["category_id"] = input.CategoryID
+			}
 		}
 
-		if err := tx.Model(&note).Updates(updates).Error; err != nil {
-			return err
+		// 按读取时的版本号更新，并发修改时只有一个成功
+		result := tx.Model(&note).Where("version = ?", note.Version).Updates(updates)
+		if result.Error != nil {
+			return result.Error
 		}
-
-		if len(input.TagIDs) > 0 {
-			var tags []model.Tag
-			if err := tx.Find(&tags, "id IN ?", input.TagIDs).Error; err != nil {
+		if result.RowsAffected == 0 {
+			return ErrNoteVersionConflict
+		}
+		if input.Content != "" {
+			if err := syncAttachmentRefs(tx, note.ID, input.Content); err != nil {
+				return err
+			}
+			if err := syncNoteTasks(tx, note.ID, input.Content); err != nil {
+				return err
+			}
+		}
+		if input.YAMLMeta != "" {
+			if err := syncYAMLReminders(tx, note.ID, input.YAMLMeta); err != nil {
 				return err
 			}
+		}
+
+		if len(tags) > 0 {
 			if err := tx.Model(&note).Association("Tags").Replace(tags); err != nil {
 				return err
 			}
 		}
-		return nil
+		return recordEvent(tx, model.EventNoteUpdated, note.ID, version)
 	})
+	if err != nil {
+		return err
+	}
+	s.afterSaveNote(id)
+	return nil
 }
 
 // DeleteNote 删除笔记
 func (s *NoteService) DeleteNote(id string) error {
 	return s.db.Transaction(func(tx *gorm.DB) error {
 		var note model.Note
-		if err := tx.First(&note, "id = ?", id).Error; err != nil {
+		if err := tx.Preload("Category").Preload("Tags").First(&note, "id = ?", id).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return errors.New("笔记不存在")
+				return ErrNoteNotFound
 			}
 			return err
 		}
+		if err := beforeDeleteNote(s.db.Statement.Context, &note); err != nil {
+			return err
+		}
 
+		// 在清除标签之前记录，Webhook 可以按删除前的路径和标签匹配
+		if err := recordEvent(tx, model.EventNoteDeleted, note.ID, note.Version); err != nil {
+			return err
+		}
 		if err := tx.Model(&note).Association("Tags").Clear(); err != nil {
 			return err
 		}
@@ -179,14 +324,14 @@ func (s *NoteService) RestoreNote(id string) error {
 		var note model.Note
 		// Unscoped 可以查询到已软删除的记录
 		if err := tx.Unscoped().First(&note, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNoteNotFound
+			}
 			return err
 		}
 
 		// 移除 .deleted 后缀
-		originalPath := strings.TrimSuffix(
-			note.FilePath,
-			note.FilePath[strings.LastIndex(note.FilePath, ".deleted."):],
-		)
+		originalPath := trashedNotePath(note.FilePath)
 
 		// 检查原始路径是否可用
 		var count int64
@@ -204,13 +349,76 @@ func (s *NoteService) RestoreNote(id string) error {
 		}
 
 		// 恢复笔记（清除删除标记）
-		return tx.Unscoped().Model(&note).Updates(map[string]interface{}{
+		if err := tx.Unscoped().Model(&note).Updates(map[string]interface{}{
 			"deleted_at": nil,
 			"file_path":  note.FilePath,
-		}).Error
+		}).Error; err != nil {
+			return err
+		}
+		// 对订阅者而言恢复的笔记重新出现，记为创建
+		return recordEvent(tx, model.EventNoteCreated, note.ID, note.Version)
 	})
 }
 
+// inTransaction 在事务中执行 fn，fn 通过传入的 notes 保存笔记；
+// 插件的 AfterSaveNote 推迟到事务提交后调用，事务回滚时不调用
+func (s *NoteService) inTransaction(fn func(tx *gorm.DB, notes *NoteService) error) error {
+	var saved []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		return fn(tx, &NoteService{db: tx, logger: s.logger, saved: &saved})
+	})
+	if err != nil {
+		return err
+	}
+	for _, id := range saved {
+		s.afterSaveNote(id)
+	}
+	return nil
+}
+
+// afterSaveNote 在事务提交后调用已启用插件的 AfterSaveNote，插件返回的错误只记录日志
+func (s *NoteService) afterSaveNote(id string) {
+	if s.saved != nil {
+		*s.saved = append(*s.saved, id)
+		return
+	}
+	var hooks []plugin.Plugin
+	for _, p := range plugin.Enabled() {
+		if _, ok := p.(plugin.AfterSaveHook); ok {
+			hooks = append(hooks, p)
+		}
+	}
+	if len(hooks) == 0 {
+		return
+	}
+	note, err := s.GetNote(id)
+	if err != nil {
+		s.logger.Error("Failed to load note for plugins", zap.String("id", id), zap.Error(err))
+		return
+	}
+	for _, p := range hooks {
+		if err := p.(plugin.AfterSaveHook).AfterSaveNote(s.db.Statement.Context, note); err != nil {
+			s.logger.Error("Plugin after-save hook failed", zap.String("plugin", p.Name()), zap.String("id", id), zap.Error(err))
+		}
+	}
+}
+
+// changedField 返回修改后的字段值，与原值相同时返回空字符串，表示保持不变
+func changedField(value, old string) string {
+	if value == old {
+		return ""
+	}
+	return value
+}
+
+// trashedNotePath 去掉删除笔记时追加的 .deleted.<时间> 后缀，返回删除前的文件路径
+func trashedNotePath(filePath string) string {
+	if i := strings.LastIndex(filePath, ".deleted."); i >= 0 {
+		return filePath[:i]
+	}
+	return filePath
+}
+
 // 生成唯一的文件路径
 func (s *NoteService) generateUniqueFilePath(originalPath string) string {
 	ext := filepath.Ext(originalPath)
@@ -238,25 +446,3 @@ func (s *NoteService) calculateChecksum(content string) string {
 	hash := md5.Sum([]byte(content))
 	return hex.EncodeToString(hash[:])
 }
-
-// ExportToMarkdown 导出笔记为 Markdown 文件
-func (s *NoteService) ExportToMarkdown(id string, exportPath string) error {
-	var note model.Note
-	if err := s.db.First(&note, "id = ?", id).Error; err != nil {
-		return err
-	}
-
-	// 构建完整的文件路径
-	fullPath := filepath.Join(exportPath, note.FilePath)
-
-	// 确保目录存在
-	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
-		return err
-	}
-
-	// 构建 Markdown 内容
-	content := fmt.Sprintf("---\n%s\n---\n\n%s", note.YAMLMeta, note.Content)
-
-	// 写入文件
-	return os.WriteFile(fullPath, []byte(content), 0644)
-}
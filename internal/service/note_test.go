@@ -1,28 +1,16 @@
 package service
 
 import (
-	"leafnote/internal/model"
+	"leafnote/internal/testutil"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
 func setupTestDB(t *testing.T) *gorm.DB {
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	if err != nil {
-		t.Fatalf("failed to connect database: %v", err)
-	}
-
-	// 自动迁移
-	err = db.AutoMigrate(&model.Note{}, &model.Tag{}, &model.Category{})
-	if err != nil {
-		t.Fatalf("failed to migrate database: %v", err)
-	}
-
-	return db
+	return testutil.NewTestDB(t)
 }
 
 func TestNoteService_CreateNote(t *testing.T) {
@@ -45,6 +33,42 @@ func TestNoteService_CreateNote(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "标签不存在",
+			input: CreateNoteInput{
+				Title:    "测试笔记3",
+				FilePath: "/test/note3.md",
+				TagIDs:   []string{"not-exist"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "目录不存在",
+			input: CreateNoteInput{
+				Title:      "测试笔记4",
+				FilePath:   "/test/note4.md",
+				CategoryID: stringPtr("not-exist"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "客户端生成的ID",
+			input: CreateNoteInput{
+				ID:       "6f1c2a4e-8d3b-4c5a-9e7f-0a1b2c3d4e5f",
+				Title:    "离线笔记",
+				FilePath: "/test/offline.md",
+			},
+			wantErr: false,
+		},
+		{
+			name: "客户端生成的ID不合法",
+			input: CreateNoteInput{
+				ID:       "not-a-uuid",
+				Title:    "离线笔记2",
+				FilePath: "/test/offline2.md",
+			},
+			wantErr: true,
+		},
 		{
 			name: "重复的文件路径",
 			input: CreateNoteInput{
@@ -148,6 +172,24 @@ func TestNoteService_UpdateNote(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "基于过期的版本",
+			id:   note.ID,
+			input: UpdateNoteInput{
+				Title:       "过期的修改",
+				BaseVersion: 1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "基于当前版本",
+			id:   note.ID,
+			input: UpdateNoteInput{
+				Title:       "再次更新的标题",
+				BaseVersion: 2,
+			},
+			wantErr: false,
+		},
 		{
 			name: "更新不存在的笔记",
 			id:   "not-exist",
@@ -0,0 +1,764 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+	"go.uber.org/zap"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gobolditalic"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/goregular"
+	"gorm.io/gorm"
+
+	"leafnote/internal/model"
+)
+
+// PDF 版式，长度单位为毫米，字号单位为磅
+const (
+	pdfMargin     = 20.0
+	pdfBodySize   = 10.5
+	pdfCodeSize   = 9.0
+	pdfMetaSize   = 9.0
+	pdfListIndent = 6.0
+	pdfTOCIndent  = 5.0
+	pdfCellPad    = 1.5
+
+	pdfFontBody = "body"
+	pdfFontMono = "mono"
+
+	// pdfNoteLink 双链解析结果的前缀，后接笔记ID，渲染时转换为文档内链接
+	pdfNoteLink = "note:"
+)
+
+// pdfHeadingSizes 一到六级标题的字号
+var pdfHeadingSizes = [...]float64{20, 16, 14, 12, 11, 10.5}
+
+// PDFFonts PDF 使用的 TrueType 字体文件，为空时使用内置的 Go 字体；
+// Go 字体不含中文字形，导出中文笔记需要配置中文字体
+type PDFFonts struct {
+	Regular string // 正文字体，同时用于斜体
+	Bold    string // 粗体，为空时使用正文字体
+	Mono    string // 代码字体，为空时使用正文字体（均未配置时使用 Go Mono）
+}
+
+// PDFFile 渲染好的 PDF 文件
+type PDFFile struct {
+	Name string // 下载时的文件名
+	Data []byte
+}
+
+// PDFService 将笔记渲染为 PDF，纯 Go 实现，不依赖浏览器或外部程序
+type PDFService struct {
+	db     *gorm.DB
+	logger *zap.Logger
+	fonts  PDFFonts
+}
+
+// NewPDFService 创建 PDF 导出服务实例
+func NewPDFService(db *gorm.DB, logger *zap.Logger, fonts PDFFonts) *PDFService {
+	return &PDFService{db: db, logger: logger, fonts: fonts}
+}
+
+// NotePDF 将单篇笔记渲染为 PDF，双链显示为普通文本
+func (s *PDFService) NotePDF(ctx context.Context, id string) (*PDFFile, error) {
+	var note model.Note
+	if err := s.db.WithContext(ctx).Preload("Tags").First(&note, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNoteNotFound
+		}
+		return nil, err
+	}
+	tagNames, err := NewExportService(s.db, s.logger).tagNames()
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := s.newRenderer(note.Title)
+	if err != nil {
+		return nil, err
+	}
+	r.note(&note, tagNames, 0)
+	return r.output(note.Title)
+}
+
+// CategoryPDF 将目录及其子目录下的笔记（不含回收站）合并为一个 PDF：
+// 首页为目录，之后每篇笔记从新页开始；笔记按目录层级和文件名排序，同一目录下的笔记排在子目录之前，
+// 指向其他已导出笔记的双链转换为文档内链接
+func (s *PDFService) CategoryPDF(ctx context.Context, id string) (*PDFFile, error) {
+	db := s.db.WithContext(ctx)
+	var category model.Category
+	if err := db.First(&category, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, err
+	}
+	exportService := NewExportService(s.db, s.logger)
+	query, err := exportService.filterQuery(ctx, ExportFilter{CategoryID: &category.ID})
+	if err != nil {
+		return nil, err
+	}
+	var notes []model.Note
+	if err := query.Find(&notes).Error; err != nil {
+		return nil, err
+	}
+	tagNames, err := exportService.tagNames()
+	if err != nil {
+		return nil, err
+	}
+	entries := pdfEntries(notes, &category)
+
+	r, err := s.newRenderer(category.Name)
+	if err != nil {
+		return nil, err
+	}
+	r.links = make(map[string]int, len(notes))
+	for _, e := range entries {
+		r.links[e.note.ID] = r.pdf.AddLink()
+	}
+	wiki := newWikiIndex(notes, nil)
+	r.resolve = func(target string) (string, bool) {
+		if id, ok := wiki.lookup(target); ok {
+			return pdfNoteLink + id, true
+		}
+		return "", false
+	}
+
+	r.contents(category.Name, entries)
+	var prev []string
+	for i, e := range entries {
+		start := r.pdf.PageNo() + 1
+		// 书签层级：目录为其深度减一，笔记为所在目录的深度
+		common := commonPrefix(prev, e.dirs)
+		for k := common; k < len(e.dirs); k++ {
+			r.pendingBookmarks = append(r.pendingBookmarks, pdfBookmark{e.dirs[k], k})
+		}
+		r.note(e.note, tagNames, len(e.dirs))
+		r.pdf.RegisterAlias(pdfPageAlias(i), fmt.Sprint(start))
+		prev = e.dirs
+	}
+	return r.output(category.Name)
+}
+
+// pdfEntry 合并导出中的一篇笔记
+type pdfEntry struct {
+	note *model.Note
+	dirs []string // 相对于导出目录的目录层级
+	name string   // 文件名，用于排序
+}
+
+// pdfEntries 计算笔记相对于 root 的目录层级并排序
+func pdfEntries(notes []model.Note, root *model.Category) []pdfEntry {
+	prefix := cleanExportPath(root.Path)
+	entries := make([]pdfEntry, 0, len(notes))
+	for i := range notes {
+		note := &notes[i]
+		e := pdfEntry{note: note, name: path.Base(cleanExportPath(note.FilePath))}
+		if note.Category != nil {
+			rel := strings.TrimPrefix(cleanExportPath(note.Category.Path), prefix)
+			if rel = strings.Trim(rel, "/"); rel != "" {
+				e.dirs = strings.Split(rel, "/")
+			}
+		}
+		entries = append(entries, e)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		for k := 0; k < len(a.dirs) && k < len(b.dirs); k++ {
+			if a.dirs[k] != b.dirs[k] {
+				return a.dirs[k] < b.dirs[k]
+			}
+		}
+		if len(a.dirs) != len(b.dirs) {
+			return len(a.dirs) < len(b.dirs)
+		}
+		return a.name < b.name
+	})
+	return entries
+}
+
+// commonPrefix 返回两个目录层级的公共前缀长度
+func commonPrefix(a, b []string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+// pdfPageAlias 目录中第 i 篇笔记页码的占位符，输出时替换为实际页码
+func pdfPageAlias(i int) string {
+	return fmt.Sprintf("{p%d}", i)
+}
+
+// newRenderer 创建 PDF 文档并注册字体
+func (s *PDFService) newRenderer(title string) (*pdfRenderer, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	pdf.SetTitle(title, true)
+	pdf.SetCreator("LeafNote", true)
+	pdf.AliasNbPages("{nb}")
+
+	regular, err := readFont(s.fonts.Regular, goregular.TTF)
+	if err != nil {
+		return nil, err
+	}
+	italic, boldItalic := goitalic.TTF, gobolditalic.TTF
+	bold, mono := gobold.TTF, gomono.TTF
+	if s.fonts.Regular != "" {
+		italic, bold, mono = regular, regular, regular
+	}
+	if bold, err = readFont(s.fonts.Bold, bold); err != nil {
+		return nil, err
+	}
+	if s.fonts.Regular != "" || s.fonts.Bold != "" {
+		boldItalic = bold
+	}
+	if mono, err = readFont(s.fonts.Mono, mono); err != nil {
+		return nil, err
+	}
+	pdf.AddUTF8FontFromBytes(pdfFontBody, "", regular)
+	pdf.AddUTF8FontFromBytes(pdfFontBody, "I", italic)
+	pdf.AddUTF8FontFromBytes(pdfFontBody, "B", bold)
+	pdf.AddUTF8FontFromBytes(pdfFontBody, "BI", boldItalic)
+	pdf.AddUTF8FontFromBytes(pdfFontMono, "", mono)
+	if err := pdf.Error(); err != nil {
+		return nil, fmt.Errorf("加载 PDF 字体失败: %w", err)
+	}
+
+	r := &pdfRenderer{pdf: pdf, size: pdfBodySize}
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin + 6)
+		pdf.SetFont(pdfFontBody, "", 8)
+		pdf.SetTextColor(110, 119, 129)
+		pdf.CellFormat(0, 4, fmt.Sprintf("%d / {nb}", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	return r, nil
+}
+
+// readFont 读取字体文件，file 为空时返回内置字体
+func readFont(file string, builtin []byte) ([]byte, error) {
+	if file == "" {
+		return builtin, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("加载 PDF 字体失败: %w", err)
+	}
+	return data, nil
+}
+
+// pdfBookmark 等待写入的书签，在下一篇笔记的首页写入
+type pdfBookmark struct {
+	title string
+	level int
+}
+
+// pdfRenderer 将 Markdown 语法树写入 PDF
+type pdfRenderer struct {
+	pdf              *fpdf.Fpdf
+	resolve          WikiLinkResolver
+	links            map[string]int // 笔记ID到文档内链接，单篇导出时为空
+	pendingBookmarks []pdfBookmark
+	source           []byte
+
+	// 当前文本样式
+	bold, italic, strike, mono bool
+	size                       float64
+	color                      [3]int
+	href                       string  // 外部链接
+	linkID                     int     // 文档内链接
+	lineH                      float64 // 最近写入的文本的行高
+}
+
+// output 生成 PDF 文件
+func (r *pdfRenderer) output(title string) (*PDFFile, error) {
+	var buf bytes.Buffer
+	if err := r.pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("生成 PDF 失败: %w", err)
+	}
+	return &PDFFile{Name: pdfFileName(title), Data: buf.Bytes()}, nil
+}
+
+// pdfFileName 根据标题生成文件名，去除路径分隔符等不能出现在文件名中的字符
+func pdfFileName(title string) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || unicode.IsControl(r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(title))
+	if name == "" {
+		name = "note"
+	}
+	return name + ".pdf"
+}
+
+// contents 写入合并导出的首页：标题和带页码的目录
+func (r *pdfRenderer) contents(title string, entries []pdfEntry) {
+	r.pdf.AddPage()
+	r.heading(title, 22)
+	r.meta(fmt.Sprintf("共 %d 篇笔记，导出于 %s", len(entries), time.Now().Format("2006-01-02 15:04")))
+	r.heading("目录", pdfHeadingSizes[1])
+
+	width, _ := r.pdf.GetPageSize()
+	numberWidth := 15.0
+	lh := lineHeight(pdfBodySize)
+	var prev []string
+	for i, e := range entries {
+		common := commonPrefix(prev, e.dirs)
+		r.withStyle(func() {
+			r.bold = true
+			r.font()
+			for k := common; k < len(e.dirs); k++ {
+				r.pdf.SetX(pdfMargin + float64(k)*pdfTOCIndent)
+				r.pdf.CellFormat(0, lh, pdfText(e.dirs[k]), "", 1, "L", false, 0, "")
+			}
+		})
+		r.font()
+		indent := float64(len(e.dirs)) * pdfTOCIndent
+		titleWidth := width - 2*pdfMargin - indent - numberWidth
+		r.pdf.SetX(pdfMargin + indent)
+		r.pdf.CellFormat(titleWidth, lh, r.truncate(e.note.Title, titleWidth), "", 0, "L", false, r.links[e.note.ID], "")
+		r.pdf.CellFormat(numberWidth, lh, pdfPageAlias(i), "", 1, "L", false, r.links[e.note.ID], "")
+		prev = e.dirs
+	}
+}
+
+// truncate 截断超出宽度的文本，末尾加省略号
+func (r *pdfRenderer) truncate(s string, width float64) string {
+	s = pdfText(s)
+	if r.pdf.GetStringWidth(s) <= width-2 {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && r.pdf.GetStringWidth(string(runes)+"…") > width-2 {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+// note 从新页开始写入一篇笔记：标题、更新时间和标签、正文；level 为书签层级
+func (r *pdfRenderer) note(note *model.Note, tagNames map[string]string, level int) {
+	r.pdf.AddPage()
+	for _, b := range r.pendingBookmarks {
+		r.pdf.Bookmark(pdfText(b.title), b.level, -1)
+	}
+	r.pendingBookmarks = nil
+	if link, ok := r.links[note.ID]; ok {
+		r.pdf.SetLink(link, -1, r.pdf.PageNo())
+	}
+	r.pdf.Bookmark(pdfText(note.Title), level, -1)
+
+	r.heading(note.Title, pdfHeadingSizes[0])
+	meta := "更新于 " + note.UpdatedAt.Local().Format("2006-01-02 15:04")
+	for _, tag := range note.Tags {
+		meta += "  #" + tagNames[tag.ID]
+	}
+	r.meta(meta)
+
+	content, err := transformMarkdown(note.Content)
+	if err != nil {
+		r.pdf.SetError(err)
+		return
+	}
+	r.source = []byte(content)
+	r.blocks(parseNoteMarkdown(r.source, r.resolve))
+}
+
+// heading 写入一行粗体标题
+func (r *pdfRenderer) heading(title string, size float64) {
+	r.withStyle(func() {
+		r.bold, r.size = true, size
+		r.text(title)
+	})
+	r.endBlock(3)
+}
+
+// meta 写入一行灰色的说明文字
+func (r *pdfRenderer) meta(s string) {
+	r.withStyle(func() {
+		r.size, r.color = pdfMetaSize, [3]int{87, 96, 106}
+		r.text(s)
+	})
+	r.endBlock(5)
+}
+
+// withStyle 执行 fn 后恢复文本样式
+func (r *pdfRenderer) withStyle(fn func()) {
+	bold, italic, strike, mono := r.bold, r.italic, r.strike, r.mono
+	size, color, href, linkID := r.size, r.color, r.href, r.linkID
+	fn()
+	r.bold, r.italic, r.strike, r.mono = bold, italic, strike, mono
+	r.size, r.color, r.href, r.linkID = size, color, href, linkID
+	r.font()
+}
+
+// font 按当前样式设置字体和颜色
+func (r *pdfRenderer) font() {
+	family, style := pdfFontBody, ""
+	if r.mono {
+		family = pdfFontMono
+	} else {
+		if r.bold {
+			style += "B"
+		}
+		if r.italic {
+			style += "I"
+		}
+	}
+	if r.strike {
+		style += "S"
+	}
+	r.pdf.SetFont(family, style, r.size)
+	r.pdf.SetTextColor(r.color[0], r.color[1], r.color[2])
+}
+
+// text 在当前位置按当前样式写入文本，超出右边距时自动换行
+func (r *pdfRenderer) text(s string) {
+	r.font()
+	s = pdfText(s)
+	h := lineHeight(r.size)
+	r.lineH = h
+	switch {
+	case r.linkID != 0:
+		r.pdf.WriteLinkID(h, s, r.linkID)
+	case r.href != "":
+		r.pdf.WriteLinkString(h, s, r.href)
+	default:
+		r.pdf.Write(h, s)
+	}
+}
+
+// endBlock 结束当前段落：换到下一行的左边距处，再留出 gap 的间距
+func (r *pdfRenderer) endBlock(gap float64) {
+	left, _, _, _ := r.pdf.GetMargins()
+	if r.pdf.GetX() > left+0.01 {
+		r.pdf.Ln(r.lineH)
+	}
+	r.pdf.Ln(gap)
+}
+
+// blocks 写入 n 的所有子块
+func (r *pdfRenderer) blocks(n ast.Node) {
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		r.block(c)
+	}
+}
+
+func (r *pdfRenderer) block(n ast.Node) {
+	switch n := n.(type) {
+	case *ast.Heading:
+		r.pdf.Ln(2)
+		r.withStyle(func() {
+			r.bold, r.size = true, pdfHeadingSizes[n.Level-1]
+			r.inlines(n)
+		})
+		r.endBlock(2)
+	case *ast.Paragraph:
+		r.inlines(n)
+		r.endBlock(3)
+	case *ast.TextBlock:
+		r.inlines(n)
+		r.endBlock(1)
+	case *ast.List:
+		r.list(n)
+		r.pdf.Ln(2)
+	case *ast.Blockquote:
+		r.blockquote(n)
+	case *ast.FencedCodeBlock, *ast.CodeBlock:
+		r.codeBlock(n)
+	case *east.Table:
+		r.table(n)
+	case *ast.ThematicBreak:
+		left, _, right, _ := r.pdf.GetMargins()
+		width, _ := r.pdf.GetPageSize()
+		y := r.pdf.GetY() + 2
+		r.pdf.SetDrawColor(208, 215, 222)
+		r.pdf.Line(left, y, width-right, y)
+		r.pdf.SetY(y + 4)
+	case *ast.HTMLBlock:
+		// 与 HTML 渲染一致，不输出原始 HTML
+	default:
+		r.blocks(n)
+	}
+}
+
+// list 写入列表，任务列表的复选框代替项目符号
+func (r *pdfRenderer) list(n *ast.List) {
+	left, _, _, _ := r.pdf.GetMargins()
+	number := n.Start
+	for item := n.FirstChild(); item != nil; item = item.NextSibling() {
+		marker := "•"
+		if n.IsOrdered() {
+			marker = fmt.Sprintf("%d.", number)
+			number++
+		}
+		if first := item.FirstChild(); first != nil {
+			if box, ok := first.FirstChild().(*east.TaskCheckBox); ok {
+				marker = "[ ]"
+				if box.IsChecked {
+					marker = "[x]"
+				}
+			}
+		}
+		r.font()
+		r.pdf.SetX(left)
+		r.pdf.CellFormat(pdfListIndent, lineHeight(r.size), marker, "", 0, "L", false, 0, "")
+		y := r.pdf.GetY()
+		r.pdf.SetLeftMargin(left + pdfListIndent)
+		r.blocks(item)
+		if r.pdf.GetY() == y {
+			// 空列表项
+			r.pdf.Ln(lineHeight(r.size))
+		}
+		r.pdf.SetLeftMargin(left)
+		r.pdf.SetX(left)
+	}
+}
+
+// blockquote 写入缩进的灰色引用，左侧画竖线
+func (r *pdfRenderer) blockquote(n *ast.Blockquote) {
+	left, _, _, _ := r.pdf.GetMargins()
+	page, top := r.pdf.PageNo(), r.pdf.GetY()
+	r.pdf.SetLeftMargin(left + 5)
+	r.pdf.SetX(left + 5)
+	r.withStyle(func() {
+		r.color = [3]int{87, 96, 106}
+		r.blocks(n)
+	})
+	r.pdf.SetLeftMargin(left)
+	r.pdf.SetX(left)
+	// 跨页时不画竖线
+	if r.pdf.PageNo() == page {
+		r.pdf.SetDrawColor(208, 215, 222)
+		r.pdf.SetLineWidth(0.8)
+		r.pdf.Line(left+1.5, top, left+1.5, r.pdf.GetY()-2)
+		r.pdf.SetLineWidth(0.2)
+	}
+}
+
+// codeBlock 写入带底色的等宽代码块，过长的行自动换行
+func (r *pdfRenderer) codeBlock(n ast.Node) {
+	left, _, right, _ := r.pdf.GetMargins()
+	width, _ := r.pdf.GetPageSize()
+	w := width - left - right
+	r.withStyle(func() {
+		r.mono, r.size, r.color = true, pdfCodeSize, [3]int{36, 41, 47}
+		r.font()
+		r.pdf.SetFillColor(246, 248, 250)
+		r.pdf.CellFormat(w, 2, "", "", 2, "", true, 0, "")
+		lines := n.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			seg := lines.At(i)
+			line := strings.TrimRight(string(seg.Value(r.source)), "\r\n")
+			line = strings.ReplaceAll(line, "\t", "    ")
+			if line == "" {
+				line = " "
+			}
+			r.pdf.MultiCell(w, lineHeight(pdfCodeSize)*0.9, pdfText(line), "", "L", true)
+		}
+		r.pdf.CellFormat(w, 2, "", "", 2, "", true, 0, "")
+	})
+	r.pdf.Ln(3)
+}
+
+// table 写入表格，列宽平均分配，单元格内的文本自动换行
+func (r *pdfRenderer) table(n *east.Table) {
+	left, _, right, bottom := r.pdf.GetMargins()
+	width, height := r.pdf.GetPageSize()
+	cols := 0
+	if header := n.FirstChild(); header != nil {
+		cols = header.ChildCount()
+	}
+	if cols == 0 {
+		return
+	}
+	w := (width - left - right) / float64(cols)
+	lh := lineHeight(pdfCodeSize)
+
+	r.withStyle(func() {
+		r.size = pdfCodeSize
+		r.pdf.SetDrawColor(208, 215, 222)
+		r.pdf.SetFillColor(246, 248, 250)
+		for row := n.FirstChild(); row != nil; row = row.NextSibling() {
+			_, isHeader := row.(*east.TableHeader)
+			r.bold = isHeader
+			r.font()
+			cells := make([][]string, cols)
+			lines := 1
+			i := 0
+			for cell := row.FirstChild(); cell != nil && i < cols; cell = cell.NextSibling() {
+				cells[i] = r.pdf.SplitText(pdfText(plainText(cell, r.source)), w-2*pdfCellPad)
+				if len(cells[i]) > lines {
+					lines = len(cells[i])
+				}
+				i++
+			}
+			h := float64(lines)*lh + 2*pdfCellPad
+			if r.pdf.GetY()+h > height-bottom {
+				r.pdf.AddPage()
+				r.font()
+			}
+			y := r.pdf.GetY()
+			for i, text := range cells {
+				x := left + float64(i)*w
+				style := "D"
+				if isHeader {
+					style = "FD"
+				}
+				r.pdf.Rect(x, y, w, h, style)
+				align := "L"
+				if i < len(n.Alignments) {
+					switch n.Alignments[i] {
+					case east.AlignCenter:
+						align = "C"
+					case east.AlignRight:
+						align = "R"
+					}
+				}
+				for k, line := range text {
+					r.pdf.SetXY(x+pdfCellPad, y+pdfCellPad+float64(k)*lh)
+					r.pdf.CellFormat(w-2*pdfCellPad, lh, line, "", 0, align, false, 0, "")
+				}
+			}
+			r.pdf.SetXY(left, y+h)
+		}
+	})
+	r.pdf.Ln(3)
+}
+
+// inlines 写入 n 的所有行内子节点
+func (r *pdfRenderer) inlines(n ast.Node) {
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		r.inline(c)
+	}
+}
+
+func (r *pdfRenderer) inline(n ast.Node) {
+	switch n := n.(type) {
+	case *ast.Text:
+		r.text(string(n.Segment.Value(r.source)))
+		if n.HardLineBreak() {
+			r.pdf.Ln(lineHeight(r.size))
+		} else if n.SoftLineBreak() {
+			r.text(" ")
+		}
+	case *ast.String:
+		r.text(string(n.Value))
+	case *ast.CodeSpan:
+		r.withStyle(func() {
+			r.mono, r.color = true, [3]int{207, 34, 46}
+			r.inlines(n)
+		})
+	case *ast.Emphasis:
+		r.withStyle(func() {
+			if n.Level >= 2 {
+				r.bold = true
+			} else {
+				r.italic = true
+			}
+			r.inlines(n)
+		})
+	case *east.Strikethrough:
+		r.withStyle(func() {
+			r.strike = true
+			r.inlines(n)
+		})
+	case *ast.Link:
+		r.withStyle(func() {
+			r.link(string(n.Destination))
+			r.inlines(n)
+		})
+	case *ast.AutoLink:
+		r.withStyle(func() {
+			r.link(string(n.URL(r.source)))
+			r.text(string(n.Label(r.source)))
+		})
+	case *ast.Image:
+		r.withStyle(func() {
+			r.color = [3]int{87, 96, 106}
+			r.text("[" + plainText(n, r.source) + "]")
+		})
+	case *wikiLink:
+		r.withStyle(func() {
+			if id, ok := strings.CutPrefix(n.Destination, pdfNoteLink); ok {
+				id, _, _ = strings.Cut(id, "#")
+				if link, ok := r.links[id]; ok {
+					r.linkID, r.color = link, [3]int{9, 105, 218}
+				}
+			}
+			r.text(n.Label)
+		})
+	case *ast.RawHTML, *east.TaskCheckBox:
+		// 原始 HTML 不输出，任务列表的复选框已作为列表符号输出
+	default:
+		r.inlines(n)
+	}
+}
+
+// link 将后续文本设为外部链接，只有带协议的地址（如 https、mailto）可以点击
+func (r *pdfRenderer) link(dest string) {
+	r.color = [3]int{9, 105, 218}
+	if u, err := url.Parse(dest); err == nil && u.Scheme != "" {
+		r.href = dest
+	}
+}
+
+// plainText 返回节点中的纯文本
+func plainText(n ast.Node, source []byte) string {
+	var b strings.Builder
+	_ = ast.Walk(n, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Text:
+			b.Write(n.Segment.Value(source))
+			if n.SoftLineBreak() || n.HardLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.Write(n.Value)
+		case *wikiLink:
+			b.WriteString(n.Label)
+		}
+		return ast.WalkContinue, nil
+	})
+	return b.String()
+}
+
+// lineHeight 返回字号 size 对应的行高（毫米）
+func lineHeight(size float64) float64 {
+	return size * 25.4 / 72 * 1.5
+}
+
+// pdfText 替换 PDF 字体无法表示的字符：基本多文种平面以外的字符（如 emoji）替换为 ?，控制字符替换为空格
+func pdfText(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r > 0xFFFF:
+			return '?'
+		case r == '\n':
+			return r
+		case unicode.IsControl(r):
+			return ' '
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"golang.org/x/image/font/gofont/goregular"
+
+	"leafnote/internal/model"
+	"leafnote/internal/testutil"
+)
+
+func TestPDFService_NotePDF(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	f := setupExportTest(t, db)
+	content := "正文 **粗体** *斜体* ~~删除~~ `代码` [链接](https://example.com) 😀 [[说明]]\n\n" +
+		"## 列表\n\n- 一\n  - 嵌套\n- [x] 完成\n-\n\n1. 甲\n2. 乙\n\n> 引用\n\n" +
+		"```go\nfunc main() {\n\tprintln(\"hi\")\n}\n```\n\n| 名称 | 数量 |\n|:--|--:|\n| 苹果 | 3 |\n\n---\n\n![图片](a.png)\n\n<div>html</div>\n"
+	require.NoError(t, db.Model(f.plan).Update("content", content).Error)
+	s := NewPDFService(db, zap.NewNop(), PDFFonts{})
+
+	file, err := s.NotePDF(context.Background(), f.plan.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "计划.pdf", file.Name)
+	assert.Equal(t, "%PDF-", string(file.Data[:5]))
+	assert.Contains(t, string(file.Data), "/Count 1")
+
+	_, err = s.NotePDF(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrNoteNotFound)
+}
+
+func TestPDFService_CategoryPDF(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	f := setupExportTest(t, db)
+	_, err := NewNoteService(db, zap.NewNop()).CreateNote(CreateNoteInput{
+		Title:      "概览",
+		Content:    "见 [[计划]]",
+		FilePath:   "/概览.md",
+		CategoryID: &f.work.ID,
+	})
+	require.NoError(t, err)
+	s := NewPDFService(db, zap.NewNop(), PDFFonts{})
+
+	file, err := s.CategoryPDF(context.Background(), f.work.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "工作.pdf", file.Name)
+	// 目录页加两篇笔记，回收站中的笔记不导出
+	assert.Contains(t, string(file.Data), "/Count 3")
+	// 目录中的页码占位符已替换
+	assert.NotContains(t, string(file.Data), "{p0}")
+
+	_, err = s.CategoryPDF(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrCategoryNotFound)
+}
+
+func TestPDFService_Fonts(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	f := setupExportTest(t, db)
+	font := filepath.Join(t.TempDir(), "font.ttf")
+	require.NoError(t, os.WriteFile(font, goregular.TTF, 0644))
+
+	_, err := NewPDFService(db, zap.NewNop(), PDFFonts{Regular: font}).NotePDF(context.Background(), f.plan.ID)
+	assert.NoError(t, err)
+	_, err = NewPDFService(db, zap.NewNop(), PDFFonts{Bold: font + ".missing"}).NotePDF(context.Background(), f.plan.ID)
+	assert.ErrorContains(t, err, "加载 PDF 字体失败")
+}
+
+func TestPDFEntries(t *testing.T) {
+	root := &model.Category{Path: "/工作"}
+	note := func(title, categoryPath string) model.Note {
+		n := model.Note{Title: title, FilePath: "/x/" + title + ".md"}
+		if categoryPath != "" {
+			n.Category = &model.Category{Path: categoryPath}
+		}
+		return n
+	}
+	notes := []model.Note{
+		note("b", "/工作/项目/子项"),
+		note("c", "/工作/项目"),
+		note("d", "/工作/项目-旧"),
+		note("e", "/工作"),
+		note("a", "/工作/项目"),
+	}
+
+	var got []string
+	for _, e := range pdfEntries(notes, root) {
+		got = append(got, e.note.Title)
+	}
+	// 同一目录下的笔记排在子目录之前，子目录整体排在一起
+	assert.Equal(t, []string{"e", "a", "c", "b", "d"}, got)
+}
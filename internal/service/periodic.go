@@ -0,0 +1,502 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"leafnote/internal/model"
+)
+
+// Period 周期笔记的周期
+type Period string
+
+// 支持的周期，周以 ISO 周为准（周一开始）
+const (
+	PeriodDaily   Period = "daily"
+	PeriodWeekly  Period = "weekly"
+	PeriodMonthly Period = "monthly"
+)
+
+// dateLayout 接口中日期的格式
+const dateLayout = "2006-01-02"
+
+// PeriodicNoteOptions 一种周期笔记的配置
+type PeriodicNoteOptions struct {
+	Path     string // 文件路径格式，日期记号同模板的 {{date:格式}}，例如 日记/YYYY/YYYY-MM-DD.md；为空时不启用
+	Template string // 新建时使用的模板名称，为空时创建空白笔记
+}
+
+// PeriodicOptions 周期笔记服务的配置
+type PeriodicOptions struct {
+	Daily   PeriodicNoteOptions
+	Weekly  PeriodicNoteOptions
+	Monthly PeriodicNoteOptions
+}
+
+// periodicLayout 编译后的文件路径格式
+type periodicLayout struct {
+	period   Period
+	layout   string         // 以 / 开头的路径格式
+	prefix   string         // 第一个日期记号之前的文字，用于按前缀查询笔记
+	pattern  *regexp.Regexp // 从文件路径中解析日期
+	tokens   []string       // pattern 中各分组对应的记号
+	template string
+}
+
+// PeriodicService 日记、周记、月记等周期笔记服务
+type PeriodicService struct {
+	db        *gorm.DB
+	logger    *zap.Logger
+	layouts   map[Period]*periodicLayout
+	templates *TemplateService
+}
+
+// PeriodicNote 一个周期及其笔记
+type PeriodicNote struct {
+	Period   Period       `json:"period"`
+	Date     string       `json:"date"`      // 周期的第一天
+	End      string       `json:"end"`       // 周期的最后一天
+	FilePath string       `json:"file_path"` // 周期笔记的文件路径
+	Note     *model.Note  `json:"note"`      // 尚未创建时为 null
+	Previous *PeriodicRef `json:"previous"`  // 之前最近的已有周期笔记
+	Next     *PeriodicRef `json:"next"`      // 之后最近的已有周期笔记
+}
+
+// PeriodicRef 已有周期笔记的摘要
+type PeriodicRef struct {
+	Date     string `json:"date"`
+	NoteID   string `json:"note_id"`
+	Title    string `json:"title"`
+	FilePath string `json:"file_path"`
+}
+
+// Calendar 一个月的日历，列出每天的日记和当天创建的笔记数
+type Calendar struct {
+	Month         string         `json:"month"`
+	MonthlyNoteID *string        `json:"monthly_note_id"`
+	Weeks         []CalendarWeek `json:"weeks"`
+	Days          []CalendarDay  `json:"days"`
+}
+
+// CalendarWeek 与该月有交集的 ISO 周
+type CalendarWeek struct {
+	Week         string  `json:"week"`  // 例如 2026-W42
+	Start        string  `json:"start"` // 周一的日期
+	WeeklyNoteID *string `json:"weekly_note_id"`
+}
+
+// CalendarDay 日历中的一天
+type CalendarDay struct {
+	Date        string  `json:"date"`
+	DailyNoteID *string `json:"daily_note_id"`
+	NoteCount   int     `json:"note_count"` // 当天创建的笔记数（不含回收站）
+}
+
+// NewPeriodicService 创建周期笔记服务，路径格式无法区分不同周期时返回错误
+func NewPeriodicService(db *gorm.DB, logger *zap.Logger, opts PeriodicOptions) (*PeriodicService, error) {
+	s := &PeriodicService{
+		db:        db,
+		logger:    logger,
+		layouts:   make(map[Period]*periodicLayout),
+		templates: NewTemplateService(db, logger),
+	}
+	for period, o := range map[Period]PeriodicNoteOptions{
+		PeriodDaily:   opts.Daily,
+		PeriodWeekly:  opts.Weekly,
+		PeriodMonthly: opts.Monthly,
+	} {
+		if o.Path == "" {
+			continue
+		}
+		l, err := compilePeriodicLayout(period, o.Path)
+		if err != nil {
+			return nil, err
+		}
+		l.template = o.Template
+		s.layouts[period] = l
+	}
+	return s, nil
+}
+
+// compilePeriodicLayout 编译路径格式，并检查连续两年多的周期都能生成合法且互不相同、可以解析回来的路径
+func compilePeriodicLayout(period Period, layout string) (*periodicLayout, error) {
+	l := &periodicLayout{period: period, layout: "/" + strings.TrimLeft(strings.TrimSpace(layout), "/")}
+	var expr strings.Builder
+	expr.WriteString("^")
+	for _, part := range splitDateLayout(l.layout) {
+		if part.token == nil {
+			if len(l.tokens) == 0 {
+				l.prefix += part.literal
+			}
+			expr.WriteString(regexp.QuoteMeta(part.literal))
+			continue
+		}
+		l.tokens = append(l.tokens, part.token.token)
+		expr.WriteString("(" + part.token.pattern + ")")
+	}
+	expr.WriteString("$")
+	l.pattern = regexp.MustCompile(expr.String())
+
+	start := periodStart(period, time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local))
+	seen := make(map[string]bool)
+	for i := 0; i < 800; i++ {
+		date := nextPeriod(period, start, i)
+		p := l.format(date)
+		if normalized, fe := NormalizeFilePath("path", p); fe != nil || normalized != p {
+			return nil, fmt.Errorf("周期笔记路径格式 %q 生成的路径 %q 不合法", layout, p)
+		}
+		if seen[p] {
+			return nil, fmt.Errorf("周期笔记路径格式 %q 无法区分不同的%s", layout, periodName(period))
+		}
+		seen[p] = true
+		if parsed, ok := l.parse(p); !ok || !parsed.Equal(date) {
+			return nil, fmt.Errorf("周期笔记路径格式 %q 无法从路径中解析日期", layout)
+		}
+	}
+	return l, nil
+}
+
+// periodName 周期的中文名称
+func periodName(period Period) string {
+	switch period {
+	case PeriodWeekly:
+		return "周"
+	case PeriodMonthly:
+		return "月"
+	}
+	return "天"
+}
+
+// format 生成周期的文件路径
+func (l *periodicLayout) format(start time.Time) string {
+	return formatTemplateTime(start, l.layout, "")
+}
+
+// parse 从文件路径中解析周期的第一天，路径与格式不完全一致时 ok 为 false
+func (l *periodicLayout) parse(filePath string) (time.Time, bool) {
+	m := l.pattern.FindStringSubmatch(filePath)
+	if m == nil {
+		return time.Time{}, false
+	}
+	values := make(map[string]int, len(l.tokens))
+	for i, token := range l.tokens {
+		v, _ := strconv.Atoi(m[i+1])
+		if _, ok := values[token]; !ok {
+			values[token] = v
+		}
+	}
+	get := func(tokens ...string) (int, bool) {
+		for _, token := range tokens {
+			if v, ok := values[token]; ok {
+				return v, true
+			}
+		}
+		return 0, false
+	}
+
+	year, hasYear := get("YYYY")
+	if v, ok := get("YY"); ok && !hasYear {
+		year, hasYear = 2000+v, true
+	}
+	month, hasMonth := get("MM", "M")
+	day, hasDay := get("DD", "D")
+	week, hasWeek := get("WW", "W")
+	var date time.Time
+	switch weekYear, ok := get("GGGG"); {
+	case ok && hasWeek:
+		date = isoWeekStart(weekYear, week)
+	case hasYear && hasMonth && hasDay:
+		date = time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.Local)
+	case hasYear && hasMonth:
+		date = time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.Local)
+	case hasYear && hasWeek:
+		date = isoWeekStart(year, week)
+	default:
+		return time.Time{}, false
+	}
+	// 日期越界（例如 02-30）或与格式中的其他记号不一致时，重新生成的路径不同
+	date = periodStart(l.period, date)
+	if l.format(date) != filePath {
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// periodStart 返回 t 所在周期的第一天
+func periodStart(period Period, t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch period {
+	case PeriodWeekly:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case PeriodMonthly:
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day
+}
+
+// nextPeriod 返回 start 之后第 n 个周期的第一天，n 可以为负数
+func nextPeriod(period Period, start time.Time, n int) time.Time {
+	switch period {
+	case PeriodWeekly:
+		return start.AddDate(0, 0, 7*n)
+	case PeriodMonthly:
+		return start.AddDate(0, n, 0)
+	}
+	return start.AddDate(0, 0, n)
+}
+
+// isoWeekStart 返回 ISO 周所在的周一
+func isoWeekStart(year, week int) time.Time {
+	// 1 月 4 日总是在第一周
+	jan4 := time.Date(year, 1, 4, 0, 0, 0, 0, time.Local)
+	return periodStart(PeriodWeekly, jan4).AddDate(0, 0, 7*(week-1))
+}
+
+// isoWeekPattern 匹配 2026-W42 形式的周
+var isoWeekPattern = regexp.MustCompile(`^(\d{4})-W(\d{1,2})$`)
+
+// parsePeriodDate 解析周期中的任意一天，空字符串表示今天；
+// 周还可以写作 2026-W42，月还可以写作 2026-10
+func parsePeriodDate(period Period, date string) (time.Time, error) {
+	if date == "" {
+		return periodStart(period, time.Now()), nil
+	}
+	if t, err := time.ParseInLocation(dateLayout, date, time.Local); err == nil {
+		return periodStart(period, t), nil
+	}
+	switch period {
+	case PeriodWeekly:
+		if m := isoWeekPattern.FindStringSubmatch(date); m != nil {
+			year, _ := strconv.Atoi(m[1])
+			week, _ := strconv.Atoi(m[2])
+			if start := isoWeekStart(year, week); week >= 1 && week <= 53 {
+				if y, w := start.ISOWeek(); y == year && w == week {
+					return start, nil
+				}
+			}
+		}
+	case PeriodMonthly:
+		if t, err := time.ParseInLocation("2006-01", date, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, ErrPeriodDateInvalid
+}
+
+// layout 返回周期的路径格式，周期不支持或未启用时返回错误
+func (s *PeriodicService) layout(period Period) (*periodicLayout, error) {
+	switch period {
+	case PeriodDaily, PeriodWeekly, PeriodMonthly:
+	default:
+		return nil, ErrPeriodInvalid
+	}
+	l, ok := s.layouts[period]
+	if !ok {
+		return nil, ErrPeriodDisabled
+	}
+	return l, nil
+}
+
+// GetPeriodicNote 获取 date 所在周期的笔记，笔记不存在时不创建，Note 为 nil
+func (s *PeriodicService) GetPeriodicNote(ctx context.Context, period Period, date string) (*PeriodicNote, error) {
+	l, err := s.layout(period)
+	if err != nil {
+		return nil, err
+	}
+	start, err := parsePeriodDate(period, date)
+	if err != nil {
+		return nil, err
+	}
+	note, err := s.findNote(ctx, l.format(start))
+	if err != nil {
+		return nil, err
+	}
+	return s.periodicNote(ctx, l, start, note)
+}
+
+// EnsurePeriodicNote 获取 date 所在周期的笔记，不存在时按配置的模板创建，created 表示是否新建；
+// variables 为模板中 {{prompt:名称}} 的取值，模板中的 {{date}} 为周期的第一天
+func (s *PeriodicService) EnsurePeriodicNote(ctx context.Context, period Period, date string, variables map[string]string) (result *PeriodicNote, created bool, err error) {
+	l, err := s.layout(period)
+	if err != nil {
+		return nil, false, err
+	}
+	start, err := parsePeriodDate(period, date)
+	if err != nil {
+		return nil, false, err
+	}
+	filePath := l.format(start)
+	note, err := s.findNote(ctx, filePath)
+	if err != nil {
+		return nil, false, err
+	}
+	if note == nil {
+		if note, err = s.createNote(ctx, l, start, filePath, variables); err != nil {
+			// 并发请求已经创建了同一篇笔记
+			if !errors.Is(err, ErrNoteFilePathExists) {
+				return nil, false, err
+			}
+			if note, err = s.findNote(ctx, filePath); err != nil {
+				return nil, false, err
+			}
+		} else {
+			created = true
+			s.logger.Info("Periodic note created", zap.String("period", string(period)), zap.String("file_path", filePath))
+		}
+	}
+	result, err = s.periodicNote(ctx, l, start, note)
+	return result, created, err
+}
+
+// createNote 创建周期笔记，文件夹逐级转换为目录；标题为文件名，模板设置了标题时使用模板的标题
+func (s *PeriodicService) createNote(ctx context.Context, l *periodicLayout, start time.Time, filePath string, variables map[string]string) (*model.Note, error) {
+	categoryID, err := ensureCategoryPath(ctx, s.db, nil, "", path.Dir(filePath), nil)
+	if err != nil {
+		return nil, err
+	}
+	title := strings.TrimSuffix(path.Base(filePath), ".md")
+	if l.template == "" {
+		return NewNoteService(s.db.WithContext(ctx), s.logger).CreateNote(CreateNoteInput{
+			Title:      title,
+			FilePath:   filePath,
+			CategoryID: categoryID,
+		})
+	}
+
+	tpl, err := s.templates.GetTemplateByName(ctx, l.template)
+	if err != nil {
+		return nil, err
+	}
+	if tpl.Title != "" {
+		title = ""
+	}
+	if categoryID == nil {
+		categoryID = new(string) // 根目录，不使用模板的默认目录
+	}
+	return s.templates.CreateNote(ctx, CreateFromTemplateInput{
+		TemplateID: tpl.ID,
+		Title:      title,
+		FilePath:   filePath,
+		CategoryID: categoryID,
+		Variables:  variables,
+		Now:        start,
+	})
+}
+
+// findNote 按文件路径查找笔记（不含回收站），不存在时返回 nil
+func (s *PeriodicService) findNote(ctx context.Context, filePath string) (*model.Note, error) {
+	var note model.Note
+	err := s.db.WithContext(ctx).Preload("Category").Preload("Tags").Take(&note, "file_path = ?", filePath).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+// periodicNote 组装周期笔记，并查找前后最近的已有周期笔记
+func (s *PeriodicService) periodicNote(ctx context.Context, l *periodicLayout, start time.Time, note *model.Note) (*PeriodicNote, error) {
+	result := &PeriodicNote{
+		Period:   l.period,
+		Date:     start.Format(dateLayout),
+		End:      nextPeriod(l.period, start, 1).AddDate(0, 0, -1).Format(dateLayout),
+		FilePath: l.format(start),
+		Note:     note,
+	}
+
+	var notes []model.Note
+	if err := s.db.WithContext(ctx).Select("id", "title", "file_path").
+		Where(likeCondition("file_path"), escapeLike(l.prefix)+"%").Find(&notes).Error; err != nil {
+		return nil, err
+	}
+	var prevDate, nextDate time.Time
+	for _, n := range notes {
+		date, ok := l.parse(n.FilePath)
+		if !ok {
+			continue
+		}
+		ref := &PeriodicRef{Date: date.Format(dateLayout), NoteID: n.ID, Title: n.Title, FilePath: n.FilePath}
+		switch {
+		case date.Before(start) && (result.Previous == nil || date.After(prevDate)):
+			result.Previous, prevDate = ref, date
+		case date.After(start) && (result.Next == nil || date.Before(nextDate)):
+			result.Next, nextDate = ref, date
+		}
+	}
+	return result, nil
+}
+
+// Calendar 返回 month（例如 2026-10，为空表示本月）的日历
+func (s *PeriodicService) Calendar(ctx context.Context, month string) (*Calendar, error) {
+	start := periodStart(PeriodMonthly, time.Now())
+	if month != "" {
+		t, err := time.ParseInLocation("2006-01", month, time.Local)
+		if err != nil {
+			return nil, ErrPeriodDateInvalid
+		}
+		start = t
+	}
+	end := start.AddDate(0, 1, 0)
+	cal := &Calendar{Month: start.Format("2006-01")}
+
+	// 先收集各周期笔记的路径，一次查询
+	paths := make(map[string]**string)
+	want := func(period Period, date time.Time, target **string) {
+		if l, ok := s.layouts[period]; ok {
+			paths[l.format(date)] = target
+		}
+	}
+	want(PeriodMonthly, start, &cal.MonthlyNoteID)
+	for week := periodStart(PeriodWeekly, start); week.Before(end); week = week.AddDate(0, 0, 7) {
+		year, w := week.ISOWeek()
+		cal.Weeks = append(cal.Weeks, CalendarWeek{Week: fmt.Sprintf("%d-W%02d", year, w), Start: week.Format(dateLayout)})
+	}
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		cal.Days = append(cal.Days, CalendarDay{Date: day.Format(dateLayout)})
+	}
+	for i := range cal.Weeks {
+		week, _ := time.ParseInLocation(dateLayout, cal.Weeks[i].Start, time.Local)
+		want(PeriodWeekly, week, &cal.Weeks[i].WeeklyNoteID)
+	}
+	for i := range cal.Days {
+		want(PeriodDaily, start.AddDate(0, 0, i), &cal.Days[i].DailyNoteID)
+	}
+
+	if len(paths) > 0 {
+		filePaths := make([]string, 0, len(paths))
+		for p := range paths {
+			filePaths = append(filePaths, p)
+		}
+		var notes []model.Note
+		if err := s.db.WithContext(ctx).Select("id", "file_path").Where("file_path IN ?", filePaths).Find(&notes).Error; err != nil {
+			return nil, err
+		}
+		for _, n := range notes {
+			id := n.ID
+			*paths[n.FilePath] = &id
+		}
+	}
+
+	var created []time.Time
+	if err := s.db.WithContext(ctx).Model(&model.Note{}).
+		Where("created_at >= ? AND created_at < ?", start, end).Pluck("created_at", &created).Error; err != nil {
+		return nil, err
+	}
+	for _, t := range created {
+		if i := t.In(time.Local).Day() - 1; i >= 0 && i < len(cal.Days) {
+			cal.Days[i].NoteCount++
+		}
+	}
+	return cal, nil
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"leafnote/internal/model"
+	"leafnote/internal/testutil"
+)
+
+// testPeriodicOptions 测试使用的路径格式
+var testPeriodicOptions = PeriodicOptions{
+	Daily:   PeriodicNoteOptions{Path: "日记/YYYY/YYYY-MM-DD.md"},
+	Weekly:  PeriodicNoteOptions{Path: "周记/GGGG-[W]WW.md"},
+	Monthly: PeriodicNoteOptions{Path: "月记/YYYY年M月.md"},
+}
+
+func TestNewPeriodicService(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    PeriodicOptions
+		wantErr string
+	}{
+		{name: "默认格式", opts: testPeriodicOptions},
+		{name: "未启用", opts: PeriodicOptions{}},
+		{name: "日记缺少日", opts: PeriodicOptions{Daily: PeriodicNoteOptions{Path: "日记/YYYY-MM.md"}}, wantErr: "无法区分"},
+		{name: "周记使用日历年", opts: PeriodicOptions{Weekly: PeriodicNoteOptions{Path: "周记/YYYY-WW.md"}}, wantErr: "无法"},
+		{name: "路径不合法", opts: PeriodicOptions{Monthly: PeriodicNoteOptions{Path: "月记/YYYY:MM.md"}}, wantErr: "不合法"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPeriodicService(testutil.NewTestDB(t), zap.NewNop(), tt.opts)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestParsePeriodDate(t *testing.T) {
+	tests := []struct {
+		name    string
+		period  Period
+		date    string
+		want    string
+		wantErr bool
+	}{
+		{name: "日期", period: PeriodDaily, date: "2026-10-18", want: "2026-10-18"},
+		{name: "周中的一天", period: PeriodWeekly, date: "2026-10-18", want: "2026-10-12"},
+		{name: "ISO 周", period: PeriodWeekly, date: "2026-W01", want: "2025-12-29"},
+		{name: "不存在的周", period: PeriodWeekly, date: "2025-W53", wantErr: true},
+		{name: "月份", period: PeriodMonthly, date: "2026-10", want: "2026-10-01"},
+		{name: "日记不接受月份", period: PeriodDaily, date: "2026-10", wantErr: true},
+		{name: "格式错误", period: PeriodDaily, date: "10/18/2026", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePeriodDate(tt.period, tt.date)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrPeriodDateInvalid)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.Format(dateLayout))
+		})
+	}
+}
+
+func TestPeriodicService_EnsurePeriodicNote(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	ctx := context.Background()
+	tpl := &model.Template{Name: "周记", Content: "# {{date:GGGG-[W]WW}}\n\n本周目标：{{prompt:目标|无}}"}
+	require.NoError(t, NewTemplateService(db, zap.NewNop()).CreateTemplate(ctx, tpl))
+	opts := testPeriodicOptions
+	opts.Weekly.Template = "周记"
+	s, err := NewPeriodicService(db, zap.NewNop(), opts)
+	require.NoError(t, err)
+
+	t.Run("创建日记", func(t *testing.T) {
+		result, created, err := s.EnsurePeriodicNote(ctx, PeriodDaily, "2026-10-18", nil)
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.Equal(t, "2026-10-18", result.Date)
+		assert.Equal(t, "2026-10-18", result.End)
+		require.NotNil(t, result.Note)
+		assert.Equal(t, "/日记/2026/2026-10-18.md", result.Note.FilePath)
+		assert.Equal(t, "2026-10-18", result.Note.Title)
+
+		// 文件夹逐级转换为目录
+		var category model.Category
+		require.NoError(t, db.First(&category, "id = ?", *result.Note.CategoryID).Error)
+		assert.Equal(t, "/日记/2026", category.Path)
+
+		// 再次请求返回已有的笔记
+		again, created, err := s.EnsurePeriodicNote(ctx, PeriodDaily, "2026-10-18", nil)
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, result.Note.ID, again.Note.ID)
+	})
+
+	t.Run("按模板创建周记", func(t *testing.T) {
+		result, created, err := s.EnsurePeriodicNote(ctx, PeriodWeekly, "2026-10-18", map[string]string{"目标": "发布"})
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.Equal(t, "2026-10-12", result.Date)
+		assert.Equal(t, "2026-10-18", result.End)
+		assert.Equal(t, "/周记/2026-W42.md", result.Note.FilePath)
+		assert.Equal(t, "2026-W42", result.Note.Title)
+		assert.Equal(t, "# 2026-W42\n\n本周目标：发布", result.Note.Content)
+	})
+
+	t.Run("周期不支持", func(t *testing.T) {
+		_, _, err := s.EnsurePeriodicNote(ctx, Period("yearly"), "", nil)
+		assert.ErrorIs(t, err, ErrPeriodInvalid)
+	})
+
+	t.Run("未启用", func(t *testing.T) {
+		disabled, err := NewPeriodicService(db, zap.NewNop(), PeriodicOptions{})
+		require.NoError(t, err)
+		_, _, err = disabled.EnsurePeriodicNote(ctx, PeriodDaily, "", nil)
+		assert.ErrorIs(t, err, ErrPeriodDisabled)
+	})
+}
+
+func TestPeriodicService_Navigation(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	ctx := context.Background()
+	s, err := NewPeriodicService(db, zap.NewNop(), testPeriodicOptions)
+	require.NoError(t, err)
+
+	for _, date := range []string{"2026-09-30", "2026-10-15", "2026-10-20"} {
+		_, _, err := s.EnsurePeriodicNote(ctx, PeriodDaily, date, nil)
+		require.NoError(t, err)
+	}
+	// 路径相近但不符合格式的笔记不参与导航
+	notes := NewNoteService(db, zap.NewNop())
+	_, err = notes.CreateNote(CreateNoteInput{Title: "草稿", FilePath: "/日记/2026/2026-10-17 草稿.md"})
+	require.NoError(t, err)
+	_, err = notes.CreateNote(CreateNoteInput{Title: "错误日期", FilePath: "/日记/2026/2026-02-30.md"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		date     string
+		wantNote bool
+		wantPrev string
+		wantNext string
+	}{
+		{name: "中间没有日记的一天", date: "2026-10-18", wantPrev: "2026-10-15", wantNext: "2026-10-20"},
+		{name: "已有日记", date: "2026-10-15", wantNote: true, wantPrev: "2026-09-30", wantNext: "2026-10-20"},
+		{name: "最早的日记", date: "2026-09-30", wantNote: true, wantNext: "2026-10-15"},
+		{name: "最新的日记之后", date: "2026-12-01", wantPrev: "2026-10-20"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := s.GetPeriodicNote(ctx, PeriodDaily, tt.date)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantNote, result.Note != nil)
+			if tt.wantPrev == "" {
+				assert.Nil(t, result.Previous)
+			} else if assert.NotNil(t, result.Previous) {
+				assert.Equal(t, tt.wantPrev, result.Previous.Date)
+			}
+			if tt.wantNext == "" {
+				assert.Nil(t, result.Next)
+			} else if assert.NotNil(t, result.Next) {
+				assert.Equal(t, tt.wantNext, result.Next.Date)
+			}
+		})
+	}
+
+	// 查看时不创建笔记
+	var count int64
+	require.NoError(t, db.Model(&model.Note{}).Where("file_path = ?", "/日记/2026/2026-10-18.md").Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestPeriodicService_Calendar(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	ctx := context.Background()
+	s, err := NewPeriodicService(db, zap.NewNop(), testPeriodicOptions)
+	require.NoError(t, err)
+
+	daily, _, err := s.EnsurePeriodicNote(ctx, PeriodDaily, "2026-10-18", nil)
+	require.NoError(t, err)
+	weekly, _, err := s.EnsurePeriodicNote(ctx, PeriodWeekly, "2026-10-01", nil)
+	require.NoError(t, err)
+	monthly, _, err := s.EnsurePeriodicNote(ctx, PeriodMonthly, "2026-10-18", nil)
+	require.NoError(t, err)
+	// 创建时间落在 10 月 3 日的笔记
+	note, err := NewNoteService(db, zap.NewNop()).CreateNote(CreateNoteInput{Title: "随笔", FilePath: "/随笔.md"})
+	require.NoError(t, err)
+	require.NoError(t, db.Model(note).UpdateColumn("created_at", time.Date(2026, 10, 3, 12, 0, 0, 0, time.Local)).Error)
+	require.NoError(t, db.Model(&model.Note{}).Where("id <> ?", note.ID).UpdateColumn("created_at", time.Date(2026, 9, 1, 0, 0, 0, 0, time.Local)).Error)
+
+	cal, err := s.Calendar(ctx, "2026-10")
+	require.NoError(t, err)
+	assert.Equal(t, "2026-10", cal.Month)
+	require.NotNil(t, cal.MonthlyNoteID)
+	assert.Equal(t, monthly.Note.ID, *cal.MonthlyNoteID)
+
+	require.Len(t, cal.Days, 31)
+	require.NotNil(t, cal.Days[17].DailyNoteID)
+	assert.Equal(t, daily.Note.ID, *cal.Days[17].DailyNoteID)
+	assert.Nil(t, cal.Days[16].DailyNoteID)
+	assert.Equal(t, 1, cal.Days[2].NoteCount)
+	assert.Zero(t, cal.Days[17].NoteCount)
+
+	// 2026-10-01 是周四，第一周为 W40
+	require.Len(t, cal.Weeks, 5)
+	assert.Equal(t, "2026-W40", cal.Weeks[0].Week)
+	assert.Equal(t, "2026-09-28", cal.Weeks[0].Start)
+	require.NotNil(t, cal.Weeks[0].WeeklyNoteID)
+	assert.Equal(t, weekly.Note.ID, *cal.Weeks[0].WeeklyNoteID)
+	assert.Nil(t, cal.Weeks[1].WeeklyNoteID)
+
+	_, err = s.Calendar(ctx, "2026/10")
+	assert.ErrorIs(t, err, ErrPeriodDateInvalid)
+}
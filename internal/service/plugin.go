@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"leafnote/internal/model"
+	"leafnote/internal/plugin"
+)
+
+// 插件实现的扩展点
+const (
+	PluginCapBeforeSave   = "before_save"
+	PluginCapAfterSave    = "after_save"
+	PluginCapBeforeDelete = "before_delete"
+	PluginCapRoutes       = "routes"
+	PluginCapMarkdown     = "markdown"
+)
+
+// PluginInfo 插件信息和状态
+type PluginInfo struct {
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description"`
+	Enabled      bool                   `json:"enabled"`
+	Config       map[string]interface{} `json:"config"`
+	Capabilities []string               `json:"capabilities"`         // 实现的扩展点
+	UpdatedAt    *time.Time             `json:"updated_at,omitempty"` // 状态最近一次修改的时间，从未修改时为空
+}
+
+// PluginService 管理插件的启用状态和配置；插件与程序一同编译，由 plugin.Register 注册
+type PluginService struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+// NewPluginService 创建插件服务实例
+func NewPluginService(db *gorm.DB, logger *zap.Logger) *PluginService {
+	return &PluginService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Load 从数据库加载插件的启用状态和配置，服务启动时调用；
+// 未注册的插件和不被接受的配置只记录日志，对应的插件保持未启用
+func (s *PluginService) Load(ctx context.Context) error {
+	var states []model.PluginState
+	if err := s.db.WithContext(ctx).Find(&states).Error; err != nil {
+		return err
+	}
+	for _, st := range states {
+		if _, ok := plugin.Lookup(st.Name); !ok {
+			s.logger.Warn("Plugin is not registered, skipped", zap.String("plugin", st.Name))
+			continue
+		}
+		if err := plugin.Apply(st.Name, st.Enabled, st.Config); err != nil {
+			s.logger.Error("Failed to configure plugin", zap.String("plugin", st.Name), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// ListPlugins 获取所有已注册的插件，按名称排序
+func (s *PluginService) ListPlugins(ctx context.Context) ([]PluginInfo, error) {
+	var states []model.PluginState
+	if err := s.db.WithContext(ctx).Find(&states).Error; err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*model.PluginState, len(states))
+	for i := range states {
+		byName[states[i].Name] = &states[i]
+	}
+	infos := []PluginInfo{}
+	for _, p := range plugin.All() {
+		infos = append(infos, pluginInfo(p, byName[p.Name()]))
+	}
+	return infos, nil
+}
+
+// GetPlugin 获取插件信息
+func (s *PluginService) GetPlugin(ctx context.Context, name string) (*PluginInfo, error) {
+	p, ok := plugin.Lookup(name)
+	if !ok {
+		return nil, ErrPluginNotFound
+	}
+	state, err := s.findState(s.db.WithContext(ctx), name)
+	if err != nil {
+		return nil, err
+	}
+	info := pluginInfo(p, state)
+	return &info, nil
+}
+
+// findState 查找插件的状态，从未修改时返回 nil
+func (s *PluginService) findState(db *gorm.DB, name string) (*model.PluginState, error) {
+	var state model.PluginState
+	if err := db.First(&state, "name = ?", name).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &state, nil
+}
+
+// UpdatePluginInput 修改插件状态的输入参数，字段为空时保持不变
+type UpdatePluginInput struct {
+	Enabled *bool
+	Config  map[string]interface{} // 整体替换原来的配置
+}
+
+// UpdatePlugin 启用、停用插件或修改配置；插件启用时配置先交给插件校验，不被接受时返回校验错误
+func (s *PluginService) UpdatePlugin(ctx context.Context, name string, input UpdatePluginInput) (*PluginInfo, error) {
+	p, ok := plugin.Lookup(name)
+	if !ok {
+		return nil, ErrPluginNotFound
+	}
+	var state, previous *model.PluginState
+	applied := false
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if previous, err = s.findState(tx, name); err != nil {
+			return err
+		}
+		state = &model.PluginState{Name: name, Config: map[string]interface{}{}}
+		if previous != nil {
+			*state = *previous
+		}
+		if input.Enabled != nil {
+			state.Enabled = *input.Enabled
+		}
+		if input.Config != nil {
+			state.Config = input.Config
+		}
+		if err := tx.Save(state).Error; err != nil {
+			return err
+		}
+		if err := plugin.Apply(name, state.Enabled, state.Config); err != nil {
+			return newValidationError([]FieldError{{Field: "config", Code: FieldInvalid, Params: map[string]string{"value": err.Error()}}})
+		}
+		applied = true
+		return nil
+	})
+	if err != nil {
+		// 提交失败时恢复原来的状态，原来的配置此前已被接受
+		if applied {
+			if previous == nil {
+				previous = &model.PluginState{Name: name}
+			}
+			if rerr := plugin.Apply(name, previous.Enabled, previous.Config); rerr != nil {
+				s.logger.Error("Failed to restore plugin state", zap.String("plugin", name), zap.Error(rerr))
+			}
+		}
+		return nil, err
+	}
+	info := pluginInfo(p, state)
+	return &info, nil
+}
+
+// pluginInfo 组合插件信息和保存的状态，state 为 nil 表示从未修改
+func pluginInfo(p plugin.Plugin, state *model.PluginState) PluginInfo {
+	info := PluginInfo{
+		Name:         p.Name(),
+		Description:  p.Description(),
+		Enabled:      plugin.IsEnabled(p.Name()),
+		Config:       map[string]interface{}{},
+		Capabilities: []string{},
+	}
+	if state != nil {
+		info.Config = state.Config
+		info.UpdatedAt = &state.UpdatedAt
+	}
+	if _, ok := p.(plugin.BeforeSaveHook); ok {
+		info.Capabilities = append(info.Capabilities, PluginCapBeforeSave)
+	}
+	if _, ok := p.(plugin.AfterSaveHook); ok {
+		info.Capabilities = append(info.Capabilities, PluginCapAfterSave)
+	}
+	if _, ok := p.(plugin.BeforeDeleteHook); ok {
+		info.Capabilities = append(info.Capabilities, PluginCapBeforeDelete)
+	}
+	if _, ok := p.(plugin.RouteProvider); ok {
+		info.Capabilities = append(info.Capabilities, PluginCapRoutes)
+	}
+	if _, ok := p.(plugin.MarkdownTransformer); ok {
+		info.Capabilities = append(info.Capabilities, PluginCapMarkdown)
+	}
+	return info
+}
+
+// pluginRejected 将插件钩子返回的错误包装为 ErrPluginRejected，字段为 plugins.<插件名称>
+func pluginRejected(p plugin.Plugin, err error) error {
+	e := *ErrPluginRejected
+	e.Fields = []FieldError{{Field: "plugins." + p.Name(), Code: FieldRejected, Params: map[string]string{"value": err.Error()}}}
+	return &e
+}
+
+// beforeSaveNote 按名称顺序调用已启用插件的 BeforeSaveNote
+func beforeSaveNote(ctx context.Context, note *plugin.Note) error {
+	for _, p := range plugin.Enabled() {
+		if h, ok := p.(plugin.BeforeSaveHook); ok {
+			if err := h.BeforeSaveNote(ctx, note); err != nil {
+				return pluginRejected(p, err)
+			}
+		}
+	}
+	return nil
+}
+
+// beforeDeleteNote 按名称顺序调用已启用插件的 BeforeDeleteNote
+func beforeDeleteNote(ctx context.Context, note *model.Note) error {
+	for _, p := range plugin.Enabled() {
+		if h, ok := p.(plugin.BeforeDeleteHook); ok {
+			if err := h.BeforeDeleteNote(ctx, note); err != nil {
+				return pluginRejected(p, err)
+			}
+		}
+	}
+	return nil
+}
+
+// transformMarkdown 依次用已启用插件的 MarkdownTransformer 转换笔记正文
+func transformMarkdown(content string) (string, error) {
+	for _, p := range plugin.Enabled() {
+		if t, ok := p.(plugin.MarkdownTransformer); ok {
+			var err error
+			if content, err = t.TransformMarkdown(content); err != nil {
+				return "", fmt.Errorf("插件 %s 转换 Markdown 失败: %w", p.Name(), err)
+			}
+		}
+	}
+	return content, nil
+}
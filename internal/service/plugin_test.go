@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"leafnote/internal/model"
+	"leafnote/internal/plugin"
+	"leafnote/internal/testutil"
+)
+
+// guardPlugin 测试用插件：保存前替换标题前缀并拒绝包含禁用词的正文，
+// 拒绝删除置顶笔记，渲染时把 ==文字== 转换为粗体
+type guardPlugin struct {
+	mu     sync.Mutex
+	banned string
+	saved  []string
+}
+
+func (p *guardPlugin) Name() string        { return "guard" }
+func (p *guardPlugin) Description() string { return "内容检查" }
+
+func (p *guardPlugin) Configure(config map[string]interface{}) error {
+	banned, ok := config["banned"].(string)
+	if !ok || banned == "" {
+		return errors.New("banned 不能为空")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.banned = banned
+	return nil
+}
+
+func (p *guardPlugin) BeforeSaveNote(ctx context.Context, note *plugin.Note) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if strings.Contains(note.Content, p.banned) {
+		return errors.New("正文包含禁用词")
+	}
+	note.Title = strings.Replace(note.Title, "TODO:", "待办：", 1)
+	return nil
+}
+
+func (p *guardPlugin) AfterSaveNote(ctx context.Context, note *model.Note) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.saved = append(p.saved, note.Title)
+	return nil
+}
+
+func (p *guardPlugin) BeforeDeleteNote(ctx context.Context, note *model.Note) error {
+	if strings.HasPrefix(note.Title, "置顶") {
+		return errors.New("置顶笔记不能删除")
+	}
+	return nil
+}
+
+func (p *guardPlugin) TransformMarkdown(content string) (string, error) {
+	return strings.ReplaceAll(content, "==", "**"), nil
+}
+
+var testGuardPlugin = &guardPlugin{}
+
+func init() {
+	plugin.Register(testGuardPlugin)
+}
+
+func TestPluginService(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	ctx := context.Background()
+	s := NewPluginService(db, zap.NewNop())
+	t.Cleanup(func() {
+		require.NoError(t, plugin.Apply("guard", false, nil))
+	})
+
+	infos, err := s.ListPlugins(ctx)
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+	assert.False(t, infos[0].Enabled)
+	assert.Nil(t, infos[0].UpdatedAt)
+	assert.Equal(t, []string{PluginCapBeforeSave, PluginCapAfterSave, PluginCapBeforeDelete, PluginCapMarkdown}, infos[0].Capabilities)
+
+	tests := []struct {
+		name    string
+		plugin  string
+		input   UpdatePluginInput
+		wantErr error
+	}{
+		{name: "插件不存在", plugin: "missing", input: UpdatePluginInput{Enabled: testutil.BoolPtr(true)}, wantErr: ErrPluginNotFound},
+		{name: "配置不被接受", plugin: "guard", input: UpdatePluginInput{Enabled: testutil.BoolPtr(true)}, wantErr: ErrValidationFailed},
+		{name: "停用时只保存配置", plugin: "guard", input: UpdatePluginInput{Config: map[string]interface{}{"banned": "机密"}}},
+		{name: "启用", plugin: "guard", input: UpdatePluginInput{Enabled: testutil.BoolPtr(true)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.UpdatePlugin(ctx, tt.plugin, tt.input)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	info, err := s.GetPlugin(ctx, "guard")
+	require.NoError(t, err)
+	assert.True(t, info.Enabled)
+	assert.Equal(t, map[string]interface{}{"banned": "机密"}, info.Config)
+
+	t.Run("保存笔记时调用钩子", func(t *testing.T) {
+		notes := NewNoteService(db, zap.NewNop())
+		note, err := notes.CreateNote(CreateNoteInput{Title: "TODO:周报", FilePath: "/周报.md", Content: "==本周==完成"})
+		require.NoError(t, err)
+		assert.Equal(t, "待办：周报", note.Title)
+
+		err = notes.UpdateNote(note.ID, UpdateNoteInput{Content: "机密内容"})
+		assert.ErrorIs(t, err, ErrPluginRejected)
+		e, ok := AsError(err)
+		require.True(t, ok)
+		require.Len(t, e.Fields, 1)
+		assert.Equal(t, "plugins.guard", e.Fields[0].Field)
+		assert.Equal(t, "正文包含禁用词", e.Fields[0].Params["value"])
+
+		require.NoError(t, notes.UpdateNote(note.ID, UpdateNoteInput{Title: "TODO:月报"}))
+		saved, err := notes.GetNote(note.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "待办：月报", saved.Title)
+		assert.Equal(t, 2, saved.Version)
+		assert.Equal(t, []string{"待办：周报", "待办：月报"}, testGuardPlugin.saved)
+
+		html, err := RenderMarkdown(saved.Content, nil)
+		require.NoError(t, err)
+		assert.Contains(t, html, "<strong>本周</strong>")
+
+		pinned, err := notes.CreateNote(CreateNoteInput{Title: "置顶说明", FilePath: "/置顶说明.md"})
+		require.NoError(t, err)
+		assert.ErrorIs(t, notes.DeleteNote(pinned.ID), ErrPluginRejected)
+		require.NoError(t, notes.DeleteNote(note.ID))
+	})
+
+	t.Run("勾选待办和同步时调用钩子", func(t *testing.T) {
+		notes := NewNoteService(db, zap.NewNop())
+		note, err := notes.CreateNote(CreateNoteInput{Title: "发布", FilePath: "/发布.md", Content: "- [ ] 打标签\n"})
+		require.NoError(t, err)
+		var task model.Task
+		require.NoError(t, db.First(&task, "note_id = ?", note.ID).Error)
+
+		_, err = s.UpdatePlugin(ctx, "guard", UpdatePluginInput{Config: map[string]interface{}{"banned": "[x]"}})
+		require.NoError(t, err)
+		_, err = NewTaskService(db, zap.NewNop()).ToggleTask(ctx, task.ID, nil)
+		assert.ErrorIs(t, err, ErrPluginRejected)
+		saved, err := notes.GetNote(note.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, saved.Version)
+		assert.Equal(t, "- [ ] 打标签\n", saved.Content)
+		_, err = s.UpdatePlugin(ctx, "guard", UpdatePluginInput{Config: map[string]interface{}{"banned": "机密"}})
+		require.NoError(t, err)
+
+		// 同步应用的修改在事务提交后调用 AfterSaveNote
+		testGuardPlugin.saved = nil
+		events := NewEventService(db, zap.NewNop(), EventOptions{})
+		result, err := NewSyncService(db, zap.NewNop(), events, SyncOptions{}).Sync(ctx, SyncInput{Limit: 1, Changes: []SyncChangeInput{
+			{ChangeID: "p1", Type: model.SyncChangeUpdate, NoteID: note.ID, BaseVersion: 1, Title: "TODO:发布"},
+			{ChangeID: "p2", Type: model.SyncChangeUpdate, NoteID: note.ID, BaseVersion: 2, Content: "机密"},
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, SyncStatusApplied, result.Changes[0].Status)
+		assert.Equal(t, SyncStatusRejected, result.Changes[1].Status)
+		assert.Equal(t, []string{"待办：发布"}, testGuardPlugin.saved)
+	})
+
+	t.Run("启动时加载状态", func(t *testing.T) {
+		require.NoError(t, plugin.Apply("guard", false, nil))
+		require.NoError(t, s.Load(ctx))
+		assert.True(t, plugin.IsEnabled("guard"))
+
+		_, err := s.UpdatePlugin(ctx, "guard", UpdatePluginInput{Enabled: testutil.BoolPtr(false)})
+		require.NoError(t, err)
+		require.NoError(t, s.Load(ctx))
+		assert.False(t, plugin.IsEnabled("guard"))
+	})
+}
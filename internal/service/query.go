@@ -0,0 +1,26 @@
+package service
+
+import "strings"
+
+// likeEscapeChar LIKE 转义字符
+// 各数据库默认转义字符不同（MySQL 为反斜杠，SQLite 没有），统一显式指定
+const likeEscapeChar = "!"
+
+// likeEscaper 转义 LIKE 模式中的通配符
+var likeEscaper = strings.NewReplacer(
+	likeEscapeChar, likeEscapeChar+likeEscapeChar,
+	"%", likeEscapeChar+"%",
+	"_", likeEscapeChar+"_",
+)
+
+// escapeLike 转义字符串，使其在 LIKE 模式中按字面匹配
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// likeCondition 返回不区分大小写的 LIKE 条件，参数需经过 escapeLike 转义
+// SQLite 和 MySQL（默认排序规则）的 LIKE 不区分大小写，PostgreSQL 区分，因此两侧统一转为小写；
+// 需要区分大小写的匹配（如路径前缀）在程序中再做精确比较
+func likeCondition(column string) string {
+	return "LOWER(" + column + ") LIKE LOWER(?) ESCAPE '" + likeEscapeChar + "'"
+}
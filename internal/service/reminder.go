@@ -0,0 +1,415 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+
+	"leafnote/internal/model"
+)
+
+// yamlReminderKey YAML 元数据中表示提醒时间的键
+const yamlReminderKey = "remind"
+
+// defaultReminderClock 只写日期的提醒在当天 9 点触发
+const defaultReminderClock = 9 * time.Hour
+
+// DefaultSnooze 未指定时稍后提醒的时长
+const DefaultSnooze = 10 * time.Minute
+
+// fireBatchSize 每次查询到期提醒的数量上限
+const fireBatchSize = 100
+
+// reminderLayouts YAML 中提醒时间支持的格式，不带时区时按服务器时区解析
+var reminderLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+// ReminderOptions 提醒配置
+type ReminderOptions struct {
+	WebhookURL     string        // 提醒触发时 POST 通知的地址，为空时不发送
+	WebhookTimeout time.Duration // 发送通知的超时时间
+}
+
+// ReminderNotification 提醒触发时推送给客户端和 Webhook 的内容
+type ReminderNotification struct {
+	Event    string         `json:"event"` // 固定为 reminder.fired
+	Reminder model.Reminder `json:"reminder"`
+}
+
+// ReminderService 笔记提醒服务：管理提醒、定时触发，并通过订阅和 Webhook 投递
+type ReminderService struct {
+	db     *gorm.DB
+	logger *zap.Logger
+	opts   ReminderOptions
+	client *http.Client
+
+	mu          sync.Mutex
+	subscribers map[chan ReminderNotification]struct{}
+	closed      bool
+}
+
+// NewReminderService 创建提醒服务实例
+func NewReminderService(db *gorm.DB, logger *zap.Logger, opts ReminderOptions) *ReminderService {
+	return &ReminderService{
+		db:          db,
+		logger:      logger,
+		opts:        opts,
+		client:      &http.Client{Timeout: opts.WebhookTimeout},
+		subscribers: make(map[chan ReminderNotification]struct{}),
+	}
+}
+
+// CreateReminderInput 创建提醒的输入参数
+type CreateReminderInput struct {
+	NoteID   string
+	RemindAt time.Time // 早于当前时间时在下一次检查时触发
+	Message  string
+}
+
+// ReminderFilter 提醒列表的筛选条件，零值表示不筛选
+type ReminderFilter struct {
+	Status model.ReminderStatus
+	NoteID string
+}
+
+// ListReminders 获取提醒列表，不含已删除笔记的提醒，按下次触发时间排序
+func (s *ReminderService) ListReminders(ctx context.Context, filter ReminderFilter) ([]model.Reminder, error) {
+	query := s.query(ctx)
+	if filter.Status != "" {
+		query = query.Where("reminders.status = ?", filter.Status)
+	}
+	if filter.NoteID != "" {
+		query = query.Where("reminders.note_id = ?", filter.NoteID)
+	}
+	var reminders []model.Reminder
+	err := query.Order("reminders.remind_at").Find(&reminders).Error
+	return reminders, err
+}
+
+// GetReminder 获取提醒
+func (s *ReminderService) GetReminder(ctx context.Context, id string) (*model.Reminder, error) {
+	var reminder model.Reminder
+	if err := s.query(ctx).First(&reminder, "reminders.id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReminderNotFound
+		}
+		return nil, err
+	}
+	return &reminder, nil
+}
+
+// query 查询提醒并填充笔记标题和路径
+func (s *ReminderService) query(ctx context.Context) *gorm.DB {
+	return s.db.WithContext(ctx).Model(&model.Reminder{}).
+		Select("reminders.*, notes.title AS note_title, notes.file_path AS note_file_path").
+		Joins("JOIN notes ON notes.id = reminders.note_id AND notes.deleted_at IS NULL")
+}
+
+// CreateReminder 为笔记创建提醒
+func (s *ReminderService) CreateReminder(ctx context.Context, input CreateReminderInput) (*model.Reminder, error) {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&model.Note{}).Where("id = ?", input.NoteID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, newValidationError([]FieldError{{Field: "note_id", Code: FieldNotFound, Params: map[string]string{"value": input.NoteID}}})
+	}
+
+	reminder := &model.Reminder{
+		NoteID:   input.NoteID,
+		Source:   model.ReminderSourceManual,
+		Message:  strings.TrimSpace(input.Message),
+		DueAt:    input.RemindAt,
+		RemindAt: input.RemindAt,
+		Status:   model.ReminderPending,
+	}
+	if err := s.db.WithContext(ctx).Create(reminder).Error; err != nil {
+		return nil, err
+	}
+	return s.GetReminder(ctx, reminder.ID)
+}
+
+// SnoozeReminder 推迟提醒到 until，已触发或已关闭的提醒重新进入等待状态
+func (s *ReminderService) SnoozeReminder(ctx context.Context, id string, until time.Time) (*model.Reminder, error) {
+	if !until.After(time.Now()) {
+		return nil, ErrReminderTimeInvalid
+	}
+	return s.update(ctx, id, map[string]interface{}{
+		"remind_at": until,
+		"status":    model.ReminderPending,
+	})
+}
+
+// DismissReminder 关闭提醒，未触发的提醒不再触发
+func (s *ReminderService) DismissReminder(ctx context.Context, id string) (*model.Reminder, error) {
+	return s.update(ctx, id, map[string]interface{}{"status": model.ReminderDismissed})
+}
+
+// update 更新提醒的字段并返回更新后的提醒
+func (s *ReminderService) update(ctx context.Context, id string, updates map[string]interface{}) (*model.Reminder, error) {
+	if _, err := s.GetReminder(ctx, id); err != nil {
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).Model(&model.Reminder{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	return s.GetReminder(ctx, id)
+}
+
+// DeleteReminder 删除提醒，来自 YAML 元数据的提醒需要在笔记中删除
+func (s *ReminderService) DeleteReminder(ctx context.Context, id string) error {
+	reminder, err := s.GetReminder(ctx, id)
+	if err != nil {
+		return err
+	}
+	if reminder.Source == model.ReminderSourceYAML {
+		return ErrReminderFromYAML
+	}
+	return s.db.WithContext(ctx).Unscoped().Delete(&model.Reminder{}, "id = ?", id).Error
+}
+
+// Subscribe 订阅提醒通知，返回的 cancel 用于取消订阅；
+// 服务关闭后通道被关闭，接收过慢时丢弃通知
+func (s *ReminderService) Subscribe() (<-chan ReminderNotification, func()) {
+	ch := make(chan ReminderNotification, 16)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	s.subscribers[ch] = struct{}{}
+	return ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.subscribers[ch]; ok {
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Close 关闭所有订阅，服务停止时调用以断开长连接
+func (s *ReminderService) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for ch := range s.subscribers {
+		delete(s.subscribers, ch)
+		close(ch)
+	}
+}
+
+// publish 向所有订阅者推送通知
+func (s *ReminderService) publish(n ReminderNotification) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.subscribers {
+		select {
+		case ch <- n:
+		default:
+			s.logger.Warn("Reminder subscriber is too slow, notification dropped", zap.String("reminder_id", n.Reminder.ID))
+		}
+	}
+}
+
+// Schedule 返回按 interval 检查并触发到期提醒的后台任务，启动时先检查一次，单次失败只记录日志
+func (s *ReminderService) Schedule(interval time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := s.FireDue(ctx, time.Now()); err != nil && ctx.Err() == nil {
+				s.logger.Error("Failed to fire reminders", zap.Error(err))
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	}
+}
+
+// FireDue 触发 now 之前到期的提醒，返回触发的数量；服务停止期间错过的提醒在启动后补发
+func (s *ReminderService) FireDue(ctx context.Context, now time.Time) (int, error) {
+	fired := 0
+	for {
+		var due []model.Reminder
+		if err := s.query(ctx).
+			Where("reminders.status = ? AND reminders.remind_at <= ?", model.ReminderPending, now).
+			Order("reminders.remind_at").Limit(fireBatchSize).Find(&due).Error; err != nil {
+			return fired, err
+		}
+		for i := range due {
+			r := &due[i]
+			// 按状态和触发时间更新，避免与稍后提醒、关闭同时发生时重复触发
+			result := s.db.WithContext(ctx).Model(&model.Reminder{}).
+				Where("id = ? AND status = ? AND remind_at = ?", r.ID, model.ReminderPending, r.RemindAt).
+				Updates(map[string]interface{}{"status": model.ReminderFired, "fired_at": now})
+			if result.Error != nil {
+				return fired, result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+			r.Status, r.FiredAt = model.ReminderFired, &now
+			s.deliver(ctx, ReminderNotification{Event: "reminder.fired", Reminder: *r})
+			fired++
+		}
+		if len(due) < fireBatchSize {
+			return fired, nil
+		}
+	}
+}
+
+// deliver 推送给订阅者并发送 Webhook，Webhook 失败只记录日志
+func (s *ReminderService) deliver(ctx context.Context, n ReminderNotification) {
+	s.publish(n)
+	if s.opts.WebhookURL == "" {
+		return
+	}
+	if err := s.postWebhook(ctx, n); err != nil {
+		s.logger.Error("Failed to send reminder webhook", zap.String("reminder_id", n.Reminder.ID), zap.Error(err))
+	}
+}
+
+// postWebhook 以 JSON 发送通知，非 2xx 响应视为失败
+func (s *ReminderService) postWebhook(ctx context.Context, n ReminderNotification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.opts.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "leafnote")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook 返回状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// syncYAMLReminders 根据笔记 YAML 元数据中的 remind 同步提醒：
+// 新增的时间创建提醒（已经过去的时间忽略），删除的时间连同提醒一并删除，未变化的保留状态
+func syncYAMLReminders(tx *gorm.DB, noteID, yamlMeta string) error {
+	now := time.Now()
+	want := yamlReminderTimes(yamlMeta)
+
+	var existing []model.Reminder
+	if err := tx.Where("note_id = ? AND source = ?", noteID, model.ReminderSourceYAML).Find(&existing).Error; err != nil {
+		return err
+	}
+	var stale []string
+	for _, r := range existing {
+		found := false
+		for i, t := range want {
+			if t.Equal(r.DueAt) {
+				want = append(want[:i], want[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			stale = append(stale, r.ID)
+		}
+	}
+	if len(stale) > 0 {
+		if err := tx.Unscoped().Where("id IN ?", stale).Delete(&model.Reminder{}).Error; err != nil {
+			return err
+		}
+	}
+
+	var created []model.Reminder
+	for _, t := range want {
+		if t.After(now) {
+			created = append(created, model.Reminder{
+				NoteID:   noteID,
+				Source:   model.ReminderSourceYAML,
+				DueAt:    t,
+				RemindAt: t,
+				Status:   model.ReminderPending,
+			})
+		}
+	}
+	if len(created) == 0 {
+		return nil
+	}
+	return tx.Create(&created).Error
+}
+
+// yamlReminderTimes 解析 YAML 元数据中的 remind，值可以是单个时间或时间列表；
+// YAML 不合法或时间格式无法识别时忽略，结果已去重
+func yamlReminderTimes(yamlMeta string) []time.Time {
+	if strings.TrimSpace(yamlMeta) == "" {
+		return nil
+	}
+	var meta map[string]yaml.Node
+	if err := yaml.Unmarshal([]byte(yamlMeta), &meta); err != nil {
+		return nil
+	}
+	node, ok := meta[yamlReminderKey]
+	if !ok {
+		return nil
+	}
+	values := []*yaml.Node{&node}
+	if node.Kind == yaml.SequenceNode {
+		values = node.Content
+	}
+
+	var times []time.Time
+	for _, v := range values {
+		if v.Kind != yaml.ScalarNode {
+			continue
+		}
+		t, ok := parseReminderTime(v.Value)
+		if !ok {
+			continue
+		}
+		duplicate := false
+		for _, existing := range times {
+			duplicate = duplicate || existing.Equal(t)
+		}
+		if !duplicate {
+			times = append(times, t)
+		}
+	}
+	return times
+}
+
+// parseReminderTime 解析提醒时间，只有日期时为当天 9 点
+func parseReminderTime(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range reminderLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	if t, err := time.ParseInLocation(dateLayout, s, time.Local); err == nil {
+		return t.Add(defaultReminderClock), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"leafnote/internal/model"
+	"leafnote/internal/testutil"
+)
+
+func TestYAMLReminderTimes(t *testing.T) {
+	at := func(s string) time.Time {
+		v, err := time.ParseInLocation("2006-01-02 15:04", s, time.Local)
+		require.NoError(t, err)
+		return v
+	}
+
+	tests := []struct {
+		name string
+		meta string
+		want []time.Time
+	}{
+		{name: "日期和时间", meta: "remind: 2026-11-01T09:30", want: []time.Time{at("2026-11-01 09:30")}},
+		{name: "只有日期时为 9 点", meta: "remind: 2026-11-01", want: []time.Time{at("2026-11-01 09:00")}},
+		{name: "带时区", meta: "remind: 2026-11-01T09:30:00+08:00", want: []time.Time{time.Date(2026, 11, 1, 9, 30, 0, 0, time.FixedZone("", 8*3600))}},
+		{name: "列表去重", meta: "remind:\n  - 2026-11-01 08:00\n  - 2026-11-01T08:00\n  - 2026-11-02", want: []time.Time{at("2026-11-01 08:00"), at("2026-11-02 09:00")}},
+		{name: "忽略无法识别的时间", meta: "remind: [明天, 2026-11-01 08:00]", want: []time.Time{at("2026-11-01 08:00")}},
+		{name: "没有 remind", meta: "title: 周会"},
+		{name: "YAML 不合法", meta: "remind: [2026-11-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := yamlReminderTimes(tt.meta)
+			require.Len(t, got, len(tt.want))
+			for i := range tt.want {
+				assert.True(t, tt.want[i].Equal(got[i]), "want %s, got %s", tt.want[i], got[i])
+			}
+		})
+	}
+}
+
+func TestReminderService_YAMLReminders(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	notes := NewNoteService(db, zap.NewNop())
+	s := NewReminderService(db, zap.NewNop(), ReminderOptions{})
+	ctx := context.Background()
+	next := time.Now().AddDate(1, 0, 0).Format("2006-01-02")
+
+	note, err := notes.CreateNote(CreateNoteInput{
+		Title:    "续费",
+		FilePath: "/续费.md",
+		YAMLMeta: "remind:\n  - " + next + " 08:00\n  - 2020-01-01 08:00",
+	})
+	require.NoError(t, err)
+
+	// 已经过去的时间不创建提醒
+	reminders, err := s.ListReminders(ctx, ReminderFilter{NoteID: note.ID})
+	require.NoError(t, err)
+	require.Len(t, reminders, 1)
+	first := reminders[0]
+	assert.Equal(t, model.ReminderSourceYAML, first.Source)
+	assert.Equal(t, "续费", first.NoteTitle)
+
+	// 稍后提醒后修改其他元数据，提醒保留
+	_, err = s.SnoozeReminder(ctx, first.ID, first.RemindAt.Add(time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, notes.UpdateNote(note.ID, UpdateNoteInput{YAMLMeta: "tags: [账单]\nremind: " + next + " 08:00"}))
+	got, err := s.GetReminder(ctx, first.ID)
+	require.NoError(t, err)
+	assert.True(t, got.RemindAt.Equal(first.RemindAt.Add(time.Hour)))
+
+	// 修改时间后替换为新的提醒
+	require.NoError(t, notes.UpdateNote(note.ID, UpdateNoteInput{YAMLMeta: "remind: " + next + " 10:00"}))
+	reminders, err = s.ListReminders(ctx, ReminderFilter{NoteID: note.ID})
+	require.NoError(t, err)
+	require.Len(t, reminders, 1)
+	assert.NotEqual(t, first.ID, reminders[0].ID)
+	assert.Equal(t, 10, reminders[0].RemindAt.Hour())
+
+	assert.ErrorIs(t, s.DeleteReminder(ctx, reminders[0].ID), ErrReminderFromYAML)
+}
+
+func TestReminderService_FireDue(t *testing.T) {
+	received := make(chan ReminderNotification, 4)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n ReminderNotification
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&n))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		received <- n
+	}))
+	defer webhook.Close()
+
+	db := testutil.NewTestDB(t)
+	s := NewReminderService(db, zap.NewNop(), ReminderOptions{WebhookURL: webhook.URL, WebhookTimeout: time.Second})
+	ctx := context.Background()
+	note, err := NewNoteService(db, zap.NewNop()).CreateNote(CreateNoteInput{Title: "周报", FilePath: "/周报.md"})
+	require.NoError(t, err)
+
+	now := time.Now()
+	due, err := s.CreateReminder(ctx, CreateReminderInput{NoteID: note.ID, RemindAt: now.Add(-time.Minute), Message: " 写周报 "})
+	require.NoError(t, err)
+	assert.Equal(t, "写周报", due.Message)
+	later, err := s.CreateReminder(ctx, CreateReminderInput{NoteID: note.ID, RemindAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+	dismissed, err := s.CreateReminder(ctx, CreateReminderInput{NoteID: note.ID, RemindAt: now.Add(-time.Minute)})
+	require.NoError(t, err)
+	_, err = s.DismissReminder(ctx, dismissed.ID)
+	require.NoError(t, err)
+
+	notifications, unsubscribe := s.Subscribe()
+	defer unsubscribe()
+
+	fired, err := s.FireDue(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, fired)
+
+	select {
+	case n := <-notifications:
+		assert.Equal(t, "reminder.fired", n.Event)
+		assert.Equal(t, due.ID, n.Reminder.ID)
+		assert.Equal(t, "周报", n.Reminder.NoteTitle)
+	default:
+		t.Fatal("订阅者没有收到提醒")
+	}
+	n := <-received
+	assert.Equal(t, due.ID, n.Reminder.ID)
+	assert.Equal(t, model.ReminderFired, n.Reminder.Status)
+
+	// 已触发的提醒不会重复触发
+	fired, err = s.FireDue(ctx, now)
+	require.NoError(t, err)
+	assert.Zero(t, fired)
+
+	t.Run("稍后提醒", func(t *testing.T) {
+		_, err := s.SnoozeReminder(ctx, due.ID, now.Add(-time.Second))
+		assert.ErrorIs(t, err, ErrReminderTimeInvalid)
+
+		snoozed, err := s.SnoozeReminder(ctx, due.ID, now.Add(10*time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, model.ReminderPending, snoozed.Status)
+		fired, err := s.FireDue(ctx, now.Add(11*time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, 1, fired)
+		assert.Equal(t, due.ID, (<-notifications).Reminder.ID)
+		assert.Equal(t, due.ID, (<-received).Reminder.ID)
+	})
+
+	t.Run("删除", func(t *testing.T) {
+		require.NoError(t, s.DeleteReminder(ctx, later.ID))
+		_, err := s.GetReminder(ctx, later.ID)
+		assert.ErrorIs(t, err, ErrReminderNotFound)
+	})
+
+	t.Run("关闭服务后取消订阅", func(t *testing.T) {
+		s.Close()
+		_, ok := <-notifications
+		assert.False(t, ok)
+		closed, cancel := s.Subscribe()
+		defer cancel()
+		_, ok = <-closed
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// highlightStyle 代码高亮使用的 Chroma 样式
+const highlightStyle = "github"
+
+// WikiLinkResolver 将 [[目标]] 中的笔记名解析为链接地址，找不到笔记时返回 false
+type WikiLinkResolver func(target string) (string, bool)
+
+// wikiResolverKey 在解析上下文中保存 WikiLinkResolver
+var wikiResolverKey = parser.NewContextKey()
+
+// markdown 渲染笔记使用的 Markdown 引擎：GFM（表格、任务列表、删除线、自动链接）、
+// 代码高亮（输出 CSS 类名，样式见 HighlightCSS）和 [[双链]]；原始 HTML 不会输出
+var markdown = goldmark.New(
+	goldmark.WithExtensions(
+		extension.GFM,
+		highlighting.NewHighlighting(
+			highlighting.WithStyle(highlightStyle),
+			highlighting.WithFormatOptions(chromahtml.WithClasses(true)),
+		),
+	),
+	goldmark.WithParserOptions(
+		parser.WithAutoHeadingID(),
+		parser.WithInlineParsers(util.Prioritized(wikiLinkParser{}, 199)),
+	),
+	goldmark.WithRendererOptions(
+		renderer.WithNodeRenderers(util.Prioritized(wikiLinkRenderer{}, 500)),
+	),
+)
+
+// RenderMarkdown 将笔记正文渲染为 HTML 片段，resolve 用于解析 [[双链]]，为空时双链都视为找不到；
+// 正文先经过已启用插件的 Markdown 转换
+func RenderMarkdown(content string, resolve WikiLinkResolver) (string, error) {
+	content, err := transformMarkdown(content)
+	if err != nil {
+		return "", err
+	}
+	source := []byte(content)
+	var buf bytes.Buffer
+	if err := markdown.Renderer().Render(&buf, source, parseNoteMarkdown(source, resolve)); err != nil {
+		return "", fmt.Errorf("渲染 Markdown 失败: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// parseNoteMarkdown 将笔记正文解析为语法树，resolve 用于解析 [[双链]]，可以为空
+func parseNoteMarkdown(source []byte, resolve WikiLinkResolver) ast.Node {
+	pc := parser.NewContext(parser.WithIDs(&headingIDs{used: make(map[string]bool)}))
+	if resolve != nil {
+		pc.Set(wikiResolverKey, resolve)
+	}
+	return markdown.Parser().Parse(text.NewReader(source), parser.WithContext(pc))
+}
+
+// HighlightCSS 返回代码高亮的样式表
+func HighlightCSS() string {
+	var buf bytes.Buffer
+	// 内置样式不会出错
+	_ = chromahtml.New(chromahtml.WithClasses(true)).WriteCSS(&buf, styles.Get(highlightStyle))
+	return buf.String()
+}
+
+// headingAnchor 根据标题文本生成锚点：保留字母和数字（包括中文），空白转换为 -
+func headingAnchor(title string) string {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(title) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(unicode.ToLower(r))
+		case unicode.IsSpace(r) || r == '-' || r == '_':
+			b.WriteByte('-')
+		}
+	}
+	if b.Len() == 0 {
+		return "heading"
+	}
+	return b.String()
+}
+
+// headingIDs 生成标题锚点，与 [[笔记#标题]] 使用相同的规则，重复时追加序号
+type headingIDs struct {
+	used map[string]bool
+}
+
+func (s *headingIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	id := headingAnchor(string(value))
+	for i := 1; s.used[id]; i++ {
+		id = fmt.Sprintf("%s-%d", headingAnchor(string(value)), i)
+	}
+	s.used[id] = true
+	return []byte(id)
+}
+
+func (s *headingIDs) Put(value []byte) {
+	s.used[string(value)] = true
+}
+
+// kindWikiLink [[双链]] 节点类型
+var kindWikiLink = ast.NewNodeKind("WikiLink")
+
+// wikiLink [[笔记名#标题|显示文本]] 形式的双链
+type wikiLink struct {
+	ast.BaseInline
+	Label       string // 显示文本
+	Destination string // 链接地址，为空表示找不到笔记
+}
+
+func (n *wikiLink) Kind() ast.NodeKind { return kindWikiLink }
+
+func (n *wikiLink) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Label": n.Label, "Destination": n.Destination}, nil)
+}
+
+// wikiLinkParser 解析 [[双链]]，优先级高于普通链接
+type wikiLinkParser struct{}
+
+func (wikiLinkParser) Trigger() []byte { return []byte{'['} }
+
+func (wikiLinkParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, _ := block.PeekLine()
+	if !bytes.HasPrefix(line, []byte("[[")) {
+		return nil
+	}
+	end := bytes.Index(line[2:], []byte("]]"))
+	if end <= 0 {
+		return nil
+	}
+	inner := string(line[2 : 2+end])
+	if strings.ContainsAny(inner, "[]\n") {
+		return nil
+	}
+	block.Advance(end + 4)
+
+	target, label, hasLabel := strings.Cut(inner, "|")
+	name, heading, _ := strings.Cut(strings.TrimSpace(target), "#")
+	name = strings.TrimSpace(name)
+	if !hasLabel {
+		label = strings.TrimPrefix(strings.TrimSpace(target), "#")
+	}
+	node := &wikiLink{Label: strings.TrimSpace(label)}
+
+	anchor := ""
+	if heading = strings.TrimSpace(heading); heading != "" {
+		anchor = "#" + headingAnchor(heading)
+	}
+	if name == "" {
+		// [[#标题]] 指向当前笔记
+		node.Destination = anchor
+	} else if resolve, ok := pc.Get(wikiResolverKey).(WikiLinkResolver); ok {
+		if dest, found := resolve(name); found {
+			node.Destination = dest + anchor
+		}
+	}
+	return node
+}
+
+// wikiLinkRenderer 输出双链，找不到笔记时输出带 wikilink-missing 类的文本
+type wikiLinkRenderer struct{}
+
+func (wikiLinkRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindWikiLink, func(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		n := node.(*wikiLink)
+		if n.Destination == "" {
+			_, _ = w.WriteString(`<span class="wikilink wikilink-missing">`)
+		} else {
+			_, _ = w.WriteString(`<a class="wikilink" href="`)
+			_, _ = w.Write(util.EscapeHTML(util.URLEscape([]byte(n.Destination), true)))
+			_, _ = w.WriteString(`">`)
+		}
+		_, _ = w.Write(util.EscapeHTML([]byte(n.Label)))
+		if n.Destination == "" {
+			_, _ = w.WriteString(`</span>`)
+		} else {
+			_, _ = w.WriteString(`</a>`)
+		}
+		return ast.WalkSkipChildren, nil
+	})
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	resolve := func(target string) (string, bool) {
+		if target == "计划" {
+			return "工作/计划.html", true
+		}
+		return "", false
+	}
+	tests := []struct {
+		name     string
+		content  string
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "GFM 表格和任务列表",
+			content:  "| a | b |\n|---|---|\n| 1 | 2 |\n\n- [x] 完成\n\n~~删除~~",
+			contains: []string{"<table>", "<td>1</td>", `<input checked="" disabled="" type="checkbox">`, "<del>删除</del>"},
+		},
+		{
+			name:     "代码高亮",
+			content:  "```go\nfunc main() {}\n```\n",
+			contains: []string{`<pre class="chroma">`, `<span class="kd">func</span>`},
+		},
+		{
+			name:     "中文标题锚点",
+			content:  "# 第一 章\n\n# 第一 章\n",
+			contains: []string{`<h1 id="第一-章">`, `<h1 id="第一-章-1">`},
+		},
+		{
+			name:     "双链",
+			content:  "[[计划]] [[计划#第二 节|看这里]] [[#本页 标题]]",
+			contains: []string{`<a class="wikilink" href="%E5%B7%A5%E4%BD%9C/%E8%AE%A1%E5%88%92.html">计划</a>`, `#%E7%AC%AC%E4%BA%8C-%E8%8A%82">看这里</a>`, `href="#%E6%9C%AC%E9%A1%B5-%E6%A0%87%E9%A2%98">本页 标题</a>`},
+		},
+		{
+			name:     "找不到的双链",
+			content:  "[[不存在]]",
+			contains: []string{`<span class="wikilink wikilink-missing">不存在</span>`},
+		},
+		{
+			name:     "代码中的双链不解析",
+			content:  "`[[计划]]`",
+			contains: []string{"<code>[[计划]]</code>"},
+			excludes: []string{"wikilink"},
+		},
+		{
+			name:     "普通链接不受影响",
+			content:  "[文档](https://example.com)",
+			contains: []string{`<a href="https://example.com">文档</a>`},
+		},
+		{
+			name:     "原始 HTML 不输出",
+			content:  "<script>alert(1)</script>\n\n文本 <b onclick=\"x\">粗</b>",
+			excludes: []string{"<script>", "onclick"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderMarkdown(tt.content, resolve)
+			require.NoError(t, err)
+			for _, s := range tt.contains {
+				assert.Contains(t, got, s)
+			}
+			for _, s := range tt.excludes {
+				assert.NotContains(t, got, s)
+			}
+		})
+	}
+}
@@ -0,0 +1,520 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"leafnote/internal/model"
+)
+
+// siteTagDir 静态站点中标签页所在的目录
+const siteTagDir = "_tags"
+
+// siteCSS 页面的基础样式，代码高亮样式由 HighlightCSS 追加
+const siteCSS = `body{margin:0;font:16px/1.7 -apple-system,BlinkMacSystemFont,"Segoe UI","PingFang SC","Microsoft YaHei",sans-serif;color:#24292f;background:#fff}
+nav{padding:12px 24px;border-bottom:1px solid #d0d7de}
+nav a{margin-right:16px;color:#0969da;text-decoration:none}
+main{max-width:860px;margin:0 auto;padding:24px}
+a{color:#0969da}
+h1,h2,h3{line-height:1.3}
+.meta{color:#57606a;font-size:14px}
+.tag{margin-left:8px}
+.wikilink-missing{color:#cf222e;border-bottom:1px dashed #cf222e}
+table{border-collapse:collapse}
+th,td{border:1px solid #d0d7de;padding:6px 12px}
+pre{padding:12px;overflow:auto;background:#f6f8fa;border-radius:6px}
+code{font-family:ui-monospace,SFMono-Regular,Menlo,Consolas,monospace;font-size:90%}
+blockquote{margin:0;padding:0 16px;color:#57606a;border-left:4px solid #d0d7de}
+img{max-width:100%}
+li.task{list-style:none}
+`
+
+// sitePage 页面布局的数据
+type sitePage struct {
+	Title string
+	Root  string       // 站点根目录的相对路径前缀，例如 ../
+	CSS   template.CSS // 内联样式，为空时引用站点根目录的 style.css
+	Nav   bool         // 是否显示站点导航
+	Body  template.HTML
+}
+
+// siteLink 页面中的链接，Href 为空时只显示文本
+type siteLink struct {
+	Name  string
+	Href  string
+	Count int // 标签下的笔记数，列表中大于 0 时显示
+}
+
+// siteSection 列表页中的一组链接
+type siteSection struct {
+	Heading string
+	Links   []siteLink
+}
+
+// siteNoteView 笔记页的数据
+type siteNoteView struct {
+	Title   string
+	Updated time.Time
+	Tags    []siteLink
+	HTML    template.HTML
+}
+
+var siteTemplates = template.Must(template.New("layout").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+{{if .CSS}}<style>{{.CSS}}</style>{{else}}<link rel="stylesheet" href="{{.Root}}style.css">{{end}}
+</head>
+<body>
+{{if .Nav}}<nav><a href="{{.Root}}index.html">首页</a><a href="{{.Root}}_tags/index.html">标签</a></nav>
+{{end}}<main>
+{{.Body}}
+</main>
+</body>
+</html>
+{{define "note"}}<article>
+<h1>{{.Title}}</h1>
+<p class="meta">更新于 <time datetime="{{.Updated.Format "2006-01-02T15:04:05Z07:00"}}">{{.Updated.Format "2006-01-02 15:04"}}</time>{{range .Tags}}{{if .Href}} <a class="tag" href="{{.Href}}">#{{.Name}}</a>{{else}} <span class="tag">#{{.Name}}</span>{{end}}{{end}}</p>
+{{.HTML}}
+</article>{{end}}
+{{define "list"}}<h1>{{.Title}}</h1>
+{{range .Sections}}<h2>{{.Heading}}</h2>
+<ul>
+{{range .Links}}<li><a href="{{.Href}}">{{.Name}}</a>{{if .Count}} <span class="meta">({{.Count}})</span>{{end}}</li>
+{{end}}</ul>
+{{else}}<p class="meta">暂无内容</p>
+{{end}}{{end}}`))
+
+// renderSitePage 渲染页面内容并套用布局
+func renderSitePage(page sitePage, content string, data interface{}) ([]byte, error) {
+	var body bytes.Buffer
+	if err := siteTemplates.ExecuteTemplate(&body, content, data); err != nil {
+		return nil, err
+	}
+	page.Body = template.HTML(body.String())
+	var buf bytes.Buffer
+	if err := siteTemplates.Execute(&buf, page); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// wikiIndex 双链目标到笔记ID的索引，键不区分大小写；
+// 按路径、文件名、标题的顺序加入，同名时先加入的优先
+type wikiIndex map[string]string
+
+// newWikiIndex 为笔记建立双链索引，names 为笔记ID到导出路径的映射，可以为空
+func newWikiIndex(notes []model.Note, names map[string]string) wikiIndex {
+	idx := make(wikiIndex)
+	for _, note := range notes {
+		idx.add(cleanExportPath(note.FilePath), note.ID)
+		if name, ok := names[note.ID]; ok {
+			idx.add(name, note.ID)
+		}
+	}
+	for _, note := range notes {
+		idx.add(path.Base(cleanExportPath(note.FilePath)), note.ID)
+	}
+	for _, note := range notes {
+		idx.add(note.Title, note.ID)
+	}
+	return idx
+}
+
+func (idx wikiIndex) add(key, id string) {
+	if key = wikiKey(key); key != "" {
+		if _, ok := idx[key]; !ok {
+			idx[key] = id
+		}
+	}
+}
+
+// lookup 查找双链目标对应的笔记ID
+func (idx wikiIndex) lookup(target string) (string, bool) {
+	id, ok := idx[wikiKey(target)]
+	return id, ok
+}
+
+// wikiKey 统一双链目标的写法：去除首尾的 / 和扩展名，转为小写
+func wikiKey(key string) string {
+	key = strings.Trim(strings.TrimSpace(key), "/")
+	if ext := path.Ext(key); strings.EqualFold(ext, ".md") || strings.EqualFold(ext, ".html") {
+		key = strings.TrimSuffix(key, ext)
+	}
+	return strings.ToLower(key)
+}
+
+// RenderNotePage 将笔记渲染为独立的 HTML 页面，样式内联；
+// 双链指向其他笔记的页面（相对于 /notes/:id/html），回收站中的笔记不参与双链解析
+func (s *ExportService) RenderNotePage(ctx context.Context, id string) ([]byte, error) {
+	db := s.db.WithContext(ctx)
+	var note model.Note
+	if err := db.Preload("Tags").First(&note, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNoteNotFound
+		}
+		return nil, err
+	}
+	var notes []model.Note
+	if err := db.Select("id", "title", "file_path").Order("file_path").Find(&notes).Error; err != nil {
+		return nil, err
+	}
+	tagNames, err := s.tagNames()
+	if err != nil {
+		return nil, err
+	}
+
+	idx := newWikiIndex(notes, nil)
+	html, err := RenderMarkdown(note.Content, func(target string) (string, bool) {
+		if id, ok := idx.lookup(target); ok {
+			return "../" + url.PathEscape(id) + "/html", true
+		}
+		return "", false
+	})
+	if err != nil {
+		return nil, err
+	}
+	view := siteNoteView{Title: note.Title, Updated: note.UpdatedAt, HTML: template.HTML(html)}
+	for _, tag := range note.Tags {
+		view.Tags = append(view.Tags, siteLink{Name: tagNames[tag.ID]})
+	}
+	return renderSitePage(sitePage{Title: note.Title, CSS: template.CSS(siteCSS + HighlightCSS())}, "note", view)
+}
+
+// ExportSiteZip 将笔记导出为静态站点并打包为 zip 写入 w
+func (s *ExportService) ExportSiteZip(ctx context.Context, w io.Writer, filter ExportFilter) (*ExportResult, error) {
+	query, root, err := s.siteQuery(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	zw := zip.NewWriter(w)
+	result, err := s.exportSite(query, root, zipTarget{zw})
+	if err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ExportSiteDir 将笔记导出为静态站点写入目录 dir，目录必须不存在或为空
+func (s *ExportService) ExportSiteDir(ctx context.Context, dir string, filter ExportFilter) (*ExportResult, error) {
+	query, root, err := s.siteQuery(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if err := prepareExportDir(dir); err != nil {
+		return nil, err
+	}
+
+	result, err := s.exportSite(query, root, dirTarget(dir))
+	if err != nil {
+		return nil, err
+	}
+	result.Dir = filepath.Base(dir)
+	return result, nil
+}
+
+// siteQuery 校验筛选条件，返回待导出笔记的查询和站点根目录对应的目录（未按目录筛选时为空）
+func (s *ExportService) siteQuery(ctx context.Context, filter ExportFilter) (*gorm.DB, *model.Category, error) {
+	query, err := s.filterQuery(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	if filter.CategoryID == nil || *filter.CategoryID == "" {
+		return query, nil, nil
+	}
+	var root model.Category
+	if err := s.db.WithContext(ctx).First(&root, "id = ?", *filter.CategoryID).Error; err != nil {
+		return nil, nil, err
+	}
+	return query, &root, nil
+}
+
+// siteBuilder 生成静态站点的页面
+type siteBuilder struct {
+	target   exportTarget
+	tagNames map[string]string
+	names    map[string]string // 笔记ID到页面路径
+	wiki     wikiIndex
+	dirs     map[string]*siteDir
+	tags     map[string]*siteTag // 标签ID到标签页
+}
+
+// siteDir 站点中的一个目录，生成 index.html
+type siteDir struct {
+	dirs  []string
+	notes []siteLink // Href 为页面路径，生成时转换为相对路径
+}
+
+// siteTag 标签页
+type siteTag struct {
+	name  string
+	page  string
+	notes []siteLink
+}
+
+// exportSite 先加载笔记的路径和标签生成索引，再分批渲染笔记页，最后生成目录页、标签页和样式表；
+// root 不为空时站点根目录对应该目录，页面路径相对于该目录
+func (s *ExportService) exportSite(query *gorm.DB, root *model.Category, target exportTarget) (*ExportResult, error) {
+	tagNames, err := s.tagNames()
+	if err != nil {
+		return nil, err
+	}
+
+	var notes []model.Note
+	if err := query.Session(&gorm.Session{}).Omit("content", "yaml_meta").Order("file_path").Find(&notes).Error; err != nil {
+		return nil, err
+	}
+	b := &siteBuilder{
+		target:   target,
+		tagNames: tagNames,
+		names:    make(map[string]string, len(notes)),
+		dirs:     map[string]*siteDir{".": {}},
+		tags:     make(map[string]*siteTag),
+	}
+	b.assignPages(notes, root)
+	b.wiki = newWikiIndex(notes, b.names)
+
+	result := &ExportResult{}
+	var batch []model.Note
+	err = query.FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, n int) error {
+		for i := range batch {
+			if err := b.writeNote(&batch[i]); err != nil {
+				return err
+			}
+			result.Notes++
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	title := "笔记"
+	if root != nil {
+		title = root.Name
+	}
+	if err := b.writeIndexes(title); err != nil {
+		return nil, err
+	}
+	if err := target.writeFile("style.css", time.Now(), []byte(siteCSS+HighlightCSS())); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// assignPages 为每篇笔记分配页面路径，并登记所在目录和标签；
+// 路径与 Markdown 导出一致，扩展名改为 .html，与目录页 index.html 重名时追加 ID
+func (b *siteBuilder) assignPages(notes []model.Note, root *model.Category) {
+	prefix := ""
+	if root != nil {
+		prefix = cleanExportPath(root.Path) + "/"
+	}
+	used := make(map[string]bool)
+	for i := range notes {
+		note := &notes[i]
+		name := strings.TrimPrefix(exportFileName(note, used), prefix)
+		name = strings.TrimSuffix(name, path.Ext(name)) + ".html"
+		if strings.EqualFold(path.Base(name), "index.html") {
+			name = fmt.Sprintf("%s-%.8s.html", strings.TrimSuffix(name, ".html"), note.ID)
+		}
+		b.names[note.ID] = name
+
+		dir := b.addDir(path.Dir(name))
+		dir.notes = append(dir.notes, siteLink{Name: note.Title, Href: name})
+		for _, tag := range note.Tags {
+			t := b.addTag(tag.ID)
+			t.notes = append(t.notes, siteLink{Name: note.Title, Href: name})
+		}
+	}
+}
+
+// addDir 登记目录及其所有上级目录
+func (b *siteBuilder) addDir(dir string) *siteDir {
+	if d, ok := b.dirs[dir]; ok {
+		return d
+	}
+	d := &siteDir{}
+	b.dirs[dir] = d
+	parent := b.addDir(path.Dir(dir))
+	parent.dirs = append(parent.dirs, dir)
+	return d
+}
+
+// addTag 登记标签页，标签名按段转换为合法的文件名，重名时追加 ID
+func (b *siteBuilder) addTag(id string) *siteTag {
+	if t, ok := b.tags[id]; ok {
+		return t
+	}
+	name := b.tagNames[id]
+	var segments []string
+	for _, segment := range strings.Split(name, "/") {
+		segments = append(segments, safeFileName(segment))
+	}
+	dir := siteTagDir + "/" + strings.Join(segments, "/")
+	for _, t := range b.tags {
+		if strings.EqualFold(t.page, dir+"/index.html") {
+			dir = fmt.Sprintf("%s-%.8s", dir, id)
+			break
+		}
+	}
+	t := &siteTag{name: name, page: dir + "/index.html"}
+	b.tags[id] = t
+	return t
+}
+
+// writeNote 渲染并写入笔记页
+func (b *siteBuilder) writeNote(note *model.Note) error {
+	name := b.names[note.ID]
+	html, err := RenderMarkdown(note.Content, func(target string) (string, bool) {
+		id, ok := b.wiki.lookup(target)
+		if !ok {
+			return "", false
+		}
+		return relativeURL(name, b.names[id]), true
+	})
+	if err != nil {
+		return err
+	}
+
+	view := siteNoteView{Title: note.Title, Updated: note.UpdatedAt, HTML: template.HTML(html)}
+	for _, tag := range note.Tags {
+		view.Tags = append(view.Tags, siteLink{Name: b.tagNames[tag.ID], Href: relativeURL(name, b.tags[tag.ID].page)})
+	}
+	data, err := renderSitePage(b.page(name, note.Title), "note", view)
+	if err != nil {
+		return err
+	}
+	if err := b.target.writeFile(name, note.UpdatedAt, data); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", name, err)
+	}
+	return nil
+}
+
+// writeIndexes 写入每个目录的 index.html、标签列表和每个标签的笔记列表
+func (b *siteBuilder) writeIndexes(title string) error {
+	now := time.Now()
+	dirNames := make([]string, 0, len(b.dirs))
+	for dir := range b.dirs {
+		dirNames = append(dirNames, dir)
+	}
+	sort.Strings(dirNames)
+	for _, dir := range dirNames {
+		d := b.dirs[dir]
+		name := "index.html"
+		if dir != "." {
+			name = dir + "/index.html"
+		}
+		dirTitle := title
+		if dir != "." {
+			dirTitle = path.Base(dir)
+		}
+		var dirs []siteLink
+		for _, sub := range d.dirs {
+			dirs = append(dirs, siteLink{Name: path.Base(sub), Href: relativeURL(name, sub+"/index.html")})
+		}
+		if err := b.writeList(name, dirTitle, now,
+			siteSection{Heading: "目录", Links: dirs},
+			siteSection{Heading: "笔记", Links: b.relativeLinks(name, d.notes)},
+		); err != nil {
+			return err
+		}
+	}
+
+	tagIndex := siteTagDir + "/index.html"
+	tagIDs := make([]string, 0, len(b.tags))
+	for id := range b.tags {
+		tagIDs = append(tagIDs, id)
+	}
+	sort.Strings(tagIDs)
+	var tags []siteLink
+	for _, id := range tagIDs {
+		t := b.tags[id]
+		tags = append(tags, siteLink{Name: t.name, Href: relativeURL(tagIndex, t.page), Count: len(t.notes)})
+		if err := b.writeList(t.page, "#"+t.name, now, siteSection{Heading: "笔记", Links: b.relativeLinks(t.page, t.notes)}); err != nil {
+			return err
+		}
+	}
+	return b.writeList(tagIndex, "标签", now, siteSection{Heading: "全部标签", Links: tags})
+}
+
+// writeList 写入列表页，链接按名称排序，空的分组不显示
+func (b *siteBuilder) writeList(name, title string, modTime time.Time, sections ...siteSection) error {
+	var nonEmpty []siteSection
+	for _, section := range sections {
+		if len(section.Links) == 0 {
+			continue
+		}
+		sort.Slice(section.Links, func(i, j int) bool { return section.Links[i].Name < section.Links[j].Name })
+		nonEmpty = append(nonEmpty, section)
+	}
+	data, err := renderSitePage(b.page(name, title), "list", struct {
+		Title    string
+		Sections []siteSection
+	}{title, nonEmpty})
+	if err != nil {
+		return err
+	}
+	if err := b.target.writeFile(name, modTime, data); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", name, err)
+	}
+	return nil
+}
+
+// relativeLinks 将链接中的页面路径转换为相对于 from 的 URL
+func (b *siteBuilder) relativeLinks(from string, links []siteLink) []siteLink {
+	out := make([]siteLink, len(links))
+	for i, link := range links {
+		out[i] = siteLink{Name: link.Name, Href: relativeURL(from, link.Href), Count: link.Count}
+	}
+	return out
+}
+
+// page 返回页面 name 的布局数据
+func (b *siteBuilder) page(name, title string) sitePage {
+	depth := strings.Count(name, "/")
+	return sitePage{Title: title, Root: strings.Repeat("../", depth), Nav: true}
+}
+
+// relativeURL 返回站点内从页面 from 指向页面 to 的相对 URL，路径各段已转义；
+// 以 ./ 开头，避免含 : 的文件名被当作 URL 协议
+func relativeURL(from, to string) string {
+	var fromDir []string
+	if dir := path.Dir(from); dir != "." {
+		fromDir = strings.Split(dir, "/")
+	}
+	toParts := strings.Split(to, "/")
+	i := 0
+	for i < len(fromDir) && i < len(toParts)-1 && fromDir[i] == toParts[i] {
+		i++
+	}
+	var parts []string
+	for range fromDir[i:] {
+		parts = append(parts, "..")
+	}
+	if len(parts) == 0 {
+		parts = append(parts, ".")
+	}
+	for _, p := range toParts[i:] {
+		parts = append(parts, url.PathEscape(p))
+	}
+	return strings.Join(parts, "/")
+}
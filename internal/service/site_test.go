@@ -0,0 +1,130 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"leafnote/internal/testutil"
+)
+
+func TestExportService_ExportSiteZip(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	f := setupExportTest(t, db)
+	s := NewExportService(db, zap.NewNop())
+	_, err := NewNoteService(db, zap.NewNop()).CreateNote(CreateNoteInput{
+		Title:      "概览",
+		Content:    "见 [[计划]] 和 [[说明]]\n\n```go\nfunc main() {}\n```\n",
+		FilePath:   "/工作/概览.md",
+		CategoryID: &f.work.ID,
+	})
+	require.NoError(t, err)
+
+	// 只导出 /工作 及其子目录，站点根目录对应 /工作
+	var buf bytes.Buffer
+	result, err := s.ExportSiteZip(context.Background(), &buf, ExportFilter{CategoryID: &f.work.ID})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Notes)
+
+	files := readZip(t, buf.Bytes())
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{
+		"_tags/index.html",
+		"_tags/语言/Go/index.html",
+		"index.html",
+		"style.css",
+		"概览.html",
+		"项目/index.html",
+		"项目/计划.html",
+	}, names)
+
+	page := files["概览.html"]
+	assert.Contains(t, page, "<title>概览</title>")
+	assert.Contains(t, page, `<link rel="stylesheet" href="style.css">`)
+	assert.Contains(t, page, `<a class="wikilink" href="./%E9%A1%B9%E7%9B%AE/%E8%AE%A1%E5%88%92.html">计划</a>`)
+	// 说明不在导出范围内
+	assert.Contains(t, page, `<span class="wikilink wikilink-missing">说明</span>`)
+	assert.Contains(t, page, `<pre class="chroma">`)
+
+	plan := files["项目/计划.html"]
+	assert.Contains(t, plan, `<link rel="stylesheet" href="../style.css">`)
+	assert.Contains(t, plan, `<a class="tag" href="../_tags/%E8%AF%AD%E8%A8%80/Go/index.html">#语言/Go</a>`)
+
+	index := files["index.html"]
+	assert.Contains(t, index, "<h1>工作</h1>")
+	assert.Contains(t, index, `<a href="./%E9%A1%B9%E7%9B%AE/index.html">项目</a>`)
+	assert.Contains(t, index, `<a href="./%E6%A6%82%E8%A7%88.html">概览</a>`)
+	assert.Contains(t, files["_tags/语言/Go/index.html"], `<a href="../../../%E9%A1%B9%E7%9B%AE/%E8%AE%A1%E5%88%92.html">计划</a>`)
+	assert.Contains(t, files["_tags/index.html"], `<a href="./%E8%AF%AD%E8%A8%80/Go/index.html">语言/Go</a>`)
+	assert.Contains(t, files["style.css"], ".chroma")
+}
+
+func TestExportService_ExportSiteDir(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	setupExportTest(t, db)
+	s := NewExportService(db, zap.NewNop())
+	// 与目录页重名的笔记追加 ID
+	index, err := NewNoteService(db, zap.NewNop()).CreateNote(CreateNoteInput{Title: "首页", FilePath: "/index.md"})
+	require.NoError(t, err)
+	dir := filepath.Join(t.TempDir(), "site")
+
+	result, err := s.ExportSiteDir(context.Background(), dir, ExportFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Notes)
+	assert.FileExists(t, filepath.Join(dir, "工作", "项目", "计划.html"))
+	assert.FileExists(t, filepath.Join(dir, "工作", "index.html"))
+	assert.FileExists(t, filepath.Join(dir, "index-"+index.ID[:8]+".html"))
+	data, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "<h1>笔记</h1>")
+
+	_, err = s.ExportSiteDir(context.Background(), dir, ExportFilter{})
+	assert.ErrorIs(t, err, ErrExportDirExists)
+}
+
+func TestExportService_RenderNotePage(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	f := setupExportTest(t, db)
+	s := NewExportService(db, zap.NewNop())
+	require.NoError(t, db.Model(f.readme).Update("content", "[[计划]]").Error)
+
+	page, err := s.RenderNotePage(context.Background(), f.readme.ID)
+	require.NoError(t, err)
+	assert.Contains(t, string(page), `<a class="wikilink" href="../`+f.plan.ID+`/html">计划</a>`)
+	assert.Contains(t, string(page), "<style>")
+
+	_, err = s.RenderNotePage(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrNoteNotFound)
+}
+
+func TestRelativeURL(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want string
+	}{
+		{name: "同一目录", from: "a.html", to: "b.html", want: "./b.html"},
+		{name: "子目录", from: "index.html", to: "工作/计划.html", want: "./%E5%B7%A5%E4%BD%9C/%E8%AE%A1%E5%88%92.html"},
+		{name: "上级目录", from: "a/b/c.html", to: "d.html", want: "../../d.html"},
+		{name: "兄弟目录", from: "a/b/c.html", to: "a/x/y.html", want: "../x/y.html"},
+		{name: "特殊字符", from: "a.html", to: "x:y #1?.html", want: "./x:y%20%231%3F.html"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, relativeURL(tt.from, tt.to))
+		})
+	}
+}
@@ -0,0 +1,495 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"leafnote/internal/model"
+)
+
+// syncPruneInterval 清理过期修改记录的间隔
+const syncPruneInterval = time.Hour
+
+// 上传修改的处理结果
+const (
+	SyncStatusApplied  = "applied"  // 已应用，包括此前已应用的重复修改
+	SyncStatusConflict = "conflict" // 修改所基于的版本不是服务端的当前版本，未应用
+	SyncStatusRejected = "rejected" // 校验失败等原因被拒绝，未应用
+)
+
+// SyncOptions 同步配置
+type SyncOptions struct {
+	ChangeRetention time.Duration // 已应用修改的记录保留时长，超过后重复上传会被再次执行；0 表示永久保留
+}
+
+// SyncService 客户端增量同步：应用客户端离线期间的笔记修改，并按变更事件返回其他客户端的修改
+type SyncService struct {
+	db     *gorm.DB
+	logger *zap.Logger
+	events *EventService
+	opts   SyncOptions
+}
+
+// NewSyncService 创建同步服务实例，远端修改的位置与变更事件的序号一致
+func NewSyncService(db *gorm.DB, logger *zap.Logger, events *EventService, opts SyncOptions) *SyncService {
+	return &SyncService{
+		db:     db,
+		logger: logger,
+		events: events,
+		opts:   opts,
+	}
+}
+
+// SyncChangeInput 客户端上传的一条笔记修改；create 时 NoteID 为客户端生成的 UUID，
+// update 和 delete 时 BaseVersion 为修改所基于的版本号
+type SyncChangeInput struct {
+	ChangeID    string
+	Type        string
+	NoteID      string
+	BaseVersion int
+	Title       string
+	Content     string
+	YAMLMeta    string
+	FilePath    string
+	CategoryID  *string
+	TagIDs      []string
+}
+
+// SyncInput 同步的输入参数
+type SyncInput struct {
+	ClientID string            // 客户端标识，不为空时远端修改中不包括该客户端上传的修改
+	Cursor   *uint64           // 上次同步返回的位置，为空时返回全部数据
+	Changes  []SyncChangeInput // 按发生顺序排列的本地修改
+	Limit    int               // 最多读取的变更事件数量
+}
+
+// SyncChangeResult 一条上传修改的处理结果
+type SyncChangeResult struct {
+	ChangeID  string    `json:"change_id"`
+	NoteID    string    `json:"note_id"`
+	Status    string    `json:"status"`
+	Duplicate bool      `json:"duplicate,omitempty"` // 此前已应用，本次未重复执行
+	Version   int       `json:"version,omitempty"`   // 应用后笔记的版本号，删除时为删除前的版本号
+	Server    *SyncNote `json:"server,omitempty"`    // 冲突时服务端的当前状态，已删除时为墓碑
+	Err       error     `json:"-"`                   // 被拒绝的原因
+}
+
+// SyncNote 同步返回的笔记，Deleted 为 true 时为墓碑，只有 ID 有效
+type SyncNote struct {
+	ID         string     `json:"id"`
+	Deleted    bool       `json:"deleted"`
+	Title      string     `json:"title,omitempty"`
+	Content    string     `json:"content,omitempty"`
+	YAMLMeta   string     `json:"yaml_meta,omitempty"`
+	FilePath   string     `json:"file_path,omitempty"`
+	CategoryID *string    `json:"category_id,omitempty"`
+	TagIDs     []string   `json:"tag_ids,omitempty"`
+	Version    int        `json:"version,omitempty"`
+	Checksum   string     `json:"checksum,omitempty"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
+
+// SyncTag 同步返回的标签，Deleted 为 true 时为墓碑
+type SyncTag struct {
+	ID        string     `json:"id"`
+	Deleted   bool       `json:"deleted"`
+	Name      string     `json:"name,omitempty"`
+	ParentID  *string    `json:"parent_id,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// SyncCategory 同步返回的目录，Deleted 为 true 时为墓碑
+type SyncCategory struct {
+	ID        string     `json:"id"`
+	Deleted   bool       `json:"deleted"`
+	Name      string     `json:"name,omitempty"`
+	Path      string     `json:"path,omitempty"`
+	ParentID  *string    `json:"parent_id,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// SyncResult 同步结果
+type SyncResult struct {
+	Cursor     uint64             `json:"cursor"`   // 下次同步时上传的位置
+	Reset      bool               `json:"reset"`    // 返回的是全部数据，客户端应以此替换本地数据
+	HasMore    bool               `json:"has_more"` // 还有未返回的修改，应立即以 Cursor 继续同步
+	Changes    []SyncChangeResult `json:"changes"`  // 与上传的修改一一对应
+	Notes      []SyncNote         `json:"notes"`
+	Tags       []SyncTag          `json:"tags"`
+	Categories []SyncCategory     `json:"categories"`
+}
+
+// Sync 先按顺序应用上传的修改，再返回 Cursor 之后其他客户端的修改；
+// 每条修改在单独的事务中应用，冲突和被拒绝的修改不影响其他修改。
+// 提供 ClientID 时跳过该客户端上传的修改产生的事件，修改记录被清理后不再跳过。
+// Cursor 为空或对应的事件已被清理时返回全部数据并设置 Reset
+func (s *SyncService) Sync(ctx context.Context, input SyncInput) (*SyncResult, error) {
+	result := &SyncResult{
+		Changes:    []SyncChangeResult{},
+		Notes:      []SyncNote{},
+		Tags:       []SyncTag{},
+		Categories: []SyncCategory{},
+	}
+	for _, change := range input.Changes {
+		r, err := s.apply(ctx, input.ClientID, change)
+		if err != nil {
+			return nil, err
+		}
+		result.Changes = append(result.Changes, r)
+	}
+
+	cursor, reset, err := s.events.Resume(ctx, input.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	result.Cursor = cursor
+	if input.Cursor == nil || reset {
+		result.Reset = true
+		return result, s.snapshot(ctx, result)
+	}
+
+	// 多读一条判断是否还有未返回的事件
+	events, err := s.events.ListEvents(ctx, cursor, input.Limit+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) > input.Limit {
+		result.HasMore = true
+		events = events[:input.Limit]
+	}
+	own, err := s.ownEvents(ctx, input.ClientID, events)
+	if err != nil {
+		return nil, err
+	}
+	// 同一实体的多个事件只返回一次当前状态
+	ids := map[string][]string{}
+	seen := map[string]bool{}
+	for _, e := range events {
+		result.Cursor = e.Seq
+		if own[e.Seq] {
+			continue
+		}
+		key := e.EntityType + ":" + e.EntityID
+		if !seen[key] {
+			seen[key] = true
+			ids[e.EntityType] = append(ids[e.EntityType], e.EntityID)
+		}
+	}
+	if result.Notes, err = s.noteStates(ctx, ids["note"]); err != nil {
+		return nil, err
+	}
+	if result.Tags, err = s.tagStates(ctx, ids["tag"]); err != nil {
+		return nil, err
+	}
+	if result.Categories, err = s.categoryStates(ctx, ids["category"]); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// apply 应用一条上传的修改并记录修改ID；修改ID已记录时直接返回保存的结果。
+// 校验失败等业务错误作为 rejected 返回，其他错误中止同步
+func (s *SyncService) apply(ctx context.Context, clientID string, change SyncChangeInput) (SyncChangeResult, error) {
+	result := SyncChangeResult{ChangeID: change.ChangeID, NoteID: change.NoteID}
+	notes := NewNoteService(s.db.WithContext(ctx), s.logger)
+	err := notes.inTransaction(func(tx *gorm.DB, notes *NoteService) error {
+		var applied model.SyncChange
+		err := tx.First(&applied, "change_id = ?", change.ChangeID).Error
+		if err == nil {
+			result.NoteID, result.Version = applied.NoteID, applied.Version
+			result.Status, result.Duplicate = SyncStatusApplied, true
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		before, err := lastNoteEvent(tx, change.NoteID)
+		if err != nil {
+			return err
+		}
+		version, err := s.applyNote(tx, notes, change)
+		if errors.Is(err, ErrNoteVersionConflict) {
+			result.Status = SyncStatusConflict
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// 删除已删除的笔记时不产生事件
+		seq, err := lastNoteEvent(tx, change.NoteID)
+		if err != nil {
+			return err
+		}
+		if seq == before {
+			seq = 0
+		}
+		result.Status, result.Version = SyncStatusApplied, version
+		return tx.Create(&model.SyncChange{
+			ChangeID: change.ChangeID,
+			NoteID:   change.NoteID,
+			Type:     change.Type,
+			Version:  version,
+			ClientID: clientID,
+			Seq:      seq,
+		}).Error
+	})
+	if err != nil {
+		if _, ok := AsError(err); !ok {
+			return result, err
+		}
+		result.Status, result.Err = SyncStatusRejected, err
+		return result, nil
+	}
+	if result.Status == SyncStatusConflict {
+		states, err := s.noteStates(ctx, []string{change.NoteID})
+		if err != nil {
+			return result, err
+		}
+		result.Server = &states[0]
+	}
+	return result, nil
+}
+
+// lastNoteEvent 返回笔记最新的变更事件序号，没有事件时为 0
+func lastNoteEvent(tx *gorm.DB, noteID string) (uint64, error) {
+	var seq uint64
+	err := tx.Model(&model.Event{}).Select("COALESCE(MAX(seq), 0)").
+		Where("entity_type = ? AND entity_id = ?", model.EventNoteCreated.Entity(), noteID).
+		Scan(&seq).Error
+	return seq, err
+}
+
+// ownEvents 返回 events 中由 clientID 上传的修改产生的事件序号，clientID 为空时返回空集合
+func (s *SyncService) ownEvents(ctx context.Context, clientID string, events []model.Event) (map[uint64]bool, error) {
+	own := map[uint64]bool{}
+	if clientID == "" || len(events) == 0 {
+		return own, nil
+	}
+	var seqs []uint64
+	err := s.db.WithContext(ctx).Model(&model.SyncChange{}).
+		Where("client_id = ? AND seq >= ? AND seq <= ?", clientID, events[0].Seq, events[len(events)-1].Seq).
+		Pluck("seq", &seqs).Error
+	for _, seq := range seqs {
+		own[seq] = true
+	}
+	return own, err
+}
+
+// applyNote 在 tx 中应用修改，返回应用后的版本号；
+// 修改所基于的版本不是当前版本或修改已删除的笔记时返回 ErrNoteVersionConflict，
+// 删除已删除的笔记视为成功。notes 在 tx 中工作，插件的 AfterSaveNote 在事务提交后调用
+func (s *SyncService) applyNote(tx *gorm.DB, notes *NoteService, change SyncChangeInput) (int, error) {
+	if change.Type == model.SyncChangeCreate {
+		note, err := notes.CreateNote(CreateNoteInput{
+			ID:         change.NoteID,
+			Title:      change.Title,
+			Content:    change.Content,
+			YAMLMeta:   change.YAMLMeta,
+			FilePath:   change.FilePath,
+			CategoryID: change.CategoryID,
+			TagIDs:     change.TagIDs,
+		})
+		if err != nil {
+			return 0, err
+		}
+		return note.Version, nil
+	}
+
+	if change.BaseVersion < 1 {
+		return 0, newValidationError([]FieldError{{Field: "base_version", Code: FieldRequired}})
+	}
+	var note model.Note
+	if err := tx.Unscoped().First(&note, "id = ?", change.NoteID).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, err
+		}
+		if change.Type == model.SyncChangeDelete {
+			return 0, nil
+		}
+		return 0, ErrNoteNotFound
+	}
+	if note.DeletedAt.Valid {
+		if change.Type == model.SyncChangeDelete {
+			return note.Version, nil
+		}
+		return 0, ErrNoteVersionConflict
+	}
+	if note.Version != change.BaseVersion {
+		return 0, ErrNoteVersionConflict
+	}
+
+	if change.Type == model.SyncChangeDelete {
+		return note.Version, notes.DeleteNote(note.ID)
+	}
+	err := notes.UpdateNote(note.ID, UpdateNoteInput{
+		Title:       change.Title,
+		Content:     change.Content,
+		YAMLMeta:    change.YAMLMeta,
+		CategoryID:  change.CategoryID,
+		TagIDs:      change.TagIDs,
+		BaseVersion: change.BaseVersion,
+	})
+	if err != nil {
+		return 0, err
+	}
+	var version int
+	err = tx.Model(&model.Note{}).Select("version").Where("id = ?", note.ID).Scan(&version).Error
+	return version, err
+}
+
+// snapshot 返回全部未删除的笔记、标签和目录
+func (s *SyncService) snapshot(ctx context.Context, result *SyncResult) error {
+	db := s.db.WithContext(ctx)
+	var notes []model.Note
+	if err := db.Preload("Tags").Order("file_path").Find(&notes).Error; err != nil {
+		return err
+	}
+	for i := range notes {
+		result.Notes = append(result.Notes, syncNote(&notes[i]))
+	}
+	var tags []model.Tag
+	if err := db.Order("name").Find(&tags).Error; err != nil {
+		return err
+	}
+	for i := range tags {
+		result.Tags = append(result.Tags, syncTag(&tags[i]))
+	}
+	// 按路径排序，上级目录在前
+	var categories []model.Category
+	if err := db.Order("path").Find(&categories).Error; err != nil {
+		return err
+	}
+	for i := range categories {
+		result.Categories = append(result.Categories, syncCategory(&categories[i]))
+	}
+	return nil
+}
+
+// noteStates 按 ids 的顺序返回笔记的当前状态，已删除或不存在的笔记返回墓碑
+func (s *SyncService) noteStates(ctx context.Context, ids []string) ([]SyncNote, error) {
+	var notes []model.Note
+	if len(ids) > 0 {
+		if err := s.db.WithContext(ctx).Unscoped().Preload("Tags").Where("id IN ?", ids).Find(&notes).Error; err != nil {
+			return nil, err
+		}
+	}
+	byID := make(map[string]*model.Note, len(notes))
+	for i := range notes {
+		byID[notes[i].ID] = &notes[i]
+	}
+	states := []SyncNote{}
+	for _, id := range ids {
+		if n, ok := byID[id]; ok && !n.DeletedAt.Valid {
+			states = append(states, syncNote(n))
+		} else {
+			states = append(states, SyncNote{ID: id, Deleted: true})
+		}
+	}
+	return states, nil
+}
+
+// tagStates 按 ids 的顺序返回标签的当前状态，已删除的标签返回墓碑
+func (s *SyncService) tagStates(ctx context.Context, ids []string) ([]SyncTag, error) {
+	var tags []model.Tag
+	if len(ids) > 0 {
+		if err := s.db.WithContext(ctx).Unscoped().Where("id IN ?", ids).Find(&tags).Error; err != nil {
+			return nil, err
+		}
+	}
+	byID := make(map[string]*model.Tag, len(tags))
+	for i := range tags {
+		byID[tags[i].ID] = &tags[i]
+	}
+	states := []SyncTag{}
+	for _, id := range ids {
+		if t, ok := byID[id]; ok && !t.DeletedAt.Valid {
+			states = append(states, syncTag(t))
+		} else {
+			states = append(states, SyncTag{ID: id, Deleted: true})
+		}
+	}
+	return states, nil
+}
+
+// categoryStates 按 ids 的顺序返回目录的当前状态，已删除的目录返回墓碑
+func (s *SyncService) categoryStates(ctx context.Context, ids []string) ([]SyncCategory, error) {
+	var categories []model.Category
+	if len(ids) > 0 {
+		if err := s.db.WithContext(ctx).Unscoped().Where("id IN ?", ids).Find(&categories).Error; err != nil {
+			return nil, err
+		}
+	}
+	byID := make(map[string]*model.Category, len(categories))
+	for i := range categories {
+		byID[categories[i].ID] = &categories[i]
+	}
+	states := []SyncCategory{}
+	for _, id := range ids {
+		if c, ok := byID[id]; ok && !c.DeletedAt.Valid {
+			states = append(states, syncCategory(c))
+		} else {
+			states = append(states, SyncCategory{ID: id, Deleted: true})
+		}
+	}
+	return states, nil
+}
+
+// Run 定期清理过期的修改记录，直到 ctx 取消；保留时长为 0 时直接返回
+func (s *SyncService) Run(ctx context.Context) error {
+	if s.opts.ChangeRetention <= 0 {
+		return nil
+	}
+	ticker := time.NewTicker(syncPruneInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.Prune(ctx, time.Now().Add(-s.opts.ChangeRetention)); err != nil && ctx.Err() == nil {
+			s.logger.Error("Failed to prune sync changes", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Prune 删除 before 之前应用的修改记录，返回删除的数量
+func (s *SyncService) Prune(ctx context.Context, before time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("created_at < ?", before).Delete(&model.SyncChange{})
+	return result.RowsAffected, result.Error
+}
+
+// syncNote 转换为同步返回的笔记
+func syncNote(n *model.Note) SyncNote {
+	tagIDs := make([]string, 0, len(n.Tags))
+	for _, t := range n.Tags {
+		tagIDs = append(tagIDs, t.ID)
+	}
+	return SyncNote{
+		ID:         n.ID,
+		Title:      n.Title,
+		Content:    n.Content,
+		YAMLMeta:   n.YAMLMeta,
+		FilePath:   n.FilePath,
+		CategoryID: n.CategoryID,
+		TagIDs:     tagIDs,
+		Version:    n.Version,
+		Checksum:   n.Checksum,
+		UpdatedAt:  &n.UpdatedAt,
+	}
+}
+
+// syncTag 转换为同步返回的标签
+func syncTag(t *model.Tag) SyncTag {
+	return SyncTag{ID: t.ID, Name: t.Name, ParentID: t.ParentID, UpdatedAt: &t.UpdatedAt}
+}
+
+// syncCategory 转换为同步返回的目录
+func syncCategory(c *model.Category) SyncCategory {
+	return SyncCategory{ID: c.ID, Name: c.Name, Path: c.Path, ParentID: c.ParentID, UpdatedAt: &c.UpdatedAt}
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"leafnote/internal/model"
+	"leafnote/internal/testutil"
+)
+
+func TestSyncService_Sync(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	ctx := context.Background()
+	events := NewEventService(db, zap.NewNop(), EventOptions{})
+	s := NewSyncService(db, zap.NewNop(), events, SyncOptions{})
+
+	work := &model.Category{Name: "工作"}
+	require.NoError(t, NewCategoryService(db).CreateCategory(ctx, work))
+	tag := &model.Tag{Name: "重要"}
+	require.NoError(t, NewTagService(db).CreateTag(ctx, tag))
+	notes := NewNoteService(db, zap.NewNop())
+	plan, err := notes.CreateNote(CreateNoteInput{Title: "计划", FilePath: "/计划.md", CategoryID: &work.ID, TagIDs: []string{tag.ID}})
+	require.NoError(t, err)
+
+	// 首次同步返回全部数据
+	full, err := s.Sync(ctx, SyncInput{Limit: 100})
+	require.NoError(t, err)
+	assert.True(t, full.Reset)
+	require.Len(t, full.Notes, 1)
+	assert.Equal(t, []string{tag.ID}, full.Notes[0].TagIDs)
+	assert.Len(t, full.Tags, 1)
+	assert.Len(t, full.Categories, 1)
+	cursor := full.Cursor
+
+	const draftID = "0b6f3c2e-5a1d-4e8f-9c7b-2d4a6e8f0a1c"
+	const clientID = "desktop"
+	result, err := s.Sync(ctx, SyncInput{ClientID: clientID, Cursor: &cursor, Limit: 100, Changes: []SyncChangeInput{
+		{ChangeID: "c1", Type: model.SyncChangeCreate, NoteID: draftID, Title: "草稿", FilePath: "/草稿.md"},
+		{ChangeID: "c2", Type: model.SyncChangeUpdate, NoteID: plan.ID, BaseVersion: 1, Content: "第一版"},
+		{ChangeID: "c3", Type: model.SyncChangeUpdate, NoteID: plan.ID, BaseVersion: 1, Content: "另一台设备的修改"},
+		{ChangeID: "c1", Type: model.SyncChangeCreate, NoteID: draftID, Title: "草稿", FilePath: "/草稿.md"},
+		{ChangeID: "c4", Type: model.SyncChangeCreate, NoteID: "a3e1f0d2-7b6c-4a5e-8f9d-1c2b3a4d5e6f", Title: "缺少路径"},
+		{ChangeID: "c5", Type: model.SyncChangeDelete, NoteID: plan.ID},
+	}})
+	require.NoError(t, err)
+	assert.False(t, result.Reset)
+	assert.Empty(t, result.Notes, "新提交的事件推送后才返回")
+
+	tests := []struct {
+		name        string
+		wantStatus  string
+		wantVersion int
+		wantDup     bool
+		wantErr     error
+	}{
+		{name: "创建", wantStatus: SyncStatusApplied, wantVersion: 1},
+		{name: "修改", wantStatus: SyncStatusApplied, wantVersion: 2},
+		{name: "基于过期版本的修改", wantStatus: SyncStatusConflict},
+		{name: "重复上传", wantStatus: SyncStatusApplied, wantVersion: 1, wantDup: true},
+		{name: "校验失败", wantStatus: SyncStatusRejected, wantErr: ErrValidationFailed},
+		{name: "缺少版本号", wantStatus: SyncStatusRejected, wantErr: ErrValidationFailed},
+	}
+	require.Len(t, result.Changes, len(tests))
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := result.Changes[i]
+			assert.Equal(t, tt.wantStatus, r.Status)
+			assert.Equal(t, tt.wantVersion, r.Version)
+			assert.Equal(t, tt.wantDup, r.Duplicate)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, r.Err, tt.wantErr)
+			} else {
+				assert.NoError(t, r.Err)
+			}
+		})
+	}
+	conflict := result.Changes[2].Server
+	require.NotNil(t, conflict)
+	assert.Equal(t, 2, conflict.Version)
+	assert.Equal(t, "第一版", conflict.Content)
+
+	t.Run("返回其他客户端的修改和墓碑", func(t *testing.T) {
+		require.NoError(t, notes.DeleteNote(plan.ID))
+		require.NoError(t, NewTagService(db).DeleteTag(ctx, tag.ID))
+		_, err := events.Poll(ctx, time.Now())
+		require.NoError(t, err)
+
+		page, err := s.Sync(ctx, SyncInput{Cursor: &cursor, Limit: 1})
+		require.NoError(t, err)
+		assert.True(t, page.HasMore)
+		assert.Greater(t, page.Cursor, cursor)
+
+		// 事件数恰好等于 limit 时没有更多
+		all, err := events.ListEvents(ctx, cursor, 100)
+		require.NoError(t, err)
+		exact, err := s.Sync(ctx, SyncInput{Cursor: &cursor, Limit: len(all)})
+		require.NoError(t, err)
+		assert.False(t, exact.HasMore)
+		assert.Equal(t, all[len(all)-1].Seq, exact.Cursor)
+
+		// 未提供客户端标识时包括自己上传的修改
+		echo, err := s.Sync(ctx, SyncInput{Cursor: &cursor, Limit: 100})
+		require.NoError(t, err)
+		assert.Len(t, echo.Notes, 2)
+
+		delta, err := s.Sync(ctx, SyncInput{ClientID: clientID, Cursor: &cursor, Limit: 100, Changes: []SyncChangeInput{
+			{ChangeID: "c6", Type: model.SyncChangeUpdate, NoteID: plan.ID, BaseVersion: 2, Title: "离线修改"},
+			{ChangeID: "c7", Type: model.SyncChangeDelete, NoteID: plan.ID, BaseVersion: 2},
+		}})
+		require.NoError(t, err)
+		assert.False(t, delta.HasMore)
+		assert.Equal(t, SyncStatusConflict, delta.Changes[0].Status)
+		assert.True(t, delta.Changes[0].Server.Deleted)
+		assert.Equal(t, SyncStatusApplied, delta.Changes[1].Status, "删除已删除的笔记视为成功")
+
+		// 草稿由该客户端创建，不再返回；计划被其他客户端删除，返回墓碑
+		assert.Equal(t, []SyncNote{{ID: plan.ID, Deleted: true}}, delta.Notes)
+		assert.Equal(t, []SyncTag{{ID: tag.ID, Deleted: true}}, delta.Tags)
+		assert.Equal(t, all[len(all)-1].Seq, delta.Cursor)
+	})
+
+	t.Run("位置超过当前序号时重新加载", func(t *testing.T) {
+		future := uint64(1 << 40)
+		reset, err := s.Sync(ctx, SyncInput{Cursor: &future, Limit: 100})
+		require.NoError(t, err)
+		assert.True(t, reset.Reset)
+		require.Len(t, reset.Notes, 1)
+		assert.Equal(t, draftID, reset.Notes[0].ID)
+		assert.Empty(t, reset.Tags)
+	})
+
+	t.Run("修改记录过期后不再识别重复上传", func(t *testing.T) {
+		pruned, err := s.Prune(ctx, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), pruned)
+
+		replay, err := s.Sync(ctx, SyncInput{Cursor: &cursor, Limit: 100, Changes: []SyncChangeInput{
+			{ChangeID: "c1", Type: model.SyncChangeCreate, NoteID: draftID, Title: "草稿", FilePath: "/草稿2.md"},
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, SyncStatusRejected, replay.Changes[0].Status)
+		assert.ErrorIs(t, replay.Changes[0].Err, ErrNoteIDExists)
+	})
+}
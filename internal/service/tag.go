@@ -22,8 +22,8 @@ func NewTagService(db *gorm.DB) *TagService {
 
 // CreateTag 创建标签
 func (s *TagService) CreateTag(ctx context.Context, tag *model.Tag) error {
-	if tag.Name == "" {
-		return errors.New("标签名称不能为空")
+	if fe := validateTagName("name", tag.Name); fe != nil {
+		return newValidationError([]FieldError{*fe})
 	}
 
 	// 如果有父标签ID，检查父标签是否存在
@@ -31,7 +31,7 @@ func (s *TagService) CreateTag(ctx context.Context, tag *model.Tag) error {
 		var parent model.Tag
 		if err := s.db.First(&parent, "id = ?", *tag.ParentID).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return errors.New("父标签不存在")
+				return ErrTagParentNotFound
 			}
 			return err
 		}
@@ -39,7 +39,12 @@ func (s *TagService) CreateTag(ctx context.Context, tag *model.Tag) error {
 
 	// 生成UUID
 	tag.ID = uuid.New().String()
-	return s.db.Create(tag).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(tag).Error; err != nil {
+			return err
+		}
+		return recordEvent(tx, model.EventTagCreated, tag.ID, 0)
+	})
 }
 
 // GetTagByID 根据ID获取标签
@@ -48,7 +53,7 @@ func (s *TagService) GetTagByID(ctx context.Context, id string) (*model.Tag, err
 	err := s.db.Preload("Children").First(&tag, "id = ?", id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("标签不存在")
+			return nil, ErrTagNotFound
 		}
 		return nil, err
 	}
@@ -66,43 +71,57 @@ func (s *TagService) ListTags(ctx context.Context) ([]model.Tag, error) {
 // UpdateTag 更新标签
 func (s *TagService) UpdateTag(ctx context.Context, tag *model.Tag) error {
 	if tag.ID == "" {
-		return errors.New("标签ID不能为空")
+		return ErrTagIDRequired
 	}
-	if tag.Name == "" {
-		return errors.New("标签名称不能为空")
+	if fe := validateTagName("name", tag.Name); fe != nil {
+		return newValidationError([]FieldError{*fe})
 	}
 
 	// 如果有父标签ID，检查父标签是否存在且不能是自己
 	if tag.ParentID != nil {
 		if *tag.ParentID == tag.ID {
-			return errors.New("父标签不能是自己")
+			return ErrTagParentSelf
 		}
 		var parent model.Tag
 		if err := s.db.First(&parent, "id = ?", *tag.ParentID).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return errors.New("父标签不存在")
+				return ErrTagParentNotFound
 			}
 			return err
 		}
 	}
 
-	return s.db.Model(tag).Updates(map[string]interface{}{
-		"name":      tag.Name,
-		"parent_id": tag.ParentID,
-	}).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(tag).Updates(map[string]interface{}{
+			"name":      tag.Name,
+			"parent_id": tag.ParentID,
+		}).Error; err != nil {
+			return err
+		}
+		return recordEvent(tx, model.EventTagUpdated, tag.ID, 0)
+	})
 }
 
 // DeleteTag 删除标签
 func (s *TagService) DeleteTag(ctx context.Context, id string) error {
 	// 开启事务
 	return s.db.Transaction(func(tx *gorm.DB) error {
+		// 检查标签是否存在
+		var tag model.Tag
+		if err := tx.First(&tag, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTagNotFound
+			}
+			return err
+		}
+
 		// 检查是否有子标签
 		var count int64
 		if err := tx.Model(&model.Tag{}).Where("parent_id = ?", id).Count(&count).Error; err != nil {
 			return err
 		}
 		if count > 0 {
-			return errors.New("请先删除子标签")
+			return ErrTagHasChildren
 		}
 
 		// 删除标签与笔记的关联关系
@@ -115,6 +134,6 @@ func (s *TagService) DeleteTag(ctx context.Context, id string) error {
 			return err
 		}
 
-		return nil
+		return recordEvent(tx, model.EventTagDeleted, id, 0)
 	})
 }
@@ -5,7 +5,6 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
 	"leafnote/internal/model"
@@ -13,14 +12,7 @@ import (
 )
 
 func setupTagTestDB(t *testing.T) *gorm.DB {
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	assert.NoError(t, err)
-
-	// 自动迁移
-	err = db.AutoMigrate(&model.Tag{}, &model.Note{})
-	assert.NoError(t, err)
-
-	return db
+	return testutil.NewTestDB(t)
 }
 
 func TestTagService_CreateTag(t *testing.T) {
@@ -221,35 +213,35 @@ func TestTagService_DeleteTag(t *testing.T) {
 	tests := []struct {
 		name    string
 		id      string
-		wantErr bool
+		wantErr error
 	}{
 		{
 			name:    "删除失败-存在子标签",
 			id:      parent.BaseModel.ID,
-			wantErr: true,
+			wantErr: ErrTagHasChildren,
 		},
 		{
 			name:    "删除成功-子标签",
 			id:      child.BaseModel.ID,
-			wantErr: false,
+			wantErr: nil,
 		},
 		{
 			name:    "删除成功-父标签",
 			id:      parent.BaseModel.ID,
-			wantErr: false,
+			wantErr: nil,
 		},
 		{
 			name:    "标签不存在",
 			id:      "not-exist",
-			wantErr: false,
+			wantErr: ErrTagNotFound,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			err := s.DeleteTag(ctx, tt.id)
-			if tt.wantErr {
-				assert.Error(t, err)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
 			} else {
 				assert.NoError(t, err)
 				// 验证删除结果
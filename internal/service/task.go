@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"leafnote/internal/model"
+)
+
+// taskLine 匹配列表项中的复选框，例如 - [ ] 待办、1. [x] 已完成
+var taskLine = regexp.MustCompile(`^(\s*(?:[-*+]|\d{1,9}[.)])\s+\[)([ xX])\]\s+(.*)$`)
+
+// taskDueDate 匹配截止日期，例如 📅 2026-10-20
+var taskDueDate = regexp.MustCompile(`📅\s*(\d{4}-\d{2}-\d{2})`)
+
+// taskPriorities 优先级符号
+var taskPriorities = map[string]int{
+	"🔺": model.TaskPriorityHighest,
+	"⏫": model.TaskPriorityHigh,
+	"🔼": model.TaskPriorityMedium,
+	"🔽": model.TaskPriorityLow,
+	"⏬": model.TaskPriorityLowest,
+}
+
+// TaskService 待办服务
+type TaskService struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+// NewTaskService 创建待办服务实例
+func NewTaskService(db *gorm.DB, logger *zap.Logger) *TaskService {
+	return &TaskService{db: db, logger: logger}
+}
+
+// TaskFilter 待办列表的筛选条件，零值表示不筛选
+type TaskFilter struct {
+	Done        *bool
+	NoteID      string
+	CategoryID  string // 只包含直接位于该目录下的笔记
+	DueFrom     string // 截止日期不早于，YYYY-MM-DD
+	DueTo       string // 截止日期不晚于，YYYY-MM-DD
+	HasDue      *bool
+	MinPriority int
+	Query       string // 按文字模糊匹配
+}
+
+// ListTasks 获取待办列表，不含已删除笔记中的待办；
+// 未完成的排在前面，其次按截止日期（未设置的排在最后）、优先级、笔记路径和行号排序
+func (s *TaskService) ListTasks(ctx context.Context, filter TaskFilter) ([]model.Task, error) {
+	query := s.db.WithContext(ctx).Model(&model.Task{}).
+		Select("tasks.*, notes.title AS note_title, notes.file_path AS note_file_path").
+		Joins("JOIN notes ON notes.id = tasks.note_id AND notes.deleted_at IS NULL")
+	if filter.Done != nil {
+		query = query.Where("tasks.done = ?", *filter.Done)
+	}
+	if filter.NoteID != "" {
+		query = query.Where("tasks.note_id = ?", filter.NoteID)
+	}
+	if filter.CategoryID != "" {
+		query = query.Where("notes.category_id = ?", filter.CategoryID)
+	}
+	if filter.DueFrom != "" {
+		query = query.Where("tasks.due_date >= ?", filter.DueFrom)
+	}
+	if filter.DueTo != "" {
+		query = query.Where("tasks.due_date <= ?", filter.DueTo)
+	}
+	if filter.HasDue != nil {
+		if *filter.HasDue {
+			query = query.Where("tasks.due_date IS NOT NULL")
+		} else {
+			query = query.Where("tasks.due_date IS NULL")
+		}
+	}
+	if filter.MinPriority > 0 {
+		query = query.Where("tasks.priority >= ?", filter.MinPriority)
+	}
+	if filter.Query != "" {
+		query = query.Where(likeCondition("tasks.text"), "%"+escapeLike(filter.Query)+"%")
+	}
+
+	var tasks []model.Task
+	err := query.
+		Order("tasks.done").
+		Order("CASE WHEN tasks.due_date IS NULL THEN 1 ELSE 0 END").
+		Order("tasks.due_date").
+		Order("tasks.priority DESC").
+		Order("notes.file_path").
+		Order("tasks.line").
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// ToggleTask 勾选或取消勾选待办，done 为 nil 时切换当前状态；
+// 改写笔记正文中对应的行并增加笔记的版本号，状态不变时不修改笔记
+func (s *TaskService) ToggleTask(ctx context.Context, id string, done *bool) (*model.Task, error) {
+	var task model.Task
+	notes := NewNoteService(s.db.WithContext(ctx), s.logger)
+	err := notes.inTransaction(func(tx *gorm.DB, notes *NoteService) error {
+		if err := tx.First(&task, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTaskNotFound
+			}
+			return err
+		}
+		var note model.Note
+		if err := tx.First(&note, "id = ?", task.NoteID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTaskNotFound
+			}
+			return err
+		}
+
+		want := !task.Done
+		if done != nil {
+			want = *done
+		}
+		if want == task.Done {
+			return nil
+		}
+		content, ok := setTaskDone(note.Content, task.Line, task.Text, want)
+		if !ok {
+			return ErrTaskOutdated
+		}
+
+		// 与修改笔记一样经过插件钩子；按读取时的版本号更新，笔记在此期间被修改时放弃
+		err := notes.UpdateNote(note.ID, UpdateNoteInput{Content: content, BaseVersion: note.Version})
+		if errors.Is(err, ErrNoteVersionConflict) {
+			return ErrTaskOutdated
+		}
+		if err != nil {
+			return err
+		}
+		return tx.First(&task, "id = ?", id).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// TaskRebuildResult 重建待办的结果
+type TaskRebuildResult struct {
+	Notes int   `json:"notes"` // 处理的笔记数
+	Tasks int64 `json:"tasks"` // 重建后的待办数
+}
+
+// RebuildTasks 按所有笔记的正文重建待办，包括已删除的笔记，恢复笔记后其待办随之恢复
+func (s *TaskService) RebuildTasks(ctx context.Context) (*TaskRebuildResult, error) {
+	result := &TaskRebuildResult{}
+	var notes []model.Note
+	err := s.db.WithContext(ctx).Unscoped().Select("id", "content").
+		FindInBatches(&notes, 100, func(_ *gorm.DB, _ int) error {
+			return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				for _, n := range notes {
+					if err := syncNoteTasks(tx, n.ID, n.Content); err != nil {
+						return err
+					}
+				}
+				result.Notes += len(notes)
+				return nil
+			})
+		}).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).Model(&model.Task{}).Count(&result.Tasks).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// syncNoteTasks 根据笔记正文重建笔记的待办；文字相同的待办按出现顺序沿用原来的 ID，
+// 编辑笔记后客户端持有的待办 ID 仍然有效
+func syncNoteTasks(tx *gorm.DB, noteID, content string) error {
+	var existing []model.Task
+	if err := tx.Where("note_id = ?", noteID).Order("line").Find(&existing).Error; err != nil {
+		return err
+	}
+	byText := make(map[string][]model.Task)
+	for _, t := range existing {
+		byText[t.Text] = append(byText[t.Text], t)
+	}
+
+	tasks := parseTasks(content)
+	for i := range tasks {
+		tasks[i].NoteID = noteID
+		if olds := byText[tasks[i].Text]; len(olds) > 0 {
+			tasks[i].ID = olds[0].ID
+			tasks[i].CreatedAt = olds[0].CreatedAt
+			byText[tasks[i].Text] = olds[1:]
+		}
+	}
+
+	var stale []string
+	for _, olds := range byText {
+		for _, t := range olds {
+			stale = append(stale, t.ID)
+		}
+	}
+	if len(stale) > 0 {
+		if err := tx.Unscoped().Where("id IN ?", stale).Delete(&model.Task{}).Error; err != nil {
+			return err
+		}
+	}
+	if len(tasks) == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"line", "text", "done", "due_date", "priority", "updated_at"}),
+	}).Create(&tasks).Error
+}
+
+// parseTasks 提取正文中的待办，跳过代码块
+func parseTasks(content string) []model.Task {
+	var tasks []model.Task
+	fence := ""
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if marker := codeFence(line); marker != "" {
+			switch {
+			case fence == "":
+				fence = marker
+			case strings.HasPrefix(marker, fence):
+				fence = ""
+			}
+			continue
+		}
+		if fence != "" {
+			continue
+		}
+		m := taskLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		task := model.Task{Line: i + 1, Done: m[2] != " "}
+		task.Text, task.DueDate, task.Priority = parseTaskText(m[3])
+		tasks = append(tasks, task)
+	}
+	return tasks
+}
+
+// codeFence 返回代码块的起止标记（``` 或 ~~~ 及其后的同一字符），不是时返回空串
+func codeFence(line string) string {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 || len(trimmed) < 3 {
+		return ""
+	}
+	c := trimmed[0]
+	if c != '`' && c != '~' {
+		return ""
+	}
+	n := len(trimmed) - len(strings.TrimLeft(trimmed, string(c)))
+	if n < 3 {
+		return ""
+	}
+	return trimmed[:n]
+}
+
+// parseTaskText 从复选框后的文字中取出截止日期和优先级，返回其余的文字；
+// 日期不存在（例如 2026-02-30）时保留在文字中
+func parseTaskText(s string) (text string, due *string, priority int) {
+	if m := taskDueDate.FindStringSubmatchIndex(s); m != nil {
+		date := s[m[2]:m[3]]
+		if _, err := time.Parse(dateLayout, date); err == nil {
+			due = &date
+			s = s[:m[0]] + " " + s[m[1]:]
+		}
+	}
+	for symbol, p := range taskPriorities {
+		if strings.Contains(s, symbol) {
+			priority = max(priority, p)
+			s = strings.ReplaceAll(s, symbol, " ")
+		}
+	}
+	return strings.Join(strings.Fields(s), " "), due, priority
+}
+
+// setTaskDone 修改第 line 行复选框的状态，该行不再是文字为 text 的待办时返回 false
+func setTaskDone(content string, line int, text string, done bool) (string, bool) {
+	lines := strings.Split(content, "\n")
+	if line < 1 || line > len(lines) {
+		return "", false
+	}
+	current := lines[line-1]
+	m := taskLine.FindStringSubmatchIndex(strings.TrimSuffix(current, "\r"))
+	if m == nil {
+		return "", false
+	}
+	if t, _, _ := parseTaskText(current[m[6]:m[7]]); t != text {
+		return "", false
+	}
+	mark := " "
+	if done {
+		mark = "x"
+	}
+	lines[line-1] = current[:m[4]] + mark + current[m[5]:]
+	return strings.Join(lines, "\n"), true
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"leafnote/internal/model"
+	"leafnote/internal/testutil"
+)
+
+func TestParseTasks(t *testing.T) {
+	content := "# 计划\n" +
+		"- [ ] 写周报 📅 2026-10-20 ⏫\n" +
+		"  * [x] 已完成的子任务\r\n" +
+		"1. [ ] 有序列表 🔽\n" +
+		"- [ ] 日期不存在 📅 2026-02-30\n" +
+		"```\n" +
+		"- [ ] 代码块中的复选框\n" +
+		"```\n" +
+		"[ ] 不是列表项\n" +
+		"- [] 格式错误\n"
+
+	tasks := parseTasks(content)
+	require.Len(t, tasks, 4)
+
+	tests := []struct {
+		name     string
+		task     model.Task
+		line     int
+		text     string
+		done     bool
+		due      string
+		priority int
+	}{
+		{name: "截止日期和优先级", task: tasks[0], line: 2, text: "写周报", due: "2026-10-20", priority: model.TaskPriorityHigh},
+		{name: "已完成", task: tasks[1], line: 3, text: "已完成的子任务", done: true},
+		{name: "有序列表", task: tasks[2], line: 4, text: "有序列表", priority: model.TaskPriorityLow},
+		{name: "日期不存在时保留原文", task: tasks[3], line: 5, text: "日期不存在 📅 2026-02-30"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.line, tt.task.Line)
+			assert.Equal(t, tt.text, tt.task.Text)
+			assert.Equal(t, tt.done, tt.task.Done)
+			assert.Equal(t, tt.priority, tt.task.Priority)
+			if tt.due == "" {
+				assert.Nil(t, tt.task.DueDate)
+			} else if assert.NotNil(t, tt.task.DueDate) {
+				assert.Equal(t, tt.due, *tt.task.DueDate)
+			}
+		})
+	}
+}
+
+func TestTaskService_ListTasks(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	notes := NewNoteService(db, zap.NewNop())
+	s := NewTaskService(db, zap.NewNop())
+	ctx := context.Background()
+
+	work, err := notes.CreateNote(CreateNoteInput{
+		Title:    "工作",
+		FilePath: "/工作.md",
+		Content:  "- [ ] 发布 📅 2026-10-20\n- [x] 评审 📅 2026-10-18\n- [ ] 整理 API 文档 🔼",
+	})
+	require.NoError(t, err)
+	home, err := notes.CreateNote(CreateNoteInput{
+		Title:    "家务",
+		FilePath: "/家务.md",
+		Content:  "- [ ] 买菜 📅 2026-10-19 🔺",
+	})
+	require.NoError(t, err)
+	deleted, err := notes.CreateNote(CreateNoteInput{Title: "已删除", FilePath: "/已删除.md", Content: "- [ ] 不显示"})
+	require.NoError(t, err)
+	require.NoError(t, notes.DeleteNote(deleted.ID))
+
+	tests := []struct {
+		name   string
+		filter TaskFilter
+		want   []string
+	}{
+		{name: "全部", want: []string{"买菜", "发布", "整理 API 文档", "评审"}},
+		{name: "未完成", filter: TaskFilter{Done: testutil.BoolPtr(false)}, want: []string{"买菜", "发布", "整理 API 文档"}},
+		{name: "指定笔记", filter: TaskFilter{NoteID: work.ID}, want: []string{"发布", "整理 API 文档", "评审"}},
+		{name: "截止日期范围", filter: TaskFilter{DueFrom: "2026-10-19", DueTo: "2026-10-20"}, want: []string{"买菜", "发布"}},
+		{name: "没有截止日期", filter: TaskFilter{HasDue: testutil.BoolPtr(false)}, want: []string{"整理 API 文档"}},
+		{name: "优先级", filter: TaskFilter{MinPriority: model.TaskPriorityMedium}, want: []string{"买菜", "整理 API 文档"}},
+		{name: "文字", filter: TaskFilter{Query: "文档"}, want: []string{"整理 API 文档"}},
+		{name: "文字不区分大小写", filter: TaskFilter{Query: "Api"}, want: []string{"整理 API 文档"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, err := s.ListTasks(ctx, tt.filter)
+			require.NoError(t, err)
+			texts := make([]string, 0, len(tasks))
+			for _, task := range tasks {
+				texts = append(texts, task.Text)
+			}
+			assert.Equal(t, tt.want, texts)
+		})
+	}
+
+	tasks, err := s.ListTasks(ctx, TaskFilter{NoteID: home.ID})
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "家务", tasks[0].NoteTitle)
+	assert.Equal(t, "/家务.md", tasks[0].NoteFilePath)
+}
+
+func TestTaskService_ToggleTask(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	notes := NewNoteService(db, zap.NewNop())
+	s := NewTaskService(db, zap.NewNop())
+	ctx := context.Background()
+
+	note, err := notes.CreateNote(CreateNoteInput{
+		Title:    "清单",
+		FilePath: "/清单.md",
+		Content:  "# 清单\r\n- [ ] 第一项\r\n- [ ] 第二项 📅 2026-10-20\r\n",
+	})
+	require.NoError(t, err)
+	tasks, err := s.ListTasks(ctx, TaskFilter{NoteID: note.ID})
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	// 有截止日期的排在前面
+	second := tasks[0]
+	require.Equal(t, "第二项", second.Text)
+
+	t.Run("勾选", func(t *testing.T) {
+		task, err := s.ToggleTask(ctx, second.ID, nil)
+		require.NoError(t, err)
+		assert.True(t, task.Done)
+		assert.Equal(t, second.ID, task.ID)
+
+		got, err := notes.GetNote(note.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "# 清单\r\n- [ ] 第一项\r\n- [x] 第二项 📅 2026-10-20\r\n", got.Content)
+		assert.Equal(t, 2, got.Version)
+		assert.Equal(t, notes.calculateChecksum(got.Content), got.Checksum)
+	})
+
+	t.Run("状态不变时不修改笔记", func(t *testing.T) {
+		task, err := s.ToggleTask(ctx, second.ID, testutil.BoolPtr(true))
+		require.NoError(t, err)
+		assert.True(t, task.Done)
+		got, err := notes.GetNote(note.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, got.Version)
+	})
+
+	t.Run("编辑笔记后沿用原来的 ID", func(t *testing.T) {
+		require.NoError(t, notes.UpdateNote(note.ID, UpdateNoteInput{Content: "- [ ] 新增\n- [x] 第二项 📅 2026-10-20\n"}))
+		tasks, err := s.ListTasks(ctx, TaskFilter{NoteID: note.ID})
+		require.NoError(t, err)
+		require.Len(t, tasks, 2)
+		assert.Equal(t, "新增", tasks[0].Text)
+		assert.Equal(t, second.ID, tasks[1].ID)
+		assert.Equal(t, 2, tasks[1].Line)
+
+		task, err := s.ToggleTask(ctx, second.ID, testutil.BoolPtr(false))
+		require.NoError(t, err)
+		assert.False(t, task.Done)
+		got, err := notes.GetNote(note.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "- [ ] 新增\n- [ ] 第二项 📅 2026-10-20\n", got.Content)
+	})
+
+	t.Run("正文与待办不一致", func(t *testing.T) {
+		require.NoError(t, db.Model(&model.Note{}).Where("id = ?", note.ID).UpdateColumn("content", "已清空").Error)
+		_, err := s.ToggleTask(ctx, second.ID, nil)
+		assert.ErrorIs(t, err, ErrTaskOutdated)
+	})
+
+	t.Run("待办不存在", func(t *testing.T) {
+		_, err := s.ToggleTask(ctx, "not-exist", nil)
+		assert.ErrorIs(t, err, ErrTaskNotFound)
+	})
+
+	t.Run("重建", func(t *testing.T) {
+		result, err := s.RebuildTasks(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Notes)
+		assert.Zero(t, result.Tasks)
+	})
+}
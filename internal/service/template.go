@@ -0,0 +1,485 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+
+	"leafnote/internal/model"
+)
+
+// MaxTemplateNameLength 模板名称的长度上限（按字符计）
+const MaxTemplateNameLength = 128
+
+// templatePlaceholder 匹配 {{名称}}、{{名称:参数}} 和 {{名称:参数|默认值}}
+var templatePlaceholder = regexp.MustCompile(`\{\{\s*([a-z]+)\s*(?::([^{}|]*))?(?:\|([^{}]*))?\}\}`)
+
+// TemplateService 笔记模板服务
+type TemplateService struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+// NewTemplateService 创建模板服务实例
+func NewTemplateService(db *gorm.DB, logger *zap.Logger) *TemplateService {
+	return &TemplateService{db: db, logger: logger}
+}
+
+// CreateFromTemplateInput 从模板创建笔记的输入参数，为空的字段使用模板中的值
+type CreateFromTemplateInput struct {
+	TemplateID string
+	Title      string
+	FilePath   string // 为空时按目录路径和标题生成，重名时追加序号
+	CategoryID *string
+	TagIDs     []string
+	Variables  map[string]string // {{prompt:名称}} 的取值
+	Now        time.Time         // {{date}}、{{time}} 使用的时间，零值表示当前时间
+}
+
+// ListTemplates 获取模板列表，按名称排序
+func (s *TemplateService) ListTemplates(ctx context.Context) ([]model.Template, error) {
+	var templates []model.Template
+	if err := s.db.WithContext(ctx).Order("name").Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	for i := range templates {
+		templates[i].Prompts = templatePrompts(&templates[i])
+	}
+	return templates, nil
+}
+
+// GetTemplate 获取模板
+func (s *TemplateService) GetTemplate(ctx context.Context, id string) (*model.Template, error) {
+	var tpl model.Template
+	if err := s.db.WithContext(ctx).First(&tpl, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTemplateNotFound
+		}
+		return nil, err
+	}
+	tpl.Prompts = templatePrompts(&tpl)
+	return &tpl, nil
+}
+
+// GetTemplateByName 按名称获取模板
+func (s *TemplateService) GetTemplateByName(ctx context.Context, name string) (*model.Template, error) {
+	var tpl model.Template
+	if err := s.db.WithContext(ctx).First(&tpl, "name = ?", name).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTemplateNotFound
+		}
+		return nil, err
+	}
+	tpl.Prompts = templatePrompts(&tpl)
+	return &tpl, nil
+}
+
+// CreateTemplate 创建模板
+func (s *TemplateService) CreateTemplate(ctx context.Context, tpl *model.Template) error {
+	if err := s.validateTemplate(ctx, tpl); err != nil {
+		return err
+	}
+	tpl.ID = ""
+	if err := s.db.WithContext(ctx).Create(tpl).Error; err != nil {
+		return err
+	}
+	tpl.Prompts = templatePrompts(tpl)
+	return nil
+}
+
+// UpdateTemplate 更新模板，所有字段整体替换
+func (s *TemplateService) UpdateTemplate(ctx context.Context, tpl *model.Template) error {
+	if _, err := s.GetTemplate(ctx, tpl.ID); err != nil {
+		return err
+	}
+	if err := s.validateTemplate(ctx, tpl); err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Model(&model.Template{}).Where("id = ?", tpl.ID).Updates(map[string]interface{}{
+		"name":        tpl.Name,
+		"description": tpl.Description,
+		"title":       tpl.Title,
+		"content":     tpl.Content,
+		"yaml_meta":   tpl.YAMLMeta,
+		"category_id": tpl.CategoryID,
+	}).Error
+}
+
+// DeleteTemplate 删除模板；模板名称唯一，因此直接物理删除，以便重新创建同名模板
+func (s *TemplateService) DeleteTemplate(ctx context.Context, id string) error {
+	result := s.db.WithContext(ctx).Unscoped().Delete(&model.Template{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTemplateNotFound
+	}
+	return nil
+}
+
+// validateTemplate 校验模板字段，并检查名称是否与其他模板重复
+func (s *TemplateService) validateTemplate(ctx context.Context, tpl *model.Template) error {
+	tpl.Name = strings.TrimSpace(tpl.Name)
+	if tpl.CategoryID != nil && *tpl.CategoryID == "" {
+		tpl.CategoryID = nil
+	}
+
+	var errs fieldErrors
+	switch {
+	case tpl.Name == "":
+		errs.add("name", FieldRequired, nil)
+	case utf8.RuneCountInString(tpl.Name) > MaxTemplateNameLength:
+		errs.addIf(tooLong("name", MaxTemplateNameLength))
+	default:
+		errs.addIf(invalidChars("name", tpl.Name, ""))
+	}
+	errs.addIf(validateTitle("title", tpl.Title, false))
+	fe, err := validateCategoryRef(s.db, "category_id", tpl.CategoryID)
+	if err != nil {
+		return err
+	}
+	errs.addIf(fe)
+	// 用示例值渲染一次，确保替换占位符后是合法的 YAML
+	sample := &templateContext{now: time.Now(), title: tpl.Name, variables: map[string]string{}}
+	for _, p := range templatePrompts(tpl) {
+		sample.variables[p.Name] = p.Name
+	}
+	errs.addIf(validateYAMLMeta("yaml_meta", sample.renderYAML(tpl.YAMLMeta)))
+	if err := errs.err(); err != nil {
+		return err
+	}
+
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&model.Template{}).
+		Where("name = ? AND id <> ?", tpl.Name, tpl.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrTemplateNameExists
+	}
+	return nil
+}
+
+// CreateNote 渲染模板并创建笔记；未提供且没有默认值的变量返回字段错误 variables.<名称>
+func (s *TemplateService) CreateNote(ctx context.Context, input CreateFromTemplateInput) (*model.Note, error) {
+	tpl, err := s.GetTemplate(ctx, input.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+
+	categoryID := input.CategoryID
+	if categoryID == nil {
+		categoryID = tpl.CategoryID
+	}
+	if categoryID != nil && *categoryID == "" {
+		categoryID = nil
+	}
+	var category model.Category
+	if categoryID != nil {
+		if err := s.db.WithContext(ctx).First(&category, "id = ?", *categoryID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, newValidationError([]FieldError{{Field: "category_id", Code: FieldNotFound, Params: map[string]string{"value": *categoryID}}})
+			}
+			return nil, err
+		}
+	}
+
+	tc := &templateContext{now: input.Now, category: category.Name, variables: input.Variables}
+	if tc.now.IsZero() {
+		tc.now = time.Now()
+	}
+	// 未指定标题时渲染模板的标题，其中的 {{title}} 为模板名称
+	title := strings.TrimSpace(input.Title)
+	if title == "" {
+		tc.title = tpl.Name
+		title = strings.TrimSpace(tc.render(tpl.Title))
+		if title == "" {
+			title = tpl.Name
+		}
+	}
+	tc.title = title
+
+	content := tc.render(tpl.Content)
+	yamlMeta := tc.renderYAML(tpl.YAMLMeta)
+	var errs fieldErrors
+	for _, name := range uniqueStrings(tc.missing) {
+		errs.add("variables."+name, FieldRequired, nil)
+	}
+	errs.addIf(validateYAMLMeta("yaml_meta", yamlMeta))
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+
+	notes := NewNoteService(s.db.WithContext(ctx), s.logger)
+	filePath := input.FilePath
+	if strings.TrimSpace(filePath) == "" {
+		filePath = notes.generateUniqueFilePath(category.Path + "/" + safeFileName(title) + ".md")
+	}
+	return notes.CreateNote(CreateNoteInput{
+		Title:      title,
+		Content:    content,
+		YAMLMeta:   yamlMeta,
+		FilePath:   filePath,
+		CategoryID: categoryID,
+		TagIDs:     input.TagIDs,
+	})
+}
+
+// templatePrompts 按出现顺序收集模板标题、元数据和正文中的 {{prompt:名称|默认值}}
+func templatePrompts(tpl *model.Template) []model.TemplatePrompt {
+	prompts := []model.TemplatePrompt{}
+	seen := make(map[string]bool)
+	for _, text := range []string{tpl.Title, tpl.YAMLMeta, tpl.Content} {
+		for _, m := range templatePlaceholder.FindAllStringSubmatchIndex(text, -1) {
+			if text[m[2]:m[3]] != "prompt" || m[4] < 0 {
+				continue
+			}
+			name := strings.TrimSpace(text[m[4]:m[5]])
+			if name == "" || seen[name] {
+				continue
+			}
+			seen[name] = true
+			p := model.TemplatePrompt{Name: name, Required: m[6] < 0}
+			if m[6] >= 0 {
+				p.Default = text[m[6]:m[7]]
+			}
+			prompts = append(prompts, p)
+		}
+	}
+	return prompts
+}
+
+// templateContext 渲染模板时的变量
+type templateContext struct {
+	now       time.Time
+	title     string
+	category  string
+	variables map[string]string
+	missing   []string // 未提供且没有默认值的 prompt 变量
+}
+
+// value 返回占位符的值，未知的占位符 ok 为 false，原样保留
+func (c *templateContext) value(name, arg string, def *string) (v string, ok bool) {
+	switch name {
+	case "date":
+		v = formatTemplateTime(c.now, strings.TrimSpace(arg), "YYYY-MM-DD")
+	case "time":
+		v = formatTemplateTime(c.now, strings.TrimSpace(arg), "HH:mm")
+	case "title":
+		v = c.title
+	case "category":
+		v = c.category
+	case "prompt":
+		key := strings.TrimSpace(arg)
+		if key == "" {
+			return "", false
+		}
+		if pv, found := c.variables[key]; found {
+			return pv, true
+		}
+		if def == nil {
+			c.missing = append(c.missing, key)
+		}
+	default:
+		return "", false
+	}
+	if v == "" && def != nil {
+		v = *def
+	}
+	return v, true
+}
+
+// render 替换文本中的占位符
+func (c *templateContext) render(text string) string {
+	return c.replace(text, func(_ int, v string) string { return v })
+}
+
+// renderYAML 替换 YAML 中的占位符，按占位符所在的标量类型转义，避免变量值破坏 YAML 结构
+func (c *templateContext) renderYAML(text string) string {
+	return c.replace(text, func(start int, v string) string {
+		lineStart := strings.LastIndexByte(text[:start], '\n') + 1
+		return quoteYAMLValue(text[lineStart:start], v)
+	})
+}
+
+// replace 依次替换占位符，escape 接收占位符在 text 中的位置和变量值
+func (c *templateContext) replace(text string, escape func(start int, v string) string) string {
+	matches := templatePlaceholder.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		var arg string
+		var def *string
+		if m[4] >= 0 {
+			arg = text[m[4]:m[5]]
+		}
+		if m[6] >= 0 {
+			d := text[m[6]:m[7]]
+			def = &d
+		}
+		v, ok := c.value(text[m[2]:m[3]], arg, def)
+		if !ok {
+			continue
+		}
+		b.WriteString(text[last:m[0]])
+		b.WriteString(escape(m[0], v))
+		last = m[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// quoteYAMLValue 按占位符之前的同行内容判断所在标量的类型：
+// 双引号内转义为 JSON 字符串内容，单引号内将单引号写两次，
+// 位于普通标量开头且原样写入会改变含义时（例如包含 ": "）加双引号
+func quoteYAMLValue(prefix, v string) string {
+	scalar := strings.TrimLeft(prefix, " \t")
+	for strings.HasPrefix(scalar, "- ") {
+		scalar = strings.TrimLeft(scalar[2:], " \t")
+	}
+	if i := strings.Index(scalar, ": "); i >= 0 {
+		scalar = strings.TrimLeft(scalar[i+2:], " \t")
+	}
+
+	quoted, _ := json.Marshal(v)
+	switch {
+	case strings.HasPrefix(scalar, `"`):
+		return string(quoted[1 : len(quoted)-1])
+	case strings.HasPrefix(scalar, "'"):
+		if strings.ContainsAny(v, "\r\n") {
+			return v
+		}
+		return strings.ReplaceAll(v, "'", "''")
+	case scalar == "" && v != "" && !plainYAMLScalar(v):
+		return string(quoted)
+	}
+	return v
+}
+
+// plainYAMLScalar 判断 v 作为普通标量写入时是否保持原样
+func plainYAMLScalar(v string) bool {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte("k: "+v), &doc); err != nil || len(doc.Content) == 0 {
+		return false
+	}
+	m := doc.Content[0]
+	return m.Kind == yaml.MappingNode && len(m.Content) == 2 &&
+		m.Content[1].Kind == yaml.ScalarNode && m.Content[1].Style == 0 && m.Content[1].Value == v
+}
+
+// validateYAMLMeta 检查元数据是否为合法的 YAML
+func validateYAMLMeta(field, text string) *FieldError {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(text), &doc); err != nil {
+		return &FieldError{Field: field, Code: FieldInvalidYAML}
+	}
+	return nil
+}
+
+// dateToken 日期格式中的记号
+type dateToken struct {
+	token   string
+	pattern string // 解析路径时匹配的正则
+	format  func(t time.Time) string
+}
+
+// dateTokens 日期格式中的记号，按长度从长到短匹配
+var dateTokens = []dateToken{
+	{"YYYY", `\d{4}`, func(t time.Time) string { return strconv.Itoa(t.Year()) }},
+	{"GGGG", `\d{4}`, func(t time.Time) string { y, _ := t.ISOWeek(); return strconv.Itoa(y) }},
+	{"YY", `\d{2}`, func(t time.Time) string { return t.Format("06") }},
+	{"MM", `\d{2}`, func(t time.Time) string { return t.Format("01") }},
+	{"DD", `\d{2}`, func(t time.Time) string { return t.Format("02") }},
+	{"HH", `\d{2}`, func(t time.Time) string { return t.Format("15") }},
+	{"mm", `\d{2}`, func(t time.Time) string { return t.Format("04") }},
+	{"ss", `\d{2}`, func(t time.Time) string { return t.Format("05") }},
+	{"WW", `\d{2}`, func(t time.Time) string { _, w := t.ISOWeek(); return twoDigits(w) }},
+	{"M", `\d{1,2}`, func(t time.Time) string { return strconv.Itoa(int(t.Month())) }},
+	{"D", `\d{1,2}`, func(t time.Time) string { return strconv.Itoa(t.Day()) }},
+	{"H", `\d{1,2}`, func(t time.Time) string { return strconv.Itoa(t.Hour()) }},
+	{"W", `\d{1,2}`, func(t time.Time) string { _, w := t.ISOWeek(); return strconv.Itoa(w) }},
+	{"Q", `\d`, func(t time.Time) string { return strconv.Itoa((int(t.Month())-1)/3 + 1) }},
+}
+
+// dateLayoutPart 日期格式中的一段，token 为空时是原样输出的文字
+type dateLayoutPart struct {
+	literal string
+	token   *dateToken
+}
+
+// splitDateLayout 将 YYYY-MM-DD 风格的格式拆分为文字和记号，[] 中的文字原样输出
+func splitDateLayout(layout string) []dateLayoutPart {
+	var parts []dateLayoutPart
+	literal := func(s string) {
+		if n := len(parts); n > 0 && parts[n-1].token == nil {
+			parts[n-1].literal += s
+			return
+		}
+		parts = append(parts, dateLayoutPart{literal: s})
+	}
+	for i := 0; i < len(layout); {
+		if layout[i] == '[' {
+			if end := strings.IndexByte(layout[i:], ']'); end > 0 {
+				literal(layout[i+1 : i+end])
+				i += end + 1
+				continue
+			}
+		}
+		var matched *dateToken
+		for j := range dateTokens {
+			if strings.HasPrefix(layout[i:], dateTokens[j].token) {
+				matched = &dateTokens[j]
+				break
+			}
+		}
+		if matched != nil {
+			parts = append(parts, dateLayoutPart{token: matched})
+			i += len(matched.token)
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(layout[i:])
+		literal(layout[i : i+size])
+		i += size
+	}
+	return parts
+}
+
+// formatTemplateTime 按 YYYY-MM-DD 风格的格式格式化时间，[] 中的文字原样输出；
+// GGGG、WW 为 ISO 周所在的年份和周数，Q 为季度
+func formatTemplateTime(t time.Time, layout, fallback string) string {
+	if layout == "" {
+		layout = fallback
+	}
+	var b strings.Builder
+	for _, part := range splitDateLayout(layout) {
+		if part.token != nil {
+			b.WriteString(part.token.format(t))
+		} else {
+			b.WriteString(part.literal)
+		}
+	}
+	return b.String()
+}
+
+// twoDigits 不足两位时补零
+func twoDigits(n int) string {
+	if n < 10 {
+		return "0" + strconv.Itoa(n)
+	}
+	return strconv.Itoa(n)
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	"leafnote/internal/model"
+	"leafnote/internal/testutil"
+)
+
+func TestFormatTemplateTime(t *testing.T) {
+	// 2027-01-01 是星期五，属于 2026 年第 53 周
+	now := time.Date(2027, 1, 1, 9, 5, 7, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		layout string
+		want   string
+	}{
+		{name: "默认格式", layout: "", want: "2027-01-01"},
+		{name: "中文日期", layout: "YYYY年M月D日", want: "2027年1月1日"},
+		{name: "时间", layout: "HH:mm:ss", want: "09:05:07"},
+		{name: "ISO 周", layout: "GGGG-[W]WW", want: "2026-W53"},
+		{name: "季度", layout: "YY[Q]Q", want: "27Q1"},
+		{name: "未闭合的括号", layout: "[YYYY", want: "[2027"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, formatTemplateTime(now, tt.layout, "YYYY-MM-DD"))
+		})
+	}
+}
+
+func TestQuoteYAMLValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		value  string
+		want   string
+	}{
+		{name: "普通值原样写入", prefix: "date: ", value: "2026-10-18", want: "2026-10-18"},
+		{name: "包含冒号时加引号", prefix: "title: ", value: "周会: 计划", want: `"周会: 计划"`},
+		{name: "以井号开头时加引号", prefix: "- ", value: "#标签", want: `"#标签"`},
+		{name: "双引号内转义", prefix: `title: "会议 `, value: `"A" 组`, want: `\"A\" 组`},
+		{name: "单引号内转义", prefix: "title: '", value: "it's", want: "it''s"},
+		{name: "标量中间不加引号", prefix: "title: 周报 ", value: "第 1 周", want: "第 1 周"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, quoteYAMLValue(tt.prefix, tt.value))
+		})
+	}
+}
+
+func TestTemplateService_CreateTemplate(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	s := NewTemplateService(db, zap.NewNop())
+	ctx := context.Background()
+
+	tpl := &model.Template{
+		Name:     "会议纪要",
+		Title:    "{{date}} {{prompt:主题}}",
+		YAMLMeta: "attendees: {{prompt:参会人|全员}}",
+		Content:  "# {{title}}\n\n主题：{{prompt:主题}}",
+	}
+	require.NoError(t, s.CreateTemplate(ctx, tpl))
+	assert.NotEmpty(t, tpl.ID)
+	assert.Equal(t, []model.TemplatePrompt{
+		{Name: "主题", Required: true},
+		{Name: "参会人", Default: "全员"},
+	}, tpl.Prompts)
+
+	tests := []struct {
+		name      string
+		tpl       *model.Template
+		wantErr   error
+		wantField string
+	}{
+		{name: "名称为空", tpl: &model.Template{Name: " "}, wantErr: ErrValidationFailed, wantField: "name"},
+		{name: "名称重复", tpl: &model.Template{Name: "会议纪要"}, wantErr: ErrTemplateNameExists},
+		{name: "目录不存在", tpl: &model.Template{Name: "日记", CategoryID: testutil.StringPtr("not-exist")}, wantErr: ErrValidationFailed, wantField: "category_id"},
+		{name: "YAML 不合法", tpl: &model.Template{Name: "日记", YAMLMeta: "tags: [a"}, wantErr: ErrValidationFailed, wantField: "yaml_meta"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.CreateTemplate(ctx, tt.tpl)
+			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.wantField != "" {
+				e, ok := AsError(err)
+				require.True(t, ok)
+				require.Len(t, e.Fields, 1)
+				assert.Equal(t, tt.wantField, e.Fields[0].Field)
+			}
+		})
+	}
+
+	// 删除后可以重新创建同名模板
+	require.NoError(t, s.DeleteTemplate(ctx, tpl.ID))
+	assert.ErrorIs(t, s.DeleteTemplate(ctx, tpl.ID), ErrTemplateNotFound)
+	require.NoError(t, s.CreateTemplate(ctx, &model.Template{Name: "会议纪要"}))
+}
+
+func TestTemplateService_CreateNote(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	s := NewTemplateService(db, zap.NewNop())
+	ctx := context.Background()
+
+	category := &model.Category{Name: "会议"}
+	require.NoError(t, db.Create(category).Error)
+	tpl := &model.Template{
+		Name:       "会议纪要",
+		Title:      "{{date:YYYYMMDD}} {{prompt:主题}}",
+		YAMLMeta:   "title: {{title}}\ndate: {{date}}\ncategory: {{category}}\nattendees: {{prompt:参会人|全员}}",
+		Content:    "# {{title}}\n\n时间：{{date}} {{time}}\n{{unknown}}",
+		CategoryID: &category.ID,
+	}
+	require.NoError(t, s.CreateTemplate(ctx, tpl))
+	now := time.Date(2026, 10, 18, 14, 30, 0, 0, time.Local)
+
+	t.Run("使用模板的标题和目录", func(t *testing.T) {
+		note, err := s.CreateNote(ctx, CreateFromTemplateInput{
+			TemplateID: tpl.ID,
+			Variables:  map[string]string{"主题": "发布: 复盘"},
+			Now:        now,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "20261018 发布: 复盘", note.Title)
+		assert.Equal(t, "/会议/20261018 发布- 复盘.md", note.FilePath)
+		assert.Equal(t, category.ID, *note.CategoryID)
+		assert.Equal(t, "# 20261018 发布: 复盘\n\n时间：2026-10-18 14:30\n{{unknown}}", note.Content)
+
+		var meta map[string]string
+		require.NoError(t, yaml.Unmarshal([]byte(note.YAMLMeta), &meta))
+		assert.Equal(t, map[string]string{
+			"title":     "20261018 发布: 复盘",
+			"date":      "2026-10-18",
+			"category":  "会议",
+			"attendees": "全员",
+		}, meta)
+
+		// 同名笔记追加序号
+		again, err := s.CreateNote(ctx, CreateFromTemplateInput{
+			TemplateID: tpl.ID,
+			Variables:  map[string]string{"主题": "发布: 复盘"},
+			Now:        now,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "/会议/20261018 发布- 复盘_1.md", again.FilePath)
+	})
+
+	t.Run("指定标题、路径和目录", func(t *testing.T) {
+		note, err := s.CreateNote(ctx, CreateFromTemplateInput{
+			TemplateID: tpl.ID,
+			Title:      "周会",
+			FilePath:   "/周会.md",
+			CategoryID: testutil.StringPtr(""),
+			Variables:  map[string]string{"主题": "进度", "参会人": "张三"},
+			Now:        now,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "周会", note.Title)
+		assert.Equal(t, "/周会.md", note.FilePath)
+		assert.Nil(t, note.CategoryID)
+		assert.Contains(t, note.YAMLMeta, "attendees: 张三")
+		assert.Contains(t, note.YAMLMeta, "category: \n")
+	})
+
+	t.Run("缺少变量", func(t *testing.T) {
+		_, err := s.CreateNote(ctx, CreateFromTemplateInput{TemplateID: tpl.ID})
+		assert.ErrorIs(t, err, ErrValidationFailed)
+		e, ok := AsError(err)
+		require.True(t, ok)
+		require.Len(t, e.Fields, 1)
+		assert.Equal(t, "variables.主题", e.Fields[0].Field)
+		assert.Equal(t, FieldRequired, e.Fields[0].Code)
+	})
+
+	t.Run("模板不存在", func(t *testing.T) {
+		_, err := s.CreateNote(ctx, CreateFromTemplateInput{TemplateID: "not-exist"})
+		assert.ErrorIs(t, err, ErrTemplateNotFound)
+	})
+}
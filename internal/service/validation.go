@@ -0,0 +1,281 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+
+	"leafnote/internal/model"
+)
+
+// 长度限制（按字符计）
+const (
+	MaxTitleLength        = 255  // 笔记标题
+	MaxFilePathLength     = 1024 // 笔记文件路径
+	MaxPathSegmentLength  = 255  // 路径中的单个文件或目录名
+	MaxTagNameLength      = 64   // 标签名称
+	MaxCategoryNameLength = 128  // 目录名称
+)
+
+// 字段级校验错误码
+const (
+	FieldRequired         = "REQUIRED"          // 必填
+	FieldTooLong          = "TOO_LONG"          // 超出长度限制
+	FieldInvalidChars     = "INVALID_CHARS"     // 包含非法字符
+	FieldControlChars     = "CONTROL_CHARS"     // 包含控制字符
+	FieldWhitespace       = "WHITESPACE"        // 包含空白字符
+	FieldReservedName     = "RESERVED_NAME"     // 系统保留名称
+	FieldInvalidName      = "INVALID_NAME"      // 名称为 . 或以点、空格结尾
+	FieldPathTraversal    = "PATH_TRAVERSAL"    // 包含 .. 路径
+	FieldAbsolutePath     = "ABSOLUTE_PATH"     // 系统绝对路径
+	FieldInvalidExtension = "INVALID_EXTENSION" // 扩展名不是 .md
+	FieldNotFound         = "NOT_FOUND"         // 引用的记录不存在
+	FieldInvalidYAML      = "INVALID_YAML"      // 不是合法的 YAML
+	FieldInvalidURL       = "INVALID_URL"       // 不是 http 或 https 地址
+	FieldUnsupported      = "UNSUPPORTED"       // 包含不支持的取值
+	FieldInvalid          = "INVALID"           // 取值不合法，原因见 value
+	FieldRejected         = "REJECTED"          // 被插件拒绝，原因见 value
+)
+
+// fileNameInvalidChars 文件和目录名中禁止出现的字符，取 Windows、macOS、Linux 的并集
+const fileNameInvalidChars = `<>:"/\|?*`
+
+// tagNameInvalidChars 标签名中禁止出现的字符，保证可以写入 YAML 和行内 #标签
+const tagNameInvalidChars = `#/\,`
+
+// reservedFileNames Windows 保留的设备名，不区分大小写和扩展名
+var reservedFileNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// FieldError 字段级校验错误
+type FieldError struct {
+	Field  string            // 字段名（与 JSON 字段一致）
+	Code   string            // 错误码，例如 TOO_LONG
+	Params map[string]string // 错误信息中的参数，例如 max
+}
+
+// fieldErrors 收集多个字段的校验错误
+type fieldErrors []FieldError
+
+// add 记录一个字段错误
+func (f *fieldErrors) add(field, code string, params map[string]string) {
+	*f = append(*f, FieldError{Field: field, Code: code, Params: params})
+}
+
+// addIf 在 fe 不为空时记录字段错误
+func (f *fieldErrors) addIf(fe *FieldError) {
+	if fe != nil {
+		*f = append(*f, *fe)
+	}
+}
+
+// err 没有错误时返回 nil，否则返回携带全部字段错误的校验错误
+func (f fieldErrors) err() error {
+	if len(f) == 0 {
+		return nil
+	}
+	return newValidationError(f)
+}
+
+// NormalizeFilePath 校验笔记文件路径，并规范化为以 / 开头、相对于笔记库根目录的路径
+func NormalizeFilePath(field, p string) (string, *FieldError) {
+	p = strings.TrimSpace(p)
+	if p == "" {
+		return "", &FieldError{Field: field, Code: FieldRequired}
+	}
+	if utf8.RuneCountInString(p) > MaxFilePathLength {
+		return "", tooLong(field, MaxFilePathLength)
+	}
+
+	// 拒绝 Windows 盘符路径（C:\、C:/）和 UNC 路径（\\server、//server）
+	if strings.HasPrefix(p, `\\`) || strings.HasPrefix(p, "//") ||
+		(len(p) >= 2 && p[1] == ':' && unicode.IsLetter(rune(p[0]))) {
+		return "", &FieldError{Field: field, Code: FieldAbsolutePath}
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(p, "/") {
+		switch segment {
+		case "", ".":
+			continue
+		case "..":
+			return "", &FieldError{Field: field, Code: FieldPathTraversal}
+		}
+		if fe := validateFileName(field, segment, MaxPathSegmentLength); fe != nil {
+			return "", fe
+		}
+		segments = append(segments, segment)
+	}
+	if len(segments) == 0 {
+		return "", &FieldError{Field: field, Code: FieldRequired}
+	}
+
+	name := segments[len(segments)-1]
+	if !strings.EqualFold(pathExt(name), ".md") || len(name) == len(".md") {
+		return "", &FieldError{Field: field, Code: FieldInvalidExtension, Params: map[string]string{"ext": ".md"}}
+	}
+
+	return "/" + strings.Join(segments, "/"), nil
+}
+
+// validateFileName 校验单个文件或目录名在各平台文件系统上都合法
+func validateFileName(field, name string, max int) *FieldError {
+	if name == "" {
+		return &FieldError{Field: field, Code: FieldRequired}
+	}
+	if utf8.RuneCountInString(name) > max {
+		return tooLong(field, max)
+	}
+	if fe := invalidChars(field, name, fileNameInvalidChars); fe != nil {
+		return fe
+	}
+	if name == "." || name == ".." || strings.HasSuffix(name, ".") || strings.HasSuffix(name, " ") {
+		return &FieldError{Field: field, Code: FieldInvalidName}
+	}
+	base := strings.ToUpper(strings.SplitN(name, ".", 2)[0])
+	if reservedFileNames[base] {
+		return &FieldError{Field: field, Code: FieldReservedName}
+	}
+	return nil
+}
+
+// validateCategoryName 校验目录名称，目录名会作为导出时的文件夹名
+func validateCategoryName(field, name string) *FieldError {
+	return validateFileName(field, name, MaxCategoryNameLength)
+}
+
+// validateTagName 校验标签名称
+func validateTagName(field, name string) *FieldError {
+	if name == "" {
+		return &FieldError{Field: field, Code: FieldRequired}
+	}
+	if utf8.RuneCountInString(name) > MaxTagNameLength {
+		return tooLong(field, MaxTagNameLength)
+	}
+	if strings.IndexFunc(name, unicode.IsSpace) >= 0 {
+		return &FieldError{Field: field, Code: FieldWhitespace}
+	}
+	return invalidChars(field, name, tagNameInvalidChars)
+}
+
+// validateTitle 校验笔记标题，required 为 false 时允许为空（更新时表示不修改）
+func validateTitle(field, title string, required bool) *FieldError {
+	if strings.TrimSpace(title) == "" {
+		if required {
+			return &FieldError{Field: field, Code: FieldRequired}
+		}
+		return nil
+	}
+	if utf8.RuneCountInString(title) > MaxTitleLength {
+		return tooLong(field, MaxTitleLength)
+	}
+	if strings.IndexFunc(title, unicode.IsControl) >= 0 {
+		return &FieldError{Field: field, Code: FieldControlChars}
+	}
+	return nil
+}
+
+// invalidChars 检查 name 中是否包含控制字符或 chars 中的字符
+func invalidChars(field, name, chars string) *FieldError {
+	var found []string
+	for _, r := range name {
+		if unicode.IsControl(r) {
+			return &FieldError{Field: field, Code: FieldControlChars}
+		}
+		if strings.ContainsRune(chars, r) && !contains(found, string(r)) {
+			found = append(found, string(r))
+		}
+	}
+	if len(found) > 0 {
+		return &FieldError{Field: field, Code: FieldInvalidChars, Params: map[string]string{"chars": strings.Join(found, " ")}}
+	}
+	return nil
+}
+
+// validateCategoryRef 检查引用的目录是否存在
+func validateCategoryRef(db *gorm.DB, field string, categoryID *string) (*FieldError, error) {
+	if categoryID == nil || *categoryID == "" {
+		return nil, nil
+	}
+	var count int64
+	if err := db.Model(&model.Category{}).Where("id = ?", *categoryID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return &FieldError{Field: field, Code: FieldNotFound, Params: map[string]string{"value": *categoryID}}, nil
+	}
+	return nil, nil
+}
+
+// loadTags 按ID加载标签，任何一个ID不存在时返回字段错误
+func loadTags(db *gorm.DB, field string, tagIDs []string) ([]model.Tag, *FieldError, error) {
+	ids := uniqueStrings(tagIDs)
+	if len(ids) == 0 {
+		return nil, nil, nil
+	}
+
+	var tags []model.Tag
+	if err := db.Find(&tags, "id IN ?", ids).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(tags) == len(ids) {
+		return tags, nil, nil
+	}
+
+	found := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		found[tag.ID] = true
+	}
+	var missing []string
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	return nil, &FieldError{Field: field, Code: FieldNotFound, Params: map[string]string{"value": strings.Join(missing, ", ")}}, nil
+}
+
+// tooLong 构造超长错误
+func tooLong(field string, max int) *FieldError {
+	return &FieldError{Field: field, Code: FieldTooLong, Params: map[string]string{"max": strconv.Itoa(max)}}
+}
+
+// pathExt 返回文件扩展名（包含点）
+func pathExt(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[i:]
+	}
+	return ""
+}
+
+// uniqueStrings 去除空字符串和重复项，保持原有顺序
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		result = append(result, v)
+	}
+	return result
+}
+
+// contains 判断切片中是否包含指定字符串
+func contains(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
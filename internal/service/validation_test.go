@@ -0,0 +1,84 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeFilePath(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		want     string
+		wantCode string
+	}{
+		{name: "以斜杠开头", path: "/test/note.md", want: "/test/note.md"},
+		{name: "相对路径", path: "test/note.md", want: "/test/note.md"},
+		{name: "规范化多余分隔符", path: "./test//sub/./note.md", want: "/test/sub/note.md"},
+		{name: "大写扩展名", path: "note.MD", want: "/note.MD"},
+		{name: "空路径", path: "  ", wantCode: FieldRequired},
+		{name: "只有目录", path: "/", wantCode: FieldRequired},
+		{name: "路径穿越", path: "../secret.md", wantCode: FieldPathTraversal},
+		{name: "中间路径穿越", path: "a/../../secret.md", wantCode: FieldPathTraversal},
+		{name: "Windows 盘符", path: `C:\notes\a.md`, wantCode: FieldAbsolutePath},
+		{name: "UNC 路径", path: "//server/share/a.md", wantCode: FieldAbsolutePath},
+		{name: "反斜杠", path: `a\b.md`, wantCode: FieldInvalidChars},
+		{name: "非法字符", path: "a/b?.md", wantCode: FieldInvalidChars},
+		{name: "控制字符", path: "a/b\x00.md", wantCode: FieldControlChars},
+		{name: "保留名称", path: "con.md", wantCode: FieldReservedName},
+		{name: "以空格结尾的目录", path: "dir /a.md", wantCode: FieldInvalidName},
+		{name: "非 Markdown 文件", path: "a/b.txt", wantCode: FieldInvalidExtension},
+		{name: "只有扩展名", path: "a/.md", wantCode: FieldInvalidExtension},
+		{name: "文件名过长", path: strings.Repeat("a", MaxPathSegmentLength) + ".md", wantCode: FieldTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, fe := NormalizeFilePath("file_path", tt.path)
+			if tt.wantCode != "" {
+				if assert.NotNil(t, fe) {
+					assert.Equal(t, tt.wantCode, fe.Code)
+					assert.Equal(t, "file_path", fe.Field)
+				}
+				return
+			}
+			assert.Nil(t, fe)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidateNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		validate func(field, name string) *FieldError
+		input    string
+		wantCode string
+	}{
+		{name: "目录-正常", validate: validateCategoryName, input: "工作 笔记"},
+		{name: "目录-为空", validate: validateCategoryName, input: "", wantCode: FieldRequired},
+		{name: "目录-斜杠", validate: validateCategoryName, input: "a/b", wantCode: FieldInvalidChars},
+		{name: "目录-点", validate: validateCategoryName, input: "..", wantCode: FieldInvalidName},
+		{name: "目录-保留名称", validate: validateCategoryName, input: "NUL", wantCode: FieldReservedName},
+		{name: "目录-过长", validate: validateCategoryName, input: strings.Repeat("目", MaxCategoryNameLength+1), wantCode: FieldTooLong},
+		{name: "标签-正常", validate: validateTagName, input: "golang"},
+		{name: "标签-空白", validate: validateTagName, input: "a b", wantCode: FieldWhitespace},
+		{name: "标签-井号", validate: validateTagName, input: "#tag", wantCode: FieldInvalidChars},
+		{name: "标签-过长", validate: validateTagName, input: strings.Repeat("t", MaxTagNameLength+1), wantCode: FieldTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fe := tt.validate("name", tt.input)
+			if tt.wantCode == "" {
+				assert.Nil(t, fe)
+				return
+			}
+			if assert.NotNil(t, fe) {
+				assert.Equal(t, tt.wantCode, fe.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,600 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"leafnote/internal/model"
+)
+
+// 投递请求携带的请求头
+const (
+	WebhookEventHeader     = "X-Leafnote-Event"     // 事件类型
+	WebhookDeliveryHeader  = "X-Leafnote-Delivery"  // 投递ID，重试时不变，可用于去重
+	WebhookSignatureHeader = "X-Leafnote-Signature" // sha256=<请求体的 HMAC-SHA256 十六进制值>
+)
+
+// 长度限制（按字符计）
+const (
+	MaxWebhookURLLength    = 2048
+	MaxWebhookSecretLength = 128
+)
+
+// webhookBatchSize 每次查询待投递记录的数量上限
+const webhookBatchSize = 100
+
+// maxWebhookErrorBody 失败时记录的响应体最大字节数
+const maxWebhookErrorBody = 1024
+
+// webhookPruneInterval 清理投递记录的间隔
+const webhookPruneInterval = time.Hour
+
+// WebhookOptions Webhook 投递配置
+type WebhookOptions struct {
+	Timeout     time.Duration // 单次投递的超时时间
+	MaxAttempts int           // 最多尝试的次数，用尽后标记为失败
+	Backoff     time.Duration // 第一次重试前的等待时间，之后每次翻倍
+	MaxBackoff  time.Duration // 重试等待时间的上限
+	Retention   time.Duration // 已结束的投递记录保留时长，0 表示永久保留
+}
+
+// WebhookPayload 投递的请求体
+type WebhookPayload struct {
+	ID        string          `json:"id"`         // 投递ID
+	Event     model.EventType `json:"event"`      // 事件类型
+	Seq       uint64          `json:"seq"`        // 事件序号，与 /api/v1/events 一致
+	CreatedAt time.Time       `json:"created_at"` // 事件发生时间
+	Data      interface{}     `json:"data"`       // 事件涉及的实体，实体已被物理删除时只有 id
+}
+
+// WebhookNote 笔记事件请求体中的笔记信息，不含正文
+type WebhookNote struct {
+	ID           string    `json:"id"`
+	Title        string    `json:"title"`
+	FilePath     string    `json:"file_path"`
+	Version      int       `json:"version"`
+	CategoryID   *string   `json:"category_id"`
+	CategoryPath string    `json:"category_path,omitempty"`
+	Tags         []string  `json:"tags"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// WebhookService Webhook 订阅和投递服务：事件写入时在同一事务中为匹配的订阅创建投递，
+// 后台任务按时间投递，失败时按指数退避重试
+type WebhookService struct {
+	db     *gorm.DB
+	logger *zap.Logger
+	opts   WebhookOptions
+	client *http.Client
+}
+
+// NewWebhookService 创建 Webhook 服务实例
+func NewWebhookService(db *gorm.DB, logger *zap.Logger, opts WebhookOptions) *WebhookService {
+	return &WebhookService{
+		db:     db,
+		logger: logger,
+		opts:   opts,
+		client: &http.Client{Timeout: opts.Timeout},
+	}
+}
+
+// WebhookInput 创建或更新 Webhook 的输入参数
+type WebhookInput struct {
+	URL        string
+	Secret     string            // 为空时创建时自动生成，更新时保持不变
+	Events     []model.EventType // 为空表示全部事件
+	CategoryID *string           // 为空表示不按目录过滤
+	TagID      *string           // 为空表示不按标签过滤
+	Enabled    *bool             // 为空时创建为启用，更新时保持不变
+}
+
+// ListWebhooks 获取 Webhook 列表，不含签名密钥
+func (s *WebhookService) ListWebhooks(ctx context.Context) ([]model.Webhook, error) {
+	var hooks []model.Webhook
+	if err := s.db.WithContext(ctx).Order("created_at").Find(&hooks).Error; err != nil {
+		return nil, err
+	}
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+	return hooks, nil
+}
+
+// GetWebhook 获取 Webhook，不含签名密钥
+func (s *WebhookService) GetWebhook(ctx context.Context, id string) (*model.Webhook, error) {
+	hook, err := s.find(s.db.WithContext(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+	hook.Secret = ""
+	return hook, nil
+}
+
+// find 按ID查找 Webhook
+func (s *WebhookService) find(db *gorm.DB, id string) (*model.Webhook, error) {
+	var hook model.Webhook
+	if err := db.First(&hook, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return &hook, nil
+}
+
+// CreateWebhook 创建 Webhook，返回值包含签名密钥，之后不再返回
+func (s *WebhookService) CreateWebhook(ctx context.Context, input WebhookInput) (*model.Webhook, error) {
+	db := s.db.WithContext(ctx)
+	if err := validateWebhookInput(db, &input); err != nil {
+		return nil, err
+	}
+	if input.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		input.Secret = secret
+	}
+	hook := &model.Webhook{
+		URL:        input.URL,
+		Secret:     input.Secret,
+		Events:     input.Events,
+		CategoryID: input.CategoryID,
+		TagID:      input.TagID,
+		Enabled:    input.Enabled == nil || *input.Enabled,
+	}
+	if err := db.Create(hook).Error; err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+// UpdateWebhook 更新 Webhook 的地址和过滤条件，签名密钥和启用状态未指定时保持不变
+func (s *WebhookService) UpdateWebhook(ctx context.Context, id string, input WebhookInput) (*model.Webhook, error) {
+	db := s.db.WithContext(ctx)
+	hook, err := s.find(db, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateWebhookInput(db, &input); err != nil {
+		return nil, err
+	}
+	hook.URL = input.URL
+	hook.Events = input.Events
+	hook.CategoryID = input.CategoryID
+	hook.TagID = input.TagID
+	if input.Secret != "" {
+		hook.Secret = input.Secret
+	}
+	if input.Enabled != nil {
+		hook.Enabled = *input.Enabled
+	}
+	if err := db.Select("url", "secret", "events", "category_id", "tag_id", "enabled").Updates(hook).Error; err != nil {
+		return nil, err
+	}
+	return s.GetWebhook(ctx, id)
+}
+
+// DeleteWebhook 删除 Webhook 及其投递记录
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := s.find(tx, id); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("webhook_id = ?", id).Delete(&model.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&model.Webhook{}, "id = ?", id).Error
+	})
+}
+
+// WebhookDeliveryFilter 投递记录的筛选条件
+type WebhookDeliveryFilter struct {
+	Status model.WebhookDeliveryStatus // 为空表示全部
+	Limit  int                         // 返回的最大数量
+}
+
+// ListDeliveries 获取 Webhook 的投递记录，最新的在前
+func (s *WebhookService) ListDeliveries(ctx context.Context, webhookID string, filter WebhookDeliveryFilter) ([]model.WebhookDelivery, error) {
+	db := s.db.WithContext(ctx)
+	if _, err := s.find(db, webhookID); err != nil {
+		return nil, err
+	}
+	query := db.Where("webhook_id = ?", webhookID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	var deliveries []model.WebhookDelivery
+	err := query.Order("created_at DESC").Order("event_seq DESC").Limit(filter.Limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+// RetryDelivery 立即重新投递，重试次数从零开始计算
+func (s *WebhookService) RetryDelivery(ctx context.Context, webhookID, deliveryID string) (*model.WebhookDelivery, error) {
+	db := s.db.WithContext(ctx)
+	var delivery model.WebhookDelivery
+	if err := db.First(&delivery, "id = ? AND webhook_id = ?", deliveryID, webhookID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		return nil, err
+	}
+	if err := db.Model(&delivery).Updates(map[string]interface{}{
+		"status":          model.WebhookDeliveryPending,
+		"attempts":        0,
+		"next_attempt_at": time.Now(),
+	}).Error; err != nil {
+		return nil, err
+	}
+	if err := db.First(&delivery, "id = ?", deliveryID).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// Schedule 返回按 interval 投递到期记录的后台任务，同时定期清理已结束的投递记录；单次失败只记录日志
+func (s *WebhookService) Schedule(interval time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var pruned time.Time
+		for {
+			if _, err := s.DeliverDue(ctx, time.Now()); err != nil && ctx.Err() == nil {
+				s.logger.Error("Failed to deliver webhooks", zap.Error(err))
+			}
+			if s.opts.Retention > 0 && time.Since(pruned) >= webhookPruneInterval {
+				pruned = time.Now()
+				if _, err := s.Prune(ctx, pruned.Add(-s.opts.Retention)); err != nil && ctx.Err() == nil {
+					s.logger.Error("Failed to prune webhook deliveries", zap.Error(err))
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	}
+}
+
+// DeliverDue 投递 now 之前到期的记录，返回投递成功的数量；停用的 Webhook 的投递保持等待
+func (s *WebhookService) DeliverDue(ctx context.Context, now time.Time) (int, error) {
+	succeeded := 0
+	for {
+		var due []model.WebhookDelivery
+		if err := s.db.WithContext(ctx).
+			Select("webhook_deliveries.*").
+			Joins("JOIN webhooks ON webhooks.id = webhook_deliveries.webhook_id AND webhooks.enabled = ? AND webhooks.deleted_at IS NULL", true).
+			Where("webhook_deliveries.status = ? AND webhook_deliveries.next_attempt_at <= ?", model.WebhookDeliveryPending, now).
+			Order("webhook_deliveries.next_attempt_at").Order("webhook_deliveries.event_seq").
+			Limit(webhookBatchSize).Find(&due).Error; err != nil {
+			return succeeded, err
+		}
+
+		hooks := make(map[string]*model.Webhook)
+		for i := range due {
+			d := &due[i]
+			hook, ok := hooks[d.WebhookID]
+			if !ok {
+				var err error
+				if hook, err = s.find(s.db.WithContext(ctx), d.WebhookID); err != nil {
+					return succeeded, err
+				}
+				hooks[d.WebhookID] = hook
+			}
+			ok, err := s.attempt(ctx, hook, d, now)
+			if err != nil {
+				return succeeded, err
+			}
+			if ok {
+				succeeded++
+			}
+			if ctx.Err() != nil {
+				return succeeded, ctx.Err()
+			}
+		}
+		if len(due) < webhookBatchSize {
+			return succeeded, nil
+		}
+	}
+}
+
+// attempt 投递一次并记录结果，失败时安排下次重试，重试次数用尽后标记为失败
+func (s *WebhookService) attempt(ctx context.Context, hook *model.Webhook, d *model.WebhookDelivery, now time.Time) (bool, error) {
+	status, postErr := s.post(ctx, hook, d)
+	attempts := d.Attempts + 1
+	updates := map[string]interface{}{
+		"attempts":        attempts,
+		"last_attempt_at": now,
+		"response_status": status,
+		"next_attempt_at": nil,
+	}
+	switch {
+	case postErr == nil:
+		updates["status"] = model.WebhookDeliverySucceeded
+		updates["error"] = ""
+	case attempts >= s.opts.MaxAttempts:
+		updates["status"] = model.WebhookDeliveryFailed
+		updates["error"] = postErr.Error()
+	default:
+		updates["next_attempt_at"] = now.Add(s.backoff(attempts))
+		updates["error"] = postErr.Error()
+	}
+	if postErr != nil {
+		s.logger.Warn("Webhook delivery failed",
+			zap.String("webhook_id", hook.ID), zap.String("delivery_id", d.ID),
+			zap.Int("attempts", attempts), zap.Error(postErr))
+	}
+	// 服务停止时仍然记录本次结果
+	if err := s.db.WithContext(context.WithoutCancel(ctx)).Model(d).Updates(updates).Error; err != nil {
+		return false, err
+	}
+	return postErr == nil, nil
+}
+
+// backoff 第 attempts 次失败后的等待时间，从 Backoff 开始每次翻倍，不超过 MaxBackoff
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	d := s.opts.Backoff
+	for i := 1; i < attempts && d < s.opts.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, s.opts.MaxBackoff)
+}
+
+// post 发送投递请求，返回响应状态码；非 2xx 响应视为失败，错误中包含响应体的开头部分
+func (s *WebhookService) post(ctx context.Context, hook *model.Webhook, d *model.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "leafnote")
+	req.Header.Set(WebhookEventHeader, string(d.EventType))
+	req.Header.Set(WebhookDeliveryHeader, d.ID)
+	req.Header.Set(WebhookSignatureHeader, signWebhookPayload(hook.Secret, d.Payload))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookErrorBody))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook 返回状态码 %d: %s", resp.StatusCode, strings.ToValidUTF8(strings.TrimSpace(string(body)), ""))
+	}
+	return resp.StatusCode, nil
+}
+
+// Prune 删除 before 之前结束的投递记录，返回删除的数量
+func (s *WebhookService) Prune(ctx context.Context, before time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Unscoped().
+		Where("status <> ? AND updated_at < ?", model.WebhookDeliveryPending, before).
+		Delete(&model.WebhookDelivery{})
+	return result.RowsAffected, result.Error
+}
+
+// signWebhookPayload 计算请求体的签名，格式为 sha256=<HMAC-SHA256 十六进制值>
+func signWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// newWebhookSecret 生成随机的签名密钥
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// validateWebhookInput 校验并规范化 Webhook 的输入参数
+func validateWebhookInput(db *gorm.DB, input *WebhookInput) error {
+	var errs fieldErrors
+	input.URL = strings.TrimSpace(input.URL)
+	errs.addIf(validateWebhookURL("url", input.URL))
+	if utf8.RuneCountInString(input.Secret) > MaxWebhookSecretLength {
+		errs.addIf(tooLong("secret", MaxWebhookSecretLength))
+	}
+
+	var unsupported []string
+	events := []model.EventType{}
+	for _, e := range input.Events {
+		if !e.Valid() {
+			unsupported = append(unsupported, string(e))
+		} else if !slices.Contains(events, e) {
+			events = append(events, e)
+		}
+	}
+	if len(unsupported) > 0 {
+		errs.add("events", FieldUnsupported, map[string]string{"value": strings.Join(unsupported, ", ")})
+	}
+	input.Events = events
+
+	if input.CategoryID != nil && *input.CategoryID == "" {
+		input.CategoryID = nil
+	}
+	fe, err := validateCategoryRef(db, "category_id", input.CategoryID)
+	if err != nil {
+		return err
+	}
+	errs.addIf(fe)
+	if input.TagID != nil && *input.TagID == "" {
+		input.TagID = nil
+	}
+	if input.TagID != nil {
+		_, fe, err := loadTags(db, "tag_id", []string{*input.TagID})
+		if err != nil {
+			return err
+		}
+		errs.addIf(fe)
+	}
+	return errs.err()
+}
+
+// validateWebhookURL 校验 Webhook 地址为带主机名的 http 或 https 地址
+func validateWebhookURL(field, raw string) *FieldError {
+	if raw == "" {
+		return &FieldError{Field: field, Code: FieldRequired}
+	}
+	if utf8.RuneCountInString(raw) > MaxWebhookURLLength {
+		return tooLong(field, MaxWebhookURLLength)
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return &FieldError{Field: field, Code: FieldInvalidURL}
+	}
+	return nil
+}
+
+// enqueueWebhookDeliveries 为订阅了事件且过滤条件匹配的 Webhook 创建投递，与事件在同一事务中写入
+func enqueueWebhookDeliveries(tx *gorm.DB, e *model.Event) error {
+	var hooks []model.Webhook
+	if err := tx.Where("enabled = ?", true).Find(&hooks).Error; err != nil {
+		return err
+	}
+
+	var subject *webhookSubject
+	var deliveries []model.WebhookDelivery
+	for i := range hooks {
+		hook := &hooks[i]
+		if !hook.Subscribes(e.Type) {
+			continue
+		}
+		if subject == nil {
+			var err error
+			if subject, err = loadWebhookSubject(tx, e); err != nil {
+				return err
+			}
+		}
+		ok, err := subject.matches(tx, hook)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		id := uuid.New().String()
+		payload, err := json.Marshal(WebhookPayload{ID: id, Event: e.Type, Seq: e.Seq, CreatedAt: e.CreatedAt, Data: subject.data})
+		if err != nil {
+			return err
+		}
+		deliveries = append(deliveries, model.WebhookDelivery{
+			BaseModel:     model.BaseModel{ID: id},
+			WebhookID:     hook.ID,
+			EventSeq:      e.Seq,
+			EventType:     e.Type,
+			Payload:       payload,
+			Status:        model.WebhookDeliveryPending,
+			NextAttemptAt: &e.CreatedAt,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return tx.Create(&deliveries).Error
+}
+
+// webhookSubject 事件涉及的实体
+type webhookSubject struct {
+	note *model.Note // 笔记事件时不为空
+	data interface{} // 请求体中的 data
+}
+
+// loadWebhookSubject 加载事件涉及的实体，实体已被物理删除时 data 只有 id
+func loadWebhookSubject(tx *gorm.DB, e *model.Event) (*webhookSubject, error) {
+	subject := &webhookSubject{data: map[string]string{"id": e.EntityID}}
+	var err error
+	switch e.Type.Entity() {
+	case "note":
+		var note model.Note
+		if err = tx.Unscoped().Preload("Category").Preload("Tags").First(&note, "id = ?", e.EntityID).Error; err == nil {
+			data := WebhookNote{
+				ID:         note.ID,
+				Title:      note.Title,
+				FilePath:   note.FilePath,
+				Version:    e.Version,
+				CategoryID: note.CategoryID,
+				Tags:       []string{},
+				UpdatedAt:  note.UpdatedAt,
+			}
+			if note.Category != nil {
+				data.CategoryPath = note.Category.Path
+			}
+			for _, tag := range note.Tags {
+				data.Tags = append(data.Tags, tag.Name)
+			}
+			subject.note, subject.data = &note, data
+		}
+	case "tag":
+		var tag model.Tag
+		if err = tx.Unscoped().First(&tag, "id = ?", e.EntityID).Error; err == nil {
+			subject.data = map[string]interface{}{"id": tag.ID, "name": tag.Name, "parent_id": tag.ParentID}
+		}
+	case "category":
+		var category model.Category
+		if err = tx.Unscoped().First(&category, "id = ?", e.EntityID).Error; err == nil {
+			subject.data = map[string]interface{}{"id": category.ID, "name": category.Name, "path": category.Path, "parent_id": category.ParentID}
+		}
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return subject, nil
+}
+
+// matches 判断笔记是否符合 Webhook 的目录和标签条件，目录条件包含子目录
+func (sub *webhookSubject) matches(tx *gorm.DB, hook *model.Webhook) (bool, error) {
+	if hook.CategoryID == nil && hook.TagID == nil {
+		return true, nil
+	}
+	if sub.note == nil {
+		return false, nil
+	}
+	if hook.TagID != nil {
+		found := false
+		for _, tag := range sub.note.Tags {
+			found = found || tag.ID == *hook.TagID
+		}
+		if !found {
+			return false, nil
+		}
+	}
+	if hook.CategoryID != nil {
+		if sub.note.Category == nil {
+			return false, nil
+		}
+		if sub.note.Category.ID == *hook.CategoryID {
+			return true, nil
+		}
+		var category model.Category
+		if err := tx.Select("path").First(&category, "id = ?", *hook.CategoryID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return false, nil
+			}
+			return false, err
+		}
+		return strings.HasPrefix(sub.note.Category.Path, category.Path+"/"), nil
+	}
+	return true, nil
+}
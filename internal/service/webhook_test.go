@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"leafnote/internal/model"
+	"leafnote/internal/testutil"
+)
+
+func TestWebhookService_Enqueue(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	ctx := context.Background()
+	s := NewWebhookService(db, zap.NewNop(), WebhookOptions{})
+
+	categories := NewCategoryService(db)
+	work := &model.Category{Name: "工作"}
+	require.NoError(t, categories.CreateCategory(ctx, work))
+	project := &model.Category{Name: "项目", ParentID: &work.ID}
+	require.NoError(t, categories.CreateCategory(ctx, project))
+	tag := &model.Tag{Name: "重要"}
+	require.NoError(t, NewTagService(db).CreateTag(ctx, tag))
+
+	disabled := false
+	create := func(input WebhookInput) *model.Webhook {
+		input.URL = "https://example.com/hook"
+		hook, err := s.CreateWebhook(ctx, input)
+		require.NoError(t, err)
+		return hook
+	}
+	all := create(WebhookInput{})
+	noteCreated := create(WebhookInput{Events: []model.EventType{model.EventNoteCreated, model.EventNoteCreated}})
+	byCategory := create(WebhookInput{CategoryID: &work.ID})
+	byTag := create(WebhookInput{TagID: &tag.ID})
+	off := create(WebhookInput{Enabled: &disabled})
+	assert.Len(t, all.Secret, 64)
+	assert.Equal(t, []model.EventType{model.EventNoteCreated}, noteCreated.Events)
+
+	notes := NewNoteService(db, zap.NewNop())
+	inProject, err := notes.CreateNote(CreateNoteInput{Title: "方案", FilePath: "/方案.md", Content: "正文", CategoryID: &project.ID})
+	require.NoError(t, err)
+	tagged, err := notes.CreateNote(CreateNoteInput{Title: "待办", FilePath: "/待办.md", TagIDs: []string{tag.ID}})
+	require.NoError(t, err)
+	require.NoError(t, notes.UpdateNote(tagged.ID, UpdateNoteInput{Title: "待办清单"}))
+
+	count := func(hook *model.Webhook) int {
+		deliveries, err := s.ListDeliveries(ctx, hook.ID, WebhookDeliveryFilter{Limit: 100})
+		require.NoError(t, err)
+		return len(deliveries)
+	}
+	// 创建 Webhook 之前的事件不投递
+	assert.Equal(t, 3, count(all))
+	assert.Equal(t, 2, count(noteCreated))
+	assert.Equal(t, 1, count(byCategory), "只包含子目录下的笔记")
+	assert.Equal(t, 2, count(byTag))
+	assert.Equal(t, 0, count(off))
+
+	deliveries, err := s.ListDeliveries(ctx, byCategory.ID, WebhookDeliveryFilter{Limit: 1})
+	require.NoError(t, err)
+	var payload struct {
+		WebhookPayload
+		Data WebhookNote `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(deliveries[0].Payload, &payload))
+	assert.Equal(t, deliveries[0].ID, payload.ID)
+	assert.Equal(t, model.EventNoteCreated, payload.Event)
+	assert.Equal(t, inProject.ID, payload.Data.ID)
+	assert.Equal(t, "/工作/项目", payload.Data.CategoryPath)
+	assert.NotContains(t, string(deliveries[0].Payload), "正文")
+
+	t.Run("校验失败", func(t *testing.T) {
+		missing := "missing"
+		_, err := s.CreateWebhook(ctx, WebhookInput{
+			URL:    "ftp://example.com",
+			Events: []model.EventType{"note.archived"},
+			TagID:  &missing,
+		})
+		assert.ErrorIs(t, err, ErrValidationFailed)
+		e, ok := AsError(err)
+		require.True(t, ok)
+		codes := map[string]string{}
+		for _, fe := range e.Fields {
+			codes[fe.Field] = fe.Code
+		}
+		assert.Equal(t, map[string]string{"url": FieldInvalidURL, "events": FieldUnsupported, "tag_id": FieldNotFound}, codes)
+	})
+}
+
+func TestWebhookService_DeliverDue(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var requests []*http.Request
+	var bodies [][]byte
+	fail := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r)
+		bodies = append(bodies, body)
+		if fail {
+			http.Error(w, "暂时不可用", http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	s := NewWebhookService(db, zap.NewNop(), WebhookOptions{
+		Timeout:     time.Second,
+		MaxAttempts: 3,
+		Backoff:     time.Minute,
+		MaxBackoff:  90 * time.Second,
+	})
+	hook, err := s.CreateWebhook(ctx, WebhookInput{URL: srv.URL, Secret: "s3cret"})
+	require.NoError(t, err)
+	require.NoError(t, NewTagService(db).CreateTag(ctx, &model.Tag{Name: "工作"}))
+
+	get := func() model.WebhookDelivery {
+		deliveries, err := s.ListDeliveries(ctx, hook.ID, WebhookDeliveryFilter{Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		return deliveries[0]
+	}
+
+	now := time.Now()
+	succeeded, err := s.DeliverDue(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 0, succeeded)
+	d := get()
+	assert.Equal(t, model.WebhookDeliveryPending, d.Status)
+	assert.Equal(t, 1, d.Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, d.ResponseStatus)
+	assert.Contains(t, d.Error, "暂时不可用")
+	assert.WithinDuration(t, now.Add(time.Minute), *d.NextAttemptAt, time.Millisecond)
+
+	require.Len(t, requests, 1)
+	assert.Equal(t, "tag.created", requests[0].Header.Get(WebhookEventHeader))
+	assert.Equal(t, d.ID, requests[0].Header.Get(WebhookDeliveryHeader))
+	assert.Equal(t, signWebhookPayload("s3cret", bodies[0]), requests[0].Header.Get(WebhookSignatureHeader))
+	assert.JSONEq(t, string(d.Payload), string(bodies[0]))
+
+	// 未到重试时间
+	_, err = s.DeliverDue(ctx, now.Add(30*time.Second))
+	require.NoError(t, err)
+	assert.Len(t, requests, 1)
+
+	// 第二次等待翻倍后受上限限制
+	_, err = s.DeliverDue(ctx, now.Add(time.Minute))
+	require.NoError(t, err)
+	d = get()
+	assert.Equal(t, 2, d.Attempts)
+	assert.WithinDuration(t, now.Add(time.Minute+90*time.Second), *d.NextAttemptAt, time.Millisecond)
+
+	_, err = s.DeliverDue(ctx, now.Add(time.Hour))
+	require.NoError(t, err)
+	d = get()
+	assert.Equal(t, model.WebhookDeliveryFailed, d.Status)
+	assert.Equal(t, 3, d.Attempts)
+	assert.Nil(t, d.NextAttemptAt)
+
+	t.Run("手动重试", func(t *testing.T) {
+		mu.Lock()
+		fail = false
+		mu.Unlock()
+		retried, err := s.RetryDelivery(ctx, hook.ID, d.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.WebhookDeliveryPending, retried.Status)
+		assert.Equal(t, 0, retried.Attempts)
+
+		succeeded, err := s.DeliverDue(ctx, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 1, succeeded)
+		d := get()
+		assert.Equal(t, model.WebhookDeliverySucceeded, d.Status)
+		assert.Equal(t, http.StatusOK, d.ResponseStatus)
+		assert.Empty(t, d.Error)
+		assert.Len(t, requests, 4)
+
+		_, err = s.RetryDelivery(ctx, hook.ID, "missing")
+		assert.ErrorIs(t, err, ErrWebhookDeliveryNotFound)
+	})
+
+	t.Run("清理已结束的投递", func(t *testing.T) {
+		pruned, err := s.Prune(ctx, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), pruned)
+	})
+}
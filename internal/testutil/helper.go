@@ -1,33 +1,117 @@
 package testutil
 
 import (
-	"leafnote/internal/model"
+	"os"
+	"testing"
 
+	"leafnote/internal/config"
+	"leafnote/internal/migration"
+
+	"go.uber.org/zap"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
+// 测试数据库环境变量，未设置驱动时使用内存 SQLite
+// 例如：LEAFNOTE_TEST_DB_DRIVER=postgres LEAFNOTE_TEST_DB_DSN="host=localhost dbname=leafnote_test" go test -p 1 ./...
+const (
+	EnvTestDBDriver = "LEAFNOTE_TEST_DB_DRIVER"
+	EnvTestDBDSN    = "LEAFNOTE_TEST_DB_DSN"
+)
+
 // StringPtr 返回字符串的指针
 func StringPtr(s string) *string {
 	return &s
 }
 
+// BoolPtr 返回布尔值的指针
+func BoolPtr(b bool) *bool {
+	return &b
+}
+
 // SetupTestDB 设置测试数据库
+// 使用外部数据库时会先删除库中所有表，多个测试包需要用 -p 1 串行执行
 func SetupTestDB() (*gorm.DB, error) {
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	db, err := openTestDB()
 	if err != nil {
 		return nil, err
 	}
 
-	// 自动迁移数据库表
-	err = db.AutoMigrate(
-		&model.Note{},
-		&model.Tag{},
-		&model.Category{},
-	)
-	if err != nil {
+	// 执行迁移，与生产环境使用相同的表结构
+	if _, err := migration.New(db, zap.NewNop()).Up(); err != nil {
 		return nil, err
 	}
 
 	return db, nil
 }
+
+// NewTestDB 创建测试数据库，失败时终止测试，测试结束后关闭连接
+func NewTestDB(t testing.TB) *gorm.DB {
+	t.Helper()
+	db, err := SetupTestDB()
+	if err != nil {
+		t.Fatalf("failed to setup test database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// openTestDB 按环境变量连接测试数据库
+func openTestDB() (*gorm.DB, error) {
+	cfg := &config.DatabaseConfig{
+		Driver: os.Getenv(EnvTestDBDriver),
+		DSN:    os.Getenv(EnvTestDBDSN),
+	}
+	if cfg.Driver == "" || cfg.IsSQLite() {
+		db, err := gorm.Open(sqlite.Open("file::memory:?_foreign_keys=1"), &gorm.Config{})
+		if err != nil {
+			return nil, err
+		}
+		// 内存数据库每个连接互相独立，只能使用一个连接
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		sqlDB.SetMaxOpenConns(1)
+		return db, nil
+	}
+
+	dialector, err := config.Dialector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	if err := resetDB(db); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// resetDB 删除外部测试数据库中的所有表
+func resetDB(db *gorm.DB) error {
+	return db.Connection(func(tx *gorm.DB) error {
+		if tx.Dialector.Name() == "mysql" {
+			if err := tx.Exec("SET FOREIGN_KEY_CHECKS = 0").Error; err != nil {
+				return err
+			}
+			defer tx.Exec("SET FOREIGN_KEY_CHECKS = 1")
+		}
+		tables, err := tx.Migrator().GetTables()
+		if err != nil {
+			return err
+		}
+		for _, table := range tables {
+			if err := tx.Migrator().DropTable(table); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}